@@ -1,6 +1,9 @@
 package edutrack
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
 
 // Role represents the type of user in the system.
 type Role string
@@ -14,6 +17,9 @@ const (
 
 	// RoleStudent is a student who can view their own information and grades.
 	RoleStudent Role = "student"
+
+	// RoleGuardian is a parent or tutor who can view the information of their linked students.
+	RoleGuardian Role = "guardian"
 )
 
 // Account represents a user account for authentication.
@@ -23,8 +29,9 @@ type Account struct {
 	// The user's full name.
 	Name string
 
-	// The user's email address (used for login).
-	Email string `gorm:"uniqueIndex:idx_account_email_tenant"`
+	// The user's email address (used for login), unique among the accounts
+	// not deleted of the institution.
+	Email string `gorm:"uniqueIndex:idx_account_email_tenant_undeleted,where:deleted_at IS NULL"`
 
 	// The hashed password.
 	Password string
@@ -35,10 +42,17 @@ type Account struct {
 	// Whether the account is active.
 	Active bool `gorm:"default:true"`
 
+	// The language the user reads messages in. Empty follows the
+	// Accept-Language header of each request.
+	Locale i18n.Locale
+
+	// Storage key of the uploaded avatar, empty if none.
+	AvatarKey string
+
 	// Foreign keys.
 
 	// TenantID links the account to an institution.
-	TenantID string `gorm:"uniqueIndex:idx_account_email_tenant"`
+	TenantID string `gorm:"uniqueIndex:idx_account_email_tenant_undeleted"`
 	Tenant   Tenant
 }
 
@@ -56,3 +70,8 @@ func (a *Account) IsTeacher() bool {
 func (a *Account) IsStudent() bool {
 	return a.Role == RoleStudent
 }
+
+// IsGuardian returns true if the account has guardian role.
+func (a *Account) IsGuardian() bool {
+	return a.Role == RoleGuardian
+}
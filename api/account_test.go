@@ -70,6 +70,39 @@ func TestAccount_IsTeacher(t *testing.T) {
 	}
 }
 
+func TestAccount_IsGuardian(t *testing.T) {
+	tests := []struct {
+		name string
+		role Role
+		want bool
+	}{
+		{
+			name: "guardian role",
+			role: RoleGuardian,
+			want: true,
+		},
+		{
+			name: "student role",
+			role: RoleStudent,
+			want: false,
+		},
+		{
+			name: "empty role",
+			role: "",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Account{Role: tt.role}
+			if got := a.IsGuardian(); got != tt.want {
+				t.Errorf("Account.IsGuardian() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestRole_Constants(t *testing.T) {
 	// Ensure role constants have expected string values
 	if RoleSecretary != "secretary" {
@@ -0,0 +1,216 @@
+package edutrack
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix is the prefix shared by all API keys, used to tell them
+// apart from JWTs in the Authorization header.
+const APIKeyPrefix = "et_"
+
+// Access levels an API key scope can grant on a resource.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// APIKeyResources returns the resources API keys can be scoped to.
+// Resources match the first segment of the route path.
+func APIKeyResources() []string {
+	return []string{
+		"accounts",
+		"students",
+		"guardians",
+		"teachers",
+		"careers",
+		"subjects",
+		"sections",
+		"topics",
+		"attendances",
+		"justifications",
+		"grades",
+		"appeals",
+		"exams",
+		"notifications",
+		"webhooks",
+	}
+}
+
+// APIKey is a tenant-scoped credential for machine-to-machine access.
+// Requests authenticated with a key act on behalf of the key's account,
+// limited to the key's scopes. Only a hash of the key is stored.
+type APIKey struct {
+	gorm.Model
+
+	// Human readable name of the integration using the key.
+	Name string
+
+	// Public part of the key, safe to display (e.g., "et_1a2b3c4d").
+	Prefix string `gorm:"uniqueIndex"`
+
+	// SHA-256 hash of the full key.
+	Hash string `json:"-"`
+
+	// Comma-separated list of scopes ("grades:read", "attendances:write", "*:read").
+	Scopes string
+
+	// When the key stops being valid. Nil means it never expires.
+	ExpiresAt *time.Time
+
+	// When the key was last used to authenticate a request.
+	LastUsedAt *time.Time
+
+	// Foreign keys.
+
+	// AccountID links the key to the account it acts on behalf of.
+	AccountID uint    `gorm:"index"`
+	Account   Account `gorm:"constraint:OnDelete:CASCADE"`
+
+	// TenantID links the key to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// GenerateAPIKey generates a new API key and returns the full key, which
+// must be shown to the user only once, and its public prefix.
+// Format: et_XXXXXXXX_YYYYYYYYYYYYYYYYYYYYYYYYYYYYYYYY (hex characters).
+func GenerateAPIKey() (key, prefix string, err error) {
+	public := make([]byte, 4)
+	secret := make([]byte, 16)
+	if _, err := rand.Read(public); err != nil {
+		return "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	prefix = APIKeyPrefix + hex.EncodeToString(public)
+	key = prefix + "_" + hex.EncodeToString(secret)
+	return key, prefix, nil
+}
+
+// HashAPIKey returns the hash stored for an API key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefixOf extracts the public prefix of a full API key.
+// Returns an empty string if the key is malformed.
+func APIKeyPrefixOf(key string) string {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return ""
+	}
+	i := strings.LastIndex(key, "_")
+	if i <= len(APIKeyPrefix) {
+		return ""
+	}
+	return key[:i]
+}
+
+// Matches returns true if the given full key corresponds to this API key.
+func (k *APIKey) Matches(key string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(k.Hash)) == 1
+}
+
+// IsExpired returns true if the key has an expiry date in the past.
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+// ScopeList returns the key scopes as a slice.
+func (k *APIKey) ScopeList() []string {
+	var scopes []string
+	for _, s := range strings.Split(k.Scopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// SetScopes stores the given scopes in the key.
+func (k *APIKey) SetScopes(scopes []string) {
+	k.Scopes = strings.Join(scopes, ",")
+}
+
+// Allows returns true if any of the key scopes grants the access level on
+// the resource. A write scope also grants read access.
+func (k *APIKey) Allows(resource, access string) bool {
+	for _, scope := range k.ScopeList() {
+		r, a, ok := strings.Cut(scope, ":")
+		if !ok {
+			continue
+		}
+		if r != "*" && r != resource {
+			continue
+		}
+		if a == "*" || a == access || (a == ScopeWrite && access == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidAPIKeyScope returns true if the scope has the form
+// "<resource>:<access>" with a known resource (or "*") and access level
+// "read", "write" or "*".
+func IsValidAPIKeyScope(scope string) bool {
+	resource, access, ok := strings.Cut(scope, ":")
+	if !ok {
+		return false
+	}
+
+	if access != ScopeRead && access != ScopeWrite && access != "*" {
+		return false
+	}
+
+	if resource == "*" {
+		return true
+	}
+	for _, r := range APIKeyResources() {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// FindAPIKey returns the API key matching the given full key, with its
+// account, tenant and license preloaded. Expired keys are rejected.
+func FindAPIKey(db *gorm.DB, key string) (*APIKey, error) {
+	prefix := APIKeyPrefixOf(key)
+	if prefix == "" {
+		return nil, fmt.Errorf("malformed API key")
+	}
+
+	var apiKey APIKey
+	if err := db.Preload("Account.Tenant.License").Preload("Account.Tenant.Settings").Where("prefix = ?", prefix).First(&apiKey).Error; err != nil {
+		return nil, err
+	}
+
+	if !apiKey.Matches(key) {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	if apiKey.IsExpired() {
+		return nil, fmt.Errorf("API key expired")
+	}
+
+	return &apiKey, nil
+}
+
+// Touch records that the key was just used.
+func (k *APIKey) Touch(db *gorm.DB) error {
+	now := time.Now()
+	k.LastUsedAt = &now
+	return db.Model(k).UpdateColumn("last_used_at", now).Error
+}
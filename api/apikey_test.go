@@ -0,0 +1,137 @@
+package edutrack
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() error = %v", err)
+	}
+
+	if !strings.HasPrefix(key, prefix+"_") {
+		t.Errorf("GenerateAPIKey() key = %q does not start with prefix %q", key, prefix)
+	}
+	if len(prefix) != len(APIKeyPrefix)+8 {
+		t.Errorf("GenerateAPIKey() prefix length = %d, want %d", len(prefix), len(APIKeyPrefix)+8)
+	}
+	if len(key) != len(prefix)+1+32 {
+		t.Errorf("GenerateAPIKey() key length = %d, want %d", len(key), len(prefix)+1+32)
+	}
+
+	other, _, _ := GenerateAPIKey()
+	if key == other {
+		t.Error("GenerateAPIKey() returned the same key twice")
+	}
+}
+
+func TestAPIKeyPrefixOf(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"et_1a2b3c4d_0123456789abcdef0123456789abcdef", "et_1a2b3c4d"},
+		{"et_1a2b3c4d", ""},
+		{"et__secret", ""},
+		{"eyJhbGciOiJIUzI1NiJ9.e30.sig", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := APIKeyPrefixOf(tt.key); got != tt.want {
+			t.Errorf("APIKeyPrefixOf(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKey_Matches(t *testing.T) {
+	key, prefix, _ := GenerateAPIKey()
+	k := &APIKey{Prefix: prefix, Hash: HashAPIKey(key)}
+
+	if !k.Matches(key) {
+		t.Error("APIKey.Matches() = false for the generated key")
+	}
+	if k.Matches(key + "0") {
+		t.Error("APIKey.Matches() = true for a different key")
+	}
+	if k.Hash == key {
+		t.Error("APIKey.Hash stores the key in plain text")
+	}
+}
+
+func TestAPIKey_IsExpired(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		want      bool
+	}{
+		{"never expires", nil, false},
+		{"expired", &past, true},
+		{"not yet expired", &future, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &APIKey{ExpiresAt: tt.expiresAt}
+			if got := k.IsExpired(); got != tt.want {
+				t.Errorf("APIKey.IsExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKey_Allows(t *testing.T) {
+	tests := []struct {
+		scopes   string
+		resource string
+		access   string
+		want     bool
+	}{
+		{"grades:read", "grades", ScopeRead, true},
+		{"grades:read", "grades", ScopeWrite, false},
+		{"grades:read", "students", ScopeRead, false},
+		{"grades:write", "grades", ScopeRead, true},
+		{"grades:write", "grades", ScopeWrite, true},
+		{"*:read", "students", ScopeRead, true},
+		{"*:read", "students", ScopeWrite, false},
+		{"attendances:*", "attendances", ScopeWrite, true},
+		{"grades:read, attendances:write", "attendances", ScopeWrite, true},
+		{"", "grades", ScopeRead, false},
+		{"grades", "grades", ScopeRead, false},
+	}
+
+	for _, tt := range tests {
+		k := &APIKey{Scopes: tt.scopes}
+		if got := k.Allows(tt.resource, tt.access); got != tt.want {
+			t.Errorf("APIKey{Scopes: %q}.Allows(%q, %q) = %v, want %v", tt.scopes, tt.resource, tt.access, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidAPIKeyScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{"grades:read", true},
+		{"attendances:write", true},
+		{"*:read", true},
+		{"students:*", true},
+		{"grades", false},
+		{"grades:delete", false},
+		{"api-keys:write", false},
+		{"unknown:read", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidAPIKeyScope(tt.scope); got != tt.want {
+			t.Errorf("IsValidAPIKeyScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
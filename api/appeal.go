@@ -0,0 +1,439 @@
+package edutrack
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AppealStatus represents the state of a grade appeal.
+type AppealStatus string
+
+const (
+	// AppealOpen indicates the appeal awaits the response of the teacher of
+	// the subject.
+	AppealOpen AppealStatus = "open"
+
+	// AppealEscalated indicates a secretary took over the appeal, which
+	// awaits their decision.
+	AppealEscalated AppealStatus = "escalated"
+
+	// AppealAccepted indicates the appeal was resolved in favor of the
+	// student, possibly changing the value of the grade.
+	AppealAccepted AppealStatus = "accepted"
+
+	// AppealRejected indicates the grade was upheld.
+	AppealRejected AppealStatus = "rejected"
+)
+
+// GradeAppeal is a request of a student to review a published grade,
+// answered by the teacher of the subject. Secretaries can escalate it to
+// decide it themselves. The messages keep the whole thread.
+type GradeAppeal struct {
+	gorm.Model
+
+	// Why the student disagrees with the grade.
+	Reason string
+
+	// State of the appeal.
+	Status AppealStatus `gorm:"default:'open';index"`
+
+	// Value of the grade when the appeal was filed.
+	OriginalValue float64
+
+	// Value the grade was changed to by the resolution, nil if it was kept.
+	ResolvedValue *float64
+
+	// When the appeal was accepted or rejected.
+	ResolvedAt *time.Time
+
+	// Messages of the thread, oldest first, deleted along with the appeal.
+	Messages []GradeAppealMessage `gorm:"foreignKey:AppealID;constraint:OnDelete:CASCADE"`
+
+	// Foreign keys.
+
+	// GradeID links to the appealed grade, deleted along with it.
+	GradeID uint  `gorm:"index"`
+	Grade   Grade `gorm:"constraint:OnDelete:CASCADE"`
+
+	// StudentID links to the student of the grade.
+	StudentID uint    `gorm:"index"`
+	Student   Student `gorm:"constraint:OnDelete:CASCADE"`
+
+	// ResolverID links to the account that accepted or rejected the appeal.
+	ResolverID *uint
+	Resolver   *Account `gorm:"constraint:OnDelete:SET NULL"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// GradeAppealMessage is a message of the thread of a grade appeal.
+type GradeAppealMessage struct {
+	gorm.Model
+
+	// Text of the message.
+	Body string
+
+	// State the appeal moved to with the message, empty for comments.
+	Status AppealStatus
+
+	// Foreign keys.
+
+	// AppealID links to the appeal.
+	AppealID uint `gorm:"index"`
+
+	// AccountID links to the author.
+	AccountID *uint
+	Account   *Account `gorm:"constraint:OnDelete:SET NULL"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// IsPending reports whether the appeal awaits a decision.
+func (a *GradeAppeal) IsPending() bool {
+	return a.Status == AppealOpen || a.Status == AppealEscalated
+}
+
+// GradeAppealFilter represents the filters of FindGradeAppeals.
+type GradeAppealFilter struct {
+	GradeID   uint
+	StudentID uint
+	Status    AppealStatus
+}
+
+// FindGradeAppeals returns the appeals visible to the account of the
+// context: students see their own, guardians those of their linked
+// students, teachers those of the subjects they teach and secretaries all
+// of the institution.
+func FindGradeAppeals(ctx context.Context, db *gorm.DB, filter GradeAppealFilter) ([]GradeAppeal, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	query := db
+	switch {
+	case account.IsStudent():
+		student, err := findOwnStudent(db, account)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("student_id = ?", student.ID)
+	case account.IsGuardian():
+		ids, err := GuardianStudentIDs(db, account.ID, account.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("student_id IN ?", ids)
+	case account.IsTeacher():
+		topics := db.Model(&Topic{}).Select("id").Where("subject_id IN (?)", taughtSubjects(db, account))
+		query = query.Where("grade_id IN (?)", db.Model(&Grade{}).Select("id").Where("topic_id IN (?)", topics))
+	}
+
+	if filter.GradeID != 0 {
+		query = query.Where("grade_id = ?", filter.GradeID)
+	}
+	if filter.StudentID != 0 {
+		query = query.Where("student_id = ?", filter.StudentID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var appeals []GradeAppeal
+	if err := query.Preload("Grade.Topic.Subject").Preload("Student.Account").Order("id DESC").Find(&appeals).Error; err != nil {
+		return nil, err
+	}
+	return appeals, nil
+}
+
+// FindGradeAppealByID returns an appeal visible to the account of the
+// context, with its grade, student and messages.
+func FindGradeAppealByID(ctx context.Context, db *gorm.DB, id uint) (*GradeAppeal, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	var appeal GradeAppeal
+	err := db.Preload("Grade.Topic.Subject").Preload("Student.Account").
+		Preload("Messages", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&appeal, id).Error
+	if err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	switch {
+	case account.IsStudent(), account.IsGuardian():
+		if err := checkStudentAccess(ctx, db, account, appeal.StudentID); err != nil {
+			return nil, err
+		}
+	case account.IsTeacher():
+		if !isSubjectTeacher(db, account, appeal.Grade.Topic.Subject) {
+			return nil, &Error{Code: EFORBIDDEN}
+		}
+	}
+	return &appeal, nil
+}
+
+// FileGradeAppeal appeals a published grade as the student of the account
+// of the context, within the appeal window of the institution. A grade can
+// only be appealed once.
+func FileGradeAppeal(ctx context.Context, db *gorm.DB, gradeID uint, reason string) (*GradeAppeal, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	if !account.IsStudent() {
+		return nil, &Error{Code: EFORBIDDEN}
+	}
+	db = TenantDB(ctx, db)
+
+	student, err := findOwnStudent(db, account)
+	if err != nil {
+		return nil, err
+	}
+	var grade Grade
+	if err := db.First(&grade, gradeID).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+	if grade.StudentID != student.ID {
+		return nil, &Error{Code: EFORBIDDEN}
+	}
+	if grade.Status == GradeDraft {
+		// Drafts are hidden until they are published.
+		return nil, &Error{Code: ENOTFOUND}
+	}
+
+	settings, err := FindTenantSettings(db, account.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.AppealDays == 0 {
+		return nil, Errorf(ECONFLICT, "appeal.disabled")
+	}
+	if deadline := settings.AppealDeadline(grade.PublicationTime()); db.NowFunc().After(deadline) {
+		return nil, Errorf(ECONFLICT, "appeal.window_closed", deadline.In(settings.Location()).Format("2006-01-02"))
+	}
+
+	var count int64
+	if err := db.Model(&GradeAppeal{}).Where("grade_id = ?", grade.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, Errorf(ECONFLICT, "appeal.exists")
+	}
+
+	appeal := &GradeAppeal{
+		Reason:        reason,
+		Status:        AppealOpen,
+		OriginalValue: grade.Value,
+		GradeID:       grade.ID,
+		StudentID:     student.ID,
+		TenantID:      account.TenantID,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(appeal).Error; err != nil {
+			return err
+		}
+		return tx.Create(&GradeAppealMessage{
+			Body:      reason,
+			Status:    AppealOpen,
+			AppealID:  appeal.ID,
+			AccountID: &account.ID,
+			TenantID:  account.TenantID,
+		}).Error
+	})
+	if err != nil {
+		return nil, TranslateDBError(err)
+	}
+	return FindGradeAppealByID(ctx, db, appeal.ID)
+}
+
+// CommentGradeAppeal adds a message to the thread of a pending appeal as
+// the account of the context: the student, the teacher of the subject or a
+// secretary.
+func CommentGradeAppeal(ctx context.Context, db *gorm.DB, appeal *GradeAppeal, body string) (*GradeAppealMessage, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	if account.IsGuardian() {
+		return nil, &Error{Code: EFORBIDDEN}
+	}
+	if !appeal.IsPending() {
+		return nil, Errorf(ECONFLICT, "appeal.resolved")
+	}
+
+	message := &GradeAppealMessage{
+		Body:      body,
+		AppealID:  appeal.ID,
+		AccountID: &account.ID,
+		TenantID:  appeal.TenantID,
+	}
+	if err := TenantDB(ctx, db).Create(message).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+	appeal.Messages = append(appeal.Messages, *message)
+	return message, nil
+}
+
+// EscalateGradeAppeal moves an open appeal, or one rejected by the teacher,
+// to the decision of the secretaries, as the secretary of the context.
+func EscalateGradeAppeal(ctx context.Context, db *gorm.DB, appeal *GradeAppeal, body string) error {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return &Error{Code: EUNAUTHORIZED}
+	}
+
+	return TenantDB(ctx, db).Transaction(func(tx *gorm.DB) error {
+		// Only the expected states change, so concurrent decisions cannot
+		// both succeed.
+		result := tx.Model(&GradeAppeal{}).
+			Where("id = ? AND status IN ?", appeal.ID, []AppealStatus{AppealOpen, AppealRejected}).
+			Updates(map[string]any{"status": AppealEscalated, "resolved_at": nil, "resolver_id": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return Errorf(ECONFLICT, "appeal.not_escalable")
+		}
+
+		message := &GradeAppealMessage{
+			Body:      body,
+			Status:    AppealEscalated,
+			AppealID:  appeal.ID,
+			AccountID: &account.ID,
+			TenantID:  appeal.TenantID,
+		}
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+
+		appeal.Status = AppealEscalated
+		appeal.ResolvedAt = nil
+		appeal.ResolverID = nil
+		appeal.Messages = append(appeal.Messages, *message)
+		return nil
+	})
+}
+
+// ResolveGradeAppeal accepts or rejects a pending appeal as the account of
+// the context, explaining the decision in body. Open appeals are decided by
+// the teacher of the subject or a secretary, escalated ones only by a
+// secretary. An accepted appeal can change the value of the grade; locked
+// grades are amended, which only secretaries can do.
+func ResolveGradeAppeal(ctx context.Context, db *gorm.DB, appeal *GradeAppeal, status AppealStatus, body string, value *float64) error {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	switch {
+	case account.IsSecretary():
+	case appeal.Status == AppealOpen && isSubjectTeacher(db, account, appeal.Grade.Topic.Subject):
+	default:
+		return &Error{Code: EFORBIDDEN}
+	}
+
+	grade := &appeal.Grade
+	change := value != nil && *value != grade.Value
+	if change {
+		settings, err := FindTenantSettings(db, appeal.TenantID)
+		if err != nil {
+			return err
+		}
+		if err := settings.ValidateGrade("value", *value); err != nil {
+			return err
+		}
+	}
+	locked := change && grade.IsLocked(db.NowFunc())
+	if locked {
+		if err := checkAmendment(account, body); err != nil {
+			return err
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := tx.NowFunc()
+		updates := map[string]any{"status": status, "resolved_at": now, "resolver_id": account.ID}
+		if change {
+			updates["resolved_value"] = *value
+		}
+		result := tx.Model(&GradeAppeal{}).
+			Where("id = ? AND status = ?", appeal.ID, appeal.Status).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return Errorf(ECONFLICT, "appeal.resolved")
+		}
+
+		message := &GradeAppealMessage{
+			Body:      body,
+			Status:    status,
+			AppealID:  appeal.ID,
+			AccountID: &account.ID,
+			TenantID:  appeal.TenantID,
+		}
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+
+		if change {
+			previous := grade.Value
+			gradeUpdates := map[string]any{"value": *value}
+			if locked {
+				gradeUpdates["status"] = GradeLocked
+			}
+			if err := tx.Model(grade).Updates(gradeUpdates).Error; err != nil {
+				return err
+			}
+			grade.Value = *value
+			if locked {
+				err := tx.Create(&GradeAmendment{
+					Reason:        body,
+					PreviousValue: &previous,
+					Value:         *value,
+					PreviousNotes: grade.Notes,
+					Notes:         grade.Notes,
+					GradeID:       grade.ID,
+					AccountID:     &account.ID,
+					TenantID:      grade.TenantID,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			appeal.ResolvedValue = value
+		}
+
+		appeal.Status = status
+		appeal.ResolvedAt = &now
+		appeal.ResolverID = &account.ID
+		appeal.Messages = append(appeal.Messages, *message)
+		return nil
+	})
+}
+
+// isSubjectTeacher reports whether the account is the teacher of the
+// subject or of one of its sections.
+func isSubjectTeacher(db *gorm.DB, account *Account, subject Subject) bool {
+	if !account.IsTeacher() {
+		return false
+	}
+	var count int64
+	if err := db.Model(&Subject{}).Where("id = ? AND id IN (?)", subject.ID, taughtSubjects(db, account)).Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
@@ -38,15 +38,38 @@ type Attendance struct {
 
 	// Foreign keys.
 
-	// StudentID links to the student.
-	StudentID uint `gorm:"index"`
-	Student   Student
+	// StudentID links to the student. The record is deleted along with the
+	// student or the subject.
+	StudentID uint    `gorm:"index"`
+	Student   Student `gorm:"constraint:OnDelete:CASCADE"`
 
 	// SubjectID links to the subject/class.
-	SubjectID uint `gorm:"index"`
-	Subject   Subject
+	SubjectID uint    `gorm:"index"`
+	Subject   Subject `gorm:"constraint:OnDelete:CASCADE"`
+
+	// SectionID links to the section of the subject the student attended,
+	// if they were enrolled in one. Deleting the section keeps the record.
+	SectionID *uint    `gorm:"index"`
+	Section   *Section `gorm:"constraint:OnDelete:SET NULL"`
+
+	// JustificationID links to the approved justification that excused the
+	// record, if any.
+	JustificationID *uint          `gorm:"index"`
+	Justification   *Justification `gorm:"constraint:OnDelete:SET NULL"`
 
 	// TenantID links the record to an institution.
 	TenantID string `gorm:"index"`
 	Tenant   Tenant
 }
+
+// AttendanceSummary counts the attendance records of a student by status.
+type AttendanceSummary struct {
+	Present int
+	Absent  int
+	Late    int
+	Excused int
+
+	// Absences that count for the student: the absences plus the late
+	// arrivals converted to absences by the settings of the institution.
+	Absences int
+}
@@ -10,8 +10,9 @@ type Career struct {
 	// Name of the career (e.g., "Licenciatura en Administración").
 	Name string
 
-	// Unique code for the career (e.g., "LAD-2024").
-	Code string `gorm:"uniqueIndex:idx_career_tenant"`
+	// Unique code for the career (e.g., "LAD-2024"). Deleted careers do not
+	// keep it.
+	Code string `gorm:"uniqueIndex:idx_career_tenant_undeleted,where:deleted_at IS NULL"`
 
 	// Description of the career.
 	Description string
@@ -24,14 +25,16 @@ type Career struct {
 
 	// Foreign keys.
 
-	TenantID string `gorm:"uniqueIndex:idx_career_tenant"`
+	TenantID string `gorm:"uniqueIndex:idx_career_tenant_undeleted"`
 	Tenant   Tenant
 
 	// Associations.
 
-	// Subjects that belong to this career.
-	Subjects []Subject
+	// Subjects that belong to this career. A career cannot be deleted while
+	// it has subjects.
+	Subjects []Subject `gorm:"constraint:OnDelete:RESTRICT"`
 
-	// Students enrolled in this career.
-	Students []Student
+	// Students enrolled in this career. A career cannot be deleted while it
+	// has students.
+	Students []Student `gorm:"constraint:OnDelete:RESTRICT"`
 }
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"gorm.io/gorm"
 	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/config"
 	"lahuerta.tecmm.edu.mx/edutrack/http"
+	"lahuerta.tecmm.edu.mx/edutrack/notify"
+	"lahuerta.tecmm.edu.mx/edutrack/storage"
 )
 
 var app = &application{
@@ -15,9 +20,73 @@ var app = &application{
 }
 
 type application struct {
-	db        *gorm.DB
-	logger    *log.Logger
-	errLogger *log.Logger
-	server    *http.Server
-	edutrack  *edutrack.App
+	db         *gorm.DB
+	logger     *log.Logger
+	errLogger  *log.Logger
+	server     *http.Server
+	edutrack   *edutrack.App
+	dispatcher *notify.Dispatcher
+
+	config *config.Config
+}
+
+// purgeTrash permanently deletes the records deleted longer than the
+// retention ago, every interval until the context is cancelled.
+func (a *application) purgeTrash(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := edutrack.PurgeExpiredTrash(ctx, a.db, time.Now().Add(-retention))
+		if err != nil {
+			a.errLogger.Printf("Failed to purge trash: %v", err)
+		} else if purged > 0 {
+			a.logger.Printf("Purged %d records from the trash.", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lockGrades locks the published grades of the topics whose deadline has
+// passed, every interval until the context is cancelled.
+func (a *application) lockGrades(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		locked, err := edutrack.LockDueGrades(ctx, a.db, time.Now())
+		if err != nil {
+			a.errLogger.Printf("Failed to lock grades: %v", err)
+		} else if locked > 0 {
+			a.logger.Printf("Locked %d grades past their deadline.", locked)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// openStorage returns the backend of the uploaded files. Download URLs of
+// the local backend are signed with a key derived from the JWT secret and
+// served by the API under http.FilesPath.
+func openStorage(cfg config.StorageConfig, secret []byte) (storage.Storage, error) {
+	if cfg.Backend == config.StorageS3 {
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+		})
+	}
+	return storage.NewLocal(cfg.Dir, storage.NewSigner(secret, http.FilesPath)), nil
 }
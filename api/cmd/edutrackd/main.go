@@ -2,27 +2,53 @@ package main
 
 import (
 	"context"
+	"flag"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/config"
+	"lahuerta.tecmm.edu.mx/edutrack/database"
+	_ "lahuerta.tecmm.edu.mx/edutrack/database/postgres"
+	_ "lahuerta.tecmm.edu.mx/edutrack/database/sqlite"
 	"lahuerta.tecmm.edu.mx/edutrack/http"
+	"lahuerta.tecmm.edu.mx/edutrack/notify"
 )
 
 func main() {
-	// Ensure database is closed on exit.
-	defer func() {
-		if app.db != nil {
-			db, _ := app.db.DB()
-			_ = db.Close()
-		}
-	}()
+	configPath := flag.String("config", os.Getenv("EDUTRACK_CONFIG"), "path to the TOML configuration file")
+	flag.Parse()
+
+	// Load and validate the configuration before touching any resource.
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		app.errLogger.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		app.errLogger.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if cfg.Auth.JWTSecret == config.DefaultJWTSecret {
+		app.errLogger.Println("WARNING: EDUTRACK_JWT_SECRET not set, using insecure default.")
+	}
+	app.config = cfg
+
+	logLevel, _ := database.ParseLogLevel(cfg.Database.LogLevel)
+	app.db, err = database.Open(cfg.Database.URL, database.Options{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		LogLevel:        logLevel,
+	})
+	if err != nil {
+		app.errLogger.Fatalf("Failed to open database: %v", err)
+	}
 
-	// Wait for database initialization from build tags.
-	if app.db == nil {
-		app.errLogger.Fatal("No database configured. Build with -tags sqlite or another database driver.")
+	// Ensure database is closed on exit.
+	if sqlDB, err := app.db.DB(); err == nil {
+		defer sqlDB.Close()
 	}
 
 	// Initialize the edutrack application.
@@ -35,28 +61,109 @@ func main() {
 	}
 	app.logger.Println("Migrations completed successfully.")
 
-	// Get configuration from environment.
-	addr := os.Getenv("EDUTRACK_ADDR")
-	if addr == "" {
-		addr = ":8080"
+	// Load the TLS certificate, reloaded on SIGHUP.
+	var certs *http.CertReloader
+	if cfg.TLS.Enabled() {
+		certs, err = http.NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			app.errLogger.Fatalf("Failed to load TLS certificate: %v", err)
+		}
+	} else if cfg.IsProduction() {
+		app.errLogger.Println("WARNING: TLS not configured, serve edutrackd behind an HTTPS reverse proxy.")
 	}
 
-	jwtSecret := os.Getenv("EDUTRACK_JWT_SECRET")
-	if jwtSecret == "" {
-		app.errLogger.Println("WARNING: EDUTRACK_JWT_SECRET not set, using insecure default.")
-		jwtSecret = "edutrack-dev-secret-change-in-production"
+	files, err := openStorage(cfg.Storage, []byte(cfg.Auth.JWTSecret))
+	if err != nil {
+		app.errLogger.Fatalf("Failed to open file storage: %v", err)
 	}
 
 	// Create and configure the HTTP server.
-	app.server = http.NewServer(addr, app.db, []byte(jwtSecret))
+	cors := http.DefaultCORSConfig()
+	cors.AllowedOrigins = cfg.CORS.AllowedOrigins
+	cors.AllowCredentials = cfg.CORS.AllowCredentials
+
+	app.server = http.NewServerWithOptions(app.db, http.Options{
+		Addr:          cfg.Server.Addr,
+		JWTSecret:     []byte(cfg.Auth.JWTSecret),
+		CORS:          cors,
+		TokenLifetime: cfg.Auth.TokenLifetime,
+		ReadTimeout:   cfg.Server.ReadTimeout,
+		WriteTimeout:  cfg.Server.WriteTimeout,
+		IdleTimeout:   cfg.Server.IdleTimeout,
+		Logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+			Level: cfg.Log.SlogLevel(),
+		})),
+		ShutdownDelay: cfg.Server.ShutdownDelay,
+		TLS:           certs,
+		RedirectAddr:  cfg.TLS.RedirectAddr,
+		HSTSMaxAge:    cfg.TLS.HSTSMaxAge,
+
+		Storage:         files,
+		FileURLLifetime: cfg.Storage.URLLifetime,
+	})
+
+	// Start the notification dispatcher, the license expiry scanner and the
+	// trash purge.
+	notifyCtx, stopNotify := context.WithCancel(context.Background())
+	defer stopNotify()
+
+	app.dispatcher = notify.NewDispatcher(app.db,
+		&notify.InboxChannel{DB: app.db},
+		&notify.WebhookChannel{},
+	)
+	app.dispatcher.Logger = app.errLogger
+
+	if cfg.SMTP.Addr != "" {
+		app.dispatcher.Register(&notify.EmailChannel{
+			Addr:     cfg.SMTP.Addr,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		})
+	} else {
+		app.errLogger.Println("WARNING: EDUTRACK_SMTP_ADDR not set, email notifications will not be delivered.")
+	}
+
+	scanner := notify.NewLicenseScanner(app.db)
+	scanner.Logger = app.errLogger
+
+	deliverer := notify.NewWebhookDeliverer(app.db)
+	deliverer.Logger = app.errLogger
+
+	go app.dispatcher.Run(notifyCtx, 30*time.Second)
+	go deliverer.Run(notifyCtx, 30*time.Second)
+	go scanner.Run(notifyCtx, 12*time.Hour)
+
+	// Lock the grades of the topics past their deadline.
+	go app.lockGrades(notifyCtx, 15*time.Minute)
+
+	// Purge the trash of the records deleted longer than the retention ago.
+	if cfg.Trash.Retention > 0 {
+		go app.purgeTrash(notifyCtx, 6*time.Hour, cfg.Trash.Retention)
+	}
 
 	// Channel to listen for shutdown signals.
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	// Reload the TLS certificate on SIGHUP (e.g., after a renewal).
+	if certs != nil {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				if err := certs.Reload(); err != nil {
+					app.errLogger.Printf("Keeping previous TLS certificate: %v", err)
+					continue
+				}
+				app.logger.Println("TLS certificate reloaded.")
+			}
+		}()
+	}
+
 	// Start the server in a goroutine.
 	go func() {
-		app.logger.Printf("Starting server on %s", addr)
+		app.logger.Printf("Starting server on %s (%s)", cfg.Server.Addr, cfg.Environment)
 		app.logger.Printf("EduTrack version %s", edutrack.Version)
 		if err := app.server.Start(); err != nil {
 			app.errLogger.Printf("Server error: %v", err)
@@ -67,8 +174,11 @@ func main() {
 	<-quit
 	app.logger.Println("Shutting down server...")
 
+	// Stop background workers.
+	stopNotify()
+
 	// Create a deadline for the shutdown.
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	// Attempt graceful shutdown.
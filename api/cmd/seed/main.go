@@ -2,25 +2,29 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/database"
+	_ "lahuerta.tecmm.edu.mx/edutrack/database/postgres"
+	_ "lahuerta.tecmm.edu.mx/edutrack/database/sqlite"
 )
 
 func main() {
+	// Open the database selected by the scheme of DATABASE_URL.
+	db, err := database.Open(os.Getenv("DATABASE_URL"), database.Options{})
+	if err != nil {
+		app.errLogger.Fatalf("Failed to open database: %v", err)
+	}
+	app.db = db
+
 	// Ensure database is closed on exit.
 	defer func() {
-		if app.db != nil {
-			db, _ := app.db.DB()
-			_ = db.Close()
-		}
+		sqlDB, _ := app.db.DB()
+		_ = sqlDB.Close()
 	}()
 
-	// Wait for database initialization from build tags.
-	if app.db == nil {
-		app.errLogger.Fatal("No database configured. Build with -tags sqlite or -tags postgres.")
-	}
-
 	// Initialize edutrack app and run migrations.
 	edutrackApp := edutrack.New(app.db)
 
@@ -169,6 +173,24 @@ func main() {
 	app.db.Model(&subjects[8]).Update("TeacherID", teachers[4].ID)
 	app.db.Model(&subjects[9]).Update("TeacherID", teachers[4].ID)
 
+	// Create a section of each subject, taught by its teacher (two subjects
+	// per teacher, as assigned above).
+	app.logger.Println("Creating sections...")
+	sections := map[uint]edutrack.Section{}
+	for i, subject := range subjects {
+		section := edutrack.Section{
+			Name:      "A",
+			Capacity:  30,
+			SubjectID: subject.ID,
+			TeacherID: &teachers[i/2].ID,
+			TenantID:  tenant.ID,
+		}
+		if err := app.db.Create(&section).Error; err != nil {
+			app.errLogger.Fatalf("Failed to create section of %s: %v", subject.Code, err)
+		}
+		sections[subject.ID] = section
+	}
+
 	// Create students.
 	app.logger.Println("Creating students...")
 	studentData := []struct {
@@ -226,6 +248,14 @@ func main() {
 		app.db.Where("career_id = ? AND semester = ?", s.CareerID, s.Semester).Find(&subjectsToEnroll)
 		if len(subjectsToEnroll) > 0 {
 			app.db.Model(&s).Association("Subjects").Append(subjectsToEnroll)
+			for _, subject := range subjectsToEnroll {
+				app.db.Create(&edutrack.Enrollment{
+					Status:    edutrack.EnrollmentEnrolled,
+					SectionID: sections[subject.ID].ID,
+					StudentID: s.ID,
+					TenantID:  tenant.ID,
+				})
+			}
 			app.logger.Printf("Enrolled student %s in %d subjects for semester %d", s.StudentID, len(subjectsToEnroll), s.Semester)
 		}
 	}
@@ -0,0 +1,457 @@
+// Package config loads and validates the configuration of edutrackd.
+//
+// The configuration is built in three layers: the defaults, an optional
+// TOML file and the EDUTRACK_* environment variables, each one overriding
+// the previous. Validate must be called before using the result.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lahuerta.tecmm.edu.mx/edutrack/database"
+)
+
+// Environments the server can run in.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// DefaultJWTSecret is the insecure secret used in development when none is
+// configured. The server refuses to start with it in production.
+const DefaultJWTSecret = "edutrack-dev-secret-change-in-production"
+
+// MinJWTSecretLength is the minimum length of the JWT secret in production.
+const MinJWTSecretLength = 32
+
+// Config is the configuration of edutrackd.
+type Config struct {
+	// Environment is "development" or "production".
+	Environment string `toml:"environment"`
+
+	Server   ServerConfig   `toml:"server"`
+	TLS      TLSConfig      `toml:"tls"`
+	Database DatabaseConfig `toml:"database"`
+	CORS     CORSConfig     `toml:"cors"`
+	Auth     AuthConfig     `toml:"auth"`
+	Log      LogConfig      `toml:"log"`
+	SMTP     SMTPConfig     `toml:"smtp"`
+	Trash    TrashConfig    `toml:"trash"`
+	Storage  StorageConfig  `toml:"storage"`
+}
+
+// ServerConfig configures the HTTP server.
+type ServerConfig struct {
+	// Address to listen on (e.g., ":8080").
+	Addr string `toml:"addr"`
+
+	ReadTimeout  time.Duration `toml:"read_timeout"`
+	WriteTimeout time.Duration `toml:"write_timeout"`
+	IdleTimeout  time.Duration `toml:"idle_timeout"`
+
+	// Maximum time to wait for active connections on shutdown.
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout"`
+
+	// Time to keep serving after readiness starts failing on shutdown.
+	ShutdownDelay time.Duration `toml:"shutdown_delay"`
+}
+
+// TLSConfig configures HTTPS. TLS is enabled when both files are set.
+type TLSConfig struct {
+	CertFile string `toml:"cert_file"`
+	KeyFile  string `toml:"key_file"`
+
+	// Address of a plain HTTP listener redirecting to HTTPS (e.g., ":80").
+	// Empty disables it.
+	RedirectAddr string `toml:"redirect_addr"`
+
+	// Max age of the Strict-Transport-Security header. Zero disables HSTS.
+	HSTSMaxAge time.Duration `toml:"hsts_max_age"`
+}
+
+// Enabled returns true if the server must serve HTTPS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// DatabaseConfig configures the database connection and its pool.
+type DatabaseConfig struct {
+	// Connection URL, whose scheme selects the driver (postgres://,
+	// sqlite:// or memory://). Empty uses database.DefaultDSN.
+	URL string `toml:"url"`
+
+	// Pool sizes. Zero means unlimited (open) or the driver default (idle).
+	MaxOpenConns int `toml:"max_open_conns"`
+	MaxIdleConns int `toml:"max_idle_conns"`
+
+	// Maximum time a connection may be reused. Zero means forever.
+	ConnMaxLifetime time.Duration `toml:"conn_max_lifetime"`
+
+	// Level of the SQL log: "silent", "error", "warn" or "info".
+	LogLevel string `toml:"log_level"`
+}
+
+// CORSConfig configures the allowed cross-origin requests.
+type CORSConfig struct {
+	AllowedOrigins   []string `toml:"allowed_origins"`
+	AllowCredentials bool     `toml:"allow_credentials"`
+}
+
+// AuthConfig configures authentication tokens.
+type AuthConfig struct {
+	JWTSecret     string        `toml:"jwt_secret"`
+	TokenLifetime time.Duration `toml:"token_lifetime"`
+}
+
+// LogConfig configures logging.
+type LogConfig struct {
+	// Minimum level: "debug", "info", "warn" or "error".
+	Level string `toml:"level"`
+}
+
+// SlogLevel returns the level as a log/slog level.
+func (c LogConfig) SlogLevel() slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(c.Level))
+	return level
+}
+
+// SMTPConfig configures email delivery. Email is disabled without address.
+type SMTPConfig struct {
+	Addr     string `toml:"addr"`
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+	From     string `toml:"from"`
+}
+
+// TrashConfig configures the trash of deleted records.
+type TrashConfig struct {
+	// Time deleted records are kept before being purged. Zero keeps them
+	// until they are purged by hand.
+	Retention time.Duration `toml:"retention"`
+}
+
+// Storage backends of the uploaded files.
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// StorageConfig configures the storage of uploaded files, such as logos and
+// avatars.
+type StorageConfig struct {
+	// Backend is "local" (a directory of the disk) or "s3" (an
+	// S3-compatible service, such as AWS S3 or MinIO).
+	Backend string `toml:"backend"`
+
+	// Directory of the local backend.
+	Dir string `toml:"dir"`
+
+	// Lifetime of the signed download URLs.
+	URLLifetime time.Duration `toml:"url_lifetime"`
+
+	S3 S3Config `toml:"s3"`
+}
+
+// S3Config configures the S3-compatible backend.
+type S3Config struct {
+	// Base URL of the service (e.g., "http://localhost:9000").
+	Endpoint  string `toml:"endpoint"`
+	Region    string `toml:"region"`
+	Bucket    string `toml:"bucket"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+
+	// Whether the bucket goes in the path instead of the host, as MinIO
+	// expects.
+	PathStyle bool `toml:"path_style"`
+}
+
+// Default returns the default configuration.
+func Default() *Config {
+	return &Config{
+		Environment: EnvDevelopment,
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		TLS: TLSConfig{
+			HSTSMaxAge: 365 * 24 * time.Hour,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns: 25,
+			MaxIdleConns: 5,
+			LogLevel:     "warn",
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+		},
+		Auth: AuthConfig{
+			TokenLifetime: 24 * time.Hour,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Trash: TrashConfig{
+			Retention: 30 * 24 * time.Hour,
+		},
+		Storage: StorageConfig{
+			Backend:     StorageLocal,
+			Dir:         "data/files",
+			URLLifetime: 15 * time.Minute,
+			S3: S3Config{
+				Region: "us-east-1",
+			},
+		},
+	}
+}
+
+// Load returns the configuration built from the defaults, the TOML file at
+// path (skipped if empty) and the environment variables. The result is not
+// validated.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := decodeTOML(string(data), cfg); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// envVar maps an environment variable to a configuration value.
+type envVar struct {
+	name string
+	set  func(c *Config, value string) error
+}
+
+// envVars lists the environment variables overriding the configuration.
+var envVars = []envVar{
+	{"EDUTRACK_ENV", func(c *Config, v string) error { c.Environment = v; return nil }},
+	{"EDUTRACK_ADDR", func(c *Config, v string) error { c.Server.Addr = v; return nil }},
+	{"EDUTRACK_READ_TIMEOUT", durationVar(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{"EDUTRACK_WRITE_TIMEOUT", durationVar(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{"EDUTRACK_IDLE_TIMEOUT", durationVar(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{"EDUTRACK_SHUTDOWN_TIMEOUT", durationVar(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"EDUTRACK_SHUTDOWN_DELAY", durationVar(func(c *Config) *time.Duration { return &c.Server.ShutdownDelay })},
+	{"EDUTRACK_TLS_CERT", func(c *Config, v string) error { c.TLS.CertFile = v; return nil }},
+	{"EDUTRACK_TLS_KEY", func(c *Config, v string) error { c.TLS.KeyFile = v; return nil }},
+	{"EDUTRACK_TLS_REDIRECT_ADDR", func(c *Config, v string) error { c.TLS.RedirectAddr = v; return nil }},
+	{"EDUTRACK_HSTS_MAX_AGE", durationVar(func(c *Config) *time.Duration { return &c.TLS.HSTSMaxAge })},
+	{"DATABASE_URL", func(c *Config, v string) error { c.Database.URL = v; return nil }},
+	{"EDUTRACK_DB_MAX_OPEN_CONNS", intVar(func(c *Config) *int { return &c.Database.MaxOpenConns })},
+	{"EDUTRACK_DB_MAX_IDLE_CONNS", intVar(func(c *Config) *int { return &c.Database.MaxIdleConns })},
+	{"EDUTRACK_DB_LOG_LEVEL", func(c *Config, v string) error { c.Database.LogLevel = v; return nil }},
+	{"EDUTRACK_DB_CONN_MAX_LIFETIME", durationVar(func(c *Config) *time.Duration { return &c.Database.ConnMaxLifetime })},
+	{"EDUTRACK_CORS_ORIGINS", func(c *Config, v string) error { c.CORS.AllowedOrigins = splitList(v); return nil }},
+	{"EDUTRACK_CORS_CREDENTIALS", func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		c.CORS.AllowCredentials = b
+		return err
+	}},
+	{"EDUTRACK_JWT_SECRET", func(c *Config, v string) error { c.Auth.JWTSecret = v; return nil }},
+	{"EDUTRACK_TOKEN_LIFETIME", durationVar(func(c *Config) *time.Duration { return &c.Auth.TokenLifetime })},
+	{"EDUTRACK_LOG_LEVEL", func(c *Config, v string) error { c.Log.Level = v; return nil }},
+	{"EDUTRACK_SMTP_ADDR", func(c *Config, v string) error { c.SMTP.Addr = v; return nil }},
+	{"EDUTRACK_SMTP_USER", func(c *Config, v string) error { c.SMTP.Username = v; return nil }},
+	{"EDUTRACK_SMTP_PASSWORD", func(c *Config, v string) error { c.SMTP.Password = v; return nil }},
+	{"EDUTRACK_SMTP_FROM", func(c *Config, v string) error { c.SMTP.From = v; return nil }},
+	{"EDUTRACK_TRASH_RETENTION", durationVar(func(c *Config) *time.Duration { return &c.Trash.Retention })},
+	{"EDUTRACK_STORAGE_BACKEND", func(c *Config, v string) error { c.Storage.Backend = v; return nil }},
+	{"EDUTRACK_STORAGE_DIR", func(c *Config, v string) error { c.Storage.Dir = v; return nil }},
+	{"EDUTRACK_STORAGE_URL_LIFETIME", durationVar(func(c *Config) *time.Duration { return &c.Storage.URLLifetime })},
+	{"EDUTRACK_S3_ENDPOINT", func(c *Config, v string) error { c.Storage.S3.Endpoint = v; return nil }},
+	{"EDUTRACK_S3_REGION", func(c *Config, v string) error { c.Storage.S3.Region = v; return nil }},
+	{"EDUTRACK_S3_BUCKET", func(c *Config, v string) error { c.Storage.S3.Bucket = v; return nil }},
+	{"EDUTRACK_S3_ACCESS_KEY", func(c *Config, v string) error { c.Storage.S3.AccessKey = v; return nil }},
+	{"EDUTRACK_S3_SECRET_KEY", func(c *Config, v string) error { c.Storage.S3.SecretKey = v; return nil }},
+	{"EDUTRACK_S3_PATH_STYLE", func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		c.Storage.S3.PathStyle = b
+		return err
+	}},
+}
+
+// applyEnv overrides the configuration with the set environment variables.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	for _, env := range envVars {
+		value, ok := lookup(env.name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := env.set(c, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", env.name, err)
+		}
+	}
+	return nil
+}
+
+// durationVar returns a setter parsing a duration into a field.
+func durationVar(field func(c *Config) *time.Duration) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		*field(c) = d
+		return err
+	}
+}
+
+// intVar returns a setter parsing an integer into a field.
+func intVar(field func(c *Config) *int) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		i, err := strconv.Atoi(v)
+		*field(c) = i
+		return err
+	}
+}
+
+// splitList splits a comma-separated list, trimming spaces.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// IsProduction returns true if the server runs in production mode.
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvProduction
+}
+
+// Validate checks the configuration is usable, returning all the problems
+// found. In development an empty JWT secret is replaced by DefaultJWTSecret;
+// in production the secret must be set, not the default and long enough.
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Environment {
+	case EnvDevelopment, EnvProduction:
+	default:
+		add("environment must be %q or %q, got %q", EnvDevelopment, EnvProduction, c.Environment)
+	}
+
+	if c.Server.Addr == "" {
+		add("server.addr is required")
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+		{"auth.token_lifetime", c.Auth.TokenLifetime},
+		{"storage.url_lifetime", c.Storage.URLLifetime},
+	} {
+		if d.value <= 0 {
+			add("%s must be positive", d.name)
+		}
+	}
+	if c.Server.ShutdownDelay < 0 {
+		add("server.shutdown_delay cannot be negative")
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		add("tls.cert_file and tls.key_file must be set together")
+	}
+	if c.TLS.RedirectAddr != "" && !c.TLS.Enabled() {
+		add("tls.redirect_addr requires tls.cert_file and tls.key_file")
+	}
+	if c.TLS.RedirectAddr != "" && c.TLS.RedirectAddr == c.Server.Addr {
+		add("tls.redirect_addr must differ from server.addr")
+	}
+	if c.TLS.HSTSMaxAge < 0 {
+		add("tls.hsts_max_age cannot be negative")
+	}
+
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		add("database pool sizes cannot be negative")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("database.max_idle_conns cannot exceed database.max_open_conns")
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		add("database.conn_max_lifetime cannot be negative")
+	}
+	if c.Database.URL != "" {
+		if _, err := database.Scheme(c.Database.URL); err != nil {
+			add("database.url: %v", err)
+		}
+	}
+	if _, err := database.ParseLogLevel(c.Database.LogLevel); err != nil {
+		add("database.log_level must be silent, error, warn or info, got %q", c.Database.LogLevel)
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		add("cors.allowed_origins requires at least one origin")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" && c.CORS.AllowCredentials {
+			add("cors.allowed_origins cannot be \"*\" when cors.allow_credentials is enabled")
+		}
+	}
+
+	if c.Trash.Retention < 0 {
+		add("trash.retention cannot be negative")
+	}
+
+	switch c.Storage.Backend {
+	case StorageLocal:
+		if c.Storage.Dir == "" {
+			add("storage.dir is required with the local backend")
+		}
+	case StorageS3:
+		s3 := c.Storage.S3
+		if s3.Endpoint == "" || s3.Region == "" || s3.Bucket == "" {
+			add("storage.s3.endpoint, storage.s3.region and storage.s3.bucket are required with the s3 backend")
+		}
+		if s3.AccessKey == "" || s3.SecretKey == "" {
+			add("storage.s3.access_key and storage.s3.secret_key are required with the s3 backend")
+		}
+	default:
+		add("storage.backend must be %q or %q, got %q", StorageLocal, StorageS3, c.Storage.Backend)
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		add("log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+
+	if c.IsProduction() {
+		switch {
+		case c.Auth.JWTSecret == "" || c.Auth.JWTSecret == DefaultJWTSecret:
+			add("auth.jwt_secret must be set to a unique secret in production")
+		case len(c.Auth.JWTSecret) < MinJWTSecretLength:
+			add("auth.jwt_secret must have at least %d characters in production", MinJWTSecretLength)
+		}
+	} else if c.Auth.JWTSecret == "" {
+		c.Auth.JWTSecret = DefaultJWTSecret
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	cfg := Default()
+	err := decodeTOML(`
+# EduTrack configuration
+environment = "production"
+
+[server]
+addr = ":9090" # inline comment
+read_timeout = "5s"
+shutdown_delay = '2s'
+
+[database]
+url = "postgres://edutrack@db/edutrack?sslmode=disable#x"
+max_open_conns = 50
+max_idle_conns = 10
+
+[cors]
+allowed_origins = ["https://app.example.com", "https://admin.example.com"]
+allow_credentials = true
+
+[storage.s3]
+bucket = "edutrack"
+path_style = true
+`, cfg)
+	if err != nil {
+		t.Fatalf("decodeTOML() error = %v", err)
+	}
+
+	if cfg.Environment != EnvProduction {
+		t.Errorf("Environment = %q, want %q", cfg.Environment, EnvProduction)
+	}
+	if cfg.Server.Addr != ":9090" {
+		t.Errorf("Server.Addr = %q, want :9090", cfg.Server.Addr)
+	}
+	if cfg.Server.ReadTimeout != 5*time.Second {
+		t.Errorf("Server.ReadTimeout = %v, want 5s", cfg.Server.ReadTimeout)
+	}
+	if cfg.Server.WriteTimeout != 30*time.Second {
+		t.Errorf("Server.WriteTimeout = %v, want default 30s", cfg.Server.WriteTimeout)
+	}
+	if cfg.Server.ShutdownDelay != 2*time.Second {
+		t.Errorf("Server.ShutdownDelay = %v, want 2s", cfg.Server.ShutdownDelay)
+	}
+	if cfg.Database.URL != "postgres://edutrack@db/edutrack?sslmode=disable#x" {
+		t.Errorf("Database.URL = %q", cfg.Database.URL)
+	}
+	if cfg.Database.MaxOpenConns != 50 || cfg.Database.MaxIdleConns != 10 {
+		t.Errorf("pool = %d/%d, want 50/10", cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns)
+	}
+	want := []string{"https://app.example.com", "https://admin.example.com"}
+	if !slices.Equal(cfg.CORS.AllowedOrigins, want) {
+		t.Errorf("CORS.AllowedOrigins = %v, want %v", cfg.CORS.AllowedOrigins, want)
+	}
+	if !cfg.CORS.AllowCredentials {
+		t.Error("CORS.AllowCredentials = false, want true")
+	}
+	if cfg.Storage.S3.Bucket != "edutrack" || !cfg.Storage.S3.PathStyle {
+		t.Errorf("Storage.S3 = %+v, want bucket edutrack with path style", cfg.Storage.S3)
+	}
+}
+
+func TestDecodeTOML_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"unknown key", "[server]\nadress = \":80\"", `unknown key "server.adress"`},
+		{"unknown table", "[servers]\naddr = \":80\"", `unknown key "servers"`},
+		{"wrong type", "[database]\nmax_open_conns = \"many\"", "incompatible types"},
+		{"invalid duration", "[server]\nread_timeout = \"soon\"", "invalid duration"},
+		{"missing value", "[server]\naddr =", "expected value"},
+		{"not a pair", "[server]\naddr", "expected key separator '='"},
+		{"unterminated string", "environment = \"production", "expected '\"'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := decodeTOML(tt.data, Default())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("decodeTOML() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "edutrack.toml")
+	data := "[server]\naddr = \":9090\"\n\n[log]\nlevel = \"debug\"\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Environment variables override the file.
+	t.Setenv("EDUTRACK_ADDR", ":7070")
+	t.Setenv("EDUTRACK_CORS_ORIGINS", "https://a.example.com, https://b.example.com")
+	t.Setenv("EDUTRACK_TOKEN_LIFETIME", "1h")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Server.Addr != ":7070" {
+		t.Errorf("Server.Addr = %q, want :7070", cfg.Server.Addr)
+	}
+	if cfg.Log.Level != "debug" {
+		t.Errorf("Log.Level = %q, want debug", cfg.Log.Level)
+	}
+	if cfg.Auth.TokenLifetime != time.Hour {
+		t.Errorf("Auth.TokenLifetime = %v, want 1h", cfg.Auth.TokenLifetime)
+	}
+	want := []string{"https://a.example.com", "https://b.example.com"}
+	if !slices.Equal(cfg.CORS.AllowedOrigins, want) {
+		t.Errorf("CORS.AllowedOrigins = %v, want %v", cfg.CORS.AllowedOrigins, want)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("Load() with a missing file should fail")
+	}
+
+	t.Setenv("EDUTRACK_DB_MAX_OPEN_CONNS", "many")
+	_, err := Load("")
+	if err == nil || !strings.Contains(err.Error(), "EDUTRACK_DB_MAX_OPEN_CONNS") {
+		t.Errorf("Load() error = %v, want mentioning EDUTRACK_DB_MAX_OPEN_CONNS", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	const secret = "a-very-long-and-unique-production-secret"
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string // empty if valid
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"production with secret", func(c *Config) {
+			c.Environment = EnvProduction
+			c.Auth.JWTSecret = secret
+		}, ""},
+		{"production without secret", func(c *Config) {
+			c.Environment = EnvProduction
+		}, "auth.jwt_secret must be set"},
+		{"production with default secret", func(c *Config) {
+			c.Environment = EnvProduction
+			c.Auth.JWTSecret = DefaultJWTSecret
+		}, "auth.jwt_secret must be set"},
+		{"production with short secret", func(c *Config) {
+			c.Environment = EnvProduction
+			c.Auth.JWTSecret = "short"
+		}, "at least 32 characters"},
+		{"unknown environment", func(c *Config) { c.Environment = "staging" }, "environment must be"},
+		{"missing addr", func(c *Config) { c.Server.Addr = "" }, "server.addr is required"},
+		{"zero timeout", func(c *Config) { c.Server.WriteTimeout = 0 }, "server.write_timeout must be positive"},
+		{"zero token lifetime", func(c *Config) { c.Auth.TokenLifetime = 0 }, "auth.token_lifetime must be positive"},
+		{"tls cert without key", func(c *Config) { c.TLS.CertFile = "cert.pem" }, "must be set together"},
+		{"redirect without tls", func(c *Config) { c.TLS.RedirectAddr = ":80" }, "requires tls.cert_file"},
+		{"redirect on server addr", func(c *Config) {
+			c.TLS.CertFile, c.TLS.KeyFile = "cert.pem", "key.pem"
+			c.TLS.RedirectAddr = c.Server.Addr
+		}, "must differ from server.addr"},
+		{"negative hsts", func(c *Config) { c.TLS.HSTSMaxAge = -time.Second }, "tls.hsts_max_age"},
+		{"idle above open", func(c *Config) { c.Database.MaxIdleConns = 100 }, "cannot exceed"},
+		{"database url without scheme", func(c *Config) { c.Database.URL = "edutrack.db" }, "database.url"},
+		{"invalid database log level", func(c *Config) { c.Database.LogLevel = "debug" }, "database.log_level"},
+		{"negative pool", func(c *Config) { c.Database.MaxOpenConns = -1 }, "cannot be negative"},
+		{"no origins", func(c *Config) { c.CORS.AllowedOrigins = nil }, "at least one origin"},
+		{"wildcard with credentials", func(c *Config) { c.CORS.AllowCredentials = true }, "cannot be \"*\""},
+		{"invalid log level", func(c *Config) { c.Log.Level = "verbose" }, "log.level"},
+		{"trash kept forever", func(c *Config) { c.Trash.Retention = 0 }, ""},
+		{"negative trash retention", func(c *Config) { c.Trash.Retention = -time.Hour }, "trash.retention"},
+		{"unknown storage backend", func(c *Config) { c.Storage.Backend = "ftp" }, "storage.backend"},
+		{"local storage without dir", func(c *Config) { c.Storage.Dir = "" }, "storage.dir"},
+		{"zero url lifetime", func(c *Config) { c.Storage.URLLifetime = 0 }, "storage.url_lifetime must be positive"},
+		{"s3 storage", func(c *Config) {
+			c.Storage.Backend = StorageS3
+			c.Storage.S3 = S3Config{Endpoint: "http://localhost:9000", Region: "us-east-1", Bucket: "edutrack", AccessKey: "minio", SecretKey: "secret"}
+		}, ""},
+		{"s3 storage without bucket", func(c *Config) {
+			c.Storage.Backend = StorageS3
+			c.Storage.S3.Endpoint = "http://localhost:9000"
+		}, "storage.s3.bucket"},
+		{"s3 storage without credentials", func(c *Config) {
+			c.Storage.Backend = StorageS3
+			c.Storage.S3 = S3Config{Endpoint: "http://localhost:9000", Region: "us-east-1", Bucket: "edutrack"}
+		}, "storage.s3.access_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_DevelopmentSecret(t *testing.T) {
+	cfg := Default()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if cfg.Auth.JWTSecret != DefaultJWTSecret {
+		t.Errorf("JWTSecret = %q, want the development default", cfg.Auth.JWTSecret)
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Addr = ""
+	cfg.Log.Level = "verbose"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil")
+	}
+	for _, want := range []string{"server.addr", "log.level"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want containing %q", err, want)
+		}
+	}
+}
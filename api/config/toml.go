@@ -0,0 +1,22 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// decodeTOML decodes a TOML document into the struct pointed by dst.
+//
+// Struct fields are matched by their `toml` tag. Durations are written as
+// strings (e.g., "30s"). Unknown keys are reported as errors to catch typos.
+func decodeTOML(data string, dst any) error {
+	md, err := toml.Decode(data, dst)
+	if err != nil {
+		return err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("unknown key %q", undecoded[0].String())
+	}
+	return nil
+}
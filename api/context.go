@@ -1,6 +1,10 @@
 package edutrack
 
-import "context"
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
 
 // contextKey represents an internal key for adding context fields.
 // This is considered best practice as it prevents other packages from
@@ -12,6 +16,21 @@ type contextKey int
 const (
 	// Stores the current logged in account in the context.
 	accountContextKey = contextKey(iota + 1)
+
+	// Stores the API key used to authenticate the request, if any.
+	apiKeyContextKey
+
+	// Stores the ID used to correlate the logs of a request.
+	requestIDContextKey
+
+	// Stores the tenant the database operations are scoped to.
+	tenantScopeContextKey
+
+	// Stores the entity tags of the If-Match precondition of the request.
+	ifMatchContextKey
+
+	// Stores the transaction the services run their operations in.
+	txContextKey
 )
 
 // NewContextWithAccount returns a new context with the given account.
@@ -33,3 +52,55 @@ func AccountIDFromContext(ctx context.Context) uint {
 	}
 	return 0
 }
+
+// NewContextWithAPIKey returns a new context with the given API key.
+func NewContextWithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey, key)
+}
+
+// APIKeyFromContext returns the API key used to authenticate the request.
+// Returns nil if the request was authenticated with a session token.
+func APIKeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(apiKeyContextKey).(*APIKey)
+	return key
+}
+
+// NewContextWithRequestID returns a new context with the given request ID.
+func NewContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestIDFromContext returns the ID of the current request.
+// Returns an empty string if the context has no request ID.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// NewContextWithIfMatch returns a new context with the entity tags a record
+// must match to be modified.
+func NewContextWithIfMatch(ctx context.Context, etags []string) context.Context {
+	return context.WithValue(ctx, ifMatchContextKey, etags)
+}
+
+// IfMatchFromContext returns the entity tags a record must match to be
+// modified. Returns nil if the request has no If-Match precondition.
+func IfMatchFromContext(ctx context.Context) []string {
+	etags, _ := ctx.Value(ifMatchContextKey).([]string)
+	return etags
+}
+
+// NewContextWithTx returns a new context with a transaction the services
+// run their database operations in, so that they commit or roll back along
+// with the other writes of the caller.
+func NewContextWithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txContextKey, tx)
+}
+
+// contextDB returns the transaction of the context, or db if it has none.
+func contextDB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey).(*gorm.DB); ok {
+		return tx
+	}
+	return db
+}
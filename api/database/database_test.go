@@ -0,0 +1,179 @@
+package database_test
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/logger"
+
+	"lahuerta.tecmm.edu.mx/edutrack/database"
+	_ "lahuerta.tecmm.edu.mx/edutrack/database/postgres"
+	_ "lahuerta.tecmm.edu.mx/edutrack/database/sqlite"
+)
+
+func TestDrivers(t *testing.T) {
+	want := []string{"memory", "postgres", "postgresql", "sqlite"}
+	if got := database.Drivers(); !slices.Equal(got, want) {
+		t.Errorf("Drivers() = %v, want %v", got, want)
+	}
+}
+
+func TestScheme(t *testing.T) {
+	tests := []struct {
+		dsn     string
+		want    string
+		wantErr bool
+	}{
+		{"postgres://edutrack@localhost/edutrack", "postgres", false},
+		{"SQLite://edutrack.db", "sqlite", false},
+		{"memory://", "memory", false},
+		{"edutrack.db", "", true},
+		{"host=localhost user=edutrack", "", true},
+		{"://edutrack.db", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			got, err := database.Scheme(tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scheme() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Scheme() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpen_SQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "edutrack.db")
+
+	db, err := database.Open("sqlite://"+path, database.Options{
+		MaxOpenConns:    3,
+		ConnMaxLifetime: time.Minute,
+		LogLevel:        logger.Silent,
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	if err := db.Exec("CREATE TABLE things (id INTEGER)").Error; err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if got := sqlDB.Stats().MaxOpenConnections; got != 3 {
+		t.Errorf("MaxOpenConnections = %d, want 3", got)
+	}
+
+	// Foreign keys are enforced.
+	var foreignKeys int
+	if err := db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error; err != nil {
+		t.Fatalf("Failed to read foreign_keys: %v", err)
+	}
+	if foreignKeys != 1 {
+		t.Errorf("foreign_keys = %d, want 1", foreignKeys)
+	}
+
+	// The data is stored in the file.
+	other, err := database.Open("sqlite://"+path, database.Options{LogLevel: logger.Silent})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	otherDB, _ := other.DB()
+	defer otherDB.Close()
+
+	if !other.Migrator().HasTable("things") {
+		t.Error("table not found when reopening the file")
+	}
+}
+
+func TestOpen_Memory(t *testing.T) {
+	db, err := database.Open("memory://open-memory-test", database.Options{LogLevel: logger.Silent})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	if err := db.Exec("CREATE TABLE things (id INTEGER)").Error; err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Connections of the pool share the database: holding one forces the
+	// next to be a new connection.
+	held, err := sqlDB.Conn(t.Context())
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	defer held.Close()
+
+	conn, err := sqlDB.Conn(t.Context())
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(t.Context(), "INSERT INTO things (id) VALUES (1)"); err != nil {
+		t.Errorf("table not shared between connections: %v", err)
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{"no scheme", "edutrack.db", "must start with a scheme"},
+		{"unknown scheme", "mysql://localhost/edutrack", `no database driver for scheme "mysql"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := database.Open(tt.dsn, database.Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Open() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() with a duplicate scheme should panic")
+		}
+	}()
+	database.Register("sqlite", nil)
+}
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    logger.LogLevel
+		wantErr bool
+	}{
+		{"silent", logger.Silent, false},
+		{"error", logger.Error, false},
+		{"", logger.Warn, false},
+		{"WARN", logger.Warn, false},
+		{"info", logger.Info, false},
+		{"debug", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			got, err := database.ParseLogLevel(tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLogLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLogLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,21 @@ package postgres
 import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+
+	"lahuerta.tecmm.edu.mx/edutrack/database"
 )
 
+func init() {
+	database.Register("postgres", driver)
+	database.Register("postgresql", driver)
+}
+
+// driver returns the dialector for a postgres:// URL, which the PostgreSQL
+// driver accepts as is.
+func driver(dsn string) gorm.Dialector {
+	return postgres.Open(dsn)
+}
+
 // Open initializes a PostgreSQL database connection.
 // The dsn should be a valid PostgreSQL connection string, for example:
 // "host=localhost user=edutrack password=secret dbname=edutrack port=5432 sslmode=disable"
@@ -1,10 +1,52 @@
 package sqlite
 
 import (
+	"strings"
+
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+
+	"lahuerta.tecmm.edu.mx/edutrack/database"
 )
 
+func init() {
+	database.Register("sqlite", fileDriver)
+	database.Register("memory", memoryDriver)
+}
+
+// fileDriver returns the dialector for a sqlite:// URL. The rest of the URL
+// is the path of the file, optionally followed by driver parameters, e.g.,
+// "sqlite://edutrack.db" or "sqlite:///var/lib/edutrack/edutrack.db?_busy_timeout=5000".
+// Foreign keys are enforced unless the parameters disable them.
+func fileDriver(dsn string) gorm.Dialector {
+	_, path, _ := strings.Cut(dsn, "://")
+	return sqlite.Open(withForeignKeys(path))
+}
+
+// memoryDriver returns the dialector for a memory:// URL: an in-memory
+// database shared by the connections of the pool, named after the rest of
+// the URL (e.g., "memory://test"). Its data is lost when the last
+// connection closes, so it is only meant for development and tests.
+func memoryDriver(dsn string) gorm.Dialector {
+	_, name, _ := strings.Cut(dsn, "://")
+	if name == "" {
+		name = "edutrack"
+	}
+	return sqlite.Open(withForeignKeys("file:" + name + "?mode=memory&cache=shared"))
+}
+
+// withForeignKeys adds the parameter enabling foreign keys, which SQLite
+// does not enforce by default, to a data source name without it.
+func withForeignKeys(dsn string) string {
+	if strings.Contains(dsn, "_foreign_keys=") || strings.Contains(dsn, "_fk=") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&_foreign_keys=1"
+	}
+	return dsn + "?_foreign_keys=1"
+}
+
 // Open initializes an SQLite database.
 func Open(dsn string) (*gorm.DB, error) {
 	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
@@ -2,6 +2,7 @@ package edutrack
 
 import (
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,7 +16,11 @@ type App struct {
 }
 
 // New creates a new application instance with the given database connection.
+// The tenant scope callbacks are registered on the database.
 func New(db *gorm.DB) *App {
+	if db != nil {
+		_ = RegisterTenantScope(db)
+	}
 	return &App{
 		DB: db,
 	}
@@ -26,9 +31,9 @@ func (a *App) Migrate() error {
 	return Migrate(a.DB)
 }
 
-// Migrate runs all database migrations on the given database connection.
-func Migrate(db *gorm.DB) error {
-	models := []any{
+// models returns the models managed by the migrations, in migration order.
+func models() []any {
+	return []any{
 		&License{},
 		&Tenant{},
 		&Account{},
@@ -37,19 +42,272 @@ func Migrate(db *gorm.DB) error {
 		&Topic{},
 		&Teacher{},
 		&Student{},
+		&Section{},
+		&Enrollment{},
+		&Justification{},
 		&Attendance{},
 		&Grade{},
+		&GradeAmendment{},
+		&GradeAppeal{},
+		&GradeAppealMessage{},
+		&ExamAttempt{},
+		&GuardianLink{},
+		&Notification{},
+		&InboxMessage{},
+		&NotificationPreference{},
+		&TenantSettings{},
+		&Webhook{},
+		&WebhookDelivery{},
+		&APIKey{},
+	}
+}
+
+// legacyIndexes lists the indexes replaced by others in the models, which
+// migrations drop. The unique indexes were replaced by partial ones ignoring
+// deleted records, so their values can be reused.
+var legacyIndexes = []struct {
+	model any
+	name  string
+}{
+	{&Account{}, "idx_account_email_tenant"},
+	{&Career{}, "idx_career_tenant"},
+	{&Subject{}, "idx_subject_code_career_tenant"},
+	{&Student{}, "idx_student_tenant"},
+}
+
+// Migrate runs all database migrations on the given database connection.
+// The foreign keys whose delete rule changed in the models are recreated.
+func Migrate(db *gorm.DB) error {
+	if db.Dialector.Name() != "sqlite" {
+		return migrate(db)
+	}
+
+	// SQLite changes constraints by recreating the tables, which must neither
+	// check nor delete the rows referencing them, so foreign keys are
+	// disabled in the connection running the migrations.
+	return db.Connection(func(tx *gorm.DB) error {
+		tx = tx.Session(&gorm.Session{})
+
+		var enabled int
+		if err := tx.Raw("PRAGMA foreign_keys").Scan(&enabled).Error; err != nil {
+			return err
+		}
+		if enabled == 1 {
+			if err := tx.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+				return err
+			}
+			defer tx.Exec("PRAGMA foreign_keys = ON")
+		}
+		return migrate(tx)
+	})
+}
+
+func migrate(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, index := range legacyIndexes {
+		if !migrator.HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := migrator.DropIndex(index.model, index.name); err != nil {
+			return fmt.Errorf("failed to drop index %s: %w", index.name, err)
+		}
+	}
+
+	// Foreign keys are changed first, as SQLite drops the indexes of the
+	// tables it recreates, which AutoMigrate then creates again.
+	keys, err := changedForeignKeys(db)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		migrator := key.migrator(db)
+		if err := migrator.DropConstraint(key.model, key.Name); err != nil {
+			return fmt.Errorf("failed to drop foreign key %s: %w", key.Name, err)
+		}
+		if err := migrator.CreateConstraint(key.model, key.Name); err != nil {
+			return fmt.Errorf("failed to create foreign key %s: %w", key.Name, err)
+		}
+	}
+
+	// Subjects had their students before they had sections: the migration
+	// creating the sections moves the students to a section of each subject.
+	backfill := !migrator.HasTable(&Section{})
+
+	// Settings added after an institution configured its settings get their
+	// default, rather than the zero that disables them.
+	var settingsColumns []string
+	if migrator.HasTable(&TenantSettings{}) {
+		for column := range defaultSettingsColumns() {
+			if !migrator.HasColumn(&TenantSettings{}, column) {
+				settingsColumns = append(settingsColumns, column)
+			}
+		}
 	}
 
-	for _, model := range models {
+	for _, model := range models() {
 		if err := db.AutoMigrate(model); err != nil {
 			return fmt.Errorf("failed to migrate %T: %w", model, err)
 		}
 	}
 
+	if backfill {
+		if err := backfillSections(db); err != nil {
+			return fmt.Errorf("failed to create the sections of the subjects: %w", err)
+		}
+	}
+
+	if len(settingsColumns) > 0 {
+		if err := backfillSettings(db, settingsColumns); err != nil {
+			return fmt.Errorf("failed to set the default settings: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// defaultSettingsColumns returns the default values of the settings columns
+// whose zero value disables a feature.
+func defaultSettingsColumns() map[string]any {
+	defaults := DefaultTenantSettings("")
+	return map[string]any{
+		"appeal_days":            defaults.AppealDays,
+		"extraordinary_attempts": defaults.ExtraordinaryAttempts,
+		"special_attempts":       defaults.SpecialAttempts,
+	}
+}
+
+// backfillSettings sets the given new columns of the existing settings to
+// their default.
+func backfillSettings(db *gorm.DB, columns []string) error {
+	defaults := defaultSettingsColumns()
+	updates := make(map[string]any, len(columns))
+	for _, column := range columns {
+		updates[column] = defaults[column]
+	}
+	return db.Session(&gorm.Session{AllowGlobalUpdate: true}).Model(&TenantSettings{}).Updates(updates).Error
+}
+
+// backfillSections creates a section of every subject with students, taught
+// by the teacher of the subject, and enrolls the students in it.
+func backfillSections(db *gorm.DB) error {
+	var subjects []Subject
+	if err := db.Where("id IN (?)", db.Table("student_subjects").Select("subject_id")).Find(&subjects).Error; err != nil {
+		return err
+	}
+
+	for _, subject := range subjects {
+		var studentIDs []uint
+		if err := db.Table("student_subjects").Where("subject_id = ?", subject.ID).Pluck("student_id", &studentIDs).Error; err != nil {
+			return err
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			section := &Section{Name: "A", SubjectID: subject.ID, TeacherID: subject.TeacherID, TenantID: subject.TenantID}
+			if err := tx.Create(section).Error; err != nil {
+				return err
+			}
+			for _, studentID := range studentIDs {
+				enrollment := &Enrollment{
+					Status:    EnrollmentEnrolled,
+					SectionID: section.ID,
+					StudentID: studentID,
+					TenantID:  subject.TenantID,
+				}
+				if err := tx.Create(enrollment).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// changedForeignKeys returns the foreign keys whose ON DELETE action in the
+// database differs from the one of the models. Foreign keys missing from the
+// database are left to AutoMigrate.
+func changedForeignKeys(db *gorm.DB) ([]foreignKey, error) {
+	keys, err := foreignKeys(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []foreignKey
+	for _, key := range keys {
+		var actions []string
+		switch db.Dialector.Name() {
+		case "sqlite":
+			err = db.Raw(`SELECT on_delete FROM pragma_foreign_key_list(?) WHERE "from" = ? AND "table" = ?`,
+				key.Table, key.Column, key.Referenced).Scan(&actions).Error
+		case "postgres":
+			err = db.Raw(`SELECT rc.delete_rule FROM information_schema.referential_constraints rc
+				JOIN information_schema.table_constraints tc
+				ON tc.constraint_schema = rc.constraint_schema AND tc.constraint_name = rc.constraint_name
+				WHERE tc.table_schema = CURRENT_SCHEMA() AND tc.table_name = ? AND tc.constraint_name = ?`,
+				key.Table, key.Name).Scan(&actions).Error
+		default:
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect foreign key %s: %w", key.Name, err)
+		}
+		if len(actions) > 0 && actions[0] != key.onDelete {
+			changed = append(changed, key)
+		}
+	}
+	return changed, nil
+}
+
+// PendingMigrations returns the tables and columns of the models that are
+// missing from the database (e.g., "grades" or "grades.notes"), the legacy
+// indexes still present, prefixed with "-" (e.g., "-idx_career_tenant"), and
+// the foreign keys whose delete rule changed (e.g., "fk_subjects_topics").
+// An empty result means the schema is up to date.
+func PendingMigrations(db *gorm.DB) ([]string, error) {
+	var pending []string
+	migrator := db.Migrator()
+
+	for _, model := range models() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("failed to parse %T: %w", model, err)
+		}
+
+		table := stmt.Schema.Table
+		if !migrator.HasTable(model) {
+			pending = append(pending, table)
+			continue
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !migrator.HasColumn(model, field.DBName) {
+				pending = append(pending, table+"."+field.DBName)
+			}
+		}
+	}
+
+	for _, index := range legacyIndexes {
+		if migrator.HasIndex(index.model, index.name) {
+			pending = append(pending, "-"+index.name)
+		}
+	}
+
+	keys, err := changedForeignKeys(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		pending = append(pending, key.Name)
+	}
+
+	return pending, nil
+}
+
 // CreateTenant creates a new tenant with the specified license type.
 func (a *App) CreateTenant(name string, licenseType LicenseType, licenseDuration int) (*Tenant, error) {
 	// Convert days to duration.
@@ -133,6 +391,71 @@ func (a *App) CreateAccount(tenantID, name, email, password string, role Role) (
 	return account, nil
 }
 
+// CreateAPIKey creates a new API key acting on behalf of the given account.
+// It returns the stored key and the full key, which is not recoverable later.
+// An expiry of zero days creates a key that never expires.
+func (a *App) CreateAPIKey(tenantID string, accountID uint, name string, scopes []string, expiryDays int) (*APIKey, string, error) {
+	for _, scope := range scopes {
+		if !IsValidAPIKeyScope(scope) {
+			return nil, "", fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("at least one scope is required")
+	}
+
+	var account Account
+	if err := a.DB.Where("id = ? AND tenant_id = ?", accountID, tenantID).First(&account).Error; err != nil {
+		return nil, "", fmt.Errorf("account not found: %w", err)
+	}
+
+	key, prefix, err := GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiKey := &APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      HashAPIKey(key),
+		AccountID: account.ID,
+		TenantID:  tenantID,
+	}
+	apiKey.SetScopes(scopes)
+
+	if expiryDays > 0 {
+		expiresAt := time.Now().Add(DaysToDuration(expiryDays))
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := a.DB.Create(apiKey).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	return apiKey, key, nil
+}
+
+// ListAPIKeys returns the API keys of a tenant.
+func (a *App) ListAPIKeys(tenantID string) ([]APIKey, error) {
+	var keys []APIKey
+	if err := a.DB.Preload("Account").Where("tenant_id = ?", tenantID).Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey deletes an API key of a tenant so it can no longer be used.
+func (a *App) RevokeAPIKey(tenantID string, id uint) error {
+	result := a.DB.Where("tenant_id = ?", tenantID).Delete(&APIKey{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 // ListTenants returns all tenants in the system.
 func (a *App) ListTenants() ([]Tenant, error) {
 	var tenants []Tenant
@@ -0,0 +1,208 @@
+package edutrack
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+// Error codes of the domain errors. The transport layer maps them to its
+// own statuses (e.g., ENOTFOUND to 404 Not Found).
+const (
+	ECONFLICT     = "conflict"
+	EFORBIDDEN    = "forbidden"
+	EINTERNAL     = "internal"
+	EINVALID      = "invalid"
+	ENOTFOUND     = "not_found"
+	EPRECONDITION = "precondition_failed"
+	EUNAUTHORIZED = "unauthorized"
+)
+
+// Codes of the field errors, describing why the value of a field was
+// rejected.
+const (
+	FieldRequired      = "required"
+	FieldTooSmall      = "too_small"
+	FieldTooLarge      = "too_large"
+	FieldInvalidChoice = "invalid_choice"
+	FieldInvalidFormat = "invalid_format"
+	FieldNotFound      = "not_found"
+	FieldTaken         = "taken"
+)
+
+// Error represents a domain error. Its message is meant for end users, so
+// it is rendered in the locale of each of them.
+type Error struct {
+	// Machine-readable error code.
+	Code string
+
+	// Key of the human-readable message in the i18n catalog. Empty uses the
+	// default of the transport.
+	Message string
+
+	// Arguments of the message.
+	Args []any
+
+	// Fields rejected by an EINVALID error, or holding the conflicting
+	// value of an ECONFLICT one.
+	Fields []FieldError
+}
+
+// FieldError represents a field rejected by an error.
+type FieldError struct {
+	// Name of the field in requests, e.g., "career_id".
+	Field string `json:"field"`
+
+	// Machine-readable reason, e.g., FieldRequired.
+	Code string `json:"code"`
+
+	// Key of the human-readable message in the i18n catalog. ErrorFields
+	// renders it.
+	Message string `json:"message"`
+
+	// Arguments of the message.
+	Args []any `json:"-"`
+}
+
+// Invalid returns an EINVALID error rejecting the given fields.
+func Invalid(fields ...FieldError) *Error {
+	return &Error{Code: EINVALID, Message: "error.invalid", Fields: fields}
+}
+
+// InvalidField returns an EINVALID error rejecting a single field with the
+// given message of the i18n catalog.
+func InvalidField(field, code, message string, args ...any) *Error {
+	return Invalid(FieldError{Field: field, Code: code, Message: message, Args: args})
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return "edutrack: " + e.Code
+	}
+	return fmt.Sprintf("edutrack: %s: %s", e.Code, i18n.Message(i18n.Default, e.Message, e.Args...))
+}
+
+// Errorf returns a domain error with the given code, and message of the
+// i18n catalog formatted with args.
+func Errorf(code string, message string, args ...any) *Error {
+	return &Error{Code: code, Message: message, Args: args}
+}
+
+// ErrorCode returns the code of a domain error, or EINTERNAL for any other
+// error. It returns "" for a nil error.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return EINTERNAL
+}
+
+// ErrorMessage returns the message of a domain error in a locale. Other
+// errors are not meant for end users, so "" is returned.
+func ErrorMessage(err error, locale i18n.Locale) string {
+	var e *Error
+	if errors.As(err, &e) && e.Message != "" {
+		return i18n.Message(locale, e.Message, e.Args...)
+	}
+	return ""
+}
+
+// ErrorFields returns the fields rejected by a domain error, if any, with
+// their messages in a locale.
+func ErrorFields(err error, locale i18n.Locale) []FieldError {
+	var e *Error
+	if !errors.As(err, &e) || e.Fields == nil {
+		return nil
+	}
+
+	fields := make([]FieldError, len(e.Fields))
+	for i, field := range e.Fields {
+		fields[i] = FieldError{Field: field.Field, Code: field.Code, Message: i18n.Message(locale, field.Message, field.Args...)}
+	}
+	return fields
+}
+
+// TranslateDBError converts the database errors with a domain meaning into
+// domain errors: a missing record into ENOTFOUND and a unique or foreign key
+// constraint violation into ECONFLICT, with the field holding the duplicate
+// value when known and, for foreign keys, whether the referenced record is
+// missing or the deleted record is still referenced. Other errors are
+// returned as is.
+func TranslateDBError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &Error{Code: ENOTFOUND}
+	case isDuplicateKey(err):
+		conflict := &Error{Code: ECONFLICT}
+		if field := duplicateField(err); field != "" {
+			conflict.Fields = []FieldError{{Field: field, Code: FieldTaken, Message: "field.taken"}}
+		}
+		return conflict
+	case isForeignKeyViolation(err):
+		// Only PostgreSQL tells the deletions of referenced records apart.
+		if strings.Contains(strings.ToLower(err.Error()), "update or delete on table") {
+			return Errorf(ECONFLICT, "record.in_use")
+		}
+		return Errorf(ECONFLICT, "record.reference_missing")
+	}
+	return err
+}
+
+// uniqueFields lists the unique indexes of the models with the field of the
+// requests they make unique. SQLite reports the table of a violated index
+// and PostgreSQL its name.
+var uniqueFields = []struct {
+	index string
+	table string
+	field string
+}{
+	{"idx_account_email_tenant_undeleted", "accounts", "email"},
+	{"idx_student_tenant_undeleted", "students", "student_id"},
+	{"idx_career_tenant_undeleted", "careers", "code"},
+	{"idx_subject_code_career_tenant_undeleted", "subjects", "code"},
+	{"idx_guardian_student", "guardian_links", "student_id"},
+	{"idx_notification_preference", "notification_preferences", "event"},
+}
+
+// duplicateField returns the field of the unique index violated by an
+// error, or "" if unknown.
+func duplicateField(err error) string {
+	msg := err.Error()
+	for _, unique := range uniqueFields {
+		if strings.Contains(msg, `"`+unique.index+`"`) || strings.Contains(msg, " "+unique.table+".") {
+			return unique.field
+		}
+	}
+	return ""
+}
+
+// isDuplicateKey checks if an error is a unique constraint violation. The
+// messages of SQLite and PostgreSQL are checked as well, as the drivers only
+// translate them when gorm.Config.TranslateError is enabled.
+func isDuplicateKey(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unique constraint") || strings.Contains(msg, "duplicate key")
+}
+
+// isForeignKeyViolation checks if an error is a foreign key constraint
+// violation, with the messages of SQLite and PostgreSQL as isDuplicateKey.
+func isForeignKeyViolation(err error) bool {
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "foreign key constraint")
+}
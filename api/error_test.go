@@ -0,0 +1,154 @@
+package edutrack
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"domain error", &Error{Code: ENOTFOUND}, ENOTFOUND},
+		{"wrapped domain error", fmt.Errorf("loading student: %w", Errorf(ECONFLICT, "Ya existe.")), ECONFLICT},
+		{"other error", errors.New("connection refused"), EINTERNAL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(tt.err); got != tt.want {
+				t.Errorf("ErrorCode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := Errorf(ECONFLICT, "record.referenced", 2, "grades")
+	if got := ErrorMessage(err, i18n.Spanish); got != "No se puede eliminar el registro: aún es referenciado por 2 registro(s) de grades." {
+		t.Errorf("ErrorMessage(es) = %q", got)
+	}
+	if got := ErrorMessage(err, i18n.English); got != "The record cannot be deleted: it is still referenced by 2 record(s) of grades." {
+		t.Errorf("ErrorMessage(en) = %q", got)
+	}
+	if got := ErrorMessage(&Error{Code: ENOTFOUND}, i18n.English); got != "" {
+		t.Errorf("ErrorMessage() without a message = %q, want empty", got)
+	}
+	if got := ErrorMessage(errors.New("connection refused"), i18n.Default); got != "" {
+		t.Errorf("ErrorMessage() of a non-domain error = %q, want empty", got)
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	if got := (&Error{Code: ENOTFOUND}).Error(); got != "edutrack: not_found" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := Errorf(ENOTFOUND, "topic.not_found").Error(); got != "edutrack: not_found: El tema especificado no existe." {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := Errorf(EINVALID, "Solicitud inválida.").Error(); got != "edutrack: invalid: Solicitud inválida." {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestTranslateDBError(t *testing.T) {
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"record not found", fmt.Errorf("query: %w", gorm.ErrRecordNotFound), ENOTFOUND},
+		{"translated duplicate", gorm.ErrDuplicatedKey, ECONFLICT},
+		{"sqlite duplicate", errors.New("UNIQUE constraint failed: students.student_id, students.tenant_id"), ECONFLICT},
+		{"postgres duplicate", errors.New(`ERROR: duplicate key value violates unique constraint "idx_student_tenant" (SQLSTATE 23505)`), ECONFLICT},
+		{"translated foreign key", gorm.ErrForeignKeyViolated, ECONFLICT},
+		{"sqlite foreign key", errors.New("FOREIGN KEY constraint failed"), ECONFLICT},
+		{"postgres foreign key", errors.New(`ERROR: update or delete on table "careers" violates foreign key constraint "fk_careers_subjects" on table "subjects" (SQLSTATE 23503)`), ECONFLICT},
+		{"other", other, EINTERNAL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(TranslateDBError(tt.err)); got != tt.want {
+				t.Errorf("ErrorCode(TranslateDBError()) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if TranslateDBError(other) != other {
+		t.Error("TranslateDBError() should return other errors as is")
+	}
+}
+
+func TestTranslateDBError_ForeignKeyMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"translated foreign key", gorm.ErrForeignKeyViolated, "A referenced record does not exist."},
+		{"sqlite foreign key", errors.New("FOREIGN KEY constraint failed"), "A referenced record does not exist."},
+		{"postgres missing reference", errors.New(`ERROR: insert or update on table "subjects" violates foreign key constraint "fk_subjects_teacher" (SQLSTATE 23503)`), "A referenced record does not exist."},
+		{"postgres referenced record", errors.New(`ERROR: update or delete on table "careers" violates foreign key constraint "fk_careers_subjects" on table "subjects" (SQLSTATE 23503)`), "The record cannot be deleted: it is still referenced by other records."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorMessage(TranslateDBError(tt.err), i18n.English); got != tt.want {
+				t.Errorf("ErrorMessage(TranslateDBError()) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateDBError_Fields(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"sqlite duplicate", errors.New("UNIQUE constraint failed: students.student_id, students.tenant_id"), "student_id"},
+		{"postgres duplicate", errors.New(`ERROR: duplicate key value violates unique constraint "idx_account_email_tenant_undeleted" (SQLSTATE 23505)`), "email"},
+		{"unknown index", errors.New(`ERROR: duplicate key value violates unique constraint "idx_other" (SQLSTATE 23505)`), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := ErrorFields(TranslateDBError(tt.err), i18n.Default)
+			if tt.want == "" {
+				if len(fields) != 0 {
+					t.Errorf("ErrorFields() = %+v, want none", fields)
+				}
+				return
+			}
+			if len(fields) != 1 || fields[0].Field != tt.want || fields[0].Code != FieldTaken {
+				t.Errorf("ErrorFields() = %+v, want %s taken", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidField(t *testing.T) {
+	err := fmt.Errorf("creating grade: %w", InvalidField("student_id", FieldNotFound, "field.not_found", "student_id"))
+	if ErrorCode(err) != EINVALID {
+		t.Errorf("ErrorCode() = %q, want %q", ErrorCode(err), EINVALID)
+	}
+	want := FieldError{Field: "student_id", Code: FieldNotFound, Message: "The record referenced by student_id does not exist."}
+	if got := ErrorFields(err, i18n.English); len(got) != 1 || !reflect.DeepEqual(got[0], want) {
+		t.Errorf("ErrorFields() = %+v, want %+v", got, want)
+	}
+	if ErrorFields(errors.New("connection refused"), i18n.Default) != nil {
+		t.Error("ErrorFields() of a non-domain error should be nil")
+	}
+}
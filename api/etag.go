@@ -0,0 +1,36 @@
+package edutrack
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// modifiedMessage is the message of the errors of writes to records modified
+// by another request.
+const modifiedMessage = "record.modified"
+
+// ETag returns the entity tag of a record. It changes every time the record
+// is updated, so clients can detect concurrent modifications with it.
+func ETag(m gorm.Model) string {
+	return fmt.Sprintf(`"%x.%x"`, m.ID, m.UpdatedAt.UnixNano())
+}
+
+// CheckIfMatch checks a record matches the If-Match precondition of the
+// context, returning an EPRECONDITION error otherwise. The "*" entity tag
+// matches any record. Contexts without precondition match every record.
+func CheckIfMatch(ctx context.Context, m gorm.Model) error {
+	etags := IfMatchFromContext(ctx)
+	if etags == nil {
+		return nil
+	}
+
+	etag := ETag(m)
+	for _, candidate := range etags {
+		if candidate == "*" || candidate == etag {
+			return nil
+		}
+	}
+	return &Error{Code: EPRECONDITION, Message: modifiedMessage}
+}
@@ -0,0 +1,383 @@
+package edutrack
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExamKind represents the kind of an exam attempt.
+type ExamKind string
+
+const (
+	// ExamOrdinary is the final exam of the course. Without one, the
+	// average of the topic grades is the ordinary result of the subject.
+	ExamOrdinary ExamKind = "ordinary"
+
+	// ExamExtraordinary ("extraordinario") recovers a subject failed in the
+	// ordinary evaluation.
+	ExamExtraordinary ExamKind = "extraordinary"
+
+	// ExamSpecial ("título de suficiencia") is the last chance to pass a
+	// subject, once the extraordinary attempts are used up.
+	ExamSpecial ExamKind = "special"
+)
+
+// ExamAttempt is an exam of a student to pass a subject. Attempts are taken
+// in order: the ordinary one, then the extraordinary ones and finally the
+// special ones, each while the subject is failed and within the limits of
+// the institution. The last graded attempt decides the final grade.
+type ExamAttempt struct {
+	gorm.Model
+
+	// Kind of the attempt.
+	Kind ExamKind `gorm:"index"`
+
+	// Day of the exam, at midnight UTC like the attendance dates.
+	Date time.Time
+
+	// Grade of the exam, nil until it is recorded.
+	Value *float64
+
+	// Optional notes about the exam.
+	Notes string
+
+	// When the grade was recorded.
+	GradedAt *time.Time
+
+	// Foreign keys.
+
+	// StudentID links to the student taking the exam, deleted along with
+	// them.
+	StudentID uint    `gorm:"index"`
+	Student   Student `gorm:"constraint:OnDelete:CASCADE"`
+
+	// SubjectID links to the subject of the exam, deleted along with it.
+	SubjectID uint    `gorm:"index"`
+	Subject   Subject `gorm:"constraint:OnDelete:CASCADE"`
+
+	// GraderID links to the account that recorded the grade.
+	GraderID *uint
+	Grader   *Account `gorm:"constraint:OnDelete:SET NULL"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// IsGraded reports whether the grade of the attempt was recorded.
+func (e *ExamAttempt) IsGraded() bool {
+	return e.Value != nil
+}
+
+// SubjectResult is the final result of a student in a subject.
+type SubjectResult struct {
+	// Final grade: the grade of the last graded attempt, or the average of
+	// the topic grades without graded attempts.
+	Value float64
+
+	// Kind of the attempt the final grade comes from, ExamOrdinary for the
+	// average of the topic grades.
+	Kind ExamKind
+
+	// Whether the final grade reaches the passing grade of the institution.
+	Passed bool
+}
+
+// FinalResult returns the result of a student in a subject given the
+// average of their topic grades and their attempts, ordered as taken.
+func (s *TenantSettings) FinalResult(average float64, attempts []ExamAttempt) SubjectResult {
+	result := SubjectResult{Value: average, Kind: ExamOrdinary}
+	for _, attempt := range attempts {
+		if attempt.IsGraded() {
+			result.Value = *attempt.Value
+			result.Kind = attempt.Kind
+		}
+	}
+	result.Passed = s.Passed(result.Value)
+	return result
+}
+
+// ExamAttemptFilter represents the filters of FindExamAttempts.
+type ExamAttemptFilter struct {
+	StudentID uint
+	SubjectID uint
+	Kind      ExamKind
+}
+
+// ExamAttemptCreate represents the fields of a new exam attempt.
+type ExamAttemptCreate struct {
+	Kind      ExamKind
+	Date      time.Time
+	Notes     string
+	StudentID uint
+	SubjectID uint
+}
+
+// FindExamAttempts returns the exam attempts visible to the account of the
+// context, ordered as taken: students see their own, guardians those of
+// their linked students, teachers those of the subjects they teach and
+// secretaries all of the institution.
+func FindExamAttempts(ctx context.Context, db *gorm.DB, filter ExamAttemptFilter) ([]ExamAttempt, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	query := db
+	switch {
+	case account.IsStudent():
+		student, err := findOwnStudent(db, account)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("student_id = ?", student.ID)
+	case account.IsGuardian():
+		ids, err := GuardianStudentIDs(db, account.ID, account.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("student_id IN ?", ids)
+	case account.IsTeacher():
+		query = query.Where("subject_id IN (?)", taughtSubjects(db, account))
+	}
+
+	if filter.StudentID != 0 {
+		query = query.Where("student_id = ?", filter.StudentID)
+	}
+	if filter.SubjectID != 0 {
+		query = query.Where("subject_id = ?", filter.SubjectID)
+	}
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+
+	var attempts []ExamAttempt
+	if err := query.Preload("Student.Account").Preload("Subject").Order("id").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// FindExamAttemptByID returns an exam attempt visible to the account of the
+// context, with its student and subject.
+func FindExamAttemptByID(ctx context.Context, db *gorm.DB, id uint) (*ExamAttempt, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	var attempt ExamAttempt
+	if err := db.Preload("Student.Account").Preload("Subject").First(&attempt, id).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	switch {
+	case account.IsStudent(), account.IsGuardian():
+		if err := checkStudentAccess(ctx, db, account, attempt.StudentID); err != nil {
+			return nil, err
+		}
+	case account.IsTeacher():
+		if !isSubjectTeacher(db, account, attempt.Subject) {
+			return nil, &Error{Code: EFORBIDDEN}
+		}
+	}
+	return &attempt, nil
+}
+
+// ScheduleExamAttempt schedules an exam of a student enrolled in a subject
+// as the secretary of the context. It fails with ECONFLICT if the student
+// passed the subject, has an attempt awaiting its grade, or cannot take an
+// attempt of the kind: the ordinary attempt must be the first one, special
+// attempts follow the extraordinary ones, and neither can exceed the
+// limits of the institution.
+func ScheduleExamAttempt(ctx context.Context, db *gorm.DB, create ExamAttemptCreate) (*ExamAttempt, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	var student Student
+	if err := db.First(&student, create.StudentID).Error; err != nil {
+		return nil, InvalidField("student_id", FieldNotFound, "student.not_found")
+	}
+	var subject Subject
+	if err := db.First(&subject, create.SubjectID).Error; err != nil {
+		return nil, InvalidField("subject_id", FieldNotFound, "subject.not_found")
+	}
+	var enrolled int64
+	err := db.Table("student_subjects").
+		Where("student_id = ? AND subject_id = ?", student.ID, subject.ID).
+		Count(&enrolled).Error
+	if err != nil {
+		return nil, err
+	}
+	if enrolled == 0 {
+		return nil, InvalidField("student_id", FieldNotFound, "exam.not_enrolled")
+	}
+
+	settings, err := FindTenantSettings(db, account.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := subjectAttempts(db, student.ID, subject.ID)
+	if err != nil {
+		return nil, err
+	}
+	average, err := topicAverage(db, &settings, student.ID, subject.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.checkNextAttempt(create.Kind, average, attempts); err != nil {
+		return nil, err
+	}
+
+	attempt := &ExamAttempt{
+		Kind:      create.Kind,
+		Date:      create.Date,
+		Notes:     create.Notes,
+		StudentID: student.ID,
+		SubjectID: subject.ID,
+		TenantID:  account.TenantID,
+	}
+	if err := db.Create(attempt).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+	return FindExamAttemptByID(ctx, db, attempt.ID)
+}
+
+// checkNextAttempt checks a student with the given average of topic grades
+// and attempts in a subject can take an attempt of the kind.
+func (s *TenantSettings) checkNextAttempt(kind ExamKind, average float64, attempts []ExamAttempt) error {
+	counts := map[ExamKind]int{}
+	for _, attempt := range attempts {
+		if !attempt.IsGraded() {
+			return Errorf(ECONFLICT, "exam.pending")
+		}
+		counts[attempt.Kind]++
+	}
+	// Passed subjects need no recovery. The ordinary attempt replaces the
+	// average of the topic grades, so it is allowed even if it passes.
+	if (kind != ExamOrdinary || len(attempts) > 0) && s.FinalResult(average, attempts).Passed {
+		return Errorf(ECONFLICT, "exam.passed")
+	}
+
+	switch kind {
+	case ExamOrdinary:
+		if len(attempts) > 0 {
+			return Errorf(ECONFLICT, "exam.ordinary_taken")
+		}
+	case ExamExtraordinary:
+		if counts[ExamSpecial] > 0 {
+			return Errorf(ECONFLICT, "exam.special_taken")
+		}
+		if counts[ExamExtraordinary] >= s.ExtraordinaryAttempts {
+			return Errorf(ECONFLICT, "exam.limit", s.ExtraordinaryAttempts)
+		}
+	case ExamSpecial:
+		if counts[ExamExtraordinary] < s.ExtraordinaryAttempts {
+			return Errorf(ECONFLICT, "exam.extraordinary_pending")
+		}
+		if counts[ExamSpecial] >= s.SpecialAttempts {
+			return Errorf(ECONFLICT, "exam.limit", s.SpecialAttempts)
+		}
+	default:
+		return InvalidField("kind", FieldInvalidChoice, "field.invalid_choice", "ordinary, extraordinary, special")
+	}
+	return nil
+}
+
+// GradeExamAttempt records the grade of an attempt as the teacher of the
+// subject or a secretary. Only the last attempt of the student in the
+// subject can be graded, as the next ones depend on its result.
+func GradeExamAttempt(ctx context.Context, db *gorm.DB, attempt *ExamAttempt, value float64, notes *string) error {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	if !account.IsSecretary() && !isSubjectTeacher(db, account, attempt.Subject) {
+		return &Error{Code: EFORBIDDEN}
+	}
+	settings, err := FindTenantSettings(db, attempt.TenantID)
+	if err != nil {
+		return err
+	}
+	if err := settings.ValidateGrade("value", value); err != nil {
+		return err
+	}
+	if err := checkLastAttempt(db, attempt); err != nil {
+		return err
+	}
+
+	now := db.NowFunc()
+	updates := map[string]any{"value": value, "graded_at": now, "grader_id": account.ID}
+	if notes != nil {
+		updates["notes"] = *notes
+	}
+	if err := db.Model(attempt).Updates(updates).Error; err != nil {
+		return TranslateDBError(err)
+	}
+	attempt.Value = &value
+	attempt.GradedAt = &now
+	attempt.GraderID = &account.ID
+	if notes != nil {
+		attempt.Notes = *notes
+	}
+	return nil
+}
+
+// CancelExamAttempt deletes an attempt as the secretary of the context.
+// Only the last attempt of the student in the subject can be deleted.
+func CancelExamAttempt(ctx context.Context, db *gorm.DB, attempt *ExamAttempt) error {
+	db = TenantDB(ctx, db)
+	if err := checkLastAttempt(db, attempt); err != nil {
+		return err
+	}
+	return TranslateDBError(DeleteRecord(db, attempt))
+}
+
+// checkLastAttempt checks no attempt of the student in the subject follows
+// the given one.
+func checkLastAttempt(db *gorm.DB, attempt *ExamAttempt) error {
+	attempts, err := subjectAttempts(db, attempt.StudentID, attempt.SubjectID)
+	if err != nil {
+		return err
+	}
+	if last := attempts[len(attempts)-1]; last.ID != attempt.ID {
+		return Errorf(ECONFLICT, "exam.superseded")
+	}
+	return nil
+}
+
+// subjectAttempts returns the attempts of a student in a subject, ordered
+// as taken, which is the order they were scheduled in.
+func subjectAttempts(db *gorm.DB, studentID, subjectID uint) ([]ExamAttempt, error) {
+	var attempts []ExamAttempt
+	err := db.Where("student_id = ? AND subject_id = ?", studentID, subjectID).
+		Order("id").Find(&attempts).Error
+	return attempts, err
+}
+
+// topicAverage returns the average of the published topic grades of a
+// student in a subject in the current academic period, like the reports,
+// or zero without grades.
+func topicAverage(db *gorm.DB, settings *TenantSettings, studentID, subjectID uint) (float64, error) {
+	var average *float64
+	query := db.Model(&Grade{}).Select("AVG(value)").
+		Where("student_id = ? AND status <> ?", studentID, GradeDraft).
+		Where("topic_id IN (?)", db.Model(&Topic{}).Select("id").Where("subject_id = ?", subjectID))
+	if !settings.AcademicStart.IsZero() {
+		query = query.Where("created_at >= ?", settings.AcademicStartTime())
+	}
+	err := query.Scan(&average).Error
+	if err != nil || average == nil {
+		return 0, err
+	}
+	return *average, nil
+}
@@ -3,6 +3,7 @@ module lahuerta.tecmm.edu.mx/edutrack
 go 1.25.5
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/golang-jwt/jwt/v5 v5.2.2
 	golang.org/x/crypto v0.45.0
 	gorm.io/driver/postgres v1.6.0
@@ -1,28 +1,589 @@
 package edutrack
 
-import "gorm.io/gorm"
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GradeStatus represents the publication state of a grade.
+type GradeStatus string
+
+const (
+	// GradeDraft is only visible to teachers and secretaries.
+	GradeDraft GradeStatus = "draft"
+
+	// GradePublished is visible to the student and their guardians.
+	GradePublished GradeStatus = "published"
+
+	// GradeLocked is published and past the deadline of its topic: only
+	// secretaries can amend it, with a reason.
+	GradeLocked GradeStatus = "locked"
+)
 
 // Grade represents a student's grade for a specific topic within a subject.
 type Grade struct {
 	gorm.Model
 
-	// The numeric grade value (e.g., 0-100 or 0-10).
+	// The numeric grade value, within the grade scale of the institution.
 	Value float64
 
 	// Optional description or notes about the grade.
 	Notes string
 
+	// Publication state. Grades recorded before publication states existed
+	// are published.
+	Status GradeStatus `gorm:"default:'published';index"`
+
+	// When the grade was published, nil for drafts.
+	PublishedAt *time.Time
+
 	// Foreign keys.
 
-	// The student who received this grade.
+	// The student who received this grade, deleted along with them.
 	StudentID uint
-	Student   Student
+	Student   Student `gorm:"constraint:OnDelete:CASCADE"`
 
 	// The topic this grade is for.
 	TopicID uint
 	Topic   Topic
 
+	// The section of the subject the student was enrolled in when graded,
+	// if any. Deleting the section keeps the grade.
+	SectionID *uint    `gorm:"index"`
+	Section   *Section `gorm:"constraint:OnDelete:SET NULL"`
+
 	// TenantID for multi-tenant support.
 	TenantID string
 	Tenant   Tenant
 }
+
+// GradeService manages the grades of the tenant of the account in the
+// context.
+type GradeService interface {
+	// FindGrades returns the grades visible to the account: their own for
+	// students, the ones of the linked students for guardians and the ones
+	// matching the filter for everyone else.
+	FindGrades(ctx context.Context, filter GradeFilter) ([]Grade, error)
+
+	// FindGradeByID returns a grade with its student, topic and subject.
+	FindGradeByID(ctx context.Context, id uint) (*Grade, error)
+
+	// CreateGrade records a grade of a student for a topic.
+	CreateGrade(ctx context.Context, create GradeCreate) (*Grade, error)
+
+	// UpdateGrade updates the set fields of a grade. It fails with
+	// EPRECONDITION if the grade does not match the If-Match precondition
+	// of the context or is modified concurrently.
+	UpdateGrade(ctx context.Context, id uint, update GradeUpdate) (*Grade, error)
+
+	// DeleteGrade deletes a grade, with the same precondition as UpdateGrade.
+	// Locked grades cannot be deleted.
+	DeleteGrade(ctx context.Context, id uint) error
+
+	// PublishTopicGrades publishes the draft grades of a topic, returning
+	// them with their student, topic and subject.
+	PublishTopicGrades(ctx context.Context, topicID uint) ([]Grade, error)
+
+	// FindGradeAmendments returns the amendments of a locked grade, oldest
+	// first.
+	FindGradeAmendments(ctx context.Context, id uint) ([]GradeAmendment, error)
+
+	// PreviewDeleteGrade returns what DeleteGrade would do, without deleting
+	// anything.
+	PreviewDeleteGrade(ctx context.Context, id uint) (*DeletePreview, error)
+}
+
+// GradeFilter represents the filters of FindGrades. Zero values are ignored.
+// Only StudentID applies to guardians, and none to students.
+type GradeFilter struct {
+	StudentID uint
+	TopicID   uint
+	SectionID uint
+	Status    GradeStatus
+}
+
+// GradeCreate represents the fields of a new grade.
+type GradeCreate struct {
+	Value     float64
+	Notes     string
+	StudentID uint
+	TopicID   uint
+
+	// GradeDraft by default.
+	Status GradeStatus
+
+	// Reason of the amendment, required to grade a topic past its deadline.
+	Reason string
+}
+
+// GradeUpdate represents the fields to update of a grade. Nil fields are
+// kept.
+type GradeUpdate struct {
+	Value *float64
+	Notes *string
+
+	// Only moves a draft to GradePublished.
+	Status *GradeStatus
+
+	// Reason of the amendment, required to change a locked grade.
+	Reason string
+}
+
+// GradeAmendment records a change of a locked grade by a secretary.
+type GradeAmendment struct {
+	gorm.Model
+
+	// Why the grade was changed.
+	Reason string
+
+	// Value and notes before and after the change. PreviousValue is nil for
+	// grades recorded past the deadline.
+	PreviousValue *float64
+	Value         float64
+	PreviousNotes string
+	Notes         string
+
+	// Foreign keys.
+
+	// GradeID links to the amended grade, deleted along with it.
+	GradeID uint  `gorm:"index"`
+	Grade   Grade `gorm:"constraint:OnDelete:CASCADE"`
+
+	// AccountID links to the secretary that amended the grade.
+	AccountID *uint
+	Account   *Account `gorm:"constraint:OnDelete:SET NULL"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// IsLocked reports whether the grade can only be amended by secretaries:
+// it is locked, or the deadline of its topic, which must be loaded, passed.
+func (g *Grade) IsLocked(now time.Time) bool {
+	return g.Status == GradeLocked || g.Topic.GradesLocked(now)
+}
+
+// PublicationTime returns when the grade was published, or when it was
+// created for the grades published before the time was recorded.
+func (g *Grade) PublicationTime() time.Time {
+	if g.PublishedAt != nil {
+		return *g.PublishedAt
+	}
+	return g.CreatedAt
+}
+
+// LockDueGrades locks the published grades of the topics whose deadline
+// passed, returning the number of locked grades.
+func LockDueGrades(ctx context.Context, db *gorm.DB, now time.Time) (int64, error) {
+	topics := db.Model(&Topic{}).Select("id").Where("grades_lock_at <= ?", now)
+	result := db.WithContext(ctx).Model(&Grade{}).
+		Where("status = ? AND topic_id IN (?)", GradePublished, topics).
+		Update("status", GradeLocked)
+	return result.RowsAffected, result.Error
+}
+
+// NewGradeService returns a GradeService backed by the database.
+func NewGradeService(db *gorm.DB) GradeService {
+	return &gradeService{db: db}
+}
+
+type gradeService struct {
+	db *gorm.DB
+}
+
+func (s *gradeService) FindGrades(ctx context.Context, filter GradeFilter) ([]Grade, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db := TenantDB(ctx, contextDB(ctx, s.db))
+
+	var query *gorm.DB
+	switch {
+	case account.IsStudent():
+		// Students can only see their own grades.
+		student, err := findOwnStudent(db, account)
+		if err != nil {
+			return nil, err
+		}
+		query = db.Where("student_id = ? AND status <> ?", student.ID, GradeDraft)
+	case account.IsGuardian():
+		// Guardians can only see the grades of their linked students.
+		ids, err := GuardianStudentIDs(db, account.ID, account.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		query = db.Where("student_id IN ? AND status <> ?", ids, GradeDraft)
+		if filter.StudentID != 0 {
+			query = query.Where("student_id = ?", filter.StudentID)
+		}
+	default:
+		query = db
+		if filter.StudentID != 0 {
+			query = query.Where("student_id = ?", filter.StudentID)
+		}
+		if filter.TopicID != 0 {
+			query = query.Where("topic_id = ?", filter.TopicID)
+		}
+		if filter.SectionID != 0 {
+			query = query.Where("section_id = ?", filter.SectionID)
+		}
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+	}
+
+	var grades []Grade
+	if err := query.Preload("Student.Account").Preload("Topic.Subject").Find(&grades).Error; err != nil {
+		return nil, err
+	}
+	return grades, nil
+}
+
+func (s *gradeService) FindGradeByID(ctx context.Context, id uint) (*Grade, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db := contextDB(ctx, s.db).WithContext(ctx)
+
+	var grade Grade
+	if err := TenantDB(ctx, db).Preload("Student.Account").Preload("Topic.Subject").First(&grade, id).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	if grade.Status == GradeDraft && (account.IsStudent() || account.IsGuardian()) {
+		// Drafts are hidden until they are published.
+		return nil, &Error{Code: ENOTFOUND}
+	}
+
+	if account.IsStudent() {
+		// Students can only access their own grades.
+		student, err := findOwnStudent(db, account)
+		if err != nil {
+			return nil, err
+		}
+		if grade.StudentID != student.ID {
+			return nil, &Error{Code: EFORBIDDEN}
+		}
+	}
+
+	if account.IsGuardian() {
+		// Guardians can only access the grades of their linked students.
+		linked, err := IsGuardianOf(ctx, db, account.ID, grade.StudentID)
+		if err != nil {
+			return nil, err
+		}
+		if !linked {
+			return nil, &Error{Code: EFORBIDDEN}
+		}
+	}
+
+	return &grade, nil
+}
+
+func (s *gradeService) CreateGrade(ctx context.Context, create GradeCreate) (*Grade, error) {
+	account, err := gradeEditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db := TenantDB(ctx, contextDB(ctx, s.db))
+
+	var missing []FieldError
+	if create.StudentID == 0 {
+		missing = append(missing, FieldError{Field: "student_id", Code: FieldRequired, Message: "grade.student_id.missing"})
+	}
+	if create.TopicID == 0 {
+		missing = append(missing, FieldError{Field: "topic_id", Code: FieldRequired, Message: "grade.topic_id.missing"})
+	}
+	if missing != nil {
+		return nil, Invalid(missing...)
+	}
+
+	// Verify the topic exists and belongs to the same tenant.
+	var topic Topic
+	if err := db.First(&topic, create.TopicID).Error; err != nil {
+		return nil, InvalidField("topic_id", FieldNotFound, "topic.not_found")
+	}
+
+	settings, err := FindTenantSettings(db, account.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.ValidateGrade("value", create.Value); err != nil {
+		return nil, err
+	}
+
+	// The grade is scoped to the section the student attends.
+	sectionID, err := StudentSectionID(db, create.StudentID, topic.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	grade := &Grade{
+		Value:     create.Value,
+		Notes:     create.Notes,
+		Status:    create.Status,
+		StudentID: create.StudentID,
+		TopicID:   create.TopicID,
+		SectionID: sectionID,
+		TenantID:  account.TenantID,
+	}
+	if grade.Status == "" {
+		grade.Status = GradeDraft
+	}
+	if grade.Status != GradeDraft {
+		now := db.NowFunc()
+		grade.PublishedAt = &now
+	}
+
+	// Grading a topic past its deadline amends its locked grades.
+	locked := topic.GradesLocked(db.NowFunc())
+	if locked {
+		if err := checkAmendment(account, create.Reason); err != nil {
+			return nil, err
+		}
+		if grade.Status == GradePublished {
+			grade.Status = GradeLocked
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(grade).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		return tx.Create(&GradeAmendment{
+			Reason:    create.Reason,
+			Value:     grade.Value,
+			Notes:     grade.Notes,
+			GradeID:   grade.ID,
+			AccountID: &account.ID,
+			TenantID:  account.TenantID,
+		}).Error
+	})
+	if err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	// Reload with associations.
+	if err := db.Preload("Student.Account").Preload("Topic.Subject").First(grade, grade.ID).Error; err != nil {
+		return nil, err
+	}
+	return grade, nil
+}
+
+func (s *gradeService) UpdateGrade(ctx context.Context, id uint, update GradeUpdate) (*Grade, error) {
+	db := contextDB(ctx, s.db).WithContext(ctx)
+
+	grade, err := findEditableGrade(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckIfMatch(ctx, grade.Model); err != nil {
+		return nil, err
+	}
+
+	account := AccountFromContext(ctx)
+	locked := grade.IsLocked(db.NowFunc())
+	if locked {
+		if err := checkAmendment(account, update.Reason); err != nil {
+			return nil, err
+		}
+	}
+	previous := grade.Value
+	amendment := &GradeAmendment{
+		Reason:        update.Reason,
+		PreviousValue: &previous,
+		PreviousNotes: grade.Notes,
+		GradeID:       grade.ID,
+		AccountID:     &account.ID,
+		TenantID:      grade.TenantID,
+	}
+
+	if update.Value != nil {
+		settings, err := FindTenantSettings(db, grade.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		if err := settings.ValidateGrade("value", *update.Value); err != nil {
+			return nil, err
+		}
+		grade.Value = *update.Value
+	}
+	if update.Notes != nil {
+		grade.Notes = *update.Notes
+	}
+	if update.Status != nil && *update.Status != grade.Status {
+		// Grades only move forward: published grades stay published.
+		if grade.Status != GradeDraft || *update.Status != GradePublished {
+			return nil, InvalidField("status", FieldInvalidChoice, "grade.status.unpublish")
+		}
+		grade.Status = GradePublished
+		now := db.NowFunc()
+		grade.PublishedAt = &now
+	}
+	if locked && grade.Status == GradePublished {
+		grade.Status = GradeLocked
+	}
+
+	err = TenantDB(ctx, contextDB(ctx, s.db)).Transaction(func(tx *gorm.DB) error {
+		// Omit the topic, loaded to check its deadline.
+		if err := tx.Omit("Topic").Save(grade).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		amendment.Value = grade.Value
+		amendment.Notes = grade.Notes
+		return tx.Create(amendment).Error
+	})
+	if err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	// Reload with associations.
+	if err := db.Preload("Student.Account").Preload("Topic.Subject").First(grade, grade.ID).Error; err != nil {
+		return nil, err
+	}
+	return grade, nil
+}
+
+func (s *gradeService) DeleteGrade(ctx context.Context, id uint) error {
+	db := contextDB(ctx, s.db).WithContext(ctx)
+
+	grade, err := findDeletableGrade(ctx, db, id)
+	if err != nil {
+		return err
+	}
+	return TranslateDBError(DeleteRecord(TenantDB(ctx, contextDB(ctx, s.db)), grade))
+}
+
+func (s *gradeService) PreviewDeleteGrade(ctx context.Context, id uint) (*DeletePreview, error) {
+	db := contextDB(ctx, s.db).WithContext(ctx)
+
+	grade, err := findDeletableGrade(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	return PreviewDelete(TenantDB(ctx, contextDB(ctx, s.db)), grade)
+}
+
+func (s *gradeService) PublishTopicGrades(ctx context.Context, topicID uint) ([]Grade, error) {
+	account, err := gradeEditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db := TenantDB(ctx, contextDB(ctx, s.db))
+
+	var topic Topic
+	if err := db.First(&topic, topicID).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	// Drafts published past the deadline are locked at once.
+	status := GradePublished
+	if topic.GradesLocked(db.NowFunc()) {
+		if !account.IsSecretary() {
+			return nil, Errorf(ECONFLICT, "grade.locked")
+		}
+		status = GradeLocked
+	}
+
+	var grades []Grade
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&Grade{}).Where("topic_id = ? AND status = ?", topic.ID, GradeDraft).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		err := tx.Model(&Grade{}).Where("id IN ?", ids).
+			Updates(map[string]any{"status": status, "published_at": tx.NowFunc()}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Preload("Student.Account").Preload("Topic.Subject").Find(&grades, ids).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return grades, nil
+}
+
+func (s *gradeService) FindGradeAmendments(ctx context.Context, id uint) ([]GradeAmendment, error) {
+	db := contextDB(ctx, s.db).WithContext(ctx)
+
+	grade, err := findEditableGrade(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var amendments []GradeAmendment
+	if err := db.Where("grade_id = ?", grade.ID).Order("id").Find(&amendments).Error; err != nil {
+		return nil, err
+	}
+	return amendments, nil
+}
+
+// gradeEditor returns the account in the context if it can edit grades:
+// only teachers and secretaries can.
+func gradeEditor(ctx context.Context) (*Account, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	if account.IsStudent() || account.IsGuardian() {
+		return nil, &Error{Code: EFORBIDDEN}
+	}
+	return account, nil
+}
+
+// checkAmendment checks the account can change locked grades: only
+// secretaries can, giving a reason.
+func checkAmendment(account *Account, reason string) error {
+	if !account.IsSecretary() {
+		return Errorf(ECONFLICT, "grade.locked")
+	}
+	if reason == "" {
+		return InvalidField("reason", FieldRequired, "grade.reason.missing")
+	}
+	return nil
+}
+
+// findEditableGrade returns a grade of the tenant of the account in the
+// context, with its topic, if the account can edit grades.
+func findEditableGrade(ctx context.Context, db *gorm.DB, id uint) (*Grade, error) {
+	if _, err := gradeEditor(ctx); err != nil {
+		return nil, err
+	}
+
+	var grade Grade
+	if err := TenantDB(ctx, db).Preload("Topic").First(&grade, id).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+	return &grade, nil
+}
+
+// findDeletableGrade returns an editable grade matching the If-Match
+// precondition of the context. Locked grades are amended instead.
+func findDeletableGrade(ctx context.Context, db *gorm.DB, id uint) (*Grade, error) {
+	grade, err := findEditableGrade(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckIfMatch(ctx, grade.Model); err != nil {
+		return nil, err
+	}
+	if grade.IsLocked(db.NowFunc()) {
+		return nil, Errorf(ECONFLICT, "grade.locked")
+	}
+	return grade, nil
+}
@@ -0,0 +1,57 @@
+package edutrack
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// GuardianLink links a guardian account (parent or tutor) to a student.
+// A guardian can be linked to several students and a student can have
+// several guardians.
+type GuardianLink struct {
+	gorm.Model
+
+	// Relationship of the guardian to the student (e.g., "Madre", "Tutor").
+	Relationship string
+
+	// Foreign keys.
+
+	// AccountID links to the guardian's login account.
+	AccountID uint    `gorm:"uniqueIndex:idx_guardian_student"`
+	Account   Account `gorm:"constraint:OnDelete:CASCADE"`
+
+	// StudentID links to the student under the guardian's care.
+	StudentID uint    `gorm:"uniqueIndex:idx_guardian_student"`
+	Student   Student `gorm:"constraint:OnDelete:CASCADE"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// GuardianStudentIDs returns the IDs of the students linked to the given
+// guardian account within a tenant.
+func GuardianStudentIDs(db *gorm.DB, accountID uint, tenantID string) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&GuardianLink{}).
+		Where("account_id = ? AND tenant_id = ?", accountID, tenantID).
+		Pluck("student_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// IsGuardianOf reports whether the given guardian account is linked to the
+// student within the tenant of the account in the context.
+func IsGuardianOf(ctx context.Context, db *gorm.DB, accountID, studentID uint) (bool, error) {
+	var count int64
+	err := TenantDB(ctx, db).Model(&GuardianLink{}).
+		Where("account_id = ? AND student_id = ?", accountID, studentID).
+		Count(&count).Error
+	if err != nil {
+		return false, TranslateDBError(err)
+	}
+	return count > 0, nil
+}
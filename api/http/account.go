@@ -5,13 +5,14 @@ import (
 	"strconv"
 
 	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
 )
 
 // handleListAccounts handles GET /accounts.
 func (s *Server) handleListAccounts(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
@@ -19,14 +20,14 @@ func (s *Server) handleListAccounts(w http.ResponseWriter, r *http.Request) {
 	if account.IsStudent() {
 		// Students can only see their own account.
 		var ownAccount edutrack.Account
-		if err := s.DB.Where("id = ? AND tenant_id = ?", account.ID, account.TenantID).First(&ownAccount).Error; err != nil {
-			sendError(w, http.StatusNotFound, ErrNotFound)
+		if err := s.tenantDB(r).Where("id = ?", account.ID).First(&ownAccount).Error; err != nil {
+			sendError(w, r, http.StatusNotFound, ErrNotFound)
 			return
 		}
 		accounts = []edutrack.Account{ownAccount}
 	} else {
 		// Teachers and secretaries can see all accounts with filters.
-		query := s.DB.Where("tenant_id = ?", account.TenantID)
+		query := s.tenantDB(r)
 
 		// Optional filters.
 		if name := r.URL.Query().Get("name"); name != "" {
@@ -44,102 +45,93 @@ func (s *Server) handleListAccounts(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err := query.Find(&accounts).Error; err != nil {
-			sendError(w, http.StatusInternalServerError, ErrInternalServer)
+			sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 			return
 		}
 	}
 
-	sendJSON(w, http.StatusOK, accounts)
+	response := make([]AccountResponse, 0, len(accounts))
+	for i := range accounts {
+		response = append(response, newAccountResponse(&accounts[i]))
+	}
+
+	sendJSON(w, http.StatusOK, response)
 }
 
 // handleGetAccount handles GET /accounts/{id}.
 func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var found edutrack.Account
-	if err := s.DB.First(&found, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	// Ensure the account belongs to the same tenant.
-	if found.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := s.tenantDB(r).First(&found, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
 	if account.IsStudent() && found.ID != account.ID {
 		// Students can only access their own account.
-		sendError(w, http.StatusForbidden, ErrForbidden)
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, found)
+	setETag(w, found.Model)
+	sendJSON(w, http.StatusOK, newAccountResponse(&found))
 }
 
 // CreateAccountRequest represents the request body for creating an account.
 type CreateAccountRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+	Role     string `json:"role" validate:"oneof=secretary teacher student guardian"`
+	Locale   string `json:"locale" validate:"oneof=es en"`
 }
 
 // handleCreateAccount handles POST /accounts.
 func (s *Server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	// Only teachers and secretaries can create accounts.
 	if account.IsStudent() {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
 		return
 	}
 
 	var req CreateAccountRequest
 	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if req.Name == "" || req.Email == "" || req.Password == "" {
-		sendErrorMessage(w, http.StatusBadRequest, "Nombre, email y contraseña son requeridos.")
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	// Hash the password.
 	hashedPassword, err := edutrack.HashPassword(req.Password)
 	if err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 		return
 	}
 
 	role := edutrack.RoleTeacher // default
 	if req.Role != "" {
-		switch req.Role {
-		case string(edutrack.RoleSecretary):
-			role = edutrack.RoleSecretary
-		case string(edutrack.RoleTeacher):
-			role = edutrack.RoleTeacher
-		case string(edutrack.RoleStudent):
-			role = edutrack.RoleStudent
-		default:
-			sendErrorMessage(w, http.StatusBadRequest, "Rol inválido.")
-			return
-		}
+		role = edutrack.Role(req.Role)
 	}
 
 	newAccount := &edutrack.Account{
@@ -148,73 +140,81 @@ func (s *Server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
 		Password: hashedPassword,
 		Role:     role,
 		Active:   true,
+		Locale:   i18n.Locale(req.Locale),
 		TenantID: account.TenantID,
 	}
 
-	if err := s.DB.Create(newAccount).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Create(newAccount).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
-	sendJSON(w, http.StatusCreated, newAccount)
+	setETag(w, newAccount.Model)
+	sendJSON(w, http.StatusCreated, newAccountResponse(newAccount))
 }
 
 // UpdateAccountRequest represents the request body for updating an account.
 type UpdateAccountRequest struct {
-	Name     *string `json:"name"`
-	Email    *string `json:"email"`
-	Password *string `json:"password"`
+	Name     *string `json:"name" validate:"required"`
+	Email    *string `json:"email" validate:"required,email"`
+	Password *string `json:"password" validate:"required"`
 	Active   *bool   `json:"active"`
+	Locale   *string `json:"locale" validate:"omitempty,oneof=es en"`
 }
 
-// handleUpdateAccount handles PUT /accounts/{id}.
+// handleUpdateAccount handles PUT and PATCH /accounts/{id}.
 func (s *Server) handleUpdateAccount(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var existing edutrack.Account
-	if err := s.DB.First(&existing, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	if err := s.tenantDB(r).First(&existing, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if existing.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := edutrack.CheckIfMatch(r.Context(), existing.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req UpdateAccountRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	if account.IsStudent() {
-		// Students can only update their own password.
+		// Students can only update their own password and language.
 		if existing.ID != account.ID {
-			sendError(w, http.StatusForbidden, ErrForbidden)
+			sendError(w, r, http.StatusForbidden, ErrForbidden)
 			return
 		}
-		if req.Password == nil {
-			sendErrorMessage(w, http.StatusBadRequest, "Solo se puede actualizar la contraseña.")
+		if req.Password == nil && req.Locale == nil {
+			sendFieldError(w, r, "password", edutrack.FieldRequired, "field.password.student_only")
 			return
 		}
-		// Only update password.
-		hashedPassword, err := edutrack.HashPassword(*req.Password)
-		if err != nil {
-			sendError(w, http.StatusInternalServerError, ErrInternalServer)
-			return
+		if req.Password != nil {
+			hashedPassword, err := edutrack.HashPassword(*req.Password)
+			if err != nil {
+				sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+				return
+			}
+			existing.Password = hashedPassword
 		}
-		existing.Password = hashedPassword
 	} else {
 		// Teachers and secretaries can update all fields.
 		if req.Name != nil {
@@ -226,7 +226,7 @@ func (s *Server) handleUpdateAccount(w http.ResponseWriter, r *http.Request) {
 		if req.Password != nil {
 			hashedPassword, err := edutrack.HashPassword(*req.Password)
 			if err != nil {
-				sendError(w, http.StatusInternalServerError, ErrInternalServer)
+				sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 				return
 			}
 			existing.Password = hashedPassword
@@ -235,56 +235,55 @@ func (s *Server) handleUpdateAccount(w http.ResponseWriter, r *http.Request) {
 			existing.Active = *req.Active
 		}
 	}
+	if req.Locale != nil {
+		existing.Locale = i18n.Locale(*req.Locale)
+	}
 
-	if err := s.DB.Save(&existing).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Save(&existing).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
-	sendJSON(w, http.StatusOK, existing)
+	setETag(w, existing.Model)
+	sendJSON(w, http.StatusOK, newAccountResponse(&existing))
 }
 
 // handleDeleteAccount handles DELETE /accounts/{id}.
 func (s *Server) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	// Only secretaries can delete accounts.
 	if !account.IsSecretary() {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var existing edutrack.Account
-	if err := s.DB.First(&existing, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	if err := s.tenantDB(r).First(&existing, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if existing.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := edutrack.CheckIfMatch(r.Context(), existing.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	// Prevent self-deletion.
 	if existing.ID == account.ID {
-		sendErrorMessage(w, http.StatusBadRequest, "No puedes eliminar tu propia cuenta.")
-		return
-	}
-
-	if err := s.DB.Delete(&existing).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+		sendErrorMessage(w, r, http.StatusBadRequest, "account.delete_self")
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	s.deleteRecord(w, r, &existing)
 }
@@ -104,7 +104,7 @@ func TestHandleListAccounts_Success(t *testing.T) {
 		t.Errorf("handleListAccounts() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var accounts []edutrack.Account
+	var accounts []AccountResponse
 	if err := json.NewDecoder(w.Body).Decode(&accounts); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -136,7 +136,7 @@ func TestHandleListAccounts_FilterByName(t *testing.T) {
 		t.Errorf("handleListAccounts() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var accounts []edutrack.Account
+	var accounts []AccountResponse
 	if err := json.NewDecoder(w.Body).Decode(&accounts); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -163,7 +163,7 @@ func TestHandleListAccounts_FilterByEmail(t *testing.T) {
 		t.Errorf("handleListAccounts() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var accounts []edutrack.Account
+	var accounts []AccountResponse
 	if err := json.NewDecoder(w.Body).Decode(&accounts); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -194,7 +194,7 @@ func TestHandleListAccounts_FilterByActive(t *testing.T) {
 		t.Errorf("handleListAccounts() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var accounts []edutrack.Account
+	var accounts []AccountResponse
 	if err := json.NewDecoder(w.Body).Decode(&accounts); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -237,7 +237,7 @@ func TestHandleListAccounts_TenantIsolation(t *testing.T) {
 
 	server.handleListAccounts(w, req)
 
-	var accounts []edutrack.Account
+	var accounts []AccountResponse
 	json.NewDecoder(w.Body).Decode(&accounts)
 
 	// Should only see accounts from tenant1
@@ -264,7 +264,7 @@ func TestHandleGetAccount_Success(t *testing.T) {
 		t.Errorf("handleGetAccount() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var found edutrack.Account
+	var found AccountResponse
 	if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -310,7 +310,7 @@ func TestHandleGetAccount_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleGetAccount_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleGetAccount_CrossTenant(t *testing.T) {
 	db := setupAccountTestDB(t)
 
 	tenant1 := createAccountTestTenant(t, db)
@@ -329,8 +329,8 @@ func TestHandleGetAccount_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleGetAccount(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleGetAccount() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleGetAccount() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -357,7 +357,7 @@ func TestHandleCreateAccount_Success(t *testing.T) {
 		t.Errorf("handleCreateAccount() status = %d, want %d", w.Code, http.StatusCreated)
 	}
 
-	var created edutrack.Account
+	var created AccountResponse
 	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -371,7 +371,9 @@ func TestHandleCreateAccount_Success(t *testing.T) {
 	}
 
 	// Verify password was hashed
-	if created.Password == "securepassword123" {
+	var stored edutrack.Account
+	db.First(&stored, created.ID)
+	if !edutrack.PasswordMatches("securepassword123", stored.Password) {
 		t.Error("handleCreateAccount() password was not hashed")
 	}
 }
@@ -449,7 +451,7 @@ func TestHandleUpdateAccount_Success(t *testing.T) {
 		t.Errorf("handleUpdateAccount() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Account
+	var updated AccountResponse
 	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -522,7 +524,7 @@ func TestHandleUpdateAccount_UpdateActive(t *testing.T) {
 		t.Errorf("handleUpdateAccount() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Account
+	var updated AccountResponse
 	json.NewDecoder(w.Body).Decode(&updated)
 
 	if updated.Active != false {
@@ -552,7 +554,7 @@ func TestHandleUpdateAccount_NotFound(t *testing.T) {
 	}
 }
 
-func TestHandleUpdateAccount_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleUpdateAccount_CrossTenant(t *testing.T) {
 	db := setupAccountTestDB(t)
 
 	tenant1 := createAccountTestTenant(t, db)
@@ -574,8 +576,8 @@ func TestHandleUpdateAccount_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleUpdateAccount(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleUpdateAccount() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleUpdateAccount() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -641,7 +643,7 @@ func TestHandleDeleteAccount_NotFound(t *testing.T) {
 	}
 }
 
-func TestHandleDeleteAccount_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleDeleteAccount_CrossTenant(t *testing.T) {
 	db := setupAccountTestDB(t)
 
 	tenant1 := createAccountTestTenant(t, db)
@@ -659,8 +661,8 @@ func TestHandleDeleteAccount_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleDeleteAccount(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleDeleteAccount() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleDeleteAccount() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
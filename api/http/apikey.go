@@ -0,0 +1,149 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// APIKeyResponse represents an API key in API responses.
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AccountID  uint       `json:"account_id"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Key is only included when the API key is created.
+	Key string `json:"key,omitempty"`
+}
+
+// newAPIKeyResponse builds the response for an API key without the full key.
+func newAPIKeyResponse(key *edutrack.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		AccountID:  key.AccountID,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// handleListAPIKeys handles GET /api-keys.
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	keys, err := edutrack.New(s.tenantDB(r)).ListAPIKeys(account.TenantID)
+	if err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	response := make([]APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, newAPIKeyResponse(&keys[i]))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key.
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required"`
+	Scopes []string `json:"scopes" validate:"required"`
+
+	// Account the key acts on behalf of. Defaults to the current account.
+	AccountID uint `json:"account_id"`
+
+	// Days until the key expires. Zero creates a key that never expires.
+	ExpiresInDays int `json:"expires_in_days" validate:"min=0"`
+}
+
+// handleCreateAPIKey handles POST /api-keys.
+// The response is the only time the full key is returned.
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !edutrack.IsValidAPIKeyScope(scope) {
+			sendFieldError(w, r, "scopes", edutrack.FieldInvalidChoice, "field.scopes.invalid", scope)
+			return
+		}
+	}
+
+	accountID := req.AccountID
+	if accountID == 0 {
+		accountID = account.ID
+	}
+
+	var owner edutrack.Account
+	if err := s.tenantDB(r).First(&owner, accountID).Error; err != nil {
+		sendFieldError(w, r, "account_id", edutrack.FieldNotFound, "account.not_found")
+		return
+	}
+
+	apiKey, key, err := edutrack.New(s.tenantDB(r)).CreateAPIKey(account.TenantID, owner.ID, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	response := newAPIKeyResponse(apiKey)
+	response.Key = key
+
+	sendJSON(w, http.StatusCreated, response)
+}
+
+// handleRevokeAPIKey handles DELETE /api-keys/{id}.
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	var apiKey edutrack.APIKey
+	if err := s.tenantDB(r).First(&apiKey, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if err := s.tenantDB(r).Delete(&apiKey).Error; err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
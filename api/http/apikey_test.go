@@ -0,0 +1,324 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// setupAPIKeyTestDB creates an in-memory SQLite database for testing.
+func setupAPIKeyTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// createAPIKeyTestTenant creates a test tenant with a valid license.
+func createAPIKeyTestTenant(t *testing.T, db *gorm.DB) *edutrack.Tenant {
+	tenant, err := edutrack.NewTenant("Test Institution", edutrack.LicenseTypeTrial, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create test tenant: %v", err)
+	}
+
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatalf("Failed to save test tenant: %v", err)
+	}
+
+	return tenant
+}
+
+// createAPIKeyTestAccount creates a test account for a tenant.
+func createAPIKeyTestAccount(t *testing.T, db *gorm.DB, tenantID, email string, role edutrack.Role) *edutrack.Account {
+	account := &edutrack.Account{
+		Name:     "Test User",
+		Email:    email,
+		Role:     role,
+		Active:   true,
+		TenantID: tenantID,
+	}
+
+	if err := db.Create(account).Error; err != nil {
+		t.Fatalf("Failed to save test account: %v", err)
+	}
+
+	return account
+}
+
+// createTestAPIKey creates an API key for an account and returns the full key.
+func createTestAPIKey(t *testing.T, db *gorm.DB, account *edutrack.Account, scopes ...string) (*edutrack.APIKey, string) {
+	apiKey, key, err := edutrack.New(db).CreateAPIKey(account.TenantID, account.ID, "Test integration", scopes, 0)
+	if err != nil {
+		t.Fatalf("Failed to create test API key: %v", err)
+	}
+
+	return apiKey, key
+}
+
+// makeAPIKeyAuthenticatedRequest creates an HTTP request with the account in context.
+func makeAPIKeyAuthenticatedRequest(t *testing.T, method, path string, body []byte, account *edutrack.Account) *http.Request {
+	var req *http.Request
+	if body != nil {
+		req = httptest.NewRequest(method, path, bytes.NewReader(body))
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	ctx := edutrack.NewContextWithAccount(req.Context(), account)
+	return req.WithContext(ctx)
+}
+
+// makeAPIKeyRequest creates an HTTP request authenticated with an API key.
+func makeAPIKeyRequest(method, path, key string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+key)
+	return req
+}
+
+func TestHandleCreateAPIKey_Success(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	tenant := createAPIKeyTestTenant(t, db)
+	secretary := createAPIKeyTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateAPIKeyRequest{
+		Name:          "SIS sync",
+		Scopes:        []string{"grades:read", "attendances:write"},
+		ExpiresInDays: 30,
+	})
+	req := makeAPIKeyAuthenticatedRequest(t, http.MethodPost, "/api-keys", body, secretary)
+	w := httptest.NewRecorder()
+
+	server.handleCreateAPIKey(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var response APIKeyResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if edutrack.APIKeyPrefixOf(response.Key) != response.Prefix {
+		t.Errorf("Expected key %q to start with prefix %q", response.Key, response.Prefix)
+	}
+	if response.AccountID != secretary.ID {
+		t.Errorf("Expected account %d, got %d", secretary.ID, response.AccountID)
+	}
+	if response.ExpiresAt == nil {
+		t.Error("Expected the key to have an expiry date")
+	}
+
+	var stored edutrack.APIKey
+	if err := db.First(&stored, response.ID).Error; err != nil {
+		t.Fatalf("Failed to load API key: %v", err)
+	}
+	if stored.Hash == response.Key || !stored.Matches(response.Key) {
+		t.Error("Expected the key to be stored hashed")
+	}
+}
+
+func TestHandleCreateAPIKey_InvalidScope(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	tenant := createAPIKeyTestTenant(t, db)
+	secretary := createAPIKeyTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	for _, scopes := range [][]string{nil, {"grades:delete"}, {"api-keys:write"}} {
+		body, _ := json.Marshal(CreateAPIKeyRequest{Name: "SIS sync", Scopes: scopes})
+		req := makeAPIKeyAuthenticatedRequest(t, http.MethodPost, "/api-keys", body, secretary)
+		w := httptest.NewRecorder()
+
+		server.handleCreateAPIKey(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Scopes %v: expected status %d, got %d", scopes, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestHandleCreateAPIKey_OtherTenantAccount(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	tenant := createAPIKeyTestTenant(t, db)
+	otherTenant := createAPIKeyTestTenant(t, db)
+	secretary := createAPIKeyTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	other := createAPIKeyTestAccount(t, db, otherTenant.ID, "other@test.com", edutrack.RoleSecretary)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateAPIKeyRequest{Name: "SIS sync", Scopes: []string{"*:read"}, AccountID: other.ID})
+	req := makeAPIKeyAuthenticatedRequest(t, http.MethodPost, "/api-keys", body, secretary)
+	w := httptest.NewRecorder()
+
+	server.handleCreateAPIKey(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandleListAPIKeys_HidesKeys(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	tenant := createAPIKeyTestTenant(t, db)
+	otherTenant := createAPIKeyTestTenant(t, db)
+	secretary := createAPIKeyTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	other := createAPIKeyTestAccount(t, db, otherTenant.ID, "other@test.com", edutrack.RoleSecretary)
+	createTestAPIKey(t, db, secretary, "grades:read")
+	createTestAPIKey(t, db, other, "grades:read")
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeAPIKeyAuthenticatedRequest(t, http.MethodGet, "/api-keys", nil, secretary)
+	w := httptest.NewRecorder()
+
+	server.handleListAPIKeys(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if bytes.Contains(w.Body.Bytes(), []byte(`"key"`)) || bytes.Contains(w.Body.Bytes(), []byte(`"Hash"`)) {
+		t.Errorf("Expected keys and hashes to be hidden, got %s", w.Body.String())
+	}
+
+	var response []APIKeyResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response) != 1 {
+		t.Errorf("Expected 1 API key, got %d", len(response))
+	}
+}
+
+func TestHandleRevokeAPIKey_OtherTenant(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	tenant := createAPIKeyTestTenant(t, db)
+	otherTenant := createAPIKeyTestTenant(t, db)
+	secretary := createAPIKeyTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	other := createAPIKeyTestAccount(t, db, otherTenant.ID, "other@test.com", edutrack.RoleSecretary)
+	apiKey, _ := createTestAPIKey(t, db, other, "grades:read")
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeAPIKeyAuthenticatedRequest(t, http.MethodDelete, "/api-keys/1", nil, secretary)
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	server.handleRevokeAPIKey(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	var count int64
+	db.Model(&edutrack.APIKey{}).Where("id = ?", apiKey.ID).Count(&count)
+	if count != 1 {
+		t.Error("Expected the API key of another tenant to remain")
+	}
+}
+
+func TestWithAuth_APIKey(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	tenant := createAPIKeyTestTenant(t, db)
+	secretary := createAPIKeyTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	apiKey, key := createTestAPIKey(t, db, secretary, "grades:read", "students:write")
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{"read scope", http.MethodGet, "/grades", key, http.StatusOK},
+		{"write implies read", http.MethodGet, "/students", key, http.StatusOK},
+		{"missing write scope", http.MethodPost, "/grades", key, http.StatusForbidden},
+		{"missing resource scope", http.MethodGet, "/teachers", key, http.StatusForbidden},
+		{"cannot manage api keys", http.MethodGet, "/api-keys", key, http.StatusForbidden},
+		{"wrong secret", http.MethodGet, "/grades", apiKey.Prefix + "_00000000000000000000000000000000", http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/grades", "et_00000000_00000000000000000000000000000000", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, makeAPIKeyRequest(tt.method, tt.path, tt.key))
+
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	var stored edutrack.APIKey
+	db.First(&stored, apiKey.ID)
+	if stored.LastUsedAt == nil {
+		t.Error("Expected last used timestamp to be recorded")
+	}
+}
+
+func TestWithAuth_APIKeyExpiredOrRevoked(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	tenant := createAPIKeyTestTenant(t, db)
+	secretary := createAPIKeyTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	expired, expiredKey := createTestAPIKey(t, db, secretary, "*:read")
+	revoked, revokedKey := createTestAPIKey(t, db, secretary, "*:read")
+
+	past := time.Now().Add(-time.Hour)
+	db.Model(expired).Update("expires_at", past)
+	if err := edutrack.New(db).RevokeAPIKey(tenant.ID, revoked.ID); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	for _, key := range []string{expiredKey, revokedKey} {
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, makeAPIKeyRequest(http.MethodGet, "/grades", key))
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	}
+}
+
+func TestWithAuth_APIKeyInactiveAccount(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	tenant := createAPIKeyTestTenant(t, db)
+	secretary := createAPIKeyTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	_, key := createTestAPIKey(t, db, secretary, "*:read")
+
+	db.Model(secretary).Update("active", false)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, makeAPIKeyRequest(http.MethodGet, "/grades", key))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
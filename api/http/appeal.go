@@ -0,0 +1,311 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// handleListAppeals handles GET /appeals.
+func (s *Server) handleListAppeals(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, appealIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	filter := edutrack.GradeAppealFilter{Status: edutrack.AppealStatus(r.URL.Query().Get("status"))}
+	switch filter.Status {
+	case "", edutrack.AppealOpen, edutrack.AppealEscalated, edutrack.AppealAccepted, edutrack.AppealRejected:
+	default:
+		sendFieldError(w, r, "status", edutrack.FieldInvalidChoice, "field.invalid_choice", "open, escalated, accepted, rejected")
+		return
+	}
+	if gradeID := r.URL.Query().Get("grade_id"); gradeID != "" {
+		id, err := strconv.ParseUint(gradeID, 10, 64)
+		if err != nil {
+			sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+			return
+		}
+		filter.GradeID = uint(id)
+	}
+	if studentID := r.URL.Query().Get("student_id"); studentID != "" {
+		id, err := strconv.ParseUint(studentID, 10, 64)
+		if err != nil {
+			sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+			return
+		}
+		filter.StudentID = uint(id)
+	}
+
+	appeals, err := edutrack.FindGradeAppeals(r.Context(), s.DB, filter)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	response := make([]GradeAppealResponse, 0, len(appeals))
+	for i := range appeals {
+		response = append(response, newGradeAppealResponse(&appeals[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// findAppeal finds the appeal of a route, visible to the authenticated
+// account. On failure it writes the error response and returns nil.
+func (s *Server) findAppeal(w http.ResponseWriter, r *http.Request) *edutrack.GradeAppeal {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return nil
+	}
+
+	appeal, err := edutrack.FindGradeAppealByID(r.Context(), s.DB, uint(id))
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return nil
+	}
+	return appeal
+}
+
+// handleGetAppeal handles GET /appeals/{id}.
+// Returns the appeal with its whole thread.
+func (s *Server) handleGetAppeal(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, appealIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	appeal := s.findAppeal(w, r)
+	if appeal == nil {
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newGradeAppealResponse(appeal, inc))
+}
+
+// CreateAppealRequest represents the request body for appealing a grade.
+type CreateAppealRequest struct {
+	Reason string `json:"reason" validate:"required,max=2000"`
+}
+
+// handleCreateAppeal handles POST /grades/{id}/appeals.
+// Files the appeal of a published grade by its student and notifies the
+// teacher of the subject.
+func (s *Server) handleCreateAppeal(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, appealIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	var req CreateAppealRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	var appeal *edutrack.GradeAppeal
+	err = s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		var err error
+		appeal, err = edutrack.FileGradeAppeal(r.Context(), tx, uint(id), req.Reason)
+		if err != nil {
+			return err
+		}
+		return s.notifyAppealFiled(tx, appeal)
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, newGradeAppealResponse(appeal, inc))
+}
+
+// AppealMessageRequest represents the request body for adding a message to
+// the thread of an appeal, or for explaining a decision on it.
+type AppealMessageRequest struct {
+	Message string `json:"message" validate:"required,max=2000"`
+}
+
+// handleCreateAppealMessage handles POST /appeals/{id}/messages.
+// Adds a message to the thread of a pending appeal.
+func (s *Server) handleCreateAppealMessage(w http.ResponseWriter, r *http.Request) {
+	var req AppealMessageRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	appeal := s.findAppeal(w, r)
+	if appeal == nil {
+		return
+	}
+
+	message, err := edutrack.CommentGradeAppeal(r.Context(), s.DB, appeal, req.Message)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, newGradeAppealMessageResponse(message))
+}
+
+// EscalateAppealRequest represents the request body for escalating an
+// appeal.
+type EscalateAppealRequest struct {
+	Message string `json:"message" validate:"max=2000"`
+}
+
+// handleEscalateAppeal handles POST /appeals/{id}/escalate.
+// Moves the appeal to the decision of the secretaries.
+func (s *Server) handleEscalateAppeal(w http.ResponseWriter, r *http.Request) {
+	// The message is optional, and so is the body.
+	var req EscalateAppealRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	appeal := s.findAppeal(w, r)
+	if appeal == nil {
+		return
+	}
+
+	if err := edutrack.EscalateGradeAppeal(r.Context(), s.DB, appeal, req.Message); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newGradeAppealResponse(appeal, nil))
+}
+
+// AcceptAppealRequest represents the request body for accepting an appeal.
+type AcceptAppealRequest struct {
+	Message string `json:"message" validate:"required,max=2000"`
+
+	// New value of the grade; absent keeps it.
+	Value *float64 `json:"value"`
+}
+
+// handleAcceptAppeal handles POST /appeals/{id}/accept.
+// Accepts the appeal, changing the value of the grade if given.
+func (s *Server) handleAcceptAppeal(w http.ResponseWriter, r *http.Request) {
+	var req AcceptAppealRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	s.resolveAppeal(w, r, edutrack.AppealAccepted, req.Message, req.Value)
+}
+
+// handleRejectAppeal handles POST /appeals/{id}/reject.
+// Rejects the appeal, keeping the grade.
+func (s *Server) handleRejectAppeal(w http.ResponseWriter, r *http.Request) {
+	var req AppealMessageRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	s.resolveAppeal(w, r, edutrack.AppealRejected, req.Message, nil)
+}
+
+// resolveAppeal accepts or rejects the appeal of a route and notifies the
+// student and their guardians.
+func (s *Server) resolveAppeal(w http.ResponseWriter, r *http.Request, status edutrack.AppealStatus, message string, value *float64) {
+	appeal := s.findAppeal(w, r)
+	if appeal == nil {
+		return
+	}
+
+	grade := &appeal.Grade
+	err := s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		if err := edutrack.ResolveGradeAppeal(r.Context(), tx, appeal, status, message, value); err != nil {
+			return err
+		}
+		if appeal.ResolvedValue != nil {
+			// Reload the grade so the event carries the same relations as
+			// the other grade.updated events.
+			updated, err := s.GradeService.FindGradeByID(r.Context(), grade.ID)
+			if err != nil {
+				return err
+			}
+			if err := s.emitWebhook(tx, updated.TenantID, edutrack.WebhookGradeUpdated, newGradeResponse(updated, includeAll(gradeIncludes))); err != nil {
+				return err
+			}
+		}
+		if status == edutrack.AppealAccepted {
+			return s.notifyStudent(tx, appeal.StudentID, edutrack.NotificationAppealAccepted,
+				grade.Topic.Subject.Name, grade.Topic.Name, grade.Value, message)
+		}
+		return s.notifyStudent(tx, appeal.StudentID, edutrack.NotificationAppealRejected,
+			grade.Topic.Subject.Name, grade.Topic.Name, message)
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newGradeAppealResponse(appeal, nil))
+}
+
+// notifyAppealFiled notifies the teacher of the section of the student, or
+// else of the subject, of a new appeal, which must have its grade, topic,
+// subject and student loaded.
+func (s *Server) notifyAppealFiled(tx *gorm.DB, appeal *edutrack.GradeAppeal) error {
+	subject := appeal.Grade.Topic.Subject
+	teacherID := subject.TeacherID
+	section, err := edutrack.FindStudentSection(tx, appeal.StudentID, subject.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load section of student %d: %w", appeal.StudentID, err)
+	}
+	if section != nil && section.TeacherID != nil {
+		teacherID = section.TeacherID
+	}
+	if teacherID == nil {
+		return nil
+	}
+
+	var teacher edutrack.Teacher
+	if err := tx.Preload("Account").First(&teacher, *teacherID).Error; err != nil {
+		return fmt.Errorf("failed to load teacher %d: %w", *teacherID, err)
+	}
+	return s.notifyAccount(tx, &teacher.Account, edutrack.NotificationAppealFiled,
+		appeal.Student.Account.Name, appeal.OriginalValue, subject.Name, appeal.Grade.Topic.Name)
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+func TestGradeAppealWorkflow(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+	student := studentAccount(t, server, tenant)
+	var teacher edutrack.Account
+	server.DB.First(&teacher, tenant.Teacher.AccountID)
+	webhook := createTestWebhook(t, server.DB, tenant.Tenant.ID, edutrack.WebhookGradeUpdated)
+
+	// Start without the seeded appeal.
+	server.DB.Unscoped().Delete(tenant.Appeal)
+
+	// The student appeals the grade and the teacher is notified.
+	path := fmt.Sprintf("/grades/%d/appeals", tenant.Grade.ID)
+	w := accountRequest(t, server, student, http.MethodPost, path, CreateAppealRequest{Reason: "La pregunta 3 estaba bien"})
+	var appeal GradeAppealResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &appeal); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if appeal.Status != edutrack.AppealOpen || appeal.OriginalValue != tenant.Grade.Value || len(appeal.Messages) != 1 {
+		t.Errorf("Appeal = %+v, want open with the grade value and the reason as first message", appeal)
+	}
+	var notified int64
+	server.DB.Model(&edutrack.Notification{}).
+		Where("account_id = ? AND event = ?", teacher.ID, edutrack.NotificationAppealFiled).
+		Count(&notified)
+	if notified == 0 {
+		t.Error("The teacher was not notified of the appeal")
+	}
+
+	// A grade is appealed only once.
+	w = accountRequest(t, server, student, http.MethodPost, path, CreateAppealRequest{Reason: "Otra vez"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("POST %s again status = %d, want %d", path, w.Code, http.StatusConflict)
+	}
+
+	// The teacher asks, then accepts changing the value.
+	appealPath := fmt.Sprintf("/appeals/%d", appeal.ID)
+	w = accountRequest(t, server, &teacher, http.MethodPost, appealPath+"/messages", AppealMessageRequest{Message: "¿Puedes traer el examen?"})
+	if w.Code != http.StatusCreated {
+		t.Errorf("POST %s/messages status = %d: %s", appealPath, w.Code, w.Body.String())
+	}
+	value := 10.0
+	w = accountRequest(t, server, &teacher, http.MethodPost, appealPath+"/accept", AcceptAppealRequest{Message: "Tienes razón", Value: &value})
+	if err := json.Unmarshal(w.Body.Bytes(), &appeal); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("POST %s/accept status = %d: %s", appealPath, w.Code, w.Body.String())
+	}
+	if appeal.Status != edutrack.AppealAccepted || appeal.ResolvedValue == nil || *appeal.ResolvedValue != 10 || appeal.ResolverID == nil {
+		t.Errorf("Appeal = %+v, want accepted with 10", appeal)
+	}
+
+	var grade edutrack.Grade
+	server.DB.First(&grade, tenant.Grade.ID)
+	if grade.Value != 10 {
+		t.Errorf("Grade value = %v, want 10", grade.Value)
+	}
+
+	// The new value is emitted with the same relations as any other update.
+	var delivery edutrack.WebhookDelivery
+	if err := server.DB.Where("webhook_id = ?", webhook.ID).First(&delivery).Error; err != nil {
+		t.Fatalf("no grade.updated delivery was enqueued: %v", err)
+	}
+	var envelope struct {
+		Data GradeResponse `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(delivery.Payload), &envelope); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if envelope.Data.Value != 10 || envelope.Data.Student == nil || envelope.Data.Topic == nil {
+		t.Errorf("grade.updated data = %+v, want the new value with the student and topic", envelope.Data)
+	}
+
+	server.DB.Model(&edutrack.Notification{}).
+		Where("account_id = ? AND event = ?", student.ID, edutrack.NotificationAppealAccepted).
+		Count(&notified)
+	if notified == 0 {
+		t.Error("The student was not notified of the decision")
+	}
+
+	// The thread is kept and closed.
+	w = accountRequest(t, server, student, http.MethodGet, appealPath, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &appeal); err != nil || len(appeal.Messages) != 3 {
+		t.Fatalf("GET %s = %s, want the three messages", appealPath, w.Body.String())
+	}
+	if last := appeal.Messages[2]; last.Status != edutrack.AppealAccepted || last.Body != "Tienes razón" {
+		t.Errorf("Last message = %+v, want the decision", last)
+	}
+	w = accountRequest(t, server, student, http.MethodPost, appealPath+"/messages", AppealMessageRequest{Message: "Gracias"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("POST %s/messages after the decision status = %d, want %d", appealPath, w.Code, http.StatusConflict)
+	}
+}
+
+func TestGradeAppealWindow(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+	student := studentAccount(t, server, tenant)
+	server.DB.Unscoped().Delete(tenant.Appeal)
+	path := fmt.Sprintf("/grades/%d/appeals", tenant.Grade.ID)
+
+	// Published past the default window.
+	server.DB.Model(tenant.Grade).Update("published_at", time.Now().AddDate(0, 0, -edutrack.DefaultAppealDays-1))
+	w := accountRequest(t, server, student, http.MethodPost, path, CreateAppealRequest{Reason: "Tarde"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("POST %s after the window status = %d, want %d", path, w.Code, http.StatusConflict)
+	}
+
+	// Institutions can disable appeals.
+	server.DB.Model(tenant.Grade).Update("published_at", time.Now())
+	settings := edutrack.DefaultTenantSettings(tenant.Tenant.ID)
+	server.DB.Create(&settings)
+	server.DB.Model(&settings).Update("appeal_days", 0)
+	w = accountRequest(t, server, student, http.MethodPost, path, CreateAppealRequest{Reason: "Sin revisión"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("POST %s with appeals disabled status = %d, want %d", path, w.Code, http.StatusConflict)
+	}
+}
+
+func TestGradeAppealEscalation(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+	var teacher edutrack.Account
+	server.DB.First(&teacher, tenant.Teacher.AccountID)
+	path := fmt.Sprintf("/appeals/%d", tenant.Appeal.ID)
+
+	// The teacher upholds the grade.
+	w := accountRequest(t, server, &teacher, http.MethodPost, path+"/reject", AppealMessageRequest{Message: "La respuesta es incorrecta"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST %s/reject status = %d: %s", path, w.Code, w.Body.String())
+	}
+
+	// A secretary takes over, and the teacher can no longer decide.
+	w = isolationRequest(t, server, tenant, http.MethodPost, path+"/escalate", nil)
+	var appeal GradeAppealResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &appeal); err != nil || appeal.Status != edutrack.AppealEscalated {
+		t.Fatalf("POST %s/escalate = %d %s, want escalated", path, w.Code, w.Body.String())
+	}
+	if w := accountRequest(t, server, &teacher, http.MethodPost, path+"/reject", AppealMessageRequest{Message: "No"}); w.Code != http.StatusForbidden {
+		t.Errorf("POST %s/reject as teacher status = %d, want %d", path, w.Code, http.StatusForbidden)
+	}
+
+	// Accepting a locked grade amends it with the message as reason.
+	server.DB.Model(tenant.Grade).Update("status", edutrack.GradeLocked)
+	value := 10.0
+	w = isolationRequest(t, server, tenant, http.MethodPost, path+"/accept", AcceptAppealRequest{Value: &value})
+	if got := fieldCodes(errorResponse(t, w, http.StatusBadRequest)); got["message"] != edutrack.FieldRequired {
+		t.Errorf("Fields = %v, want message %s", got, edutrack.FieldRequired)
+	}
+	w = isolationRequest(t, server, tenant, http.MethodPost, path+"/accept", AcceptAppealRequest{Message: "Revisado por la academia", Value: &value})
+	if err := json.Unmarshal(w.Body.Bytes(), &appeal); err != nil || appeal.Status != edutrack.AppealAccepted {
+		t.Fatalf("POST %s/accept = %d %s, want accepted", path, w.Code, w.Body.String())
+	}
+
+	var amendments []edutrack.GradeAmendment
+	server.DB.Where("grade_id = ?", tenant.Grade.ID).Order("id").Find(&amendments)
+	if len(amendments) != 2 || amendments[1].Reason != "Revisado por la academia" || amendments[1].Value != 10 {
+		t.Errorf("Amendments = %+v, want the seeded one and the appeal", amendments)
+	}
+}
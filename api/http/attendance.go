@@ -5,6 +5,8 @@ import (
 	"strconv"
 	"time"
 
+	"gorm.io/gorm"
+
 	edutrack "lahuerta.tecmm.edu.mx/edutrack"
 )
 
@@ -12,12 +14,28 @@ import (
 func (s *Server) handleListAttendances(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, attendanceIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var attendances []edutrack.Attendance
-	query := s.DB.Where("tenant_id = ?", account.TenantID).Preload("Student").Preload("Subject")
+	query := s.tenantDB(r).Preload("Student.Account").Preload("Subject").Preload("Justification")
+
+	if account.IsGuardian() {
+		// Guardians can only see the attendance of their linked students.
+		ids, err := edutrack.GuardianStudentIDs(s.tenantDB(r), account.ID, account.TenantID)
+		if err != nil {
+			sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+			return
+		}
+		query = query.Where("student_id IN ?", ids)
+	}
 
 	// Optional filters.
 	if studentID := r.URL.Query().Get("student_id"); studentID != "" {
@@ -26,106 +44,134 @@ func (s *Server) handleListAttendances(w http.ResponseWriter, r *http.Request) {
 	if subjectID := r.URL.Query().Get("subject_id"); subjectID != "" {
 		query = query.Where("subject_id = ?", subjectID)
 	}
+	if sectionID := r.URL.Query().Get("section_id"); sectionID != "" {
+		query = query.Where("section_id = ?", sectionID)
+	}
 	if date := r.URL.Query().Get("date"); date != "" {
 		query = query.Where("DATE(date) = ?", date)
 	}
+	if justificationID := r.URL.Query().Get("justification_id"); justificationID != "" {
+		query = query.Where("justification_id = ?", justificationID)
+	}
 
 	if err := query.Find(&attendances).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, attendances)
+	response := make([]AttendanceResponse, 0, len(attendances))
+	for i := range attendances {
+		response = append(response, newAttendanceResponse(&attendances[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
 }
 
 // handleGetAttendance handles GET /attendances/{id}.
 func (s *Server) handleGetAttendance(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, attendanceIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var attendance edutrack.Attendance
-	if err := s.DB.Preload("Student").Preload("Subject").First(&attendance, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	if err := s.tenantDB(r).Preload("Student.Account").Preload("Subject").Preload("Justification").First(&attendance, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if attendance.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
-		return
+	if account.IsGuardian() {
+		// Guardians can only access the attendance of their linked students.
+		linked, err := edutrack.IsGuardianOf(r.Context(), s.tenantDB(r), account.ID, attendance.StudentID)
+		if err != nil {
+			s.sendAppError(w, r, err)
+			return
+		}
+		if !linked {
+			sendError(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
 	}
 
-	sendJSON(w, http.StatusOK, attendance)
+	setETag(w, attendance.Model)
+	sendJSON(w, http.StatusOK, newAttendanceResponse(&attendance, inc))
 }
 
 // CreateAttendanceRequest represents the request body for creating an attendance record.
 type CreateAttendanceRequest struct {
-	Date      string                    `json:"date"` // Format: "2006-01-02"
-	Status    edutrack.AttendanceStatus `json:"status"`
+	Date      string                    `json:"date" validate:"omitempty,date"`
+	Status    edutrack.AttendanceStatus `json:"status" validate:"required,oneof=present absent late excused"`
 	Notes     string                    `json:"notes"`
-	StudentID uint                      `json:"student_id"`
-	SubjectID uint                      `json:"subject_id"`
+	StudentID uint                      `json:"student_id" validate:"required"`
+	SubjectID uint                      `json:"subject_id" validate:"required"`
 }
 
 // handleCreateAttendance handles POST /attendances.
 func (s *Server) handleCreateAttendance(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
-	var req CreateAttendanceRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+	inc, err := parseInclude(r, attendanceIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	// Parse the date.
-	date, err := time.Parse("2006-01-02", req.Date)
-	if err != nil {
-		sendErrorMessage(w, http.StatusBadRequest, "Formato de fecha inválido. Use YYYY-MM-DD.")
+	var req CreateAttendanceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	// Validate status.
-	if !isValidAttendanceStatus(req.Status) {
-		sendErrorMessage(w, http.StatusBadRequest, "Estado de asistencia inválido.")
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	if req.StudentID == 0 || req.SubjectID == 0 {
-		sendErrorMessage(w, http.StatusBadRequest, "El ID de estudiante y materia son requeridos.")
-		return
+	// Records without a date are for today in the timezone of the
+	// institution.
+	if req.Date == "" {
+		settings, err := edutrack.FindTenantSettings(s.tenantDB(r), account.TenantID)
+		if err != nil {
+			sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+			return
+		}
+		req.Date = settings.Today().Format("2006-01-02")
 	}
+	date, _ := time.Parse("2006-01-02", req.Date)
 
 	// Verify student belongs to the same tenant.
 	var student edutrack.Student
-	if err := s.DB.First(&student, req.StudentID).Error; err != nil {
-		sendErrorMessage(w, http.StatusBadRequest, "El estudiante especificado no existe.")
-		return
-	}
-	if student.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := s.tenantDB(r).First(&student, req.StudentID).Error; err != nil {
+		sendFieldError(w, r, "student_id", edutrack.FieldNotFound, "student.not_found")
 		return
 	}
-
 	// Verify subject belongs to the same tenant.
 	var subject edutrack.Subject
-	if err := s.DB.First(&subject, req.SubjectID).Error; err != nil {
-		sendErrorMessage(w, http.StatusBadRequest, "La materia especificada no existe.")
+	if err := s.tenantDB(r).First(&subject, req.SubjectID).Error; err != nil {
+		sendFieldError(w, r, "subject_id", edutrack.FieldNotFound, "subject.not_found")
 		return
 	}
-	if subject.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	// The record is scoped to the section the student attends.
+	sectionID, err := edutrack.StudentSectionID(s.tenantDB(r), student.ID, subject.ID)
+	if err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 		return
 	}
 
@@ -135,72 +181,92 @@ func (s *Server) handleCreateAttendance(w http.ResponseWriter, r *http.Request)
 		Notes:     req.Notes,
 		StudentID: req.StudentID,
 		SubjectID: req.SubjectID,
+		SectionID: sectionID,
 		TenantID:  account.TenantID,
 	}
 
-	if err := s.DB.Create(attendance).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	err = s.inTransaction(r, func(tx *gorm.DB, _ *http.Request) error {
+		if err := tx.Create(attendance).Error; err != nil {
+			return edutrack.TranslateDBError(err)
+		}
+
+		// Reload with associations.
+		err := tx.Preload("Student.Account").Preload("Subject").Preload("Justification").First(attendance, attendance.ID).Error
+		if err != nil {
+			return err
+		}
+
+		err = s.emitWebhook(tx, account.TenantID, edutrack.WebhookAttendanceRecorded, newAttendanceResponse(attendance, includeAll(attendanceIncludes)))
+		if err != nil || attendance.Status != edutrack.AttendanceAbsent {
+			return err
+		}
+		return s.notifyAbsence(tx, attendance)
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	// Reload with associations.
-	s.DB.Preload("Student").Preload("Subject").First(attendance, attendance.ID)
-
-	sendJSON(w, http.StatusCreated, attendance)
+	setETag(w, attendance.Model)
+	sendJSON(w, http.StatusCreated, newAttendanceResponse(attendance, inc))
 }
 
 // UpdateAttendanceRequest represents the request body for updating an attendance record.
 type UpdateAttendanceRequest struct {
-	Date   *string                    `json:"date"` // Format: "2006-01-02"
-	Status *edutrack.AttendanceStatus `json:"status"`
+	Date   *string                    `json:"date" validate:"date"`
+	Status *edutrack.AttendanceStatus `json:"status" validate:"oneof=present absent late excused"`
 	Notes  *string                    `json:"notes"`
 }
 
-// handleUpdateAttendance handles PUT /attendances/{id}.
+// handleUpdateAttendance handles PUT and PATCH /attendances/{id}.
 func (s *Server) handleUpdateAttendance(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, attendanceIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var attendance edutrack.Attendance
-	if err := s.DB.First(&attendance, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	if err := s.tenantDB(r).First(&attendance, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if attendance.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := edutrack.CheckIfMatch(r.Context(), attendance.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req UpdateAttendanceRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	if req.Date != nil {
-		date, err := time.Parse("2006-01-02", *req.Date)
-		if err != nil {
-			sendErrorMessage(w, http.StatusBadRequest, "Formato de fecha inválido. Use YYYY-MM-DD.")
-			return
-		}
-		attendance.Date = date
+		attendance.Date, _ = time.Parse("2006-01-02", *req.Date)
 	}
 
+	// Only notify when the record becomes an absence.
+	wasAbsent := attendance.Status == edutrack.AttendanceAbsent
+
 	if req.Status != nil {
-		if !isValidAttendanceStatus(*req.Status) {
-			sendErrorMessage(w, http.StatusBadRequest, "Estado de asistencia inválido.")
-			return
-		}
 		attendance.Status = *req.Status
 	}
 
@@ -208,59 +274,61 @@ func (s *Server) handleUpdateAttendance(w http.ResponseWriter, r *http.Request)
 		attendance.Notes = *req.Notes
 	}
 
-	if err := s.DB.Save(&attendance).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	err = s.inTransaction(r, func(tx *gorm.DB, _ *http.Request) error {
+		if err := tx.Save(&attendance).Error; err != nil {
+			return edutrack.TranslateDBError(err)
+		}
+
+		// Reload with associations.
+		err := tx.Preload("Student.Account").Preload("Subject").Preload("Justification").First(&attendance, attendance.ID).Error
+		if err != nil {
+			return err
+		}
+
+		if wasAbsent || attendance.Status != edutrack.AttendanceAbsent {
+			return nil
+		}
+		return s.notifyAbsence(tx, &attendance)
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	// Reload with associations.
-	s.DB.Preload("Student").Preload("Subject").First(&attendance, attendance.ID)
-
-	sendJSON(w, http.StatusOK, attendance)
+	setETag(w, attendance.Model)
+	sendJSON(w, http.StatusOK, newAttendanceResponse(&attendance, inc))
 }
 
 // handleDeleteAttendance handles DELETE /attendances/{id}.
 func (s *Server) handleDeleteAttendance(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var attendance edutrack.Attendance
-	if err := s.DB.First(&attendance, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	if err := s.tenantDB(r).First(&attendance, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if attendance.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := edutrack.CheckIfMatch(r.Context(), attendance.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	if err := s.DB.Delete(&attendance).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
+	s.deleteRecord(w, r, &attendance)
 }
 
-// isValidAttendanceStatus checks if the given status is a valid attendance status.
-func isValidAttendanceStatus(status edutrack.AttendanceStatus) bool {
-	switch status {
-	case edutrack.AttendancePresent,
-		edutrack.AttendanceAbsent,
-		edutrack.AttendanceLate,
-		edutrack.AttendanceExcused:
-		return true
-	default:
-		return false
-	}
+// notifyAbsence notifies the student and their guardians of an absence.
+func (s *Server) notifyAbsence(tx *gorm.DB, attendance *edutrack.Attendance) error {
+	return s.notifyStudent(tx, attendance.StudentID, edutrack.NotificationAbsenceRecorded,
+		attendance.Subject.Name, attendance.Date.Format("2006-01-02"))
 }
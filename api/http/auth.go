@@ -7,6 +7,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
 )
 
 // Claims represents the JWT claims.
@@ -19,8 +20,8 @@ type Claims struct {
 
 // LoginRequest represents the login request body.
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 // LoginResponse represents the login response body.
@@ -39,7 +40,7 @@ type LoginResponseUser struct {
 
 // LicenseLoginRequest represents the license login request body.
 type LicenseLoginRequest struct {
-	LicenseKey string `json:"license_key"`
+	LicenseKey string `json:"license_key" validate:"required"`
 }
 
 // LicenseLoginResponse represents the license login response body.
@@ -54,44 +55,50 @@ type LicenseLoginResponse struct {
 func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		s.Metrics.IncLoginFailure(LoginFailureBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if req.Email == "" || req.Password == "" {
-		sendErrorMessage(w, http.StatusBadRequest, "Email y contraseña son requeridos.")
+	if err := validate(&req); err != nil {
+		s.Metrics.IncLoginFailure(LoginFailureBadRequest)
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	// Find the account by email.
 	var account edutrack.Account
 	if err := s.DB.Preload("Tenant").Preload("Tenant.License").Where("email = ?", req.Email).First(&account).Error; err != nil {
-		sendError(w, http.StatusUnauthorized, ErrInvalidCredentials)
+		s.Metrics.IncLoginFailure(LoginFailureInvalidCredentials)
+		sendError(w, r, http.StatusUnauthorized, ErrInvalidCredentials)
 		return
 	}
 
 	// Check if the account is active.
 	if !account.Active {
-		sendErrorMessage(w, http.StatusUnauthorized, "La cuenta está desactivada.")
+		s.Metrics.IncLoginFailure(LoginFailureInactiveAccount)
+		sendError(w, r, http.StatusUnauthorized, ErrAccountInactive)
 		return
 	}
 
 	// Check if the tenant's license is valid.
 	if !account.Tenant.License.IsValid() {
-		sendErrorMessage(w, http.StatusUnauthorized, "La licencia de la institución ha expirado.")
+		s.Metrics.IncLoginFailure(LoginFailureExpiredLicense)
+		sendError(w, r, http.StatusUnauthorized, ErrLicenseExpired)
 		return
 	}
 
 	// Verify password.
 	if !edutrack.PasswordMatches(req.Password, account.Password) {
-		sendError(w, http.StatusUnauthorized, ErrInvalidCredentials)
+		s.Metrics.IncLoginFailure(LoginFailureInvalidCredentials)
+		sendError(w, r, http.StatusUnauthorized, ErrInvalidCredentials)
 		return
 	}
 
 	// Generate JWT token.
 	token, err := s.generateToken(&account)
 	if err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 		return
 	}
 
@@ -113,28 +120,30 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleLicenseLogin(w http.ResponseWriter, r *http.Request) {
 	var req LicenseLoginRequest
 	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if req.LicenseKey == "" {
-		sendErrorMessage(w, http.StatusBadRequest, "La llave de licencia es requerida.")
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	// Find the license by key.
 	var license edutrack.License
 	if err := s.DB.Where("key = ?", req.LicenseKey).First(&license).Error; err != nil {
-		sendErrorMessage(w, http.StatusUnauthorized, "Llave de licencia inválida.")
+		s.Metrics.IncLoginFailure(LoginFailureInvalidLicense)
+		sendError(w, r, http.StatusUnauthorized, ErrInvalidLicense)
 		return
 	}
 
 	// Check if the license is valid.
 	if !license.IsValid() {
+		s.Metrics.IncLoginFailure(LoginFailureInvalidLicense)
 		if license.IsExpired() {
-			sendErrorMessage(w, http.StatusUnauthorized, "La licencia ha expirado.")
+			sendError(w, r, http.StatusUnauthorized, ErrLicenseExpired)
 		} else {
-			sendErrorMessage(w, http.StatusUnauthorized, "La licencia está desactivada.")
+			sendError(w, r, http.StatusUnauthorized, ErrLicenseInactive)
 		}
 		return
 	}
@@ -142,7 +151,7 @@ func (s *Server) handleLicenseLogin(w http.ResponseWriter, r *http.Request) {
 	// Find the tenant associated with this license.
 	var tenant edutrack.Tenant
 	if err := s.DB.Where("license_id = ?", license.ID).First(&tenant).Error; err != nil {
-		sendErrorMessage(w, http.StatusUnauthorized, "No se encontró la institución asociada a esta licencia.")
+		sendError(w, r, http.StatusUnauthorized, ErrTenantNotFound)
 		return
 	}
 
@@ -150,15 +159,15 @@ func (s *Server) handleLicenseLogin(w http.ResponseWriter, r *http.Request) {
 	var secretaryCount int64
 	s.DB.Model(&edutrack.Account{}).Where("tenant_id = ? AND role = ?", tenant.ID, edutrack.RoleSecretary).Count(&secretaryCount)
 
-	message := "Licencia válida. Inicie sesión con su cuenta."
+	message := "license.valid"
 	if secretaryCount == 0 {
-		message = "Licencia válida. Es necesario crear una cuenta de secretario."
+		message = "license.valid_first_use"
 	}
 
 	sendJSON(w, http.StatusOK, LicenseLoginResponse{
 		TenantID:   tenant.ID,
 		TenantName: tenant.Name,
-		Message:    message,
+		Message:    i18n.Message(requestLocale(r), message),
 	})
 }
 
@@ -169,7 +178,7 @@ func (s *Server) generateToken(account *edutrack.Account) (string, error) {
 		TenantID:  account.TenantID,
 		Role:      account.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.TokenLifetime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -184,19 +193,25 @@ func (s *Server) withAuth(next http.HandlerFunc) http.HandlerFunc {
 		// Get the Authorization header.
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+			sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 			return
 		}
 
 		// Check for Bearer prefix.
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+			sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 			return
 		}
 
 		tokenString := parts[1]
 
+		// API keys are accepted as an alternative to the JWT.
+		if strings.HasPrefix(tokenString, edutrack.APIKeyPrefix) {
+			s.withAPIKey(tokenString, next)(w, r)
+			return
+		}
+
 		// Parse and validate the token.
 		claims := &Claims{}
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
@@ -204,46 +219,137 @@ func (s *Server) withAuth(next http.HandlerFunc) http.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+			sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 			return
 		}
 
 		// Load the account from the database.
 		var account edutrack.Account
-		if err := s.DB.Preload("Tenant").Preload("Tenant.License").First(&account, claims.AccountID).Error; err != nil {
-			sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		if err := s.DB.Preload("Tenant.License").Preload("Tenant.Settings").First(&account, claims.AccountID).Error; err != nil {
+			sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 			return
 		}
 
 		// Check if the account is still active.
 		if !account.Active {
-			sendErrorMessage(w, http.StatusUnauthorized, "La cuenta está desactivada.")
+			sendError(w, r, http.StatusUnauthorized, ErrAccountInactive)
 			return
 		}
 
 		// Check if the tenant's license is still valid.
 		if !account.Tenant.License.IsValid() {
-			sendErrorMessage(w, http.StatusUnauthorized, "La licencia de la institución ha expirado.")
+			sendError(w, r, http.StatusUnauthorized, ErrLicenseExpired)
 			return
 		}
 
 		// Add the account to the context.
+		setRequestLogAccount(r.Context(), &account)
 		ctx := edutrack.NewContextWithAccount(r.Context(), &account)
 		next(w, r.WithContext(ctx))
 	}
 }
 
+// withAPIKey authenticates the request with the given API key. The request
+// acts on behalf of the key's account and is limited to the key's scopes.
+func (s *Server) withAPIKey(key string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiKey, err := edutrack.FindAPIKey(s.DB, key)
+		if err != nil {
+			sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+
+		account := &apiKey.Account
+
+		// Keys must act within their own tenant.
+		if account.TenantID != apiKey.TenantID {
+			sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+
+		// Check if the account is still active.
+		if !account.Active {
+			sendError(w, r, http.StatusUnauthorized, ErrAccountInactive)
+			return
+		}
+
+		// Check if the tenant's license is still valid.
+		if !account.Tenant.License.IsValid() {
+			sendError(w, r, http.StatusUnauthorized, ErrLicenseExpired)
+			return
+		}
+
+		// Check the key scopes grant access to the requested resource.
+		resource := apiKeyResource(r)
+		if resource == "" || !apiKey.Allows(resource, apiKeyAccess(r)) {
+			sendError(w, r, http.StatusForbidden, ErrInsufficientScope)
+			return
+		}
+
+		if err := apiKey.Touch(s.DB); err != nil {
+			sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+			return
+		}
+
+		setRequestLogAccount(r.Context(), account)
+		ctx := edutrack.NewContextWithAccount(r.Context(), account)
+		ctx = edutrack.NewContextWithAPIKey(ctx, apiKey)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// apiKeyResource returns the resource of the request used to check API key
+// scopes, i.e. the first segment of the path. Returns an empty string for
+// resources that cannot be accessed with API keys.
+func apiKeyResource(r *http.Request) string {
+	resource, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	for _, res := range edutrack.APIKeyResources() {
+		if res == resource {
+			return resource
+		}
+	}
+	return ""
+}
+
+// apiKeyAccess returns the access level the request method requires.
+func apiKeyAccess(r *http.Request) string {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return edutrack.ScopeRead
+	}
+	return edutrack.ScopeWrite
+}
+
 // withRole is a middleware that checks if the user has the required role.
 func (s *Server) withRole(role edutrack.Role, next http.HandlerFunc) http.HandlerFunc {
 	return s.withAuth(func(w http.ResponseWriter, r *http.Request) {
 		account := edutrack.AccountFromContext(r.Context())
 		if account == nil {
-			sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+			sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 			return
 		}
 
 		if account.Role != role {
-			sendError(w, http.StatusForbidden, ErrForbidden)
+			sendError(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// withoutGuardian is a middleware that rejects guardian accounts.
+// Guardians only have read access to the endpoints that scope their data
+// to the guardian's linked students.
+func (s *Server) withoutGuardian(next http.HandlerFunc) http.HandlerFunc {
+	return s.withAuth(func(w http.ResponseWriter, r *http.Request) {
+		account := edutrack.AccountFromContext(r.Context())
+		if account == nil {
+			sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+
+		if account.IsGuardian() {
+			sendError(w, r, http.StatusForbidden, ErrForbidden)
 			return
 		}
 
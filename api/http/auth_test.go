@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -780,3 +781,36 @@ func BenchmarkWithAuth(b *testing.B) {
 		wrappedHandler(w, req)
 	}
 }
+
+func TestGenerateToken_Lifetime(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db)
+	account := createTestAccount(t, db, tenant.ID, "test@example.com", "password123", edutrack.RoleSecretary)
+
+	server := NewServerWithOptions(db, Options{
+		Addr:          ":8080",
+		JWTSecret:     []byte("test-secret"),
+		TokenLifetime: time.Hour,
+	})
+
+	token, err := server.generateToken(account)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return server.JWTSecret, nil
+	}); err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+
+	lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time)
+	if lifetime != time.Hour {
+		t.Errorf("token lifetime = %v, want %v", lifetime, time.Hour)
+	}
+
+	if NewServer(":8080", db, nil).TokenLifetime != DefaultTokenLifetime {
+		t.Errorf("NewServer() TokenLifetime should default to %v", DefaultTokenLifetime)
+	}
+}
@@ -11,11 +11,11 @@ import (
 func (s *Server) handleListCareers(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
-	query := s.DB.Where("tenant_id = ?", account.TenantID)
+	query := s.tenantDB(r)
 
 	// Optional filters.
 	if name := r.URL.Query().Get("name"); name != "" {
@@ -30,65 +30,66 @@ func (s *Server) handleListCareers(w http.ResponseWriter, r *http.Request) {
 
 	var careers []edutrack.Career
 	if err := query.Find(&careers).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, careers)
+	response := make([]CareerResponse, 0, len(careers))
+	for i := range careers {
+		response = append(response, newCareerResponse(&careers[i]))
+	}
+
+	sendJSON(w, http.StatusOK, response)
 }
 
 // handleGetCareer handles GET /careers/{id}.
 func (s *Server) handleGetCareer(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var career edutrack.Career
-	if err := s.DB.First(&career, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	if career.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := s.tenantDB(r).First(&career, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, career)
+	setETag(w, career.Model)
+	sendJSON(w, http.StatusOK, newCareerResponse(&career))
 }
 
 // CreateCareerRequest represents the request body for creating a career.
 type CreateCareerRequest struct {
-	Name        string `json:"name"`
-	Code        string `json:"code"`
+	Name        string `json:"name" validate:"required"`
+	Code        string `json:"code" validate:"required"`
 	Description string `json:"description"`
-	Duration    int    `json:"duration"`
+	Duration    int    `json:"duration" validate:"min=1"`
 }
 
 // handleCreateCareer handles POST /careers.
 func (s *Server) handleCreateCareer(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	var req CreateCareerRequest
 	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if req.Name == "" || req.Code == "" {
-		sendErrorMessage(w, http.StatusBadRequest, "El nombre y código son requeridos.")
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
@@ -101,51 +102,56 @@ func (s *Server) handleCreateCareer(w http.ResponseWriter, r *http.Request) {
 		TenantID:    account.TenantID,
 	}
 
-	if err := s.DB.Create(career).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Create(career).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
-	sendJSON(w, http.StatusCreated, career)
+	setETag(w, career.Model)
+	sendJSON(w, http.StatusCreated, newCareerResponse(career))
 }
 
 // UpdateCareerRequest represents the request body for updating a career.
 type UpdateCareerRequest struct {
-	Name        *string `json:"name"`
-	Code        *string `json:"code"`
+	Name        *string `json:"name" validate:"required"`
+	Code        *string `json:"code" validate:"required"`
 	Description *string `json:"description"`
-	Duration    *int    `json:"duration"`
+	Duration    *int    `json:"duration" validate:"min=1"`
 	Active      *bool   `json:"active"`
 }
 
-// handleUpdateCareer handles PUT /careers/{id}.
+// handleUpdateCareer handles PUT and PATCH /careers/{id}.
 func (s *Server) handleUpdateCareer(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var career edutrack.Career
-	if err := s.DB.First(&career, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	if err := s.tenantDB(r).First(&career, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if career.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := edutrack.CheckIfMatch(r.Context(), career.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req UpdateCareerRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
@@ -165,43 +171,39 @@ func (s *Server) handleUpdateCareer(w http.ResponseWriter, r *http.Request) {
 		career.Active = *req.Active
 	}
 
-	if err := s.DB.Save(&career).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Save(&career).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
-	sendJSON(w, http.StatusOK, career)
+	setETag(w, career.Model)
+	sendJSON(w, http.StatusOK, newCareerResponse(&career))
 }
 
 // handleDeleteCareer handles DELETE /careers/{id}.
 func (s *Server) handleDeleteCareer(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var career edutrack.Career
-	if err := s.DB.First(&career, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	if career.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := s.tenantDB(r).First(&career, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if err := s.DB.Delete(&career).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := edutrack.CheckIfMatch(r.Context(), career.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	s.deleteRecord(w, r, &career)
 }
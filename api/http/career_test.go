@@ -120,7 +120,7 @@ func TestHandleListCareers_Success(t *testing.T) {
 		t.Errorf("handleListCareers() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var careers []edutrack.Career
+	var careers []CareerResponse
 	if err := json.NewDecoder(w.Body).Decode(&careers); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -149,7 +149,7 @@ func TestHandleListCareers_FilterByName(t *testing.T) {
 		t.Errorf("handleListCareers() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var careers []edutrack.Career
+	var careers []CareerResponse
 	if err := json.NewDecoder(w.Body).Decode(&careers); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -178,7 +178,7 @@ func TestHandleListCareers_FilterByCode(t *testing.T) {
 		t.Errorf("handleListCareers() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var careers []edutrack.Career
+	var careers []CareerResponse
 	if err := json.NewDecoder(w.Body).Decode(&careers); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -209,7 +209,7 @@ func TestHandleListCareers_FilterByActive(t *testing.T) {
 		t.Errorf("handleListCareers() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var careers []edutrack.Career
+	var careers []CareerResponse
 	if err := json.NewDecoder(w.Body).Decode(&careers); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -251,7 +251,7 @@ func TestHandleListCareers_TenantIsolation(t *testing.T) {
 
 	server.handleListCareers(w, req)
 
-	var careers []edutrack.Career
+	var careers []CareerResponse
 	json.NewDecoder(w.Body).Decode(&careers)
 
 	if len(careers) != 1 {
@@ -277,7 +277,7 @@ func TestHandleGetCareer_Success(t *testing.T) {
 		t.Errorf("handleGetCareer() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var found edutrack.Career
+	var found CareerResponse
 	if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -323,7 +323,7 @@ func TestHandleGetCareer_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleGetCareer_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleGetCareer_CrossTenant(t *testing.T) {
 	db := setupCareerTestDB(t)
 
 	tenant1 := createCareerTestTenant(t, db)
@@ -341,8 +341,8 @@ func TestHandleGetCareer_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleGetCareer(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleGetCareer() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleGetCareer() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -370,7 +370,7 @@ func TestHandleCreateCareer_Success(t *testing.T) {
 		t.Errorf("handleCreateCareer() status = %d, want %d", w.Code, http.StatusCreated)
 	}
 
-	var created edutrack.Career
+	var created CareerResponse
 	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -388,6 +388,25 @@ func TestHandleCreateCareer_Success(t *testing.T) {
 	}
 }
 
+func TestHandleCreateCareer_DuplicateCode(t *testing.T) {
+	db := setupCareerTestDB(t)
+	tenant := createCareerTestTenant(t, db)
+	account := createCareerTestAccount(t, db, tenant.ID, "admin@test.com", "password123", edutrack.RoleSecretary)
+	createTestCareer(t, db, tenant.ID, "Ingeniería en Sistemas", "ISC")
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateCareerRequest{Name: "Otra Carrera", Code: "ISC", Duration: 8})
+	req := makeCareerAuthenticatedRequest(t, http.MethodPost, "/careers", body, account)
+	w := httptest.NewRecorder()
+
+	server.handleCreateCareer(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("handleCreateCareer() status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
 func TestHandleCreateCareer_MissingFields(t *testing.T) {
 	db := setupCareerTestDB(t)
 	tenant := createCareerTestTenant(t, db)
@@ -460,7 +479,7 @@ func TestHandleUpdateCareer_Success(t *testing.T) {
 		t.Errorf("handleUpdateCareer() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Career
+	var updated CareerResponse
 	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -503,7 +522,7 @@ func TestHandleUpdateCareer_UpdateAllFields(t *testing.T) {
 		t.Errorf("handleUpdateCareer() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Career
+	var updated CareerResponse
 	json.NewDecoder(w.Body).Decode(&updated)
 
 	if updated.Name != newName {
@@ -545,7 +564,7 @@ func TestHandleUpdateCareer_NotFound(t *testing.T) {
 	}
 }
 
-func TestHandleUpdateCareer_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleUpdateCareer_CrossTenant(t *testing.T) {
 	db := setupCareerTestDB(t)
 
 	tenant1 := createCareerTestTenant(t, db)
@@ -567,8 +586,8 @@ func TestHandleUpdateCareer_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleUpdateCareer(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleUpdateCareer() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleUpdateCareer() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -634,7 +653,7 @@ func TestHandleDeleteCareer_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleDeleteCareer_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleDeleteCareer_CrossTenant(t *testing.T) {
 	db := setupCareerTestDB(t)
 
 	tenant1 := createCareerTestTenant(t, db)
@@ -652,8 +671,8 @@ func TestHandleDeleteCareer_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleDeleteCareer(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleDeleteCareer() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleDeleteCareer() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
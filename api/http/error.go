@@ -3,29 +3,72 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
 )
 
 // ErrorResponse represents an error response body.
 type ErrorResponse struct {
+	// Machine-readable error code, stable across versions: clients should
+	// match on it rather than on the message.
+	Code string `json:"code"`
+
+	// Human-readable message, meant for end users, in the locale of the
+	// request. The common errors leave it empty for the message of their
+	// code, or hold the key of their message in the i18n catalog.
 	Message string `json:"message"`
+
+	// Fields rejected by the request, if any.
+	Fields []edutrack.FieldError `json:"fields,omitempty"`
 }
 
 // Error makes ErrorResponse implement the error interface.
 func (e *ErrorResponse) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
 	return e.Message
 }
 
-// Common API errors.
+// Error codes of the responses that have no domain error code.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeUnprocessable        = "unprocessable"
+	CodeInvalidCredentials   = "invalid_credentials"
+	CodeAccountInactive      = "account_inactive"
+	CodeInvalidLicense       = "invalid_license"
+	CodeLicenseExpired       = "license_expired"
+	CodeLicenseInactive      = "license_inactive"
+	CodeInsufficientScope    = "insufficient_scope"
+	CodePreconditionRequired = "precondition_required"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+)
+
+// Common API errors. Their messages are rendered from the i18n catalog,
+// keyed by their code, in the locale of each request.
 var (
-	ErrUnauthorized       = &ErrorResponse{Message: "No autorizado."}
-	ErrForbidden          = &ErrorResponse{Message: "Acceso denegado."}
-	ErrNotFound           = &ErrorResponse{Message: "Recurso no encontrado."}
-	ErrMethodNotAllowed   = &ErrorResponse{Message: "Método no permitido."}
-	ErrBadRequest         = &ErrorResponse{Message: "Solicitud inválida."}
-	ErrInternalServer     = &ErrorResponse{Message: "Error interno del servidor."}
-	ErrConflict           = &ErrorResponse{Message: "El recurso ya existe."}
-	ErrUnprocessable      = &ErrorResponse{Message: "No se pudo procesar la solicitud."}
-	ErrInvalidCredentials = &ErrorResponse{Message: "Credenciales inválidas."}
+	ErrUnauthorized       = &ErrorResponse{Code: edutrack.EUNAUTHORIZED}
+	ErrForbidden          = &ErrorResponse{Code: edutrack.EFORBIDDEN}
+	ErrNotFound           = &ErrorResponse{Code: edutrack.ENOTFOUND}
+	ErrMethodNotAllowed   = &ErrorResponse{Code: CodeMethodNotAllowed}
+	ErrBadRequest         = &ErrorResponse{Code: CodeBadRequest}
+	ErrInvalid            = &ErrorResponse{Code: edutrack.EINVALID}
+	ErrInternalServer     = &ErrorResponse{Code: edutrack.EINTERNAL}
+	ErrConflict           = &ErrorResponse{Code: edutrack.ECONFLICT}
+	ErrUnprocessable      = &ErrorResponse{Code: CodeUnprocessable}
+	ErrInvalidCredentials = &ErrorResponse{Code: CodeInvalidCredentials}
+	ErrAccountInactive    = &ErrorResponse{Code: CodeAccountInactive}
+	ErrInvalidLicense     = &ErrorResponse{Code: CodeInvalidLicense}
+	ErrLicenseExpired     = &ErrorResponse{Code: CodeLicenseExpired}
+	ErrLicenseInactive    = &ErrorResponse{Code: CodeLicenseInactive}
+	ErrInsufficientScope  = &ErrorResponse{Code: CodeInsufficientScope}
+	ErrTenantNotFound     = &ErrorResponse{Code: CodeInvalidLicense, Message: "license.tenant_not_found"}
+
+	ErrPreconditionFailed   = &ErrorResponse{Code: edutrack.EPRECONDITION}
+	ErrPreconditionRequired = &ErrorResponse{Code: CodePreconditionRequired}
+	ErrUnsupportedMediaType = &ErrorResponse{Code: CodeUnsupportedMediaType}
 )
 
 // sendJSON writes a JSON response with the given status code and data.
@@ -35,17 +78,87 @@ func sendJSON(w http.ResponseWriter, status int, data any) {
 
 	if data != nil {
 		if err := json.NewEncoder(w).Encode(data); err != nil {
-			http.Error(w, `{"message":"Error al codificar respuesta."}`, http.StatusInternalServerError)
+			http.Error(w, `{"code":"internal","message":"Error al codificar respuesta."}`, http.StatusInternalServerError)
 		}
 	}
 }
 
-// sendError writes a JSON error response with the given status code.
-func sendError(w http.ResponseWriter, status int, err *ErrorResponse) {
+// sendError writes a JSON error response with the given status code. Its
+// message is the one of its code, unless it has its own key of the i18n
+// catalog, rendered in the locale of the request.
+func sendError(w http.ResponseWriter, r *http.Request, status int, err *ErrorResponse) {
+	key := err.Message
+	if key == "" {
+		key = "error." + err.Code
+	}
+	locale := requestLocale(r)
+	sendLocalizedError(w, locale, status, &ErrorResponse{Code: err.Code, Message: i18n.Message(locale, key)})
+}
+
+// sendErrorMessage writes a JSON error response with a custom message of
+// the i18n catalog and the code of the domain errors with the same status.
+func sendErrorMessage(w http.ResponseWriter, r *http.Request, status int, message string, args ...any) {
+	code := edutrack.EINTERNAL
+	for domainCode, mapping := range errorStatus {
+		if mapping.status == status {
+			code = domainCode
+		}
+	}
+	locale := requestLocale(r)
+	sendLocalizedError(w, locale, status, &ErrorResponse{Code: code, Message: i18n.Message(locale, message, args...)})
+}
+
+// sendFieldError writes a 400 JSON error response rejecting a single field
+// with a message of the i18n catalog.
+func sendFieldError(w http.ResponseWriter, r *http.Request, field, code, message string, args ...any) {
+	locale := requestLocale(r)
+	sendLocalizedError(w, locale, http.StatusBadRequest, &ErrorResponse{
+		Code:    ErrInvalid.Code,
+		Message: i18n.Message(locale, "error."+ErrInvalid.Code),
+		Fields:  edutrack.ErrorFields(edutrack.InvalidField(field, code, message, args...), locale),
+	})
+}
+
+// sendLocalizedError writes a JSON error response whose messages are
+// rendered in the given locale.
+func sendLocalizedError(w http.ResponseWriter, locale i18n.Locale, status int, err *ErrorResponse) {
+	w.Header().Set("Content-Language", string(locale))
 	sendJSON(w, status, err)
 }
 
-// sendErrorMessage writes a JSON error response with a custom message.
-func sendErrorMessage(w http.ResponseWriter, status int, message string) {
-	sendError(w, status, &ErrorResponse{Message: message})
+// errorStatus maps the domain error codes to HTTP statuses and errors.
+var errorStatus = map[string]struct {
+	status int
+	err    *ErrorResponse
+}{
+	edutrack.ECONFLICT:     {http.StatusConflict, ErrConflict},
+	edutrack.EFORBIDDEN:    {http.StatusForbidden, ErrForbidden},
+	edutrack.EINTERNAL:     {http.StatusInternalServerError, ErrInternalServer},
+	edutrack.EINVALID:      {http.StatusBadRequest, ErrInvalid},
+	edutrack.ENOTFOUND:     {http.StatusNotFound, ErrNotFound},
+	edutrack.EPRECONDITION: {http.StatusPreconditionFailed, ErrPreconditionFailed},
+	edutrack.EUNAUTHORIZED: {http.StatusUnauthorized, ErrUnauthorized},
+}
+
+// sendAppError writes the JSON error response of an error returned by the
+// domain layer. Errors that are not domain errors are logged and reported
+// as internal errors without details.
+func (s *Server) sendAppError(w http.ResponseWriter, r *http.Request, err error) {
+	code := edutrack.ErrorCode(err)
+	mapping, ok := errorStatus[code]
+	if !ok {
+		mapping = errorStatus[edutrack.EINTERNAL]
+	}
+
+	if code == edutrack.EINTERNAL {
+		s.Logger.Error("internal error", "request_id", edutrack.RequestIDFromContext(r.Context()),
+			"method", r.Method, "path", r.URL.Path, "error", err)
+	}
+
+	locale := requestLocale(r)
+	response := &ErrorResponse{Code: mapping.err.Code, Message: edutrack.ErrorMessage(err, locale), Fields: edutrack.ErrorFields(err, locale)}
+	if response.Message == "" {
+		response.Message = i18n.Message(locale, "error."+mapping.err.Code)
+	}
+	sendLocalizedError(w, locale, mapping.status, response)
 }
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// MergePatchContentType is the media type of JSON Merge Patch documents
+// (RFC 7396) accepted by the PATCH routes.
+const MergePatchContentType = "application/merge-patch+json"
+
+// setETag sets the ETag header of a response to the entity tag of a record.
+func setETag(w http.ResponseWriter, m gorm.Model) {
+	w.Header().Set("ETag", edutrack.ETag(m))
+}
+
+// parseIfMatch returns the entity tags of an If-Match header. Weak tags never
+// match, as If-Match uses the strong comparison.
+func parseIfMatch(header string) []string {
+	var etags []string
+	for _, etag := range strings.Split(header, ",") {
+		etag = strings.TrimSpace(etag)
+		if etag != "" && !strings.HasPrefix(etag, "W/") {
+			etags = append(etags, etag)
+		}
+	}
+	return etags
+}
+
+// withIfMatch is a middleware that requires an If-Match precondition, so
+// clients can only modify the version of a record they have seen. The
+// entity tags are stored in the context, where the handlers check them
+// with edutrack.CheckIfMatch.
+func withIfMatch(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("If-Match")
+		if header == "" {
+			sendError(w, r, http.StatusPreconditionRequired, ErrPreconditionRequired)
+			return
+		}
+
+		// A header without strong tags matches no record; an empty (non-nil)
+		// list keeps the precondition in the context.
+		etags := parseIfMatch(header)
+		if etags == nil {
+			etags = []string{}
+		}
+
+		next(w, r.WithContext(edutrack.NewContextWithIfMatch(r.Context(), etags)))
+	}
+}
+
+// withMergePatch is a middleware that rejects PATCH requests whose body is
+// not a JSON Merge Patch document. Plain JSON is accepted as well.
+func withMergePatch(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+			sendError(w, r, http.StatusUnsupportedMediaType, ErrUnsupportedMediaType)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// decodeUpdate decodes the body of an update request. PUT bodies are plain
+// JSON; PATCH bodies are decoded with decodeMergePatch.
+func decodeUpdate(r *http.Request, dst any) error {
+	if r.Method == http.MethodPatch {
+		return decodeMergePatch(r, dst)
+	}
+	return decodeJSON(r, dst)
+}
+
+// decodeMergePatch decodes a JSON Merge Patch document into an update
+// request. Members absent from the patch leave the pointer fields of the
+// request nil, so they are not changed; null members remove the value of a
+// field, setting it to its zero value. Fields of nullable columns are
+// pointers to pointers, so a null member sets them to a nil pointer.
+func decodeMergePatch(r *http.Request, dst any) error {
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		return err
+	}
+	if patch == nil {
+		return errors.New("merge patch must be a JSON object")
+	}
+
+	value := reflect.ValueOf(dst).Elem()
+	fields := map[string]reflect.Value{}
+	for i := range value.NumField() {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = value.Field(i)
+		}
+	}
+
+	var removed []reflect.Value
+	for name, raw := range patch {
+		if string(raw) != "null" {
+			continue
+		}
+		if field, ok := fields[name]; ok {
+			removed = append(removed, field)
+		}
+		delete(patch, name)
+	}
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return err
+	}
+
+	for _, field := range removed {
+		if field.Kind() == reflect.Pointer {
+			field.Set(reflect.New(field.Type().Elem()))
+		} else {
+			field.SetZero()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// etagRequest sends a request through the whole handler chain as the
+// secretary of a tenant, with the given If-Match and Content-Type headers.
+func etagRequest(t *testing.T, server *Server, as *isolationTenant, method, path, ifMatch, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	token, err := server.generateToken(as.Secretary)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+
+	server.server.Handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{`"1.a"`, []string{`"1.a"`}},
+		{`"1.a", "1.b"`, []string{`"1.a"`, `"1.b"`}},
+		{"*", []string{"*"}},
+		{`W/"1.a"`, nil},
+		{`W/"1.a", "1.b"`, []string{`"1.b"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := parseIfMatch(tt.header); !slices.Equal(got, tt.want) {
+				t.Errorf("parseIfMatch(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeMergePatch(t *testing.T) {
+	decode := func(body string) (UpdateSubjectRequest, error) {
+		var req UpdateSubjectRequest
+		r := httptest.NewRequest(http.MethodPatch, "/subjects/1", strings.NewReader(body))
+		err := decodeMergePatch(r, &req)
+		return req, err
+	}
+
+	req, err := decode(`{"name": "Álgebra", "teacher_id": null}`)
+	if err != nil {
+		t.Fatalf("decodeMergePatch() error = %v", err)
+	}
+	if req.Name == nil || *req.Name != "Álgebra" {
+		t.Errorf("Name = %v, want Álgebra", req.Name)
+	}
+	if req.TeacherID == nil || *req.TeacherID != nil {
+		t.Errorf("TeacherID = %v, want a pointer to nil for a null member", req.TeacherID)
+	}
+	if req.Code != nil || req.CareerID != nil {
+		t.Error("Absent members should be nil")
+	}
+
+	req, err = decode(`{"teacher_id": 5}`)
+	if err != nil {
+		t.Fatalf("decodeMergePatch() error = %v", err)
+	}
+	if req.TeacherID == nil || *req.TeacherID == nil || **req.TeacherID != 5 {
+		t.Errorf("TeacherID = %v, want 5", req.TeacherID)
+	}
+
+	for _, body := range []string{`null`, `[]`, `"name"`, `{"credits": "five"}`} {
+		if _, err := decode(body); err == nil {
+			t.Errorf("decodeMergePatch(%s) should fail", body)
+		}
+	}
+}
+
+func TestETag_GetAndUpdate(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+	path := fmt.Sprintf("/careers/%d", tenant.Career.ID)
+
+	w := etagRequest(t, server, tenant, http.MethodGet, path, "", "", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag != edutrack.ETag(tenant.Career.Model) {
+		t.Fatalf("GET status = %d, ETag = %q, want 200 and %q", w.Code, etag, edutrack.ETag(tenant.Career.Model))
+	}
+
+	t.Run("missing If-Match", func(t *testing.T) {
+		w := etagRequest(t, server, tenant, http.MethodPut, path, "", "application/json", `{"duration": 9}`)
+		if w.Code != http.StatusPreconditionRequired {
+			t.Errorf("Expected status %d, got %d", http.StatusPreconditionRequired, w.Code)
+		}
+	})
+
+	t.Run("stale If-Match", func(t *testing.T) {
+		w := etagRequest(t, server, tenant, http.MethodPut, path, `"0.0", W/`+etag, "application/json", `{"duration": 9}`)
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status %d, got %d", http.StatusPreconditionFailed, w.Code)
+		}
+	})
+
+	var updated string
+	t.Run("matching If-Match", func(t *testing.T) {
+		w := etagRequest(t, server, tenant, http.MethodPut, path, etag, "application/json", `{"duration": 9}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		updated = w.Header().Get("ETag")
+		if updated == "" || updated == etag {
+			t.Errorf("ETag after update = %q, want a new one", updated)
+		}
+	})
+
+	t.Run("outdated ETag after update", func(t *testing.T) {
+		w := etagRequest(t, server, tenant, http.MethodDelete, path, etag, "", "")
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status %d, got %d", http.StatusPreconditionFailed, w.Code)
+		}
+
+		w = etagRequest(t, server, tenant, http.MethodGet, path, "", "", "")
+		if got := w.Header().Get("ETag"); got != updated {
+			t.Errorf("GET ETag = %q, want the one of the update %q", got, updated)
+		}
+	})
+}
+
+func TestETag_Patch(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+	path := fmt.Sprintf("/subjects/%d", tenant.Subject.ID)
+	etag := edutrack.ETag(tenant.Subject.Model)
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		w := etagRequest(t, server, tenant, http.MethodPatch, path, etag, "text/plain", `{"credits": 6}`)
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("Expected status %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+		}
+	})
+
+	t.Run("merge patch", func(t *testing.T) {
+		w := etagRequest(t, server, tenant, http.MethodPatch, path, etag, MergePatchContentType, `{"credits": 6, "teacher_id": null}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var subject edutrack.Subject
+		db.First(&subject, tenant.Subject.ID)
+		if subject.Credits != 6 {
+			t.Errorf("Credits = %d, want 6", subject.Credits)
+		}
+		if subject.TeacherID != nil {
+			t.Errorf("TeacherID = %d, want it unassigned", *subject.TeacherID)
+		}
+		if subject.Name != tenant.Subject.Name || subject.CareerID != tenant.Subject.CareerID {
+			t.Error("Members absent from the patch should not change")
+		}
+		if got := w.Header().Get("ETag"); got != edutrack.ETag(subject.Model) {
+			t.Errorf("ETag = %q, want %q", got, edutrack.ETag(subject.Model))
+		}
+	})
+}
+
+func TestETag_Services(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+
+	studentPath := fmt.Sprintf("/students/%d", tenant.Student.ID)
+	w := etagRequest(t, server, tenant, http.MethodPatch, studentPath, `"0.0"`, MergePatchContentType, `{"semester": 2}`)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PATCH student with stale ETag status = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	w = etagRequest(t, server, tenant, http.MethodPatch, studentPath, edutrack.ETag(tenant.Student.Model), MergePatchContentType, `{"semester": 2}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("PATCH student status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	gradePath := fmt.Sprintf("/grades/%d", tenant.Grade.ID)
+	w = etagRequest(t, server, tenant, http.MethodGet, gradePath, "", "", "")
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET grade should return an ETag")
+	}
+	w = etagRequest(t, server, tenant, http.MethodDelete, gradePath, `"0.0"`, "", "")
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE grade with stale ETag status = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	w = etagRequest(t, server, tenant, http.MethodDelete, gradePath, etag, "", "")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("DELETE grade status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+}
+
+// TestETag_ConcurrentWrite checks a record modified between its load and
+// its write is not overwritten, even once the If-Match check passed.
+func TestETag_ConcurrentWrite(t *testing.T) {
+	db := setupIsolationTestDB(t)
+	if err := edutrack.RegisterTenantScope(db); err != nil {
+		t.Fatalf("RegisterTenantScope() error = %v", err)
+	}
+	tenant := seedIsolationTenant(t, db, "TENANT-A-SECRET")
+	ctx := edutrack.NewContextWithAccount(context.Background(), tenant.Secretary)
+
+	var first, second edutrack.Grade
+	db.First(&first, tenant.Grade.ID)
+	db.First(&second, tenant.Grade.ID)
+
+	first.Value = 7
+	if err := edutrack.TenantDB(ctx, db).Save(&first).Error; err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	second.Value = 8
+	err := edutrack.TenantDB(ctx, db).Save(&second).Error
+	if edutrack.ErrorCode(err) != edutrack.EPRECONDITION {
+		t.Errorf("Save() of a stale record error = %v, want precondition failed", err)
+	}
+	err = edutrack.TenantDB(ctx, db).Delete(&second).Error
+	if edutrack.ErrorCode(err) != edutrack.EPRECONDITION {
+		t.Errorf("Delete() of a stale record error = %v, want precondition failed", err)
+	}
+
+	var saved edutrack.Grade
+	if err := db.First(&saved, tenant.Grade.ID).Error; err != nil {
+		t.Fatalf("Stale delete should not delete the record: %v", err)
+	}
+	if saved.Value != 7 {
+		t.Errorf("Value = %v, want the first write 7", saved.Value)
+	}
+}
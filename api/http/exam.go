@@ -0,0 +1,208 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// handleListExams handles GET /exams.
+func (s *Server) handleListExams(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, examIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	filter := edutrack.ExamAttemptFilter{Kind: edutrack.ExamKind(r.URL.Query().Get("kind"))}
+	switch filter.Kind {
+	case "", edutrack.ExamOrdinary, edutrack.ExamExtraordinary, edutrack.ExamSpecial:
+	default:
+		sendFieldError(w, r, "kind", edutrack.FieldInvalidChoice, "field.invalid_choice", "ordinary, extraordinary, special")
+		return
+	}
+	if studentID := r.URL.Query().Get("student_id"); studentID != "" {
+		id, err := strconv.ParseUint(studentID, 10, 64)
+		if err != nil {
+			sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+			return
+		}
+		filter.StudentID = uint(id)
+	}
+	if subjectID := r.URL.Query().Get("subject_id"); subjectID != "" {
+		id, err := strconv.ParseUint(subjectID, 10, 64)
+		if err != nil {
+			sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+			return
+		}
+		filter.SubjectID = uint(id)
+	}
+
+	attempts, err := edutrack.FindExamAttempts(r.Context(), s.DB, filter)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	response := make([]ExamAttemptResponse, 0, len(attempts))
+	for i := range attempts {
+		response = append(response, newExamAttemptResponse(&attempts[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// findExam finds the exam attempt of a route, visible to the authenticated
+// account. On failure it writes the error response and returns nil.
+func (s *Server) findExam(w http.ResponseWriter, r *http.Request) *edutrack.ExamAttempt {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return nil
+	}
+
+	attempt, err := edutrack.FindExamAttemptByID(r.Context(), s.DB, uint(id))
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return nil
+	}
+	return attempt
+}
+
+// handleGetExam handles GET /exams/{id}.
+func (s *Server) handleGetExam(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, examIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	attempt := s.findExam(w, r)
+	if attempt == nil {
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newExamAttemptResponse(attempt, inc))
+}
+
+// CreateExamRequest represents the request body for scheduling an exam
+// attempt.
+type CreateExamRequest struct {
+	Kind      edutrack.ExamKind `json:"kind" validate:"required,oneof=ordinary extraordinary special"`
+	Date      string            `json:"date" validate:"required,date"`
+	Notes     string            `json:"notes" validate:"max=2000"`
+	StudentID uint              `json:"student_id" validate:"required"`
+	SubjectID uint              `json:"subject_id" validate:"required"`
+}
+
+// handleCreateExam handles POST /exams.
+// Schedules an exam attempt of a student enrolled in a subject and notifies
+// the student and their guardians.
+func (s *Server) handleCreateExam(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, examIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	var req CreateExamRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+	date, _ := time.Parse("2006-01-02", req.Date)
+
+	var attempt *edutrack.ExamAttempt
+	err = s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		var err error
+		attempt, err = edutrack.ScheduleExamAttempt(r.Context(), tx, edutrack.ExamAttemptCreate{
+			Kind:      req.Kind,
+			Date:      date,
+			Notes:     req.Notes,
+			StudentID: req.StudentID,
+			SubjectID: req.SubjectID,
+		})
+		if err != nil {
+			return err
+		}
+		return s.notifyStudent(tx, attempt.StudentID, edutrack.NotificationExamScheduled,
+			attempt.Subject.Name, attempt.Date.Format("2006-01-02"))
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, newExamAttemptResponse(attempt, inc))
+}
+
+// GradeExamRequest represents the request body for recording the grade of
+// an exam attempt.
+type GradeExamRequest struct {
+	Value *float64 `json:"value"`
+
+	// Notes of the attempt; absent keeps them.
+	Notes *string `json:"notes" validate:"max=2000"`
+}
+
+// handleGradeExam handles POST /exams/{id}/grade.
+// Records the grade of the last exam attempt of a student in a subject and
+// notifies the student and their guardians.
+func (s *Server) handleGradeExam(w http.ResponseWriter, r *http.Request) {
+	var req GradeExamRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+	if req.Value == nil {
+		sendFieldError(w, r, "value", edutrack.FieldRequired, "field.required")
+		return
+	}
+
+	attempt := s.findExam(w, r)
+	if attempt == nil {
+		return
+	}
+
+	err := s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		if err := edutrack.GradeExamAttempt(r.Context(), tx, attempt, *req.Value, req.Notes); err != nil {
+			return err
+		}
+		return s.notifyStudent(tx, attempt.StudentID, edutrack.NotificationExamGraded,
+			attempt.Subject.Name, attempt.Date.Format("2006-01-02"), *attempt.Value)
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newExamAttemptResponse(attempt, nil))
+}
+
+// handleDeleteExam handles DELETE /exams/{id}.
+// Cancels the last exam attempt of a student in a subject.
+func (s *Server) handleDeleteExam(w http.ResponseWriter, r *http.Request) {
+	attempt := s.findExam(w, r)
+	if attempt == nil {
+		return
+	}
+
+	if err := edutrack.CancelExamAttempt(r.Context(), s.DB, attempt); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+func TestExamAttemptWorkflow(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+	student := studentAccount(t, server, tenant)
+	var teacher edutrack.Account
+	server.DB.First(&teacher, tenant.Teacher.AccountID)
+
+	grade := func(id uint, value float64) *ExamAttemptResponse {
+		t.Helper()
+		path := fmt.Sprintf("/exams/%d/grade", id)
+		w := accountRequest(t, server, &teacher, http.MethodPost, path, GradeExamRequest{Value: &value})
+		var attempt ExamAttemptResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &attempt); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("POST %s status = %d: %s", path, w.Code, w.Body.String())
+		}
+		return &attempt
+	}
+	schedule := func(kind edutrack.ExamKind, date string) *httptest.ResponseRecorder {
+		t.Helper()
+		return isolationRequest(t, server, tenant, http.MethodPost, "/exams", CreateExamRequest{
+			Kind: kind, Date: date, StudentID: tenant.Student.ID, SubjectID: tenant.Subject.ID,
+		})
+	}
+
+	// The teacher fails the student in the seeded ordinary exam, and the
+	// student is notified.
+	if attempt := grade(tenant.Exam.ID, 50); attempt.Value == nil || *attempt.Value != 50 || attempt.GraderID == nil {
+		t.Errorf("Attempt = %+v, want graded with 50", attempt)
+	}
+	var notified int64
+	server.DB.Model(&edutrack.Notification{}).
+		Where("account_id = ? AND event = ?", student.ID, edutrack.NotificationExamGraded).
+		Count(&notified)
+	if notified == 0 {
+		t.Error("The student was not notified of the grade")
+	}
+
+	// Special exams follow the extraordinary ones.
+	if w := schedule(edutrack.ExamSpecial, "2026-07-01"); w.Code != http.StatusConflict {
+		t.Errorf("POST /exams special status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	// An extraordinary exam is scheduled, and no other until it is graded.
+	w := schedule(edutrack.ExamExtraordinary, "2026-07-01")
+	var extraordinary ExamAttemptResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &extraordinary); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST /exams status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := schedule(edutrack.ExamExtraordinary, "2026-07-08"); w.Code != http.StatusConflict {
+		t.Errorf("POST /exams with a pending exam status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	// Only the last attempt can be graded.
+	value := 90.0
+	path := fmt.Sprintf("/exams/%d/grade", tenant.Exam.ID)
+	if w := accountRequest(t, server, &teacher, http.MethodPost, path, GradeExamRequest{Value: &value}); w.Code != http.StatusConflict {
+		t.Errorf("POST %s of a superseded exam status = %d, want %d", path, w.Code, http.StatusConflict)
+	}
+
+	// Passing the extraordinary exam decides the final grade.
+	grade(extraordinary.ID, 85)
+	if w := schedule(edutrack.ExamExtraordinary, "2026-07-08"); w.Code != http.StatusConflict {
+		t.Errorf("POST /exams of a passed subject status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	var report StudentResponse
+	w = accountRequest(t, server, student, http.MethodGet, fmt.Sprintf("/students/%d", tenant.Student.ID), nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode student: %v", err)
+	}
+	want := SubjectAverageResponse{
+		SubjectID:   tenant.Subject.ID,
+		SubjectName: tenant.Subject.Name,
+		Average:     tenant.Grade.Value,
+		FinalGrade:  85,
+		Attempt:     edutrack.ExamExtraordinary,
+		Passed:      true,
+	}
+	if len(report.SubjectAverages) != 1 || report.SubjectAverages[0] != want {
+		t.Errorf("SubjectAverages = %+v, want %+v", report.SubjectAverages, want)
+	}
+
+	// Students see their attempts but cannot grade them.
+	w = accountRequest(t, server, student, http.MethodGet, "/exams", nil)
+	var attempts []ExamAttemptResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &attempts); err != nil || len(attempts) != 2 {
+		t.Errorf("GET /exams as student = %s, want both attempts", w.Body.String())
+	}
+	path = fmt.Sprintf("/exams/%d/grade", extraordinary.ID)
+	if w := accountRequest(t, server, student, http.MethodPost, path, GradeExamRequest{Value: &value}); w.Code != http.StatusForbidden {
+		t.Errorf("POST %s as student status = %d, want %d", path, w.Code, http.StatusForbidden)
+	}
+
+	// Only the last attempt can be canceled.
+	if w := isolationRequest(t, server, tenant, http.MethodDelete, fmt.Sprintf("/exams/%d", tenant.Exam.ID), nil); w.Code != http.StatusConflict {
+		t.Errorf("DELETE of a superseded exam status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if w := isolationRequest(t, server, tenant, http.MethodDelete, fmt.Sprintf("/exams/%d", extraordinary.ID), nil); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE of the last exam status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+}
+
+func TestExamAttemptLimits(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+	failed := 10.0
+	server.DB.Model(tenant.Exam).Update("value", failed)
+
+	w := isolationRequest(t, server, tenant, http.MethodPut, "/tenant/settings", map[string]any{"extraordinary_attempts": 0})
+	decodeTenantSettings(t, w)
+
+	// Without extraordinary exams, the special one follows the ordinary.
+	w = isolationRequest(t, server, tenant, http.MethodPost, "/exams", CreateExamRequest{
+		Kind: edutrack.ExamExtraordinary, Date: "2026-07-01", StudentID: tenant.Student.ID, SubjectID: tenant.Subject.ID,
+	})
+	if w.Code != http.StatusConflict {
+		t.Errorf("POST /exams extraordinary status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	w = isolationRequest(t, server, tenant, http.MethodPost, "/exams", CreateExamRequest{
+		Kind: edutrack.ExamSpecial, Date: "2026-07-01", StudentID: tenant.Student.ID, SubjectID: tenant.Subject.ID,
+	})
+	if w.Code != http.StatusCreated {
+		t.Errorf("POST /exams special status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+}
@@ -0,0 +1,342 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/storage"
+)
+
+// FilesPath is the path under which the API serves the files of the local
+// storage backend, through signed URLs.
+const FilesPath = "/files"
+
+// DefaultFileURLLifetime is the lifetime of the signed download URLs by
+// default.
+const DefaultFileURLLifetime = 15 * time.Minute
+
+// errNoStorage is returned when a file route is used without a storage
+// backend configured.
+var errNoStorage = errors.New("http: file storage not configured")
+
+// uploadFile stores the file of the "file" field of a multipart request
+// under a new key of the tenant, checking it against limits. On failure it
+// writes the error response and returns false.
+func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request, tenantID, kind string, limits storage.Limits) (string, bool) {
+	if !s.parseUpload(w, r, limits) {
+		return "", false
+	}
+	data, contentType, ok := readFile(w, r, limits)
+	if !ok {
+		return "", false
+	}
+	return s.storeFile(w, r, tenantID, kind, data, contentType)
+}
+
+// parseUpload parses a multipart request carrying a file within limits, so
+// its other fields can be read before the file. On failure it writes the
+// error response and returns false.
+func (s *Server) parseUpload(w http.ResponseWriter, r *http.Request, limits storage.Limits) bool {
+	if s.Storage == nil {
+		s.sendAppError(w, r, errNoStorage)
+		return false
+	}
+
+	// Leave room for the multipart headers and the other fields.
+	r.Body = http.MaxBytesReader(w, r.Body, limits.MaxSize+64<<10)
+	err := r.ParseMultipartForm(32 << 20)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		sendFieldError(w, r, "file", edutrack.FieldTooLarge, "field.file.too_large", formatSize(limits.MaxSize))
+		return false
+	case err != nil:
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return false
+	}
+	return true
+}
+
+// readFile reads the file of the "file" field of a parsed multipart
+// request, checking it against limits. On failure it writes the error
+// response and returns false.
+func readFile(w http.ResponseWriter, r *http.Request, limits storage.Limits) ([]byte, string, bool) {
+	file, _, err := r.FormFile("file")
+	switch {
+	case errors.Is(err, http.ErrMissingFile):
+		sendFieldError(w, r, "file", edutrack.FieldRequired, "field.required")
+		return nil, "", false
+	case err != nil:
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return nil, "", false
+	}
+	defer file.Close()
+
+	data, contentType, err := limits.Read(file)
+	switch {
+	case errors.Is(err, storage.ErrTooLarge):
+		sendFieldError(w, r, "file", edutrack.FieldTooLarge, "field.file.too_large", formatSize(limits.MaxSize))
+		return nil, "", false
+	case errors.Is(err, storage.ErrContentType):
+		sendFieldError(w, r, "file", edutrack.FieldInvalidChoice, "field.invalid_choice", strings.Join(limits.ContentTypes, ", "))
+		return nil, "", false
+	case err != nil:
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return nil, "", false
+	}
+	return data, contentType, true
+}
+
+// storeFile stores the data of a file under a new key of the tenant. On
+// failure it writes the error response and returns false.
+func (s *Server) storeFile(w http.ResponseWriter, r *http.Request, tenantID, kind string, data []byte, contentType string) (string, bool) {
+	key, err := storage.NewKey(tenantID, kind, contentType)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return "", false
+	}
+	if err := s.Storage.Put(r.Context(), key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		s.sendAppError(w, r, err)
+		return "", false
+	}
+	return key, true
+}
+
+// deleteFile deletes a replaced or removed file. Failures only leave an
+// orphan object behind, so they are logged instead of failing the request.
+func (s *Server) deleteFile(r *http.Request, key string) {
+	if key == "" || s.Storage == nil {
+		return
+	}
+	if err := s.Storage.Delete(r.Context(), key); err != nil {
+		s.Logger.Warn("failed to delete file", "request_id", edutrack.RequestIDFromContext(r.Context()), "key", key, "error", err)
+	}
+}
+
+// redirectToFile redirects to a signed download URL of the file with the
+// given key, or responds 404 if there is no file.
+func (s *Server) redirectToFile(w http.ResponseWriter, r *http.Request, key string) {
+	if key == "" {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	if s.Storage == nil {
+		s.sendAppError(w, r, errNoStorage)
+		return
+	}
+
+	url, err := s.Storage.SignedURL(r.Context(), key, s.FileURLLifetime)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	// The URL expires, so the redirect must not be cached.
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+}
+
+// handleGetFile handles GET /files/{key...}.
+// Serves a file of the local storage backend to the holder of a signed URL.
+func (s *Server) handleGetFile(w http.ResponseWriter, r *http.Request) {
+	local, ok := s.Storage.(*storage.Local)
+	if !ok {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	key := r.PathValue("key")
+	if err := local.Signer().Verify(key, r.URL.Query(), time.Now()); err != nil {
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
+		return
+	}
+
+	body, err := local.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", storage.ContentType(key))
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(s.FileURLLifetime.Seconds())))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, body)
+}
+
+// handleUploadTenantLogo handles PUT /tenant/logo.
+// Replaces the logo of the institution with the uploaded image.
+func (s *Server) handleUploadTenantLogo(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	var tenant edutrack.Tenant
+	if err := s.DB.First(&tenant, "id = ?", account.TenantID).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	key, ok := s.uploadFile(w, r, tenant.ID, "logo", storage.ImageLimits)
+	if !ok {
+		return
+	}
+
+	previous := tenant.LogoKey
+	if err := s.DB.Model(&tenant).Updates(map[string]any{"logo_key": key, "logo_url": "/tenant/logo"}).Error; err != nil {
+		s.deleteFile(r, key)
+		s.sendAppError(w, r, err)
+		return
+	}
+	s.deleteFile(r, previous)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteTenantLogo handles DELETE /tenant/logo.
+func (s *Server) handleDeleteTenantLogo(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	var tenant edutrack.Tenant
+	if err := s.DB.First(&tenant, "id = ?", account.TenantID).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if err := s.DB.Model(&tenant).Updates(map[string]any{"logo_key": "", "logo_url": ""}).Error; err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+	s.deleteFile(r, tenant.LogoKey)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetTenantLogo handles GET /tenant/logo.
+// Redirects to a signed URL of the logo of the institution.
+func (s *Server) handleGetTenantLogo(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	var tenant edutrack.Tenant
+	if err := s.DB.First(&tenant, "id = ?", account.TenantID).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	s.redirectToFile(w, r, tenant.LogoKey)
+}
+
+// findAvatarAccount finds the account of an avatar route. Any account of
+// the institution can see avatars, but only the account itself and
+// secretaries can change them. On failure it writes the error response and
+// returns nil.
+func (s *Server) findAvatarAccount(w http.ResponseWriter, r *http.Request, modify bool) *edutrack.Account {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return nil
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return nil
+	}
+
+	var found edutrack.Account
+	if err := s.tenantDB(r).First(&found, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return nil
+	}
+
+	if modify && found.ID != account.ID && !account.IsSecretary() {
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
+		return nil
+	}
+	return &found
+}
+
+// handleUploadAccountAvatar handles PUT /accounts/{id}/avatar.
+// Replaces the avatar of an account with the uploaded image.
+func (s *Server) handleUploadAccountAvatar(w http.ResponseWriter, r *http.Request) {
+	found := s.findAvatarAccount(w, r, true)
+	if found == nil {
+		return
+	}
+
+	key, ok := s.uploadFile(w, r, found.TenantID, "avatar", storage.ImageLimits)
+	if !ok {
+		return
+	}
+
+	previous := found.AvatarKey
+	if err := s.tenantDB(r).Model(found).Update("avatar_key", key).Error; err != nil {
+		s.deleteFile(r, key)
+		s.sendAppError(w, r, err)
+		return
+	}
+	s.deleteFile(r, previous)
+
+	setETag(w, found.Model)
+	sendJSON(w, http.StatusOK, newAccountResponse(found))
+}
+
+// handleDeleteAccountAvatar handles DELETE /accounts/{id}/avatar.
+func (s *Server) handleDeleteAccountAvatar(w http.ResponseWriter, r *http.Request) {
+	found := s.findAvatarAccount(w, r, true)
+	if found == nil {
+		return
+	}
+
+	previous := found.AvatarKey
+	if err := s.tenantDB(r).Model(found).Update("avatar_key", "").Error; err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+	s.deleteFile(r, previous)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetAccountAvatar handles GET /accounts/{id}/avatar.
+// Redirects to a signed URL of the avatar of an account.
+func (s *Server) handleGetAccountAvatar(w http.ResponseWriter, r *http.Request) {
+	found := s.findAvatarAccount(w, r, false)
+	if found == nil {
+		return
+	}
+
+	s.redirectToFile(w, r, found.AvatarKey)
+}
+
+// formatSize formats a size in bytes for messages (e.g., "2 MiB").
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20 && size%(1<<20) == 0:
+		return fmt.Sprintf("%d MiB", size>>20)
+	case size >= 1<<10 && size%(1<<10) == 0:
+		return fmt.Sprintf("%d KiB", size>>10)
+	}
+	return fmt.Sprintf("%d B", size)
+}
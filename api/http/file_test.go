@@ -0,0 +1,201 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/storage"
+)
+
+// testPNG is the signature of a PNG image, enough to detect its type.
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// setupFileTest returns an isolation test server storing files in a
+// temporary directory.
+func setupFileTest(t *testing.T) (*Server, *isolationTenant, *isolationTenant) {
+	server, _, tenantA, tenantB := setupIsolationTest(t)
+	server.Storage = storage.NewLocal(t.TempDir(), storage.NewSigner([]byte("test-secret"), FilesPath))
+	return server, tenantA, tenantB
+}
+
+// uploadRequest sends a multipart request as the given account with data
+// in the given field.
+func uploadRequest(t *testing.T, server *Server, as *edutrack.Account, method, path, field string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	token, err := server.generateToken(as)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile(field, "upload.bin")
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	return w
+}
+
+// download follows the redirect of a file route, returning the response of
+// the signed URL.
+func download(t *testing.T, server *Server, w *httptest.ResponseRecorder) *httptest.ResponseRecorder {
+	t.Helper()
+
+	if w.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("Status = %d, want %d: %s", w.Code, http.StatusTemporaryRedirect, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+	if !strings.HasPrefix(location, FilesPath+"/tenants/") {
+		t.Fatalf("Location = %q, want a signed URL under %s", location, FilesPath)
+	}
+
+	file := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(file, httptest.NewRequest(http.MethodGet, location, nil))
+	return file
+}
+
+func TestHandleTenantLogo(t *testing.T) {
+	server, tenantA, tenantB := setupFileTest(t)
+
+	if w := isolationRequest(t, server, tenantA, http.MethodGet, "/tenant/logo", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET /tenant/logo without logo status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	w := uploadRequest(t, server, tenantA.Secretary, http.MethodPut, "/tenant/logo", "file", testPNG)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("PUT /tenant/logo status = %d: %s", w.Code, w.Body.String())
+	}
+
+	redirect := isolationRequest(t, server, tenantA, http.MethodGet, "/tenant/logo", nil)
+	location := redirect.Header().Get("Location")
+	if !strings.HasPrefix(location, FilesPath+"/tenants/"+tenantA.Tenant.ID+"/logo/") {
+		t.Errorf("Location = %q, want a key of the tenant", location)
+	}
+	file := download(t, server, redirect)
+	if file.Code != http.StatusOK || !bytes.Equal(file.Body.Bytes(), testPNG) {
+		t.Fatalf("GET %s status = %d, body = %q", location, file.Code, file.Body.Bytes())
+	}
+	if got := file.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", got)
+	}
+
+	// Signed URLs cannot be forged for other files.
+	forged := strings.Replace(location, "signature=", "signature=0", 1)
+	file = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(file, httptest.NewRequest(http.MethodGet, forged, nil))
+	if file.Code != http.StatusForbidden {
+		t.Errorf("GET with a forged signature status = %d, want %d", file.Code, http.StatusForbidden)
+	}
+
+	// Other institutions do not see the logo.
+	if w := isolationRequest(t, server, tenantB, http.MethodGet, "/tenant/logo", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET /tenant/logo of another tenant status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// Replacing the logo deletes the previous file.
+	if w := uploadRequest(t, server, tenantA.Secretary, http.MethodPut, "/tenant/logo", "file", testPNG); w.Code != http.StatusNoContent {
+		t.Fatalf("PUT /tenant/logo status = %d: %s", w.Code, w.Body.String())
+	}
+	file = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(file, httptest.NewRequest(http.MethodGet, location, nil))
+	if file.Code != http.StatusNotFound {
+		t.Errorf("GET of the replaced logo status = %d, want %d", file.Code, http.StatusNotFound)
+	}
+
+	if w := isolationRequest(t, server, tenantA, http.MethodDelete, "/tenant/logo", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /tenant/logo status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := isolationRequest(t, server, tenantA, http.MethodGet, "/tenant/logo", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET /tenant/logo after DELETE status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleUploadTenantLogo_Invalid(t *testing.T) {
+	server, tenant, _ := setupFileTest(t)
+
+	tests := []struct {
+		name  string
+		field string
+		data  []byte
+		want  map[string]string
+	}{
+		{"html", "file", []byte("<html><script>alert(1)</script></html>"), map[string]string{"file": edutrack.FieldInvalidChoice}},
+		{"pdf", "file", []byte("%PDF-1.7\n"), map[string]string{"file": edutrack.FieldInvalidChoice}},
+		{"too large", "file", append(testPNG, make([]byte, storage.ImageLimits.MaxSize)...), map[string]string{"file": edutrack.FieldTooLarge}},
+		{"missing file", "logo", testPNG, map[string]string{"file": edutrack.FieldRequired}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := uploadRequest(t, server, tenant.Secretary, http.MethodPut, "/tenant/logo", tt.field, tt.data)
+			if got := fieldCodes(errorResponse(t, w, http.StatusBadRequest)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Only secretaries can change the logo.
+	if w := uploadRequest(t, server, tenant.Guardian, http.MethodPut, "/tenant/logo", "file", testPNG); w.Code != http.StatusForbidden {
+		t.Errorf("PUT /tenant/logo as guardian status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleAccountAvatar(t *testing.T) {
+	server, tenant, _ := setupFileTest(t)
+	path := fmt.Sprintf("/accounts/%d/avatar", tenant.Guardian.ID)
+
+	// Accounts can change their own avatar, but not others'.
+	w := uploadRequest(t, server, tenant.Guardian, http.MethodPut, path, "file", testPNG)
+	var account AccountResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &account); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("PUT %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if account.AvatarURL != path {
+		t.Errorf("AvatarURL = %q, want %q", account.AvatarURL, path)
+	}
+
+	other := fmt.Sprintf("/accounts/%d/avatar", tenant.Secretary.ID)
+	if w := uploadRequest(t, server, tenant.Guardian, http.MethodPut, other, "file", testPNG); w.Code != http.StatusForbidden {
+		t.Errorf("PUT %s as another account status = %d, want %d", other, w.Code, http.StatusForbidden)
+	}
+	if w := isolationRequest(t, server, tenant, http.MethodGet, other, nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET %s without avatar status = %d, want %d", other, w.Code, http.StatusNotFound)
+	}
+
+	// Other accounts of the institution see it.
+	if file := download(t, server, isolationRequest(t, server, tenant, http.MethodGet, path, nil)); !bytes.Equal(file.Body.Bytes(), testPNG) {
+		t.Errorf("Avatar = %q, want the uploaded image", file.Body.Bytes())
+	}
+
+	// Secretaries can remove it.
+	if w := isolationRequest(t, server, tenant, http.MethodDelete, path, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if w := isolationRequest(t, server, tenant, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET %s after DELETE status = %d, want %d", path, w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleGetFile_NotLocal(t *testing.T) {
+	server, _, _, _ := setupIsolationTest(t)
+
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, FilesPath+"/tenants/a/logo/x.png", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
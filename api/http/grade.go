@@ -5,247 +5,316 @@ import (
 	"strconv"
 
 	"gorm.io/gorm"
+
 	edutrack "lahuerta.tecmm.edu.mx/edutrack"
 )
 
 // handleListGrades handles GET /grades.
 func (s *Server) handleListGrades(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+	inc, err := parseInclude(r, gradeIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	var query *gorm.DB
-	if account.IsStudent() {
-		// Students can only see their own grades.
-		var student edutrack.Student
-		if err := s.DB.Where("account_id = ? AND tenant_id = ?", account.ID, account.TenantID).First(&student).Error; err != nil {
-			sendError(w, http.StatusNotFound, ErrNotFound)
-			return
+	var filter edutrack.GradeFilter
+
+	// Optional filters; students cannot filter.
+	for param, dst := range map[string]*uint{
+		"student_id": &filter.StudentID,
+		"topic_id":   &filter.TopicID,
+		"section_id": &filter.SectionID,
+	} {
+		if value := r.URL.Query().Get(param); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+				return
+			}
+			*dst = uint(id)
 		}
-		query = s.DB.Where("student_id = ? AND tenant_id = ?", student.ID, account.TenantID)
-	} else {
-		query = s.DB.Where("tenant_id = ?", account.TenantID)
+	}
 
-		// Optional filters for teachers/secretaries.
-		if studentID := r.URL.Query().Get("student_id"); studentID != "" {
-			query = query.Where("student_id = ?", studentID)
-		}
-		if topicID := r.URL.Query().Get("topic_id"); topicID != "" {
-			query = query.Where("topic_id = ?", topicID)
-		}
+	switch filter.Status = edutrack.GradeStatus(r.URL.Query().Get("status")); filter.Status {
+	case "", edutrack.GradeDraft, edutrack.GradePublished, edutrack.GradeLocked:
+	default:
+		sendFieldError(w, r, "status", edutrack.FieldInvalidChoice, "field.invalid_choice", "draft, published, locked")
+		return
 	}
 
-	var grades []edutrack.Grade
-	if err := query.Preload("Student").Preload("Topic.Subject").Find(&grades).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	grades, err := s.GradeService.FindGrades(r.Context(), filter)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, grades)
+	response := make([]GradeResponse, 0, len(grades))
+	for i := range grades {
+		response = append(response, newGradeResponse(&grades[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
 }
 
 // handleGetGrade handles GET /grades/{id}.
 func (s *Server) handleGetGrade(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+	inc, err := parseInclude(r, gradeIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
-		return
-	}
-
-	var grade edutrack.Grade
-	if err := s.DB.Preload("Student").Preload("Topic.Subject").First(&grade, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if grade.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	grade, err := s.GradeService.FindGradeByID(r.Context(), uint(id))
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	if account.IsStudent() {
-		// Students can only access their own grades.
-		var student edutrack.Student
-		if err := s.DB.Where("account_id = ? AND tenant_id = ?", account.ID, account.TenantID).First(&student).Error; err != nil {
-			sendError(w, http.StatusNotFound, ErrNotFound)
-			return
-		}
-		if grade.StudentID != student.ID {
-			sendError(w, http.StatusForbidden, ErrForbidden)
-			return
-		}
-	}
-
-	sendJSON(w, http.StatusOK, grade)
+	setETag(w, grade.Model)
+	sendJSON(w, http.StatusOK, newGradeResponse(grade, inc))
 }
 
 // CreateGradeRequest represents the request body for creating a grade.
 type CreateGradeRequest struct {
 	Value     float64 `json:"value"`
 	Notes     string  `json:"notes"`
-	StudentID uint    `json:"student_id"`
-	TopicID   uint    `json:"topic_id"`
+	StudentID uint    `json:"student_id" validate:"required"`
+	TopicID   uint    `json:"topic_id" validate:"required"`
+
+	// Draft by default, hidden from the student until published.
+	Status edutrack.GradeStatus `json:"status" validate:"omitempty,oneof=draft published"`
+
+	// Reason of the amendment, required past the deadline of the topic.
+	Reason string `json:"reason"`
 }
 
 // handleCreateGrade handles POST /grades.
 func (s *Server) handleCreateGrade(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
-		return
-	}
-
-	// Only teachers and secretaries can create grades.
-	if account.IsStudent() {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	inc, err := parseInclude(r, gradeIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req CreateGradeRequest
 	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
-		return
-	}
-
-	if req.StudentID == 0 || req.TopicID == 0 {
-		sendErrorMessage(w, http.StatusBadRequest, "El estudiante y el tema son requeridos.")
-		return
-	}
-
-	// Verify topic exists and belongs to the same tenant.
-	var topic edutrack.Topic
-	if err := s.DB.First(&topic, req.TopicID).Error; err != nil {
-		sendErrorMessage(w, http.StatusBadRequest, "El tema especificado no existe.")
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
-	if topic.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	grade := &edutrack.Grade{
-		Value:     req.Value,
-		Notes:     req.Notes,
-		StudentID: req.StudentID,
-		TopicID:   req.TopicID,
-		TenantID:  account.TenantID,
-	}
-
-	if err := s.DB.Create(grade).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	var grade *edutrack.Grade
+	err = s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		var err error
+		grade, err = s.GradeService.CreateGrade(r.Context(), edutrack.GradeCreate{
+			Value:     req.Value,
+			Notes:     req.Notes,
+			StudentID: req.StudentID,
+			TopicID:   req.TopicID,
+			Status:    req.Status,
+			Reason:    req.Reason,
+		})
+		if err != nil {
+			return err
+		}
+		// Drafts are hidden until they are published.
+		if grade.Status == edutrack.GradeDraft {
+			return nil
+		}
+		return s.postGrade(tx, grade)
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	// Reload with associations.
-	s.DB.Preload("Student").Preload("Topic.Subject").First(grade, grade.ID)
-
-	sendJSON(w, http.StatusCreated, grade)
+	setETag(w, grade.Model)
+	sendJSON(w, http.StatusCreated, newGradeResponse(grade, inc))
 }
 
 // UpdateGradeRequest represents the request body for updating a grade.
 type UpdateGradeRequest struct {
 	Value *float64 `json:"value"`
 	Notes *string  `json:"notes"`
+
+	// Publishes a draft; published grades cannot go back to draft.
+	Status *edutrack.GradeStatus `json:"status" validate:"omitempty,oneof=draft published"`
+
+	// Reason of the amendment, required to change a locked grade.
+	Reason *string `json:"reason"`
 }
 
-// handleUpdateGrade handles PUT /grades/{id}.
+// handleUpdateGrade handles PUT and PATCH /grades/{id}.
 func (s *Server) handleUpdateGrade(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
-		return
-	}
-
-	// Only teachers and secretaries can update grades.
-	if account.IsStudent() {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	inc, err := parseInclude(r, gradeIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	var grade edutrack.Grade
-	if err := s.DB.First(&grade, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	var req UpdateGradeRequest
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
-
-	if grade.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	var req UpdateGradeRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
-		return
-	}
-
-	if req.Value != nil {
-		grade.Value = *req.Value
+	update := edutrack.GradeUpdate{
+		Value:  req.Value,
+		Notes:  req.Notes,
+		Status: req.Status,
 	}
-	if req.Notes != nil {
-		grade.Notes = *req.Notes
+	if req.Reason != nil {
+		update.Reason = *req.Reason
 	}
 
-	if err := s.DB.Save(&grade).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	var grade *edutrack.Grade
+	err = s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		// Only notify when the grade is published.
+		previous, err := s.GradeService.FindGradeByID(r.Context(), uint(id))
+		if err != nil {
+			return err
+		}
+		grade, err = s.GradeService.UpdateGrade(r.Context(), uint(id), update)
+		if err != nil {
+			return err
+		}
+		switch {
+		case grade.Status == edutrack.GradeDraft:
+			// Drafts are hidden until they are published.
+			return nil
+		case previous.Status == edutrack.GradeDraft:
+			return s.postGrade(tx, grade)
+		default:
+			return s.emitWebhook(tx, grade.TenantID, edutrack.WebhookGradeUpdated, newGradeResponse(grade, includeAll(gradeIncludes)))
+		}
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	// Reload with associations.
-	s.DB.Preload("Student").Preload("Topic.Subject").First(&grade, grade.ID)
-
-	sendJSON(w, http.StatusOK, grade)
+	setETag(w, grade.Model)
+	sendJSON(w, http.StatusOK, newGradeResponse(grade, inc))
 }
 
 // handleDeleteGrade handles DELETE /grades/{id}.
 func (s *Server) handleDeleteGrade(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	// Only teachers and secretaries can delete grades.
-	if account.IsStudent() {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if isDeletePreview(r) {
+		preview, err := s.GradeService.PreviewDeleteGrade(r.Context(), uint(id))
+		if err != nil {
+			s.sendAppError(w, r, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, preview)
 		return
 	}
 
+	if err := s.GradeService.DeleteGrade(r.Context(), uint(id)); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PublishTopicGradesResponse represents the result of publishing the grades
+// of a topic.
+type PublishTopicGradesResponse struct {
+	// Number of draft grades published.
+	Published int `json:"published"`
+}
+
+// handlePublishTopicGrades handles POST /topics/{id}/publish.
+// Publishes the draft grades of a topic at once.
+func (s *Server) handlePublishTopicGrades(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	var grade edutrack.Grade
-	if err := s.DB.First(&grade, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	var grades []edutrack.Grade
+	err = s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		var err error
+		grades, err = s.GradeService.PublishTopicGrades(r.Context(), uint(id))
+		if err != nil {
+			return err
+		}
+		for i := range grades {
+			if err := s.postGrade(tx, &grades[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	if grade.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	sendJSON(w, http.StatusOK, PublishTopicGradesResponse{Published: len(grades)})
+}
+
+// handleListGradeAmendments handles GET /grades/{id}/amendments.
+func (s *Server) handleListGradeAmendments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if err := s.DB.Delete(&grade).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	amendments, err := s.GradeService.FindGradeAmendments(r.Context(), uint(id))
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	response := make([]GradeAmendmentResponse, 0, len(amendments))
+	for i := range amendments {
+		response = append(response, newGradeAmendmentResponse(&amendments[i]))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// postGrade emits a published grade, which must have its student, topic and
+// subject loaded, and notifies the student and their guardians.
+func (s *Server) postGrade(tx *gorm.DB, grade *edutrack.Grade) error {
+	err := s.emitWebhook(tx, grade.TenantID, edutrack.WebhookGradeCreated, newGradeResponse(grade, includeAll(gradeIncludes)))
+	if err != nil {
+		return err
+	}
+	return s.notifyGradePosted(tx, grade)
+}
+
+// notifyGradePosted notifies the student and their guardians of a
+// published grade, which must have its topic and subject loaded.
+func (s *Server) notifyGradePosted(tx *gorm.DB, grade *edutrack.Grade) error {
+	return s.notifyStudent(tx, grade.StudentID, edutrack.NotificationGradePosted,
+		grade.Value, grade.Topic.Subject.Name, grade.Topic.Name)
 }
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -205,7 +206,7 @@ func TestHandleListGrades_Success(t *testing.T) {
 		t.Errorf("handleListGrades() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var grades []edutrack.Grade
+	var grades []GradeResponse
 	if err := json.NewDecoder(w.Body).Decode(&grades); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -242,7 +243,7 @@ func TestHandleListGrades_FilterByStudentID(t *testing.T) {
 		t.Errorf("handleListGrades() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var grades []edutrack.Grade
+	var grades []GradeResponse
 	if err := json.NewDecoder(w.Body).Decode(&grades); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -277,7 +278,7 @@ func TestHandleListGrades_FilterByTopicID(t *testing.T) {
 		t.Errorf("handleListGrades() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var grades []edutrack.Grade
+	var grades []GradeResponse
 	if err := json.NewDecoder(w.Body).Decode(&grades); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -333,7 +334,7 @@ func TestHandleListGrades_TenantIsolation(t *testing.T) {
 
 	server.handleListGrades(w, req)
 
-	var grades []edutrack.Grade
+	var grades []GradeResponse
 	json.NewDecoder(w.Body).Decode(&grades)
 
 	if len(grades) != 1 {
@@ -365,7 +366,7 @@ func TestHandleGetGrade_Success(t *testing.T) {
 		t.Errorf("handleGetGrade() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var found edutrack.Grade
+	var found GradeResponse
 	if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -411,7 +412,7 @@ func TestHandleGetGrade_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleGetGrade_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleGetGrade_CrossTenant(t *testing.T) {
 	db := setupGradeTestDB(t)
 
 	tenant1 := createGradeTestTenant(t, db)
@@ -436,8 +437,8 @@ func TestHandleGetGrade_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleGetGrade(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleGetGrade() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleGetGrade() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -470,7 +471,7 @@ func TestHandleCreateGrade_Success(t *testing.T) {
 		t.Errorf("handleCreateGrade() status = %d, want %d", w.Code, http.StatusCreated)
 	}
 
-	var created edutrack.Grade
+	var created GradeResponse
 	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -516,7 +517,7 @@ func TestHandleCreateGrade_VariousValues(t *testing.T) {
 				t.Errorf("handleCreateGrade() status = %d, want %d", w.Code, http.StatusCreated)
 			}
 
-			var created edutrack.Grade
+			var created GradeResponse
 			json.NewDecoder(w.Body).Decode(&created)
 
 			if created.Value != value {
@@ -604,7 +605,7 @@ func TestHandleUpdateGrade_Success(t *testing.T) {
 		t.Errorf("handleUpdateGrade() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Grade
+	var updated GradeResponse
 	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -644,7 +645,7 @@ func TestHandleUpdateGrade_UpdateNotes(t *testing.T) {
 		t.Errorf("handleUpdateGrade() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Grade
+	var updated GradeResponse
 	json.NewDecoder(w.Body).Decode(&updated)
 
 	if updated.Notes != newNotes {
@@ -684,7 +685,7 @@ func TestHandleUpdateGrade_UpdateAllFields(t *testing.T) {
 		t.Errorf("handleUpdateGrade() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Grade
+	var updated GradeResponse
 	json.NewDecoder(w.Body).Decode(&updated)
 
 	if updated.Value != newValue {
@@ -717,7 +718,7 @@ func TestHandleUpdateGrade_NotFound(t *testing.T) {
 	}
 }
 
-func TestHandleUpdateGrade_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleUpdateGrade_CrossTenant(t *testing.T) {
 	db := setupGradeTestDB(t)
 
 	tenant1 := createGradeTestTenant(t, db)
@@ -746,8 +747,8 @@ func TestHandleUpdateGrade_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleUpdateGrade(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleUpdateGrade() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleUpdateGrade() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -819,7 +820,7 @@ func TestHandleDeleteGrade_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleDeleteGrade_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleDeleteGrade_CrossTenant(t *testing.T) {
 	db := setupGradeTestDB(t)
 
 	tenant1 := createGradeTestTenant(t, db)
@@ -845,8 +846,111 @@ func TestHandleDeleteGrade_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleDeleteGrade(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleDeleteGrade() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleDeleteGrade() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// fakeGradeService is a GradeService returning fixed results, to test the
+// handlers without a database.
+type fakeGradeService struct {
+	grade *edutrack.Grade
+	err   error
+
+	// Filter of the last FindGrades call.
+	filter edutrack.GradeFilter
+}
+
+func (f *fakeGradeService) FindGrades(ctx context.Context, filter edutrack.GradeFilter) ([]edutrack.Grade, error) {
+	f.filter = filter
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []edutrack.Grade{*f.grade}, nil
+}
+
+func (f *fakeGradeService) FindGradeByID(ctx context.Context, id uint) (*edutrack.Grade, error) {
+	return f.grade, f.err
+}
+
+func (f *fakeGradeService) CreateGrade(ctx context.Context, create edutrack.GradeCreate) (*edutrack.Grade, error) {
+	return f.grade, f.err
+}
+
+func (f *fakeGradeService) UpdateGrade(ctx context.Context, id uint, update edutrack.GradeUpdate) (*edutrack.Grade, error) {
+	return f.grade, f.err
+}
+
+func (f *fakeGradeService) DeleteGrade(ctx context.Context, id uint) error {
+	return f.err
+}
+
+func (f *fakeGradeService) PreviewDeleteGrade(ctx context.Context, id uint) (*edutrack.DeletePreview, error) {
+	return &edutrack.DeletePreview{Allowed: true}, f.err
+}
+
+func (f *fakeGradeService) PublishTopicGrades(ctx context.Context, topicID uint) ([]edutrack.Grade, error) {
+	return nil, f.err
+}
+
+func (f *fakeGradeService) FindGradeAmendments(ctx context.Context, id uint) ([]edutrack.GradeAmendment, error) {
+	return nil, f.err
+}
+
+func TestHandleListGrades_Filters(t *testing.T) {
+	service := &fakeGradeService{grade: &edutrack.Grade{Value: 90}}
+	server := NewServer(":8080", nil, []byte("test-secret"))
+	server.GradeService = service
+
+	req := httptest.NewRequest(http.MethodGet, "/grades?student_id=4&topic_id=7", nil)
+	w := httptest.NewRecorder()
+
+	server.handleListGrades(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleListGrades() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	want := edutrack.GradeFilter{StudentID: 4, TopicID: 7}
+	if service.filter != want {
+		t.Errorf("handleListGrades() filter = %+v, want %+v", service.filter, want)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/grades?topic_id=abc", nil)
+	w = httptest.NewRecorder()
+
+	server.handleListGrades(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleListGrades() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleCreateGrade_DomainError(t *testing.T) {
+	// The service runs in a transaction of the server database.
+	db := setupGradeTestDB(t)
+	tenant := createGradeTestTenant(t, db)
+	account := createGradeTestAccount(t, db, tenant.ID, "teacher@test.com", "Teacher", edutrack.RoleTeacher)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+	server.GradeService = &fakeGradeService{err: edutrack.Errorf(edutrack.EINVALID, "El tema especificado no existe.")}
+
+	body, _ := json.Marshal(CreateGradeRequest{Value: 90, StudentID: 1, TopicID: 99})
+	req := makeGradeAuthenticatedRequest(t, http.MethodPost, "/grades", body, account)
+	w := httptest.NewRecorder()
+
+	server.handleCreateGrade(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleCreateGrade() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	var response ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Message != "El tema especificado no existe." {
+		t.Errorf("handleCreateGrade() message = %q", response.Message)
 	}
 }
 
@@ -949,3 +1053,147 @@ func BenchmarkHandleListGrades(b *testing.B) {
 		server.handleListGrades(w, req)
 	}
 }
+
+// accountRequest sends a request as the given account, with If-Match * on
+// PUT, PATCH and DELETE.
+func accountRequest(t *testing.T, server *Server, as *edutrack.Account, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	token, err := server.generateToken(as)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	data, _ := json.Marshal(body)
+	if body == nil {
+		data = nil
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	if method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete {
+		req.Header.Set("If-Match", "*")
+	}
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestGradePublicationWorkflow(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+	student := studentAccount(t, server, tenant)
+	var teacher edutrack.Account
+	server.DB.First(&teacher, tenant.Teacher.AccountID)
+
+	// New grades are drafts, hidden from the student and not notified.
+	w := accountRequest(t, server, &teacher, http.MethodPost, "/grades", CreateGradeRequest{
+		Value: 8, StudentID: tenant.Student.ID, TopicID: tenant.Topic.ID,
+	})
+	var draft GradeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &draft); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST /grades status = %d: %s", w.Code, w.Body.String())
+	}
+	if draft.Status != edutrack.GradeDraft {
+		t.Errorf("Status = %s, want %s", draft.Status, edutrack.GradeDraft)
+	}
+
+	path := fmt.Sprintf("/grades/%d", draft.ID)
+	if w := accountRequest(t, server, student, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET %s as student status = %d, want %d", path, w.Code, http.StatusNotFound)
+	}
+	var grades []GradeResponse
+	w = accountRequest(t, server, student, http.MethodGet, "/grades", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &grades); err != nil || len(grades) != 1 || grades[0].ID != tenant.Grade.ID {
+		t.Errorf("GET /grades as student = %s, want only the published grade", w.Body.String())
+	}
+
+	notified := func() int64 {
+		var count int64
+		server.DB.Model(&edutrack.Notification{}).
+			Where("account_id = ? AND event = ?", student.ID, edutrack.NotificationGradePosted).
+			Count(&count)
+		return count
+	}
+	if n := notified(); n != 0 {
+		t.Errorf("Notifications = %d, want none for a draft", n)
+	}
+
+	// Staff filter the drafts of a topic.
+	w = isolationRequest(t, server, tenant, http.MethodGet, fmt.Sprintf("/grades?topic_id=%d&status=draft", tenant.Topic.ID), nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &grades); err != nil || len(grades) != 1 || grades[0].ID != draft.ID {
+		t.Errorf("GET /grades?status=draft = %s, want the draft", w.Body.String())
+	}
+
+	// Publishing the topic publishes its drafts and notifies the student.
+	w = accountRequest(t, server, &teacher, http.MethodPost, fmt.Sprintf("/topics/%d/publish", tenant.Topic.ID), nil)
+	var published PublishTopicGradesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &published); err != nil || published.Published != 1 {
+		t.Fatalf("POST /topics/{id}/publish = %d %s, want 1 published", w.Code, w.Body.String())
+	}
+	if w := accountRequest(t, server, student, http.MethodGet, path, nil); w.Code != http.StatusOK {
+		t.Errorf("GET %s as student status = %d, want %d", path, w.Code, http.StatusOK)
+	}
+	if n := notified(); n == 0 {
+		t.Error("The student was not notified of the published grade")
+	}
+
+	// Published grades cannot go back to draft.
+	w = accountRequest(t, server, &teacher, http.MethodPut, path, map[string]any{"value": 8, "status": "draft"})
+	if got := fieldCodes(errorResponse(t, w, http.StatusBadRequest)); got["status"] != edutrack.FieldInvalidChoice {
+		t.Errorf("Fields = %v, want status %s", got, edutrack.FieldInvalidChoice)
+	}
+}
+
+func TestGradeLock(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+	var teacher edutrack.Account
+	server.DB.First(&teacher, tenant.Teacher.AccountID)
+	path := fmt.Sprintf("/grades/%d", tenant.Grade.ID)
+
+	// The deadline of the topic passed.
+	deadline := time.Now().Add(-time.Hour)
+	server.DB.Model(tenant.Topic).Update("grades_lock_at", deadline)
+	locked, err := edutrack.LockDueGrades(context.Background(), server.DB, time.Now())
+	if err != nil || locked != 1 {
+		t.Fatalf("LockDueGrades() = %d, %v, want the seeded grade", locked, err)
+	}
+
+	// Teachers can no longer change or delete the grades.
+	if w := accountRequest(t, server, &teacher, http.MethodPut, path, map[string]any{"value": 10}); w.Code != http.StatusConflict {
+		t.Errorf("PUT %s as teacher status = %d, want %d", path, w.Code, http.StatusConflict)
+	}
+	if w := isolationRequest(t, server, tenant, http.MethodDelete, path, nil); w.Code != http.StatusConflict {
+		t.Errorf("DELETE %s status = %d, want %d", path, w.Code, http.StatusConflict)
+	}
+
+	// Secretaries amend them giving a reason.
+	w := isolationRequest(t, server, tenant, http.MethodPut, path, map[string]any{"value": 10})
+	if got := fieldCodes(errorResponse(t, w, http.StatusBadRequest)); got["reason"] != edutrack.FieldRequired {
+		t.Errorf("Fields = %v, want reason %s", got, edutrack.FieldRequired)
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodPut, path, map[string]any{"value": 10, "reason": "Error de captura"})
+	var grade GradeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &grade); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("PUT %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if grade.Value != 10 || grade.Status != edutrack.GradeLocked {
+		t.Errorf("Grade = %+v, want 10 and still locked", grade)
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodGet, path+"/amendments", nil)
+	var amendments []GradeAmendmentResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &amendments); err != nil || len(amendments) != 2 {
+		t.Fatalf("GET %s/amendments = %s, want the seeded and the new amendment", path, w.Body.String())
+	}
+	last := amendments[1]
+	if last.Reason != "Error de captura" || last.PreviousValue == nil || *last.PreviousValue != 9 || last.Value != 10 {
+		t.Errorf("Amendment = %+v, want 9 to 10 with the reason", last)
+	}
+
+	// Drafts of the topic can no longer be published by teachers.
+	publish := fmt.Sprintf("/topics/%d/publish", tenant.Topic.ID)
+	if w := accountRequest(t, server, &teacher, http.MethodPost, publish, nil); w.Code != http.StatusConflict {
+		t.Errorf("POST %s as teacher status = %d, want %d", publish, w.Code, http.StatusConflict)
+	}
+}
@@ -0,0 +1,324 @@
+package http
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// handleListGuardians handles GET /guardians.
+// Only secretaries can list the guardians of the institution.
+func (s *Server) handleListGuardians(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, guardianLinkIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	if !account.IsSecretary() {
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
+		return
+	}
+
+	query := s.tenantDB(r).Where("role = ?", edutrack.RoleGuardian)
+
+	// Optional filters.
+	if name := r.URL.Query().Get("name"); name != "" {
+		query = query.Where("name LIKE ?", "%"+name+"%")
+	}
+	if studentID := r.URL.Query().Get("student_id"); studentID != "" {
+		query = query.Where("id IN (?)", s.tenantDB(r).Model(&edutrack.GuardianLink{}).Select("account_id").Where("student_id = ?", studentID))
+	}
+
+	var accounts []edutrack.Account
+	if err := query.Find(&accounts).Error; err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	guardians := make([]GuardianResponse, 0, len(accounts))
+	for i := range accounts {
+		var links []edutrack.GuardianLink
+		if err := s.tenantDB(r).Preload("Student.Account").Where("account_id = ?", accounts[i].ID).Find(&links).Error; err != nil {
+			sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+			return
+		}
+		guardians = append(guardians, newGuardianResponse(&accounts[i], links, inc))
+	}
+
+	sendJSON(w, http.StatusOK, guardians)
+}
+
+// handleGetGuardian handles GET /guardians/{id}.
+// Secretaries can access any guardian; guardians can only access themselves.
+func (s *Server) handleGetGuardian(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, guardianLinkIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	if !account.IsSecretary() && !(account.IsGuardian() && account.ID == uint(id)) {
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
+		return
+	}
+
+	var guardian edutrack.Account
+	if err := s.tenantDB(r).Where("role = ?", edutrack.RoleGuardian).First(&guardian, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	var links []edutrack.GuardianLink
+	if err := s.tenantDB(r).Preload("Student.Account").Where("account_id = ?", guardian.ID).Find(&links).Error; err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newGuardianResponse(&guardian, links, inc))
+}
+
+// CreateGuardianRequest represents the request body for inviting a guardian.
+type CreateGuardianRequest struct {
+	Name         string `json:"name" validate:"required"`
+	Email        string `json:"email" validate:"required,email"`
+	Password     string `json:"password" validate:"required"`
+	Relationship string `json:"relationship"`
+	StudentIDs   []uint `json:"student_ids" validate:"required"`
+}
+
+// handleCreateGuardian handles POST /guardians.
+// It creates a guardian account linked to one or more students.
+// Only secretaries can invite guardians.
+func (s *Server) handleCreateGuardian(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, guardianLinkIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	if !account.IsSecretary() {
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
+		return
+	}
+
+	var req CreateGuardianRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	// Verify all students exist and belong to the same tenant. A student
+	// listed more than once is linked once.
+	req.StudentIDs = slices.Compact(slices.Sorted(slices.Values(req.StudentIDs)))
+	var count int64
+	if err := s.tenantDB(r).Model(&edutrack.Student{}).Where("id IN ?", req.StudentIDs).Count(&count).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
+		return
+	}
+	if int(count) != len(req.StudentIDs) {
+		sendFieldError(w, r, "student_ids", edutrack.FieldNotFound, "student.some_not_found")
+		return
+	}
+
+	// Hash the password.
+	hashedPassword, err := edutrack.HashPassword(req.Password)
+	if err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	guardian := &edutrack.Account{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: hashedPassword,
+		Role:     edutrack.RoleGuardian,
+		Active:   true,
+		TenantID: account.TenantID,
+	}
+
+	// The account is only created along with its links.
+	var links []edutrack.GuardianLink
+	err = s.tenantDB(r).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(guardian).Error; err != nil {
+			return err
+		}
+		links = make([]edutrack.GuardianLink, 0, len(req.StudentIDs))
+		for _, studentID := range req.StudentIDs {
+			links = append(links, edutrack.GuardianLink{
+				Relationship: req.Relationship,
+				AccountID:    guardian.ID,
+				StudentID:    studentID,
+				TenantID:     account.TenantID,
+			})
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
+		return
+	}
+
+	// Load the students of the links for the response.
+	s.tenantDB(r).Preload("Student.Account").Where("account_id = ?", guardian.ID).Find(&links)
+
+	sendJSON(w, http.StatusCreated, newGuardianResponse(guardian, links, inc))
+}
+
+// LinkGuardianStudentRequest represents the request body for linking a student to a guardian.
+type LinkGuardianStudentRequest struct {
+	StudentID    uint   `json:"student_id" validate:"required"`
+	Relationship string `json:"relationship"`
+}
+
+// handleLinkGuardianStudent handles POST /guardians/{id}/students.
+// It links a student to an existing guardian. Only secretaries can perform this action.
+func (s *Server) handleLinkGuardianStudent(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, guardianLinkIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	if !account.IsSecretary() {
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	var req LinkGuardianStudentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	var guardian edutrack.Account
+	if err := s.tenantDB(r).Where("role = ?", edutrack.RoleGuardian).First(&guardian, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	var student edutrack.Student
+	if err := s.tenantDB(r).First(&student, req.StudentID).Error; err != nil {
+		sendFieldError(w, r, "student_id", edutrack.FieldNotFound, "student.not_found")
+		return
+	}
+
+	linked, err := edutrack.IsGuardianOf(r.Context(), s.tenantDB(r), guardian.ID, student.ID)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+	if linked {
+		s.sendAppError(w, r, &edutrack.Error{Code: edutrack.ECONFLICT, Fields: []edutrack.FieldError{
+			{Field: "student_id", Code: edutrack.FieldTaken, Message: "student.already_linked"},
+		}})
+		return
+	}
+
+	link := &edutrack.GuardianLink{
+		Relationship: req.Relationship,
+		AccountID:    guardian.ID,
+		StudentID:    student.ID,
+		TenantID:     account.TenantID,
+	}
+
+	if err := s.tenantDB(r).Create(link).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
+		return
+	}
+
+	// Load the student for the response.
+	s.tenantDB(r).Preload("Student.Account").First(link, link.ID)
+
+	sendJSON(w, http.StatusCreated, newGuardianLinkResponse(link, inc))
+}
+
+// handleUnlinkGuardianStudent handles DELETE /guardians/{id}/students/{student_id}.
+// It removes the link between a guardian and a student. Only secretaries can perform this action.
+func (s *Server) handleUnlinkGuardianStudent(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	if !account.IsSecretary() {
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
+		return
+	}
+
+	guardianID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	studentID, err := strconv.ParseUint(r.PathValue("student_id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	var link edutrack.GuardianLink
+	if err := s.tenantDB(r).Where("account_id = ? AND student_id = ?", guardianID, studentID).First(&link).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	// Hard delete so the guardian can be linked to the student again later.
+	if err := s.tenantDB(r).Unscoped().Delete(&link).Error; err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,505 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// setupGuardianTestDB creates an in-memory SQLite database for testing.
+func setupGuardianTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// createGuardianTestTenant creates a test tenant with a valid license.
+func createGuardianTestTenant(t *testing.T, db *gorm.DB) *edutrack.Tenant {
+	tenant, err := edutrack.NewTenant("Test Institution", edutrack.LicenseTypeTrial, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create test tenant: %v", err)
+	}
+
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatalf("Failed to save test tenant: %v", err)
+	}
+
+	return tenant
+}
+
+// createGuardianTestAccount creates a test account for a tenant.
+func createGuardianTestAccount(t *testing.T, db *gorm.DB, tenantID, email string, role edutrack.Role) *edutrack.Account {
+	hashedPassword, err := edutrack.HashPassword("password123")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	account := &edutrack.Account{
+		Name:     "Test User",
+		Email:    email,
+		Password: hashedPassword,
+		Role:     role,
+		Active:   true,
+		TenantID: tenantID,
+	}
+
+	if err := db.Create(account).Error; err != nil {
+		t.Fatalf("Failed to save test account: %v", err)
+	}
+
+	return account
+}
+
+// createGuardianTestStudent creates a test student (and its account) for a tenant.
+func createGuardianTestStudent(t *testing.T, db *gorm.DB, tenantID string) *edutrack.Student {
+	account := createGuardianTestAccount(t, db, tenantID, fmt.Sprintf("student-%d@test.com", time.Now().UnixNano()), edutrack.RoleStudent)
+
+	student := &edutrack.Student{
+		StudentID: fmt.Sprintf("STU-%d", time.Now().UnixNano()),
+		AccountID: account.ID,
+		Semester:  1,
+		TenantID:  tenantID,
+	}
+
+	if err := db.Create(student).Error; err != nil {
+		t.Fatalf("Failed to create test student: %v", err)
+	}
+
+	return student
+}
+
+// linkGuardianTestStudent links a guardian account to a student.
+func linkGuardianTestStudent(t *testing.T, db *gorm.DB, tenantID string, guardianID, studentID uint) {
+	link := &edutrack.GuardianLink{
+		Relationship: "Madre",
+		AccountID:    guardianID,
+		StudentID:    studentID,
+		TenantID:     tenantID,
+	}
+
+	if err := db.Create(link).Error; err != nil {
+		t.Fatalf("Failed to link guardian: %v", err)
+	}
+}
+
+// createGuardianTestGrade creates a grade for a student on a new topic.
+func createGuardianTestGrade(t *testing.T, db *gorm.DB, tenantID string, studentID uint) *edutrack.Grade {
+	subject := &edutrack.Subject{
+		Name:     "Matemáticas I",
+		Code:     fmt.Sprintf("MAT-%d", time.Now().UnixNano()),
+		TenantID: tenantID,
+	}
+	if err := db.Create(subject).Error; err != nil {
+		t.Fatalf("Failed to create test subject: %v", err)
+	}
+
+	topic := &edutrack.Topic{Name: "Unidad 1", SubjectID: subject.ID, TenantID: tenantID}
+	if err := db.Create(topic).Error; err != nil {
+		t.Fatalf("Failed to create test topic: %v", err)
+	}
+
+	grade := &edutrack.Grade{Value: 90, StudentID: studentID, TopicID: topic.ID, TenantID: tenantID}
+	if err := db.Create(grade).Error; err != nil {
+		t.Fatalf("Failed to create test grade: %v", err)
+	}
+
+	return grade
+}
+
+// makeGuardianAuthenticatedRequest creates an HTTP request with the account in context.
+func makeGuardianAuthenticatedRequest(t *testing.T, method, path string, body []byte, account *edutrack.Account) *http.Request {
+	var req *http.Request
+	if body != nil {
+		req = httptest.NewRequest(method, path, bytes.NewReader(body))
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	ctx := edutrack.NewContextWithAccount(req.Context(), account)
+	return req.WithContext(ctx)
+}
+
+func TestHandleCreateGuardian_Success(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	secretary := createGuardianTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	student := createGuardianTestStudent(t, db, tenant.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateGuardianRequest{
+		Name:         "María López",
+		Email:        "maria@test.com",
+		Password:     "password123",
+		Relationship: "Madre",
+		StudentIDs:   []uint{student.ID},
+	})
+
+	req := makeGuardianAuthenticatedRequest(t, http.MethodPost, "/guardians", body, secretary)
+	w := httptest.NewRecorder()
+
+	server.handleCreateGuardian(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("handleCreateGuardian() status = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	var guardian edutrack.Account
+	if err := db.Where("email = ?", "maria@test.com").First(&guardian).Error; err != nil {
+		t.Fatalf("Guardian account not created: %v", err)
+	}
+
+	if !guardian.IsGuardian() {
+		t.Errorf("handleCreateGuardian() role = %q, want %q", guardian.Role, edutrack.RoleGuardian)
+	}
+
+	if linked, err := edutrack.IsGuardianOf(req.Context(), db, guardian.ID, student.ID); err != nil || !linked {
+		t.Errorf("handleCreateGuardian() did not link the student: %v", err)
+	}
+}
+
+func TestHandleCreateGuardian_RepeatedStudent(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	secretary := createGuardianTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	student := createGuardianTestStudent(t, db, tenant.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateGuardianRequest{
+		Name:       "María López",
+		Email:      "maria@test.com",
+		Password:   "password123",
+		StudentIDs: []uint{student.ID, student.ID},
+	})
+
+	req := makeGuardianAuthenticatedRequest(t, http.MethodPost, "/guardians", body, secretary)
+	w := httptest.NewRecorder()
+
+	server.handleCreateGuardian(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("handleCreateGuardian() status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	var links int64
+	db.Model(&edutrack.GuardianLink{}).Where("student_id = ?", student.ID).Count(&links)
+	if links != 1 {
+		t.Errorf("handleCreateGuardian() created %d links, want 1", links)
+	}
+}
+
+func TestHandleCreateGuardian_ForbiddenForTeacher(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	teacher := createGuardianTestAccount(t, db, tenant.ID, "teacher@test.com", edutrack.RoleTeacher)
+	student := createGuardianTestStudent(t, db, tenant.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateGuardianRequest{
+		Name:       "María López",
+		Email:      "maria@test.com",
+		Password:   "password123",
+		StudentIDs: []uint{student.ID},
+	})
+
+	req := makeGuardianAuthenticatedRequest(t, http.MethodPost, "/guardians", body, teacher)
+	w := httptest.NewRecorder()
+
+	server.handleCreateGuardian(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("handleCreateGuardian() status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleCreateGuardian_StudentFromOtherTenant(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant1 := createGuardianTestTenant(t, db)
+	tenant2 := createGuardianTestTenant(t, db)
+	secretary := createGuardianTestAccount(t, db, tenant1.ID, "admin@test.com", edutrack.RoleSecretary)
+	otherStudent := createGuardianTestStudent(t, db, tenant2.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateGuardianRequest{
+		Name:       "María López",
+		Email:      "maria@test.com",
+		Password:   "password123",
+		StudentIDs: []uint{otherStudent.ID},
+	})
+
+	req := makeGuardianAuthenticatedRequest(t, http.MethodPost, "/guardians", body, secretary)
+	w := httptest.NewRecorder()
+
+	server.handleCreateGuardian(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleCreateGuardian() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleLinkGuardianStudent_Conflict(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	secretary := createGuardianTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	guardian := createGuardianTestAccount(t, db, tenant.ID, "guardian@test.com", edutrack.RoleGuardian)
+	student := createGuardianTestStudent(t, db, tenant.ID)
+	linkGuardianTestStudent(t, db, tenant.ID, guardian.ID, student.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(LinkGuardianStudentRequest{StudentID: student.ID})
+	req := makeGuardianAuthenticatedRequest(t, http.MethodPost, fmt.Sprintf("/guardians/%d/students", guardian.ID), body, secretary)
+	req.SetPathValue("id", fmt.Sprintf("%d", guardian.ID))
+	w := httptest.NewRecorder()
+
+	server.handleLinkGuardianStudent(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("handleLinkGuardianStudent() status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestHandleUnlinkGuardianStudent_Success(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	secretary := createGuardianTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	guardian := createGuardianTestAccount(t, db, tenant.ID, "guardian@test.com", edutrack.RoleGuardian)
+	student := createGuardianTestStudent(t, db, tenant.ID)
+	linkGuardianTestStudent(t, db, tenant.ID, guardian.ID, student.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeGuardianAuthenticatedRequest(t, http.MethodDelete, "/guardians/x/students/y", nil, secretary)
+	req.SetPathValue("id", fmt.Sprintf("%d", guardian.ID))
+	req.SetPathValue("student_id", fmt.Sprintf("%d", student.ID))
+	w := httptest.NewRecorder()
+
+	server.handleUnlinkGuardianStudent(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("handleUnlinkGuardianStudent() status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	if linked, err := edutrack.IsGuardianOf(req.Context(), db, guardian.ID, student.ID); err != nil || linked {
+		t.Errorf("handleUnlinkGuardianStudent() did not remove the link: %v", err)
+	}
+}
+
+func TestHandleListStudents_GuardianSeesLinkedOnly(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	guardian := createGuardianTestAccount(t, db, tenant.ID, "guardian@test.com", edutrack.RoleGuardian)
+	linked := createGuardianTestStudent(t, db, tenant.ID)
+	createGuardianTestStudent(t, db, tenant.ID)
+	linkGuardianTestStudent(t, db, tenant.ID, guardian.ID, linked.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeGuardianAuthenticatedRequest(t, http.MethodGet, "/students", nil, guardian)
+	w := httptest.NewRecorder()
+
+	server.handleListStudents(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleListStudents() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var students []StudentResponse
+	if err := json.NewDecoder(w.Body).Decode(&students); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(students) != 1 || students[0].ID != linked.ID {
+		t.Errorf("handleListStudents() returned %d students, want only the linked one", len(students))
+	}
+}
+
+func TestHandleGetStudent_GuardianForbiddenForUnlinked(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	guardian := createGuardianTestAccount(t, db, tenant.ID, "guardian@test.com", edutrack.RoleGuardian)
+	other := createGuardianTestStudent(t, db, tenant.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeGuardianAuthenticatedRequest(t, http.MethodGet, fmt.Sprintf("/students/%d", other.ID), nil, guardian)
+	req.SetPathValue("id", fmt.Sprintf("%d", other.ID))
+	w := httptest.NewRecorder()
+
+	server.handleGetStudent(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("handleGetStudent() status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleListGrades_GuardianSeesLinkedOnly(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	guardian := createGuardianTestAccount(t, db, tenant.ID, "guardian@test.com", edutrack.RoleGuardian)
+	linked := createGuardianTestStudent(t, db, tenant.ID)
+	other := createGuardianTestStudent(t, db, tenant.ID)
+	linkGuardianTestStudent(t, db, tenant.ID, guardian.ID, linked.ID)
+
+	linkedGrade := createGuardianTestGrade(t, db, tenant.ID, linked.ID)
+	createGuardianTestGrade(t, db, tenant.ID, other.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	// Filtering by an unlinked student must not leak their grades.
+	req := makeGuardianAuthenticatedRequest(t, http.MethodGet, fmt.Sprintf("/grades?student_id=%d", other.ID), nil, guardian)
+	w := httptest.NewRecorder()
+	server.handleListGrades(w, req)
+
+	var grades []GradeResponse
+	if err := json.NewDecoder(w.Body).Decode(&grades); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(grades) != 0 {
+		t.Errorf("handleListGrades() returned %d grades for unlinked student, want 0", len(grades))
+	}
+
+	req = makeGuardianAuthenticatedRequest(t, http.MethodGet, "/grades", nil, guardian)
+	w = httptest.NewRecorder()
+	server.handleListGrades(w, req)
+
+	if err := json.NewDecoder(w.Body).Decode(&grades); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(grades) != 1 || grades[0].ID != linkedGrade.ID {
+		t.Errorf("handleListGrades() returned %d grades, want only the linked student's grade", len(grades))
+	}
+}
+
+func TestHandleGetGrade_GuardianForbiddenForUnlinked(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	guardian := createGuardianTestAccount(t, db, tenant.ID, "guardian@test.com", edutrack.RoleGuardian)
+	other := createGuardianTestStudent(t, db, tenant.ID)
+	grade := createGuardianTestGrade(t, db, tenant.ID, other.ID)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeGuardianAuthenticatedRequest(t, http.MethodGet, fmt.Sprintf("/grades/%d", grade.ID), nil, guardian)
+	req.SetPathValue("id", fmt.Sprintf("%d", grade.ID))
+	w := httptest.NewRecorder()
+
+	server.handleGetGrade(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("handleGetGrade() status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleListAttendances_GuardianSeesLinkedOnly(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	guardian := createGuardianTestAccount(t, db, tenant.ID, "guardian@test.com", edutrack.RoleGuardian)
+	linked := createGuardianTestStudent(t, db, tenant.ID)
+	other := createGuardianTestStudent(t, db, tenant.ID)
+	linkGuardianTestStudent(t, db, tenant.ID, guardian.ID, linked.ID)
+
+	for _, studentID := range []uint{linked.ID, other.ID} {
+		attendance := &edutrack.Attendance{
+			Date:      time.Now(),
+			Status:    edutrack.AttendancePresent,
+			StudentID: studentID,
+			TenantID:  tenant.ID,
+		}
+		if err := db.Create(attendance).Error; err != nil {
+			t.Fatalf("Failed to create test attendance: %v", err)
+		}
+	}
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeGuardianAuthenticatedRequest(t, http.MethodGet, "/attendances", nil, guardian)
+	w := httptest.NewRecorder()
+
+	server.handleListAttendances(w, req)
+
+	var attendances []AttendanceResponse
+	if err := json.NewDecoder(w.Body).Decode(&attendances); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(attendances) != 1 || attendances[0].StudentID != linked.ID {
+		t.Errorf("handleListAttendances() returned %d records, want only the linked student's", len(attendances))
+	}
+}
+
+func TestWithoutGuardian(t *testing.T) {
+	db := setupGuardianTestDB(t)
+	tenant := createGuardianTestTenant(t, db)
+	guardian := createGuardianTestAccount(t, db, tenant.ID, "guardian@test.com", edutrack.RoleGuardian)
+	teacher := createGuardianTestAccount(t, db, tenant.ID, "teacher@test.com", edutrack.RoleTeacher)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	tests := []struct {
+		name    string
+		account *edutrack.Account
+		method  string
+		path    string
+		want    int
+	}{
+		{"guardian cannot create grades", guardian, http.MethodPost, "/grades", http.StatusForbidden},
+		{"guardian cannot list accounts", guardian, http.MethodGet, "/accounts", http.StatusForbidden},
+		{"guardian cannot list subjects", guardian, http.MethodGet, "/subjects", http.StatusForbidden},
+		{"guardian can list grades", guardian, http.MethodGet, "/grades", http.StatusOK},
+		{"teacher can list subjects", teacher, http.MethodGet, "/subjects", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _ := server.generateToken(tt.account)
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewReader([]byte("{}")))
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+
+			server.router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsGuardianOf_OtherTenant(t *testing.T) {
+	_, db, tenantA, tenantB := setupIsolationTest(t)
+
+	ctx := edutrack.NewContextWithAccount(context.Background(), tenantA.Secretary)
+	if linked, err := edutrack.IsGuardianOf(ctx, db, tenantB.Guardian.ID, tenantB.Student.ID); err != nil || linked {
+		t.Errorf("IsGuardianOf() of another tenant = %v, %v, want false", linked, err)
+	}
+
+	ctx = edutrack.NewContextWithAccount(context.Background(), tenantB.Secretary)
+	if linked, err := edutrack.IsGuardianOf(ctx, db, tenantB.Guardian.ID, tenantB.Student.ID); err != nil || !linked {
+		t.Errorf("IsGuardianOf() = %v, %v, want true", linked, err)
+	}
+}
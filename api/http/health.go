@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// Health statuses reported by the health endpoints.
+const (
+	HealthOK           = "ok"
+	HealthFailing      = "failing"
+	HealthShuttingDown = "shutting_down"
+)
+
+// readinessTimeout bounds the time spent checking the dependencies.
+const readinessTimeout = 2 * time.Second
+
+// HealthCheck represents the result of checking a dependency.
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthResponse represents the response body of the health endpoints.
+type HealthResponse struct {
+	Status  string                 `json:"status"`
+	Version string                 `json:"version"`
+	Checks  map[string]HealthCheck `json:"checks,omitempty"`
+
+	// Tables and columns missing from the database.
+	PendingMigrations []string `json:"pending_migrations,omitempty"`
+}
+
+// handleHealthz handles GET /healthz.
+// It reports the process is alive without checking any dependency, so it
+// keeps succeeding while the server drains connections.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, http.StatusOK, HealthResponse{
+		Status:  HealthOK,
+		Version: edutrack.Version,
+	})
+}
+
+// handleReadyz handles GET /readyz.
+// It reports whether the server can take traffic: the database answers, the
+// schema has no pending migrations and the server is not shutting down.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{
+		Status:  HealthOK,
+		Version: edutrack.Version,
+		Checks:  map[string]HealthCheck{},
+	}
+
+	if s.shuttingDown.Load() {
+		response.Status = HealthShuttingDown
+		sendJSON(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	// The endpoint is unauthenticated: errors are logged and the response
+	// only tells the check failed, never the driver, host or DSN.
+	database := HealthCheck{Status: HealthOK}
+	if err := s.pingDB(ctx); err != nil {
+		s.Logger.Error("readiness: database check failed", "request_id", edutrack.RequestIDFromContext(r.Context()), "error", err)
+		database = HealthCheck{Status: HealthFailing, Error: "unavailable"}
+	}
+	response.Checks["database"] = database
+
+	migrations := HealthCheck{Status: HealthOK}
+	if database.Status != HealthOK {
+		migrations = HealthCheck{Status: HealthFailing, Error: "database unavailable"}
+	} else if pending, err := edutrack.PendingMigrations(s.DB.WithContext(ctx)); err != nil {
+		s.Logger.Error("readiness: migrations check failed", "request_id", edutrack.RequestIDFromContext(r.Context()), "error", err)
+		migrations = HealthCheck{Status: HealthFailing, Error: "unavailable"}
+	} else if len(pending) > 0 {
+		migrations = HealthCheck{Status: HealthFailing, Error: "pending migrations"}
+		response.PendingMigrations = pending
+	}
+	response.Checks["migrations"] = migrations
+
+	status := http.StatusOK
+	for _, check := range response.Checks {
+		if check.Status != HealthOK {
+			response.Status = HealthFailing
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	sendJSON(w, status, response)
+}
+
+// pingDB checks the database connection answers.
+func (s *Server) pingDB(ctx context.Context) error {
+	db, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
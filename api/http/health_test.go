@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// setupHealthTestDB creates an in-memory SQLite database for testing.
+func setupHealthTestDB(t *testing.T, migrate bool) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if migrate {
+		if err := edutrack.Migrate(db); err != nil {
+			t.Fatalf("Failed to run migrations: %v", err)
+		}
+	}
+
+	return db
+}
+
+// getHealth performs a request to a health endpoint and decodes the response.
+func getHealth(t *testing.T, server *Server, path string) (int, HealthResponse) {
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var response HealthResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	return w.Code, response
+}
+
+func TestHandleHealthz(t *testing.T) {
+	server := NewServer(":8080", setupHealthTestDB(t, true), []byte("test-secret"))
+
+	status, response := getHealth(t, server, "/healthz")
+
+	if status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, status)
+	}
+	if response.Status != HealthOK || response.Version != edutrack.Version {
+		t.Errorf("Unexpected response %+v", response)
+	}
+}
+
+func TestHandleReadyz_Ready(t *testing.T) {
+	server := NewServer(":8080", setupHealthTestDB(t, true), []byte("test-secret"))
+	spec := loadOpenAPITestSpec(t, server)
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	validateOpenAPIResponse(t, spec, "GET /readyz", w)
+
+	var response HealthResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	for _, name := range []string{"database", "migrations"} {
+		if response.Checks[name].Status != HealthOK {
+			t.Errorf("Expected check %q to be ok, got %+v", name, response.Checks[name])
+		}
+	}
+}
+
+func TestHandleReadyz_PendingMigrations(t *testing.T) {
+	db := setupHealthTestDB(t, true)
+	if err := db.Migrator().DropColumn(&edutrack.Grade{}, "notes"); err != nil {
+		t.Fatalf("Failed to drop column: %v", err)
+	}
+	if err := db.Migrator().DropTable(&edutrack.APIKey{}); err != nil {
+		t.Fatalf("Failed to drop table: %v", err)
+	}
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	status, response := getHealth(t, server, "/readyz")
+
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, status)
+	}
+	if response.Checks["migrations"].Status != HealthFailing {
+		t.Errorf("Expected migrations check to fail, got %+v", response.Checks["migrations"])
+	}
+	for _, want := range []string{"grades.notes", "api_keys"} {
+		if !slices.Contains(response.PendingMigrations, want) {
+			t.Errorf("Expected pending migration %q, got %v", want, response.PendingMigrations)
+		}
+	}
+}
+
+func TestHandleReadyz_DatabaseDown(t *testing.T) {
+	db := setupHealthTestDB(t, true)
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	status, response := getHealth(t, server, "/readyz")
+
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, status)
+	}
+	if response.Checks["database"].Status != HealthFailing || response.Checks["database"].Error != "unavailable" {
+		t.Errorf("Expected database check to fail without details, got %+v", response.Checks["database"])
+	}
+}
+
+func TestServerShutdown_FailsReadiness(t *testing.T) {
+	server := NewServer(":8080", setupHealthTestDB(t, true), []byte("test-secret"))
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	status, response := getHealth(t, server, "/readyz")
+	if status != http.StatusServiceUnavailable || response.Status != HealthShuttingDown {
+		t.Errorf("Expected readiness to fail while shutting down, got %d %+v", status, response)
+	}
+
+	// Liveness keeps succeeding while connections drain.
+	if status, _ := getHealth(t, server, "/healthz"); status != http.StatusOK {
+		t.Errorf("Expected liveness status %d, got %d", http.StatusOK, status)
+	}
+}
+
+func TestServerShutdown_Delay(t *testing.T) {
+	server := NewServer(":8080", setupHealthTestDB(t, true), []byte("test-secret"))
+	server.ShutdownDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	server.Shutdown(ctx)
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the delay to stop at the context deadline, took %s", elapsed)
+	}
+	if status, _ := getHealth(t, server, "/readyz"); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, status)
+	}
+}
@@ -0,0 +1,289 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// deletePreview decodes the delete preview of a response.
+func deletePreview(t *testing.T, w *httptest.ResponseRecorder) edutrack.DeletePreview {
+	t.Helper()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Preview status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var preview edutrack.DeletePreview
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("Failed to decode delete preview: %v", err)
+	}
+	return preview
+}
+
+// assertEffects checks the effects of a delete preview, in any order.
+func assertEffects(t *testing.T, preview edutrack.DeletePreview, want ...edutrack.DeleteEffect) {
+	t.Helper()
+
+	if len(preview.Effects) != len(want) {
+		t.Errorf("Effects = %+v, want %+v", preview.Effects, want)
+		return
+	}
+	for _, effect := range want {
+		if !slices.Contains(preview.Effects, effect) {
+			t.Errorf("Effects = %+v, want %+v among them", preview.Effects, effect)
+		}
+	}
+}
+
+func TestDelete_Restrict(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+	path := fmt.Sprintf("/careers/%d", tenant.Career.ID)
+
+	preview := deletePreview(t, isolationRequest(t, server, tenant, http.MethodDelete, path+"?preview=true", nil))
+	if preview.Allowed {
+		t.Error("Preview of a career with subjects and students should not be allowed")
+	}
+	assertEffects(t, preview,
+		edutrack.DeleteEffect{Type: "subjects", Field: "career_id", Rule: edutrack.DeleteRestrict, Count: 1},
+		edutrack.DeleteEffect{Type: "students", Field: "career_id", Rule: edutrack.DeleteRestrict, Count: 1},
+	)
+
+	w := isolationRequest(t, server, tenant, http.MethodDelete, path, nil)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("DELETE career status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+	}
+	if isTrashed(t, db, &edutrack.Career{}, tenant.Career.ID) {
+		t.Error("Restricted career should not be deleted")
+	}
+}
+
+func TestDelete_Cascade(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+	path := fmt.Sprintf("/subjects/%d", tenant.Subject.ID)
+
+	// Previews do not delete anything.
+	preview := deletePreview(t, isolationRequest(t, server, tenant, http.MethodDelete, path+"?preview=true", nil))
+	if !preview.Allowed {
+		t.Error("Preview of a subject should be allowed")
+	}
+	assertEffects(t, preview,
+		edutrack.DeleteEffect{Type: "topics", Field: "subject_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "grades", Field: "topic_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "grade_appeals", Field: "grade_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "attendances", Field: "subject_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "exam_attempts", Field: "subject_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "sections", Field: "subject_id", Rule: edutrack.DeleteCascade, Count: 1},
+	)
+	if isTrashed(t, db, &edutrack.Subject{}, tenant.Subject.ID) {
+		t.Fatal("Preview should not delete the subject")
+	}
+
+	w := isolationRequest(t, server, tenant, http.MethodDelete, path, nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE subject status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+	for _, deleted := range []struct {
+		model any
+		id    uint
+	}{
+		{&edutrack.Subject{}, tenant.Subject.ID},
+		{&edutrack.Topic{}, tenant.Topic.ID},
+		{&edutrack.Grade{}, tenant.Grade.ID},
+		{&edutrack.GradeAppeal{}, tenant.Appeal.ID},
+		{&edutrack.Attendance{}, tenant.Attendance.ID},
+		{&edutrack.ExamAttempt{}, tenant.Exam.ID},
+		{&edutrack.Section{}, tenant.Section.ID},
+	} {
+		if !isTrashed(t, db, deleted.model, deleted.id) {
+			t.Errorf("%T %d should be deleted with the subject", deleted.model, deleted.id)
+		}
+	}
+	if isTrashed(t, db, &edutrack.Student{}, tenant.Student.ID) {
+		t.Error("Enrolled student should not be deleted with the subject")
+	}
+
+	// The records deleted in cascade are restored with the subject.
+	w = isolationRequest(t, server, tenant, http.MethodPost, fmt.Sprintf("/trash/subjects/%d/restore", tenant.Subject.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Restore status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if items := trashItems(t, w.Body.String()); len(items) != 7 {
+		t.Errorf("Restored records = %+v, want the subject, section, topic, grade, appeal, attendance and exam", items)
+	}
+
+	// Records deleted in cascade keep their references to each other.
+	var grade edutrack.Grade
+	db.First(&grade, tenant.Grade.ID)
+	if grade.SectionID == nil || *grade.SectionID != tenant.Section.ID {
+		t.Errorf("Restored grade SectionID = %v, want %d", grade.SectionID, tenant.Section.ID)
+	}
+}
+
+func TestDelete_CascadeAccount(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+	path := fmt.Sprintf("/accounts/%d", tenant.Student.AccountID)
+
+	preview := deletePreview(t, isolationRequest(t, server, tenant, http.MethodDelete, path+"?preview=true", nil))
+	assertEffects(t, preview,
+		edutrack.DeleteEffect{Type: "students", Field: "account_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "grades", Field: "student_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "attendances", Field: "student_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "justifications", Field: "student_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "grade_appeals", Field: "student_id", Rule: edutrack.DeleteCascade, Count: 1},
+		edutrack.DeleteEffect{Type: "exam_attempts", Field: "student_id", Rule: edutrack.DeleteCascade, Count: 1},
+	)
+
+	w := isolationRequest(t, server, tenant, http.MethodDelete, path, nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE account status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+	if !isTrashed(t, db, &edutrack.Student{}, tenant.Student.ID) {
+		t.Error("Student should be deleted with their account")
+	}
+}
+
+func TestDelete_Nullify(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+	path := fmt.Sprintf("/teachers/%d", tenant.Teacher.ID)
+
+	preview := deletePreview(t, isolationRequest(t, server, tenant, http.MethodDelete, path+"?preview=true", nil))
+	assertEffects(t, preview,
+		edutrack.DeleteEffect{Type: "subjects", Field: "teacher_id", Rule: edutrack.DeleteNullify, Count: 1},
+		edutrack.DeleteEffect{Type: "sections", Field: "teacher_id", Rule: edutrack.DeleteNullify, Count: 1},
+	)
+
+	w := isolationRequest(t, server, tenant, http.MethodDelete, path, nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE teacher status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+	var subject edutrack.Subject
+	if err := db.First(&subject, tenant.Subject.ID).Error; err != nil {
+		t.Fatalf("Subject of the deleted teacher should be kept: %v", err)
+	}
+	if subject.TeacherID != nil {
+		t.Errorf("Subject TeacherID = %d, want nil", *subject.TeacherID)
+	}
+}
+
+func TestPurge_Nullify(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+
+	// The subject was deleted before the teacher, so it still references it.
+	if err := db.Delete(tenant.Subject).Error; err != nil {
+		t.Fatalf("Failed to delete subject: %v", err)
+	}
+	if err := db.Delete(tenant.Teacher).Error; err != nil {
+		t.Fatalf("Failed to delete teacher: %v", err)
+	}
+
+	w := isolationRequest(t, server, tenant, http.MethodDelete, fmt.Sprintf("/trash/teachers/%d", tenant.Teacher.ID), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Purge status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+	var subject edutrack.Subject
+	db.Unscoped().First(&subject, tenant.Subject.ID)
+	if subject.ID == 0 || subject.TeacherID != nil {
+		t.Errorf("Deleted subject = %+v, want it kept without teacher", subject)
+	}
+}
+
+func TestDelete_ForeignKeys(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=1"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	tenant := seedIsolationTenant(t, db, "TENANT-A-SECRET")
+
+	// The database applies the same rules to permanent deletions.
+	err = db.Unscoped().Delete(tenant.Career).Error
+	if edutrack.ErrorCode(edutrack.TranslateDBError(err)) != edutrack.ECONFLICT {
+		t.Errorf("Delete of a referenced career error = %v, want a conflict", err)
+	}
+	if err := db.Unscoped().Delete(tenant.Teacher).Error; err != nil {
+		t.Fatalf("Failed to delete teacher: %v", err)
+	}
+	if err := db.Unscoped().Delete(tenant.Subject).Error; err != nil {
+		t.Fatalf("Failed to delete subject: %v", err)
+	}
+
+	for _, purged := range []struct {
+		model any
+		id    uint
+	}{
+		{&edutrack.Topic{}, tenant.Topic.ID},
+		{&edutrack.Grade{}, tenant.Grade.ID},
+		{&edutrack.Attendance{}, tenant.Attendance.ID},
+	} {
+		if !isPurged(db, purged.model, purged.id) {
+			t.Errorf("%T %d should be deleted by the database", purged.model, purged.id)
+		}
+	}
+	var enrollments int64
+	db.Table("student_subjects").Where("subject_id = ?", tenant.Subject.ID).Count(&enrollments)
+	if enrollments != 0 {
+		t.Errorf("Deleted subject kept %d enrollments, want none", enrollments)
+	}
+}
+
+func TestMigrate_DeleteRules(t *testing.T) {
+	db := setupIsolationTestDB(t)
+	tenant := seedIsolationTenant(t, db, "TENANT-A-SECRET")
+
+	// Recreate the topics table with the foreign key of previous versions,
+	// without the delete rule.
+	var ddl string
+	db.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'topics'").Scan(&ddl)
+	for _, stmt := range []string{
+		"PRAGMA legacy_alter_table = ON",
+		"ALTER TABLE topics RENAME TO topics_old",
+		strings.Replace(ddl, " ON DELETE CASCADE", "", 1),
+		"INSERT INTO topics SELECT * FROM topics_old",
+		"DROP TABLE topics_old",
+		"PRAGMA legacy_alter_table = OFF",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Failed to create legacy table: %v", err)
+		}
+	}
+
+	pending, err := edutrack.PendingMigrations(db)
+	if err != nil {
+		t.Fatalf("PendingMigrations() error = %v", err)
+	}
+	if !slices.Contains(pending, "fk_subjects_topics") {
+		t.Errorf("PendingMigrations() = %v, want the changed foreign key", pending)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	var actions []string
+	db.Raw(`SELECT on_delete FROM pragma_foreign_key_list('topics') WHERE "from" = 'subject_id'`).Scan(&actions)
+	if !slices.Equal(actions, []string{"CASCADE"}) {
+		t.Errorf("ON DELETE of topics.subject_id = %v, want CASCADE", actions)
+	}
+	if !db.Migrator().HasIndex(&edutrack.Topic{}, "idx_topics_subject_id") {
+		t.Error("Migrate() should keep the indexes of the recreated table")
+	}
+	if isPurged(db, &edutrack.Topic{}, tenant.Topic.ID) {
+		t.Error("Migrate() should keep the rows of the recreated table")
+	}
+	if pending, _ := edutrack.PendingMigrations(db); len(pending) != 0 {
+		t.Errorf("PendingMigrations() after Migrate() = %v, want none", pending)
+	}
+}
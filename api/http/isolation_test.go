@@ -0,0 +1,667 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// isolationTables are the tenant-scoped tables checked by the isolation
+// tests.
+var isolationTables = []string{
+	"accounts", "students", "guardian_links", "teachers", "careers", "subjects", "sections", "enrollments", "topics",
+	"attendances", "justifications", "grades", "grade_amendments",
+	"grade_appeals", "grade_appeal_messages", "exam_attempts", "webhooks", "webhook_deliveries", "api_keys", "inbox_messages",
+}
+
+// isolationTenant holds the records seeded for a tenant.
+type isolationTenant struct {
+	Tenant        *edutrack.Tenant
+	Marker        string
+	Secretary     *edutrack.Account
+	Student       *edutrack.Student
+	Guardian      *edutrack.Account
+	Teacher       *edutrack.Teacher
+	Career        *edutrack.Career
+	Subject       *edutrack.Subject
+	Section       *edutrack.Section
+	Topic         *edutrack.Topic
+	Attendance    *edutrack.Attendance
+	Justification *edutrack.Justification
+	Grade         *edutrack.Grade
+	Amendment     *edutrack.GradeAmendment
+	Appeal        *edutrack.GradeAppeal
+	Exam          *edutrack.ExamAttempt
+	Webhook       *edutrack.Webhook
+	Delivery      *edutrack.WebhookDelivery
+	APIKey        *edutrack.APIKey
+	Message       *edutrack.InboxMessage
+}
+
+// setupIsolationTestDB creates an in-memory SQLite database for testing.
+func setupIsolationTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// seedIsolationTenant creates a tenant with a record of every kind. The
+// names of the records contain the marker of the tenant.
+func seedIsolationTenant(t *testing.T, db *gorm.DB, marker string) *isolationTenant {
+	tenant, err := edutrack.NewTenant(marker, edutrack.LicenseTypeTrial, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create test tenant: %v", err)
+	}
+	mustCreate := func(value any) {
+		t.Helper()
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("Failed to seed %T: %v", value, err)
+		}
+	}
+	mustCreate(tenant)
+
+	hashedPassword, err := edutrack.HashPassword("password123")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	account := func(role edutrack.Role) *edutrack.Account {
+		account := &edutrack.Account{
+			Name:     marker + " " + string(role),
+			Email:    fmt.Sprintf("%s@%s.example.com", role, tenant.ID),
+			Password: hashedPassword,
+			Role:     role,
+			Active:   true,
+			TenantID: tenant.ID,
+		}
+		mustCreate(account)
+		return account
+	}
+
+	seed := &isolationTenant{Tenant: tenant, Marker: marker}
+	seed.Secretary = account(edutrack.RoleSecretary)
+	seed.Guardian = account(edutrack.RoleGuardian)
+
+	seed.Career = &edutrack.Career{Name: marker + " carrera", Code: "ISC", Duration: 8, Active: true, TenantID: tenant.ID}
+	mustCreate(seed.Career)
+
+	seed.Student = &edutrack.Student{
+		StudentID: "20260001",
+		Semester:  1,
+		AccountID: account(edutrack.RoleStudent).ID,
+		CareerID:  seed.Career.ID,
+		TenantID:  tenant.ID,
+	}
+	mustCreate(seed.Student)
+	mustCreate(&edutrack.GuardianLink{Relationship: marker, AccountID: seed.Guardian.ID, StudentID: seed.Student.ID, TenantID: tenant.ID})
+
+	seed.Teacher = &edutrack.Teacher{AccountID: account(edutrack.RoleTeacher).ID, TenantID: tenant.ID}
+	mustCreate(seed.Teacher)
+
+	seed.Subject = &edutrack.Subject{
+		Name:      marker + " materia",
+		Code:      "MAT-1",
+		Semester:  1,
+		CareerID:  seed.Career.ID,
+		TeacherID: &seed.Teacher.ID,
+		TenantID:  tenant.ID,
+	}
+	mustCreate(seed.Subject)
+	if err := db.Model(seed.Subject).Association("Students").Append(seed.Student); err != nil {
+		t.Fatalf("Failed to enroll student: %v", err)
+	}
+
+	seed.Section = &edutrack.Section{
+		Name:      "A",
+		Capacity:  30,
+		Schedule:  marker,
+		SubjectID: seed.Subject.ID,
+		TeacherID: &seed.Teacher.ID,
+		TenantID:  tenant.ID,
+	}
+	mustCreate(seed.Section)
+	mustCreate(&edutrack.Enrollment{Status: edutrack.EnrollmentEnrolled, SectionID: seed.Section.ID, StudentID: seed.Student.ID, TenantID: tenant.ID})
+
+	seed.Topic = &edutrack.Topic{Name: marker + " tema", SubjectID: seed.Subject.ID, TenantID: tenant.ID}
+	mustCreate(seed.Topic)
+
+	seed.Attendance = &edutrack.Attendance{
+		Date:      time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Status:    edutrack.AttendanceAbsent,
+		Notes:     marker,
+		StudentID: seed.Student.ID,
+		SubjectID: seed.Subject.ID,
+		SectionID: &seed.Section.ID,
+		TenantID:  tenant.ID,
+	}
+	mustCreate(seed.Attendance)
+
+	seed.Justification = &edutrack.Justification{
+		StartDate:   seed.Attendance.Date,
+		EndDate:     seed.Attendance.Date,
+		Reason:      marker,
+		DocumentKey: "tenants/" + tenant.ID + "/justification/document.pdf",
+		Status:      edutrack.JustificationPending,
+		StudentID:   seed.Student.ID,
+		TenantID:    tenant.ID,
+	}
+	mustCreate(seed.Justification)
+
+	seed.Grade = &edutrack.Grade{Value: 9, Notes: marker, StudentID: seed.Student.ID, TopicID: seed.Topic.ID, SectionID: &seed.Section.ID, TenantID: tenant.ID}
+	mustCreate(seed.Grade)
+
+	previous := 8.0
+	seed.Amendment = &edutrack.GradeAmendment{
+		Reason:        marker,
+		PreviousValue: &previous,
+		Value:         seed.Grade.Value,
+		GradeID:       seed.Grade.ID,
+		AccountID:     &seed.Secretary.ID,
+		TenantID:      tenant.ID,
+	}
+	mustCreate(seed.Amendment)
+
+	seed.Appeal = &edutrack.GradeAppeal{
+		Reason:        marker,
+		OriginalValue: seed.Grade.Value,
+		GradeID:       seed.Grade.ID,
+		StudentID:     seed.Student.ID,
+		TenantID:      tenant.ID,
+	}
+	mustCreate(seed.Appeal)
+	mustCreate(&edutrack.GradeAppealMessage{
+		Body:      marker,
+		Status:    edutrack.AppealOpen,
+		AppealID:  seed.Appeal.ID,
+		AccountID: &seed.Student.AccountID,
+		TenantID:  tenant.ID,
+	})
+
+	seed.Exam = &edutrack.ExamAttempt{
+		Kind:      edutrack.ExamOrdinary,
+		Date:      time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC),
+		Notes:     marker,
+		StudentID: seed.Student.ID,
+		SubjectID: seed.Subject.ID,
+		TenantID:  tenant.ID,
+	}
+	mustCreate(seed.Exam)
+
+	seed.Webhook = &edutrack.Webhook{URL: "https://example.com/hook", Secret: "secret", Active: true, Description: marker, TenantID: tenant.ID}
+	mustCreate(seed.Webhook)
+
+	seed.Delivery = &edutrack.WebhookDelivery{
+		Event:     edutrack.WebhookStudentCreated,
+		Payload:   `{"marker":"` + marker + `"}`,
+		Status:    edutrack.WebhookDeliveryFailed,
+		WebhookID: seed.Webhook.ID,
+		TenantID:  tenant.ID,
+	}
+	mustCreate(seed.Delivery)
+
+	seed.APIKey = &edutrack.APIKey{Name: marker, Prefix: "et_" + tenant.ID[:8], Hash: "hash", AccountID: seed.Secretary.ID, TenantID: tenant.ID}
+	mustCreate(seed.APIKey)
+
+	seed.Message = &edutrack.InboxMessage{Subject: marker, Body: marker, AccountID: seed.Secretary.ID, TenantID: tenant.ID}
+	mustCreate(seed.Message)
+
+	return seed
+}
+
+// pathIDs returns the values of the path parameters of a route for the
+// records of a tenant.
+func (seed *isolationTenant) pathIDs(pattern string) map[string]uint {
+	resource := strings.Split(strings.TrimPrefix(strings.Fields(pattern)[1], "/"), "/")[0]
+	ids := map[string]uint{
+		"accounts":       seed.Secretary.ID,
+		"students":       seed.Student.ID,
+		"guardians":      seed.Guardian.ID,
+		"teachers":       seed.Teacher.ID,
+		"careers":        seed.Career.ID,
+		"subjects":       seed.Subject.ID,
+		"sections":       seed.Section.ID,
+		"topics":         seed.Topic.ID,
+		"attendances":    seed.Attendance.ID,
+		"justifications": seed.Justification.ID,
+		"notifications":  seed.Message.ID,
+		"webhooks":       seed.Webhook.ID,
+		"api-keys":       seed.APIKey.ID,
+		"grades":         seed.Grade.ID,
+		"appeals":        seed.Appeal.ID,
+		"exams":          seed.Exam.ID,
+		"trash":          seed.Grade.ID,
+	}
+	return map[string]uint{
+		"id":          ids[resource],
+		"student_id":  seed.Student.ID,
+		"delivery_id": seed.Delivery.ID,
+	}
+}
+
+// foreignBody returns a request body for a route referencing the records
+// of a tenant.
+func (seed *isolationTenant) foreignBody(pattern string) map[string]any {
+	switch pattern {
+	case "POST /students":
+		return map[string]any{"student_id": "20269999", "name": "Nuevo", "email": "nuevo@example.com", "password": "password123", "career_id": seed.Career.ID, "semester": 1}
+	case "PUT /students/{id}", "PATCH /students/{id}":
+		return map[string]any{"career_id": seed.Career.ID}
+	case "POST /guardians":
+		return map[string]any{"name": "Tutor", "email": "tutor@example.com", "password": "password123", "relationship": "madre", "student_ids": []uint{seed.Student.ID}}
+	case "POST /teachers":
+		return map[string]any{"account_id": seed.Secretary.ID}
+	case "PUT /teachers/{id}", "PATCH /teachers/{id}":
+		return map[string]any{"account_id": seed.Secretary.ID}
+	case "POST /subjects":
+		return map[string]any{"name": "Materia", "code": "NUEVA-1", "semester": 1, "career_id": seed.Career.ID, "teacher_id": seed.Teacher.ID}
+	case "PUT /subjects/{id}", "PATCH /subjects/{id}":
+		return map[string]any{"career_id": seed.Career.ID, "teacher_id": seed.Teacher.ID}
+	case "POST /sections":
+		return map[string]any{"name": "B", "subject_id": seed.Subject.ID, "teacher_id": seed.Teacher.ID}
+	case "PUT /sections/{id}", "PATCH /sections/{id}":
+		return map[string]any{"teacher_id": seed.Teacher.ID}
+	case "POST /topics":
+		return map[string]any{"name": "Tema", "subject_id": seed.Subject.ID}
+	case "POST /attendances":
+		return map[string]any{"date": "2026-03-03", "status": "present", "student_id": seed.Student.ID, "subject_id": seed.Subject.ID}
+	case "POST /grades":
+		return map[string]any{"value": 10, "student_id": seed.Student.ID, "topic_id": seed.Topic.ID}
+	case "POST /exams":
+		return map[string]any{"kind": "extraordinary", "date": "2026-07-01", "student_id": seed.Student.ID, "subject_id": seed.Subject.ID}
+	case "POST /api-keys":
+		return map[string]any{"name": "Llave", "scopes": []string{"students:read"}, "account_id": seed.Secretary.ID}
+	}
+	return map[string]any{"student_id": seed.Student.ID, "name": "Cambio"}
+}
+
+// foreignQuery returns query parameters referencing the records of a tenant.
+func (seed *isolationTenant) foreignQuery() string {
+	return fmt.Sprintf("?career_id=%d&student_id=%d&subject_id=%d&topic_id=%d&account_id=%d",
+		seed.Career.ID, seed.Student.ID, seed.Subject.ID, seed.Topic.ID, seed.Secretary.ID)
+}
+
+// isolationRequest sends a request through the whole handler chain as the
+// secretary of a tenant.
+func isolationRequest(t *testing.T, server *Server, as *isolationTenant, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	token, err := server.generateToken(as.Secretary)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	if method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete {
+		req.Header.Set("If-Match", "*")
+	}
+	w := httptest.NewRecorder()
+
+	server.server.Handler.ServeHTTP(w, req)
+	return w
+}
+
+// isolationSnapshot returns every row of the tenant-scoped tables of a
+// tenant, and its enrollments.
+func isolationSnapshot(t *testing.T, db *gorm.DB, tenantID string) map[string][]map[string]any {
+	snapshot := map[string][]map[string]any{}
+	for _, table := range isolationTables {
+		var rows []map[string]any
+		if err := db.Table(table).Where("tenant_id = ?", tenantID).Order("id").Find(&rows).Error; err != nil {
+			t.Fatalf("Failed to read %s: %v", table, err)
+		}
+		snapshot[table] = rows
+	}
+
+	var enrollments []map[string]any
+	if err := db.Table("student_subjects").
+		Where("student_id IN (?)", db.Table("students").Select("id").Where("tenant_id = ?", tenantID)).
+		Order("student_id, subject_id").Find(&enrollments).Error; err != nil {
+		t.Fatalf("Failed to read enrollments: %v", err)
+	}
+	snapshot["student_subjects"] = enrollments
+
+	return snapshot
+}
+
+// assertNoCrossTenantReferences checks that no row references a row of
+// another tenant.
+func assertNoCrossTenantReferences(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	references := []struct{ table, column, target string }{
+		{"students", "account_id", "accounts"},
+		{"students", "career_id", "careers"},
+		{"guardian_links", "account_id", "accounts"},
+		{"guardian_links", "student_id", "students"},
+		{"teachers", "account_id", "accounts"},
+		{"subjects", "career_id", "careers"},
+		{"subjects", "teacher_id", "teachers"},
+		{"sections", "subject_id", "subjects"},
+		{"sections", "teacher_id", "teachers"},
+		{"enrollments", "section_id", "sections"},
+		{"enrollments", "student_id", "students"},
+		{"topics", "subject_id", "subjects"},
+		{"attendances", "student_id", "students"},
+		{"attendances", "subject_id", "subjects"},
+		{"attendances", "section_id", "sections"},
+		{"attendances", "justification_id", "justifications"},
+		{"justifications", "student_id", "students"},
+		{"grades", "student_id", "students"},
+		{"grades", "topic_id", "topics"},
+		{"grades", "section_id", "sections"},
+		{"grade_amendments", "grade_id", "grades"},
+		{"grade_amendments", "account_id", "accounts"},
+		{"grade_appeals", "grade_id", "grades"},
+		{"grade_appeals", "student_id", "students"},
+		{"grade_appeals", "resolver_id", "accounts"},
+		{"grade_appeal_messages", "appeal_id", "grade_appeals"},
+		{"grade_appeal_messages", "account_id", "accounts"},
+		{"exam_attempts", "student_id", "students"},
+		{"exam_attempts", "subject_id", "subjects"},
+		{"exam_attempts", "grader_id", "accounts"},
+		{"webhook_deliveries", "webhook_id", "webhooks"},
+		{"api_keys", "account_id", "accounts"},
+		{"inbox_messages", "account_id", "accounts"},
+	}
+	for _, ref := range references {
+		var count int64
+		db.Table(ref.table).
+			Joins(fmt.Sprintf("JOIN %s ON %s.id = %s.%s", ref.target, ref.target, ref.table, ref.column)).
+			Where(fmt.Sprintf("%s.tenant_id <> %s.tenant_id", ref.target, ref.table)).
+			Count(&count)
+		if count > 0 {
+			t.Errorf("%d rows of %s reference %s of another tenant through %s", count, ref.table, ref.target, ref.column)
+		}
+	}
+
+	var count int64
+	db.Table("student_subjects").
+		Joins("JOIN students ON students.id = student_subjects.student_id").
+		Joins("JOIN subjects ON subjects.id = student_subjects.subject_id").
+		Where("students.tenant_id <> subjects.tenant_id").
+		Count(&count)
+	if count > 0 {
+		t.Errorf("%d enrollments join a student and a subject of different tenants", count)
+	}
+}
+
+// setupIsolationTest seeds two tenants and returns a server for them.
+func setupIsolationTest(t *testing.T) (*Server, *gorm.DB, *isolationTenant, *isolationTenant) {
+	db := setupIsolationTestDB(t)
+	tenantA := seedIsolationTenant(t, db, "TENANT-A-SECRET")
+	tenantB := seedIsolationTenant(t, db, "TENANT-B-SECRET")
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	return server, db, tenantA, tenantB
+}
+
+// TestTenantIsolation_ForeignPathIDs requests every route with the IDs of
+// another tenant in its path: none may succeed, leak data or change it.
+func TestTenantIsolation_ForeignPathIDs(t *testing.T) {
+	server, db, tenantA, tenantB := setupIsolationTest(t)
+	before := isolationSnapshot(t, db, tenantB.Tenant.ID)
+
+	for _, pattern := range server.routes {
+		if !strings.Contains(pattern, "{") {
+			continue
+		}
+
+		method, path, _ := strings.Cut(pattern, " ")
+		for name, id := range tenantB.pathIDs(pattern) {
+			path = strings.ReplaceAll(path, "{"+name+"}", fmt.Sprint(id))
+		}
+		path = strings.ReplaceAll(path, "{type}", "grades")
+
+		t.Run(pattern, func(t *testing.T) {
+			var body any
+			if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
+				body = tenantB.foreignBody(pattern)
+			}
+
+			w := isolationRequest(t, server, tenantA, method, path, body)
+			if w.Code < 400 {
+				t.Errorf("%s %s status = %d, want an error", method, path, w.Code)
+			}
+			if strings.Contains(w.Body.String(), tenantB.Marker) {
+				t.Errorf("%s %s leaked data of another tenant: %s", method, path, w.Body.String())
+			}
+		})
+	}
+
+	if after := isolationSnapshot(t, db, tenantB.Tenant.ID); !reflect.DeepEqual(before, after) {
+		t.Error("Data of another tenant was modified")
+	}
+	assertNoCrossTenantReferences(t, db)
+}
+
+// TestTenantIsolation_ForeignReferences creates and updates records of the
+// tenant referencing records of another tenant.
+func TestTenantIsolation_ForeignReferences(t *testing.T) {
+	server, db, tenantA, tenantB := setupIsolationTest(t)
+	before := isolationSnapshot(t, db, tenantB.Tenant.ID)
+
+	patterns := []string{
+		"POST /students",
+		"PUT /students/{id}",
+		"PATCH /students/{id}",
+		"POST /guardians",
+		"POST /guardians/{id}/students",
+		"POST /teachers",
+		"PUT /teachers/{id}",
+		"POST /subjects",
+		"PUT /subjects/{id}",
+		"PATCH /subjects/{id}",
+		"POST /sections",
+		"PUT /sections/{id}",
+		"PATCH /sections/{id}",
+		"POST /sections/{id}/students",
+		"POST /topics",
+		"POST /attendances",
+		"POST /grades",
+		"POST /exams",
+		"POST /api-keys",
+	}
+	for _, pattern := range patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		for name, id := range tenantA.pathIDs(pattern) {
+			path = strings.ReplaceAll(path, "{"+name+"}", fmt.Sprint(id))
+		}
+
+		t.Run(pattern, func(t *testing.T) {
+			w := isolationRequest(t, server, tenantA, method, path, tenantB.foreignBody(pattern))
+			if w.Code < 400 || w.Code >= 500 {
+				t.Errorf("%s %s status = %d, want a client error; body: %s", method, path, w.Code, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), tenantB.Marker) {
+				t.Errorf("%s %s leaked data of another tenant: %s", method, path, w.Body.String())
+			}
+		})
+	}
+
+	if after := isolationSnapshot(t, db, tenantB.Tenant.ID); !reflect.DeepEqual(before, after) {
+		t.Error("Data of another tenant was modified")
+	}
+	assertNoCrossTenantReferences(t, db)
+}
+
+// TestTenantIsolation_Lists requests every list with filters referencing
+// another tenant.
+func TestTenantIsolation_Lists(t *testing.T) {
+	server, db, tenantA, tenantB := setupIsolationTest(t)
+
+	for _, pattern := range server.routes {
+		method, path, _ := strings.Cut(pattern, " ")
+		if method != http.MethodGet || strings.Contains(path, "{") {
+			continue
+		}
+
+		t.Run(pattern, func(t *testing.T) {
+			w := isolationRequest(t, server, tenantA, method, path+tenantB.foreignQuery(), nil)
+			if strings.Contains(w.Body.String(), tenantB.Marker) {
+				t.Errorf("GET %s leaked data of another tenant: %s", path, w.Body.String())
+			}
+		})
+	}
+
+	assertNoCrossTenantReferences(t, db)
+}
+
+func TestHandleCreateSubject_ForeignReferences(t *testing.T) {
+	server, _, tenantA, tenantB := setupIsolationTest(t)
+
+	tests := []struct {
+		name string
+		body map[string]any
+	}{
+		{"foreign career", map[string]any{"name": "Materia", "code": "NUEVA-1", "semester": 1, "career_id": tenantB.Career.ID}},
+		{"foreign teacher", map[string]any{"name": "Materia", "code": "NUEVA-2", "semester": 1, "career_id": tenantA.Career.ID, "teacher_id": tenantB.Teacher.ID}},
+		{"missing career", map[string]any{"name": "Materia", "code": "NUEVA-3", "semester": 1, "career_id": 9999}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := isolationRequest(t, server, tenantA, http.MethodPost, "/subjects", tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("own references", func(t *testing.T) {
+		body := map[string]any{"name": "Materia", "code": "NUEVA-4", "semester": 1, "career_id": tenantA.Career.ID, "teacher_id": tenantA.Teacher.ID}
+		w := isolationRequest(t, server, tenantA, http.MethodPost, "/subjects", body)
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestHandleCreateGrade_ForeignStudent(t *testing.T) {
+	server, db, tenantA, tenantB := setupIsolationTest(t)
+
+	body := map[string]any{"value": 10, "student_id": tenantB.Student.ID, "topic_id": tenantA.Topic.ID}
+	w := isolationRequest(t, server, tenantA, http.MethodPost, "/grades", body)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	var count int64
+	db.Model(&edutrack.Grade{}).Where("student_id = ? AND tenant_id = ?", tenantB.Student.ID, tenantA.Tenant.ID).Count(&count)
+	if count != 0 {
+		t.Error("Grade referencing a student of another tenant was created")
+	}
+}
+
+func TestTenantDB(t *testing.T) {
+	db := setupIsolationTestDB(t)
+	if err := edutrack.RegisterTenantScope(db); err != nil {
+		t.Fatalf("RegisterTenantScope() error = %v", err)
+	}
+	tenantA := seedIsolationTenant(t, db, "TENANT-A-SECRET")
+	tenantB := seedIsolationTenant(t, db, "TENANT-B-SECRET")
+
+	ctx := edutrack.NewContextWithAccount(context.Background(), tenantA.Secretary)
+
+	t.Run("queries are scoped", func(t *testing.T) {
+		var careers []edutrack.Career
+		if err := edutrack.TenantDB(ctx, db).Find(&careers).Error; err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		if len(careers) != 1 || careers[0].ID != tenantA.Career.ID {
+			t.Errorf("Find() = %d careers, want only the career of the tenant", len(careers))
+		}
+
+		var career edutrack.Career
+		err := edutrack.TenantDB(ctx, db).First(&career, tenantB.Career.ID).Error
+		if edutrack.ErrorCode(edutrack.TranslateDBError(err)) != edutrack.ENOTFOUND {
+			t.Errorf("First() of another tenant error = %v, want not found", err)
+		}
+	})
+
+	t.Run("creates are assigned to the tenant", func(t *testing.T) {
+		career := &edutrack.Career{Name: "Nueva", Code: "NUEVA"}
+		if err := edutrack.TenantDB(ctx, db).Create(career).Error; err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if career.TenantID != tenantA.Tenant.ID {
+			t.Errorf("TenantID = %q, want %q", career.TenantID, tenantA.Tenant.ID)
+		}
+
+		other := &edutrack.Career{Name: "Otra", Code: "OTRA", TenantID: tenantB.Tenant.ID}
+		err := edutrack.TenantDB(ctx, db).Create(other).Error
+		if edutrack.ErrorCode(err) != edutrack.EFORBIDDEN {
+			t.Errorf("Create() for another tenant error = %v, want forbidden", err)
+		}
+	})
+
+	t.Run("updates and deletes are scoped", func(t *testing.T) {
+		result := edutrack.TenantDB(ctx, db).Model(&edutrack.Career{}).Where("id = ?", tenantB.Career.ID).Update("name", "Cambio")
+		if result.Error != nil || result.RowsAffected != 0 {
+			t.Errorf("Update() of another tenant affected %d rows, error = %v", result.RowsAffected, result.Error)
+		}
+
+		result = edutrack.TenantDB(ctx, db).Delete(&edutrack.Career{}, tenantB.Career.ID)
+		if result.Error != nil || result.RowsAffected != 0 {
+			t.Errorf("Delete() of another tenant affected %d rows, error = %v", result.RowsAffected, result.Error)
+		}
+
+		var saved edutrack.Career
+		db.First(&saved, tenantB.Career.ID)
+		if saved.Name != tenantB.Career.Name {
+			t.Errorf("Career of another tenant was modified: %q", saved.Name)
+		}
+	})
+
+	t.Run("references must belong to the tenant", func(t *testing.T) {
+		topic := &edutrack.Topic{Name: "Tema", SubjectID: tenantB.Subject.ID}
+		err := edutrack.TenantDB(ctx, db).Create(topic).Error
+		if edutrack.ErrorCode(err) != edutrack.EINVALID {
+			t.Errorf("Create() with a foreign reference error = %v, want invalid", err)
+		}
+
+		err = edutrack.TenantDB(ctx, db).Model(tenantA.Topic).Update("subject_id", tenantB.Subject.ID).Error
+		if edutrack.ErrorCode(err) != edutrack.EINVALID {
+			t.Errorf("Update() with a foreign reference error = %v, want invalid", err)
+		}
+	})
+
+	t.Run("requires an account", func(t *testing.T) {
+		var careers []edutrack.Career
+		err := edutrack.TenantDB(context.Background(), db).Find(&careers).Error
+		if edutrack.ErrorCode(err) != edutrack.EUNAUTHORIZED {
+			t.Errorf("Find() without account error = %v, want unauthorized", err)
+		}
+	})
+}
@@ -0,0 +1,250 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/storage"
+)
+
+// handleListJustifications handles GET /justifications.
+func (s *Server) handleListJustifications(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, justificationIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	filter := edutrack.JustificationFilter{Status: edutrack.JustificationStatus(r.URL.Query().Get("status"))}
+	switch filter.Status {
+	case "", edutrack.JustificationPending, edutrack.JustificationApproved, edutrack.JustificationRejected:
+	default:
+		sendFieldError(w, r, "status", edutrack.FieldInvalidChoice, "field.invalid_choice", "pending, approved, rejected")
+		return
+	}
+	if studentID := r.URL.Query().Get("student_id"); studentID != "" {
+		id, err := strconv.ParseUint(studentID, 10, 64)
+		if err != nil {
+			sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+			return
+		}
+		filter.StudentID = uint(id)
+	}
+
+	justifications, err := edutrack.FindJustifications(r.Context(), s.DB, filter)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	response := make([]JustificationResponse, 0, len(justifications))
+	for i := range justifications {
+		response = append(response, newJustificationResponse(&justifications[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// findJustification finds the justification of a route, visible to the
+// authenticated account. On failure it writes the error response and
+// returns nil.
+func (s *Server) findJustification(w http.ResponseWriter, r *http.Request) *edutrack.Justification {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return nil
+	}
+
+	justification, err := edutrack.FindJustificationByID(r.Context(), s.DB, uint(id))
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return nil
+	}
+	return justification
+}
+
+// handleGetJustification handles GET /justifications/{id}.
+func (s *Server) handleGetJustification(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, justificationIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	justification := s.findJustification(w, r)
+	if justification == nil {
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newJustificationResponse(justification, inc))
+}
+
+// CreateJustificationRequest represents the form fields for submitting a
+// justification, sent along with the supporting document in the "file"
+// field of a multipart request.
+type CreateJustificationRequest struct {
+	StudentID uint   `json:"student_id" validate:"required"`
+	StartDate string `json:"start_date" validate:"required,date"`
+	EndDate   string `json:"end_date" validate:"omitempty,date"`
+	Reason    string `json:"reason" validate:"required,max=1000"`
+}
+
+// handleCreateJustification handles POST /justifications.
+// Submits a justification of the absences of a student between two dates,
+// with a supporting document, for review by a secretary.
+func (s *Server) handleCreateJustification(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, justificationIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	if !s.parseUpload(w, r, storage.DocumentLimits) {
+		return
+	}
+
+	req := CreateJustificationRequest{
+		StartDate: r.FormValue("start_date"),
+		EndDate:   r.FormValue("end_date"),
+		Reason:    r.FormValue("reason"),
+	}
+	if studentID := r.FormValue("student_id"); studentID != "" {
+		id, err := strconv.ParseUint(studentID, 10, 64)
+		if err != nil {
+			sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+			return
+		}
+		req.StudentID = uint(id)
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	// A single day by default.
+	if req.EndDate == "" {
+		req.EndDate = req.StartDate
+	}
+	justification := &edutrack.Justification{
+		Reason:    req.Reason,
+		StudentID: req.StudentID,
+	}
+	justification.StartDate, _ = time.Parse("2006-01-02", req.StartDate)
+	justification.EndDate, _ = time.Parse("2006-01-02", req.EndDate)
+
+	data, contentType, ok := readFile(w, r, storage.DocumentLimits)
+	if !ok {
+		return
+	}
+	justification.DocumentKey, ok = s.storeFile(w, r, account.TenantID, "justification", data, contentType)
+	if !ok {
+		return
+	}
+
+	if err := edutrack.SubmitJustification(r.Context(), s.DB, justification); err != nil {
+		s.deleteFile(r, justification.DocumentKey)
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, newJustificationResponse(justification, inc))
+}
+
+// handleGetJustificationDocument handles GET /justifications/{id}/document.
+// Redirects to a signed URL of the supporting document.
+func (s *Server) handleGetJustificationDocument(w http.ResponseWriter, r *http.Request) {
+	justification := s.findJustification(w, r)
+	if justification == nil {
+		return
+	}
+
+	s.redirectToFile(w, r, justification.DocumentKey)
+}
+
+// ReviewJustificationRequest represents the request body for approving or
+// rejecting a justification.
+type ReviewJustificationRequest struct {
+	Notes string `json:"notes" validate:"max=1000"`
+}
+
+// ReviewJustificationResponse represents the result of reviewing a
+// justification.
+type ReviewJustificationResponse struct {
+	Justification JustificationResponse `json:"justification"`
+
+	// Attendance records converted to excused by the approval. They can be
+	// listed with GET /attendances?justification_id={id}.
+	Excused int64 `json:"excused"`
+}
+
+// handleApproveJustification handles POST /justifications/{id}/approve.
+func (s *Server) handleApproveJustification(w http.ResponseWriter, r *http.Request) {
+	s.reviewJustification(w, r, edutrack.JustificationApproved)
+}
+
+// handleRejectJustification handles POST /justifications/{id}/reject.
+func (s *Server) handleRejectJustification(w http.ResponseWriter, r *http.Request) {
+	s.reviewJustification(w, r, edutrack.JustificationRejected)
+}
+
+// reviewJustification approves or rejects the pending justification of a
+// route and notifies the student and their guardians.
+func (s *Server) reviewJustification(w http.ResponseWriter, r *http.Request, status edutrack.JustificationStatus) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	// The notes are optional, and so is the body.
+	var req ReviewJustificationRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	justification := s.findJustification(w, r)
+	if justification == nil {
+		return
+	}
+
+	var excused int64
+	err := s.inTransaction(r, func(tx *gorm.DB, _ *http.Request) error {
+		var err error
+		excused, err = edutrack.ReviewJustification(tx, justification, account, status, req.Notes)
+		if err != nil {
+			return err
+		}
+
+		start, end := justification.StartDate.Format("2006-01-02"), justification.EndDate.Format("2006-01-02")
+		if status == edutrack.JustificationApproved {
+			return s.notifyStudent(tx, justification.StudentID, edutrack.NotificationJustificationApproved, start, end, excused)
+		}
+		return s.notifyStudent(tx, justification.StudentID, edutrack.NotificationJustificationRejected, start, end, req.Notes)
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, ReviewJustificationResponse{
+		Justification: newJustificationResponse(justification, nil),
+		Excused:       excused,
+	})
+}
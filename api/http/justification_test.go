@@ -0,0 +1,215 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// testPDF is the signature of a PDF document, enough to detect its type.
+var testPDF = []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+// submitJustification sends POST /justifications as the given account with
+// the given form fields and document.
+func submitJustification(t *testing.T, server *Server, as *edutrack.Account, fields map[string]string, document []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	token, err := server.generateToken(as)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	if document != nil {
+		part, _ := form.CreateFormFile("file", "justificante.pdf")
+		part.Write(document)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/justifications", &body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	return w
+}
+
+// studentAccount returns the account of the student of a tenant.
+func studentAccount(t *testing.T, server *Server, seed *isolationTenant) *edutrack.Account {
+	t.Helper()
+
+	var account edutrack.Account
+	if err := server.DB.First(&account, seed.Student.AccountID).Error; err != nil {
+		t.Fatalf("Failed to load student account: %v", err)
+	}
+	return &account
+}
+
+func TestJustificationWorkflow(t *testing.T) {
+	server, tenant, _ := setupFileTest(t)
+	student := studentAccount(t, server, tenant)
+
+	// The seeded absence is on 2026-03-02.
+	w := submitJustification(t, server, student, map[string]string{
+		"student_id": fmt.Sprint(tenant.Student.ID),
+		"start_date": "2026-03-01",
+		"end_date":   "2026-03-03",
+		"reason":     "Consulta médica",
+	}, testPDF)
+	var justification JustificationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &justification); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST /justifications status = %d: %s", w.Code, w.Body.String())
+	}
+	if justification.Status != edutrack.JustificationPending || justification.SubmittedByID == nil || *justification.SubmittedByID != student.ID {
+		t.Errorf("Justification = %+v, want pending and submitted by the student", justification)
+	}
+
+	// The document is available to the secretaries.
+	path := fmt.Sprintf("/justifications/%d", justification.ID)
+	if justification.DocumentURL != path+"/document" {
+		t.Errorf("DocumentURL = %q, want %q", justification.DocumentURL, path+"/document")
+	}
+	if file := download(t, server, isolationRequest(t, server, tenant, http.MethodGet, justification.DocumentURL, nil)); !bytes.Equal(file.Body.Bytes(), testPDF) {
+		t.Errorf("Document = %q, want the uploaded one", file.Body.Bytes())
+	}
+
+	// Guardians see the requests of their linked students.
+	w = httptest.NewRecorder()
+	token, _ := server.generateToken(tenant.Guardian)
+	req := httptest.NewRequest(http.MethodGet, "/justifications?status=pending", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	server.server.Handler.ServeHTTP(w, req)
+	var list []JustificationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 2 {
+		t.Fatalf("GET /justifications as guardian = %s, want the seeded and the new request", w.Body.String())
+	}
+
+	// Approval excuses the absences within the dates.
+	w = isolationRequest(t, server, tenant, http.MethodPost, path+"/approve", map[string]any{"notes": "Comprobante válido"})
+	var review ReviewJustificationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("POST %s/approve status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if review.Excused != 1 || review.Justification.Status != edutrack.JustificationApproved ||
+		review.Justification.ReviewerID == nil || *review.Justification.ReviewerID != tenant.Secretary.ID {
+		t.Errorf("Review = %+v, want 1 excused record reviewed by the secretary", review)
+	}
+
+	var attendance edutrack.Attendance
+	server.DB.First(&attendance, tenant.Attendance.ID)
+	if attendance.Status != edutrack.AttendanceExcused || attendance.JustificationID == nil || *attendance.JustificationID != justification.ID {
+		t.Errorf("Attendance status = %s, justification = %v, want excused by the justification", attendance.Status, attendance.JustificationID)
+	}
+
+	// The attendance history shows the justification.
+	w = isolationRequest(t, server, tenant, http.MethodGet, fmt.Sprintf("/attendances?justification_id=%d&include=justification", justification.ID), nil)
+	var attendances []AttendanceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &attendances); err != nil || len(attendances) != 1 {
+		t.Fatalf("GET /attendances?justification_id = %s, want the excused record", w.Body.String())
+	}
+	if attendances[0].Justification == nil || attendances[0].Justification.Reason != "Consulta médica" {
+		t.Errorf("Included justification = %+v, want the approved one", attendances[0].Justification)
+	}
+
+	// Requests are reviewed once.
+	w = isolationRequest(t, server, tenant, http.MethodPost, path+"/reject", nil)
+	errorResponse(t, w, http.StatusConflict)
+}
+
+func TestHandleRejectJustification(t *testing.T) {
+	server, tenant, _ := setupFileTest(t)
+	path := fmt.Sprintf("/justifications/%d", tenant.Justification.ID)
+
+	// Only secretaries review requests.
+	token, _ := server.generateToken(tenant.Guardian)
+	req := httptest.NewRequest(http.MethodPost, path+"/approve", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("POST %s/approve as guardian status = %d, want %d", path, w.Code, http.StatusForbidden)
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodPost, path+"/reject", map[string]any{"notes": "Documento ilegible"})
+	var review ReviewJustificationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("POST %s/reject status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if review.Excused != 0 || review.Justification.Status != edutrack.JustificationRejected || review.Justification.ReviewNotes != "Documento ilegible" {
+		t.Errorf("Review = %+v, want rejected with the notes", review)
+	}
+
+	var attendance edutrack.Attendance
+	server.DB.First(&attendance, tenant.Attendance.ID)
+	if attendance.Status != edutrack.AttendanceAbsent || attendance.JustificationID != nil {
+		t.Errorf("Attendance status = %s, want the absence unchanged", attendance.Status)
+	}
+}
+
+func TestHandleCreateJustification_Invalid(t *testing.T) {
+	server, tenantA, tenantB := setupFileTest(t)
+	student := studentAccount(t, server, tenantA)
+
+	fields := func(changes map[string]string) map[string]string {
+		fields := map[string]string{
+			"student_id": fmt.Sprint(tenantA.Student.ID),
+			"start_date": "2026-03-02",
+			"reason":     "Enfermedad",
+		}
+		for name, value := range changes {
+			if value == "" {
+				delete(fields, name)
+			} else {
+				fields[name] = value
+			}
+		}
+		return fields
+	}
+
+	tests := []struct {
+		name     string
+		fields   map[string]string
+		document []byte
+		want     map[string]string
+	}{
+		{"missing fields", fields(map[string]string{"reason": "", "start_date": ""}), testPDF, map[string]string{"reason": edutrack.FieldRequired, "start_date": edutrack.FieldRequired}},
+		{"missing document", fields(nil), nil, map[string]string{"file": edutrack.FieldRequired}},
+		{"invalid document", fields(nil), []byte("<html></html>"), map[string]string{"file": edutrack.FieldInvalidChoice}},
+		{"end before start", fields(map[string]string{"end_date": "2026-03-01"}), testPDF, map[string]string{"end_date": edutrack.FieldTooSmall}},
+		{"too long", fields(map[string]string{"end_date": "2026-05-01"}), testPDF, map[string]string{"end_date": edutrack.FieldTooLarge}},
+		{"foreign student", fields(map[string]string{"student_id": fmt.Sprint(tenantB.Student.ID)}), testPDF, map[string]string{"student_id": edutrack.FieldNotFound}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := submitJustification(t, server, student, tt.fields, tt.document)
+			if got := fieldCodes(errorResponse(t, w, http.StatusBadRequest)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Teachers cannot submit requests.
+	var teacher edutrack.Account
+	server.DB.First(&teacher, tenantA.Teacher.AccountID)
+	if w := submitJustification(t, server, &teacher, fields(nil), testPDF); w.Code != http.StatusForbidden {
+		t.Errorf("POST /justifications as teacher status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	var count int64
+	server.DB.Model(&edutrack.Justification{}).Count(&count)
+	if count != 2 {
+		t.Errorf("Justifications = %d, want only the seeded ones", count)
+	}
+}
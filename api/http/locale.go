@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+// requestLocale returns the locale of the messages of a request: the
+// preference of the authenticated account, or else the locale preferred by
+// its Accept-Language header, or else the locale of the institution.
+func requestLocale(r *http.Request) i18n.Locale {
+	fallback := i18n.Default
+	if account := edutrack.AccountFromContext(r.Context()); account != nil {
+		if account.Locale != "" {
+			return account.Locale
+		}
+		if settings := account.Tenant.Settings; settings != nil && settings.Locale != "" {
+			fallback = settings.Locale
+		}
+	}
+	return i18n.Negotiate(r.Header.Get("Accept-Language"), fallback)
+}
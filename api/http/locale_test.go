@@ -0,0 +1,136 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+func TestRequestLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		account *edutrack.Account
+		header  string
+		want    i18n.Locale
+	}{
+		{"default", nil, "", i18n.Spanish},
+		{"header", nil, "en-US,en;q=0.9", i18n.English},
+		{"account without preference", &edutrack.Account{}, "en", i18n.English},
+		{"account preference", &edutrack.Account{Locale: i18n.Spanish}, "en", i18n.Spanish},
+		{"tenant locale", &edutrack.Account{Tenant: edutrack.Tenant{Settings: &edutrack.TenantSettings{Locale: i18n.English}}}, "fr", i18n.English},
+		{"header over tenant locale", &edutrack.Account{Tenant: edutrack.Tenant{Settings: &edutrack.TenantSettings{Locale: i18n.English}}}, "es", i18n.Spanish},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", tt.header)
+			if tt.account != nil {
+				r = r.WithContext(edutrack.NewContextWithAccount(r.Context(), tt.account))
+			}
+			if got := requestLocale(r); got != tt.want {
+				t.Errorf("requestLocale() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// localeRequest sends a request as the secretary of a tenant, accepting the
+// given languages.
+func localeRequest(t *testing.T, server *Server, as *isolationTenant, method, path, acceptLanguage string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	token, err := server.generateToken(as.Secretary)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	data, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", acceptLanguage)
+	req.Header.Set("If-Match", "*")
+	w := httptest.NewRecorder()
+
+	server.server.Handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestErrorResponse_Locale(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+
+	tests := []struct {
+		acceptLanguage string
+		message        string
+		fieldMessage   string
+	}{
+		{"", "Uno o más campos son inválidos.", "Es requerido."},
+		{"en-US,en;q=0.9,es;q=0.8", "One or more fields are invalid.", "Is required."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			w := localeRequest(t, server, tenant, http.MethodPost, "/careers", tt.acceptLanguage, map[string]any{"code": "X"})
+			response := errorResponse(t, w, http.StatusBadRequest)
+			if response.Message != tt.message {
+				t.Errorf("Message = %q, want %q", response.Message, tt.message)
+			}
+			if len(response.Fields) != 1 || response.Fields[0].Message != tt.fieldMessage {
+				t.Errorf("Fields = %+v, want name: %q", response.Fields, tt.fieldMessage)
+			}
+		})
+	}
+
+	w := localeRequest(t, server, tenant, http.MethodGet, "/careers/9999", "en", nil)
+	if response := errorResponse(t, w, http.StatusNotFound); response.Message != "Resource not found." {
+		t.Errorf("Message = %q, want the English message", response.Message)
+	}
+	if got := w.Header().Get("Content-Language"); got != "en" {
+		t.Errorf("Content-Language = %q, want en", got)
+	}
+}
+
+func TestHandleUpdateAccount_Locale(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+	path := fmt.Sprintf("/accounts/%d", tenant.Secretary.ID)
+
+	w := localeRequest(t, server, tenant, http.MethodPatch, path, "", map[string]any{"locale": "fr"})
+	if got, want := fieldCodes(errorResponse(t, w, http.StatusBadRequest)), (map[string]string{"locale": edutrack.FieldInvalidChoice}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields = %v, want %v", got, want)
+	}
+
+	w = localeRequest(t, server, tenant, http.MethodPatch, path, "", map[string]any{"locale": "en"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var account AccountResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &account); err != nil || account.Locale != i18n.English {
+		t.Fatalf("Locale = %q, want en (%v)", account.Locale, err)
+	}
+
+	// The preference of the account wins over Accept-Language.
+	w = localeRequest(t, server, tenant, http.MethodGet, "/careers/9999", "es", nil)
+	if response := errorResponse(t, w, http.StatusNotFound); response.Message != "Resource not found." {
+		t.Errorf("Message = %q, want the English message", response.Message)
+	}
+
+	// Null removes the preference.
+	req := httptest.NewRequest(http.MethodPatch, path, bytes.NewReader([]byte(`{"locale": null}`)))
+	token, _ := server.generateToken(tenant.Secretary)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", MergePatchContentType)
+	req.Header.Set("If-Match", "*")
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &account); err != nil || account.Locale != "" {
+		t.Errorf("Locale = %q, want none (%s)", account.Locale, w.Body.String())
+	}
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// RequestIDHeader is the header carrying the ID used to correlate the logs
+// of a request. Incoming IDs (e.g., from a proxy) are kept; otherwise one is
+// generated. The ID is always echoed in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// withRequestID is a middleware that assigns an ID to every request.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := edutrack.NewContextWithRequestID(r.Context(), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// newRequestID generates a random request ID (32 hex characters).
+func newRequestID() string {
+	bytes := make([]byte, 16)
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// isValidRequestID checks an incoming request ID is safe to log and echo.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestLog holds the fields of the access log that are only known once
+// the request is authenticated, deep inside the handler chain.
+type requestLog struct {
+	AccountID uint
+	TenantID  string
+}
+
+// requestLogContextKey stores the *requestLog of the current request.
+type requestLogContextKey struct{}
+
+// setRequestLogAccount records the authenticated account in the access log
+// of the request, if any.
+func setRequestLogAccount(ctx context.Context, account *edutrack.Account) {
+	if entry, ok := ctx.Value(requestLogContextKey{}).(*requestLog); ok {
+		entry.AccountID = account.ID
+		entry.TenantID = account.TenantID
+	}
+}
+
+// statusRecorder captures the status code and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader records the status code before writing it.
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the response size, defaulting the status to 200.
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap returns the original writer for http.ResponseController.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// withAccessLog is a middleware that writes a structured access log entry
+// and records the request metrics once the request is served.
+func (s *Server) withAccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		entry := &requestLog{}
+
+		ctx := context.WithValue(r.Context(), requestLogContextKey{}, entry)
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		elapsed := time.Since(start)
+
+		// The router sets the pattern of the matched route on the request.
+		route := routeLabel(r.Pattern)
+
+		if s.Metrics != nil {
+			s.Metrics.ObserveRequest(r.Method, route, rec.status, elapsed)
+		}
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		s.Logger.LogAttrs(r.Context(), level, "request",
+			slog.String("request_id", edutrack.RequestIDFromContext(r.Context())),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", rec.status),
+			slog.Int("bytes", rec.bytes),
+			slog.Float64("latency_ms", float64(elapsed.Microseconds())/1000),
+			slog.Uint64("account_id", uint64(entry.AccountID)),
+			slog.String("tenant_id", entry.TenantID),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+		)
+	})
+}
+
+// routeLabel returns the path of a route pattern ("GET /students/{id}"
+// becomes "/students/{id}"), or "unmatched" for requests without route.
+// Using patterns instead of raw paths keeps the number of labels bounded.
+func routeLabel(pattern string) string {
+	if pattern == "" {
+		return "unmatched"
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultLatencyBuckets are the upper bounds (in seconds) of the request
+// latency histogram buckets.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Login failure reasons counted by the metrics.
+const (
+	LoginFailureBadRequest         = "bad_request"
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureInactiveAccount    = "inactive_account"
+	LoginFailureExpiredLicense     = "expired_license"
+	LoginFailureInvalidLicense     = "invalid_license"
+)
+
+// requestKey identifies the requests counter of a route.
+type requestKey struct {
+	method string
+	route  string
+	status int
+}
+
+// routeKey identifies the latency histogram of a route.
+type routeKey struct {
+	method string
+	route  string
+}
+
+// histogram is a cumulative latency histogram.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Metrics collects the metrics of the API in memory and exposes them in the
+// Prometheus text format. It is safe for concurrent use.
+type Metrics struct {
+	mu            sync.Mutex
+	buckets       []float64
+	requests      map[requestKey]uint64
+	latencies     map[routeKey]*histogram
+	loginFailures map[string]uint64
+}
+
+// NewMetrics creates an empty metrics collector.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		buckets:       DefaultLatencyBuckets,
+		requests:      map[requestKey]uint64{},
+		latencies:     map[routeKey]*histogram{},
+		loginFailures: map[string]uint64{},
+	}
+}
+
+// ObserveRequest records a served request.
+func (m *Metrics) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestKey{method, route, status}]++
+
+	key := routeKey{method, route}
+	h, ok := m.latencies[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.latencies[key] = h
+	}
+
+	seconds := elapsed.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// IncLoginFailure records a failed login attempt.
+func (m *Metrics) IncLoginFailure(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.loginFailures[reason]++
+}
+
+// LoginFailures returns the number of failed logins for a reason.
+func (m *Metrics) LoginFailures(reason string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.loginFailures[reason]
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format,
+// including the connection pool stats of the database if not nil.
+func (m *Metrics) WriteTo(w io.Writer, db *gorm.DB) error {
+	var b strings.Builder
+
+	m.mu.Lock()
+
+	writeHeader(&b, "edutrack_http_requests_total", "counter", "Total number of HTTP requests by method, route and status.")
+	requests := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, c := requests[i], requests[j]
+		if a.route != c.route {
+			return a.route < c.route
+		}
+		if a.method != c.method {
+			return a.method < c.method
+		}
+		return a.status < c.status
+	})
+	for _, key := range requests {
+		fmt.Fprintf(&b, "edutrack_http_requests_total{method=%q,route=%q,status=\"%d\"} %d\n",
+			key.method, escapeLabel(key.route), key.status, m.requests[key])
+	}
+
+	writeHeader(&b, "edutrack_http_request_duration_seconds", "histogram", "Latency of HTTP requests by method and route.")
+	routes := make([]routeKey, 0, len(m.latencies))
+	for key := range m.latencies {
+		routes = append(routes, key)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].route != routes[j].route {
+			return routes[i].route < routes[j].route
+		}
+		return routes[i].method < routes[j].method
+	})
+	for _, key := range routes {
+		h := m.latencies[key]
+		labels := fmt.Sprintf("method=%q,route=%q", key.method, escapeLabel(key.route))
+		for i, bound := range m.buckets {
+			fmt.Fprintf(&b, "edutrack_http_request_duration_seconds_bucket{%s,le=%q} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(&b, "edutrack_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "edutrack_http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "edutrack_http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	writeHeader(&b, "edutrack_login_failures_total", "counter", "Total number of failed logins by reason.")
+	reasons := make([]string, 0, len(m.loginFailures))
+	for reason := range m.loginFailures {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(&b, "edutrack_login_failures_total{reason=%q} %d\n", escapeLabel(reason), m.loginFailures[reason])
+	}
+
+	m.mu.Unlock()
+
+	if db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			stats := sqlDB.Stats()
+			writeGauge(&b, "edutrack_db_max_open_connections", "Maximum number of open connections to the database.", float64(stats.MaxOpenConnections))
+			writeGauge(&b, "edutrack_db_open_connections", "Number of established connections to the database.", float64(stats.OpenConnections))
+			writeGauge(&b, "edutrack_db_in_use_connections", "Number of connections currently in use.", float64(stats.InUse))
+			writeGauge(&b, "edutrack_db_idle_connections", "Number of idle connections.", float64(stats.Idle))
+			writeCounter(&b, "edutrack_db_wait_count_total", "Total number of connections waited for.", float64(stats.WaitCount))
+			writeCounter(&b, "edutrack_db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", stats.WaitDuration.Seconds())
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// handleMetrics handles GET /metrics.
+// It exposes the metrics in the Prometheus text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.Metrics.WriteTo(w, s.DB); err != nil {
+		s.Logger.Error("metrics: failed to write response", "error", err)
+	}
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric.
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeGauge writes a gauge metric without labels.
+func writeGauge(b *strings.Builder, name, help string, value float64) {
+	writeHeader(b, name, "gauge", help)
+	fmt.Fprintf(b, "%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// writeCounter writes a counter metric without labels.
+func writeCounter(b *strings.Builder, name, help string, value float64) {
+	writeHeader(b, name, "counter", help)
+	fmt.Fprintf(b, "%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// escapeLabel escapes a label value for the Prometheus text format.
+// Values are later quoted with %q, which escapes backslashes, quotes and
+// newlines the same way, so only invalid UTF-8 has to be replaced.
+func escapeLabel(value string) string {
+	return strings.ToValidUTF8(value, "�")
+}
@@ -0,0 +1,215 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// setupMetricsTestServer creates a server on an in-memory SQLite database
+// whose access logs are written to the returned buffer.
+func setupMetricsTestServer(t *testing.T) (*Server, *gorm.DB, *bytes.Buffer) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	var logs bytes.Buffer
+	server := NewServer(":8080", db, []byte("test-secret"))
+	server.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	return server, db, &logs
+}
+
+// createMetricsTestAccount creates a secretary in a new tenant.
+func createMetricsTestAccount(t *testing.T, db *gorm.DB) *edutrack.Account {
+	tenant, err := edutrack.NewTenant("Test Institution", edutrack.LicenseTypeTrial, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create test tenant: %v", err)
+	}
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatalf("Failed to save test tenant: %v", err)
+	}
+
+	account := &edutrack.Account{
+		Name:     "Test User",
+		Email:    "admin@test.com",
+		Role:     edutrack.RoleSecretary,
+		Active:   true,
+		TenantID: tenant.ID,
+	}
+	if err := db.Create(account).Error; err != nil {
+		t.Fatalf("Failed to save test account: %v", err)
+	}
+
+	return account
+}
+
+// decodeAccessLogs returns the access log entries written to the buffer.
+func decodeAccessLogs(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid JSON log line %q: %v", line, err)
+		}
+		if entry["msg"] == "request" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestWithRequestID(t *testing.T) {
+	server, _, _ := setupMetricsTestServer(t)
+
+	// A request ID is generated when missing.
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if id := w.Header().Get(RequestIDHeader); len(id) != 32 {
+		t.Errorf("Expected a generated request ID, got %q", id)
+	}
+
+	// A valid incoming request ID is kept.
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	req.Header.Set(RequestIDHeader, "proxy-123")
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	if id := w.Header().Get(RequestIDHeader); id != "proxy-123" {
+		t.Errorf("Expected request ID %q, got %q", "proxy-123", id)
+	}
+
+	// Unsafe incoming request IDs are replaced.
+	req = httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	if id := w.Header().Get(RequestIDHeader); id == "bad id\n" || len(id) != 32 {
+		t.Errorf("Expected the request ID to be replaced, got %q", id)
+	}
+}
+
+func TestWithAccessLog(t *testing.T) {
+	server, db, logs := setupMetricsTestServer(t)
+	account := createMetricsTestAccount(t, db)
+
+	token, err := server.generateToken(account)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/students/999", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	entries := decodeAccessLogs(t, logs)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 access log entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	expected := map[string]any{
+		"request_id": "req-1",
+		"method":     "GET",
+		"path":       "/students/999",
+		"route":      "/students/{id}",
+		"status":     float64(http.StatusNotFound),
+		"account_id": float64(account.ID),
+		"tenant_id":  account.TenantID,
+	}
+	for key, want := range expected {
+		if entry[key] != want {
+			t.Errorf("Expected %s = %v, got %v", key, want, entry[key])
+		}
+	}
+	if _, ok := entry["latency_ms"].(float64); !ok {
+		t.Errorf("Expected latency_ms in the access log, got %v", entry["latency_ms"])
+	}
+}
+
+func TestHandleMetrics(t *testing.T) {
+	server, _, _ := setupMetricsTestServer(t)
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/students/1", nil))
+	}
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/no-such-route", nil))
+
+	// Failed logins are counted by reason.
+	body, _ := json.Marshal(LoginRequest{Email: "nobody@test.com", Password: "secret"})
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body)))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if got := server.Metrics.LoginFailures(LoginFailureInvalidCredentials); got != 1 {
+		t.Errorf("Expected 1 login failure, got %d", got)
+	}
+
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected text/plain content type, got %q", ct)
+	}
+
+	metrics := w.Body.String()
+	for _, want := range []string{
+		`edutrack_http_requests_total{method="GET",route="/students/{id}",status="401"} 2`,
+		`edutrack_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`edutrack_http_request_duration_seconds_bucket{method="GET",route="/students/{id}",le="+Inf"} 2`,
+		`edutrack_http_request_duration_seconds_count{method="GET",route="/students/{id}"} 2`,
+		`edutrack_login_failures_total{reason="invalid_credentials"} 1`,
+		`# TYPE edutrack_db_open_connections gauge`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestMetrics_HistogramBuckets(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveRequest("GET", "/grades", 200, 3*time.Millisecond)
+	m.ObserveRequest("GET", "/grades", 200, 300*time.Millisecond)
+
+	var b strings.Builder
+	if err := m.WriteTo(&b, nil); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`edutrack_http_request_duration_seconds_bucket{method="GET",route="/grades",le="0.005"} 1`,
+		`edutrack_http_request_duration_seconds_bucket{method="GET",route="/grades",le="0.25"} 1`,
+		`edutrack_http_request_duration_seconds_bucket{method="GET",route="/grades",le="0.5"} 2`,
+		`edutrack_http_request_duration_seconds_bucket{method="GET",route="/grades",le="+Inf"} 2`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, b.String())
+		}
+	}
+}
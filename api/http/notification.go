@@ -0,0 +1,203 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// notifyStudent enqueues a notification for a student and their guardians,
+// with args filling the body of the event. The entries are written in the
+// transaction of the write that emitted the event, so they are only sent
+// if it commits.
+func (s *Server) notifyStudent(tx *gorm.DB, studentID uint, event edutrack.NotificationEvent, args ...any) error {
+	recipients, err := edutrack.StudentRecipients(tx, studentID)
+	if err != nil {
+		return fmt.Errorf("failed to load recipients of student %d: %w", studentID, err)
+	}
+
+	for i := range recipients {
+		if err := s.notifyAccount(tx, &recipients[i], event, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyAccount enqueues a notification for an account in tx, with args
+// filling the body of the event.
+func (s *Server) notifyAccount(tx *gorm.DB, account *edutrack.Account, event edutrack.NotificationEvent, args ...any) error {
+	return edutrack.EnqueueNotification(tx, account, event, "", args...)
+}
+
+// handleListNotifications handles GET /notifications.
+// It lists the in-app inbox of the current account, newest first.
+func (s *Server) handleListNotifications(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	query := s.tenantDB(r).Where("account_id = ?", account.ID)
+
+	// Optional filters.
+	if unread := r.URL.Query().Get("unread"); unread == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var messages []edutrack.InboxMessage
+	if err := query.Order("created_at DESC").Find(&messages).Error; err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	response := make([]InboxMessageResponse, 0, len(messages))
+	for i := range messages {
+		response = append(response, newInboxMessageResponse(&messages[i]))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// handleReadNotification handles PUT /notifications/{id}/read.
+// It marks a message of the current account's inbox as read.
+func (s *Server) handleReadNotification(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	var message edutrack.InboxMessage
+	if err := s.tenantDB(r).First(&message, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if message.AccountID != account.ID {
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
+		return
+	}
+
+	if message.ReadAt == nil {
+		now := time.Now()
+		message.ReadAt = &now
+		if err := s.tenantDB(r).Save(&message).Error; err != nil {
+			s.sendAppError(w, r, edutrack.TranslateDBError(err))
+			return
+		}
+	}
+
+	sendJSON(w, http.StatusOK, newInboxMessageResponse(&message))
+}
+
+// handleListNotificationPreferences handles GET /notifications/preferences.
+// It returns the preference of the current account for every event,
+// including the defaults for events that were never configured.
+func (s *Server) handleListNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	events := edutrack.NotificationEvents()
+	prefs := make([]NotificationPreferenceResponse, 0, len(events))
+	for _, event := range events {
+		pref, err := edutrack.FindNotificationPreference(s.tenantDB(r), account.ID, event)
+		if err != nil {
+			sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+			return
+		}
+		prefs = append(prefs, newNotificationPreferenceResponse(&pref))
+	}
+
+	sendJSON(w, http.StatusOK, prefs)
+}
+
+// UpdateNotificationPreferenceRequest represents the request body for updating
+// the preference of an event.
+type UpdateNotificationPreferenceRequest struct {
+	Event      edutrack.NotificationEvent `json:"event" validate:"required"`
+	Email      *bool                      `json:"email"`
+	Inbox      *bool                      `json:"inbox"`
+	WebhookURL *string                    `json:"webhook_url"`
+}
+
+// handleUpdateNotificationPreference handles PUT /notifications/preferences.
+func (s *Server) handleUpdateNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	var req UpdateNotificationPreferenceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+	if !isValidNotificationEvent(req.Event) {
+		sendFieldError(w, r, "event", edutrack.FieldInvalidChoice, "field.event.invalid")
+		return
+	}
+
+	pref, err := edutrack.FindNotificationPreference(s.tenantDB(r), account.ID, req.Event)
+	if err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	if req.Email != nil {
+		pref.Email = *req.Email
+	}
+	if req.Inbox != nil {
+		pref.Inbox = *req.Inbox
+	}
+	if req.WebhookURL != nil {
+		// The server posts to the endpoint, so only secretaries choose it.
+		if *req.WebhookURL != "" && !account.IsSecretary() {
+			sendError(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+		if *req.WebhookURL != "" && !isValidWebhookURL(*req.WebhookURL) {
+			sendFieldError(w, r, "webhook_url", edutrack.FieldInvalidFormat, "field.webhook_url.invalid")
+			return
+		}
+		pref.WebhookURL = *req.WebhookURL
+	}
+
+	if err := s.tenantDB(r).Save(&pref).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newNotificationPreferenceResponse(&pref))
+}
+
+// isValidNotificationEvent checks if the given event can be configured.
+func isValidNotificationEvent(event edutrack.NotificationEvent) bool {
+	for _, e := range edutrack.NotificationEvents() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
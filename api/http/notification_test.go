@@ -0,0 +1,320 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// setupNotificationTestDB creates an in-memory SQLite database for testing.
+func setupNotificationTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// createNotificationTestTenant creates a test tenant with a valid license.
+func createNotificationTestTenant(t *testing.T, db *gorm.DB) *edutrack.Tenant {
+	tenant, err := edutrack.NewTenant("Test Institution", edutrack.LicenseTypeTrial, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create test tenant: %v", err)
+	}
+
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatalf("Failed to save test tenant: %v", err)
+	}
+
+	return tenant
+}
+
+// createNotificationTestAccount creates a test account for a tenant.
+func createNotificationTestAccount(t *testing.T, db *gorm.DB, tenantID, email string, role edutrack.Role) *edutrack.Account {
+	account := &edutrack.Account{
+		Name:     "Test User",
+		Email:    email,
+		Role:     role,
+		Active:   true,
+		TenantID: tenantID,
+	}
+
+	if err := db.Create(account).Error; err != nil {
+		t.Fatalf("Failed to save test account: %v", err)
+	}
+
+	return account
+}
+
+// makeNotificationAuthenticatedRequest creates an HTTP request with the account in context.
+func makeNotificationAuthenticatedRequest(t *testing.T, method, path string, body []byte, account *edutrack.Account) *http.Request {
+	var req *http.Request
+	if body != nil {
+		req = httptest.NewRequest(method, path, bytes.NewReader(body))
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	ctx := edutrack.NewContextWithAccount(req.Context(), account)
+	return req.WithContext(ctx)
+}
+
+func TestHandleCreateGrade_NotifiesStudentAndGuardians(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	tenant := createNotificationTestTenant(t, db)
+	teacher := createNotificationTestAccount(t, db, tenant.ID, "teacher@test.com", edutrack.RoleTeacher)
+	studentAccount := createNotificationTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+	guardian := createNotificationTestAccount(t, db, tenant.ID, "guardian@test.com", edutrack.RoleGuardian)
+
+	student := &edutrack.Student{StudentID: "STU-1", AccountID: studentAccount.ID, TenantID: tenant.ID}
+	db.Create(student)
+	db.Create(&edutrack.GuardianLink{AccountID: guardian.ID, StudentID: student.ID, TenantID: tenant.ID})
+
+	subject := &edutrack.Subject{Name: "Matemáticas I", Code: "MAT-1", TenantID: tenant.ID}
+	db.Create(subject)
+	topic := &edutrack.Topic{Name: "Unidad 1", SubjectID: subject.ID, TenantID: tenant.ID}
+	db.Create(topic)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateGradeRequest{Value: 95, StudentID: student.ID, TopicID: topic.ID, Status: edutrack.GradePublished})
+	req := makeNotificationAuthenticatedRequest(t, http.MethodPost, "/grades", body, teacher)
+	w := httptest.NewRecorder()
+
+	server.handleCreateGrade(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("handleCreateGrade() status = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	for _, account := range []*edutrack.Account{studentAccount, guardian} {
+		var count int64
+		db.Model(&edutrack.Notification{}).
+			Where("account_id = ? AND event = ?", account.ID, edutrack.NotificationGradePosted).
+			Count(&count)
+		if count == 0 {
+			t.Errorf("account %s was not notified of the grade", account.Email)
+		}
+	}
+}
+
+func TestHandleCreateAttendance_NotifiesOnlyAbsences(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	tenant := createNotificationTestTenant(t, db)
+	teacher := createNotificationTestAccount(t, db, tenant.ID, "teacher@test.com", edutrack.RoleTeacher)
+	studentAccount := createNotificationTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	student := &edutrack.Student{StudentID: "STU-1", AccountID: studentAccount.ID, TenantID: tenant.ID}
+	db.Create(student)
+	subject := &edutrack.Subject{Name: "Matemáticas I", Code: "MAT-1", TenantID: tenant.ID}
+	db.Create(subject)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	for i, status := range []edutrack.AttendanceStatus{edutrack.AttendancePresent, edutrack.AttendanceAbsent} {
+		body, _ := json.Marshal(CreateAttendanceRequest{
+			Date:      fmt.Sprintf("2024-01-0%d", i+1),
+			Status:    status,
+			StudentID: student.ID,
+			SubjectID: subject.ID,
+		})
+		req := makeNotificationAuthenticatedRequest(t, http.MethodPost, "/attendances", body, teacher)
+		w := httptest.NewRecorder()
+
+		server.handleCreateAttendance(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("handleCreateAttendance() status = %d, want %d", w.Code, http.StatusCreated)
+		}
+	}
+
+	var count int64
+	db.Model(&edutrack.Notification{}).
+		Where("account_id = ? AND event = ? AND channel = ?", studentAccount.ID, edutrack.NotificationAbsenceRecorded, edutrack.ChannelInbox).
+		Count(&count)
+
+	if count != 1 {
+		t.Errorf("student received %d absence notifications, want 1", count)
+	}
+}
+
+func TestHandleListNotifications_OwnInboxOnly(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	tenant := createNotificationTestTenant(t, db)
+	account := createNotificationTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+	other := createNotificationTestAccount(t, db, tenant.ID, "other@test.com", edutrack.RoleStudent)
+
+	db.Create(&edutrack.InboxMessage{Subject: "Mío", AccountID: account.ID, TenantID: tenant.ID})
+	db.Create(&edutrack.InboxMessage{Subject: "Ajeno", AccountID: other.ID, TenantID: tenant.ID})
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeNotificationAuthenticatedRequest(t, http.MethodGet, "/notifications", nil, account)
+	w := httptest.NewRecorder()
+
+	server.handleListNotifications(w, req)
+
+	var messages []InboxMessageResponse
+	if err := json.NewDecoder(w.Body).Decode(&messages); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(messages) != 1 || messages[0].Subject != "Mío" {
+		t.Errorf("handleListNotifications() = %+v, want only the account's message", messages)
+	}
+}
+
+func TestHandleReadNotification_ForbiddenForOtherAccount(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	tenant := createNotificationTestTenant(t, db)
+	account := createNotificationTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+	other := createNotificationTestAccount(t, db, tenant.ID, "other@test.com", edutrack.RoleStudent)
+
+	message := &edutrack.InboxMessage{Subject: "Ajeno", AccountID: other.ID, TenantID: tenant.ID}
+	db.Create(message)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeNotificationAuthenticatedRequest(t, http.MethodPut, fmt.Sprintf("/notifications/%d/read", message.ID), nil, account)
+	req.SetPathValue("id", fmt.Sprintf("%d", message.ID))
+	w := httptest.NewRecorder()
+
+	server.handleReadNotification(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("handleReadNotification() status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleUpdateNotificationPreference(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	tenant := createNotificationTestTenant(t, db)
+	account := createNotificationTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	disabled := false
+	body, _ := json.Marshal(UpdateNotificationPreferenceRequest{
+		Event: edutrack.NotificationGradePosted,
+		Email: &disabled,
+	})
+	req := makeNotificationAuthenticatedRequest(t, http.MethodPut, "/notifications/preferences", body, account)
+	w := httptest.NewRecorder()
+
+	server.handleUpdateNotificationPreference(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleUpdateNotificationPreference() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	pref, _ := edutrack.FindNotificationPreference(db, account.ID, edutrack.NotificationGradePosted)
+	if pref.Email || !pref.Inbox {
+		t.Errorf("preference = %+v, want email disabled and inbox enabled", pref)
+	}
+}
+
+func TestHandleUpdateNotificationPreference_WebhookForbiddenForStudent(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	tenant := createNotificationTestTenant(t, db)
+	account := createNotificationTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	url := "http://127.0.0.1:8080/internal"
+	body, _ := json.Marshal(UpdateNotificationPreferenceRequest{
+		Event:      edutrack.NotificationGradePosted,
+		WebhookURL: &url,
+	})
+	req := makeNotificationAuthenticatedRequest(t, http.MethodPut, "/notifications/preferences", body, account)
+	w := httptest.NewRecorder()
+
+	server.handleUpdateNotificationPreference(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("handleUpdateNotificationPreference() status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if pref, _ := edutrack.FindNotificationPreference(db, account.ID, edutrack.NotificationGradePosted); pref.WebhookURL != "" {
+		t.Errorf("preference WebhookURL = %q, want none", pref.WebhookURL)
+	}
+}
+
+func TestHandleUpdateNotificationPreference_InvalidWebhook(t *testing.T) {
+	db := setupNotificationTestDB(t)
+	tenant := createNotificationTestTenant(t, db)
+	account := createNotificationTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	url := "ftp://example.com"
+	body, _ := json.Marshal(UpdateNotificationPreferenceRequest{
+		Event:      edutrack.NotificationGradePosted,
+		WebhookURL: &url,
+	})
+	req := makeNotificationAuthenticatedRequest(t, http.MethodPut, "/notifications/preferences", body, account)
+	w := httptest.NewRecorder()
+
+	server.handleUpdateNotificationPreference(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleUpdateNotificationPreference() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// TestNotifications_WrittenWithTheEvent checks that a failure to enqueue the
+// notifications of an event rolls back the write that emitted it.
+func TestNotifications_WrittenWithTheEvent(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_notifications", func(tx *gorm.DB) {
+		if tx.Statement.Table == "notifications" {
+			_ = tx.AddError(fmt.Errorf("outbox unavailable"))
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to register callback: %v", err)
+	}
+
+	w := isolationRequest(t, server, tenant, http.MethodPost, "/grades", map[string]any{
+		"value": 8, "status": "published", "student_id": tenant.Student.ID, "topic_id": tenant.Topic.ID,
+	})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("POST /grades status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	var grades int64
+	db.Model(&edutrack.Grade{}).Where("student_id = ? AND value = ?", tenant.Student.ID, 8).Count(&grades)
+	if grades != 0 {
+		t.Errorf("Grades after a failed notification = %d, want 0", grades)
+	}
+
+	var before int64
+	db.Model(&edutrack.Attendance{}).Where("student_id = ?", tenant.Student.ID).Count(&before)
+	w = isolationRequest(t, server, tenant, http.MethodPost, "/attendances", map[string]any{
+		"date": "2026-03-04", "status": "absent", "student_id": tenant.Student.ID, "subject_id": tenant.Subject.ID,
+	})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("POST /attendances status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	var after int64
+	db.Model(&edutrack.Attendance{}).Where("student_id = ?", tenant.Student.ID).Count(&after)
+	if after != before {
+		t.Errorf("Attendances after a failed notification = %d, want %d", after, before)
+	}
+}
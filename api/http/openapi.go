@@ -0,0 +1,595 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification served by the API.
+const OpenAPIVersion = "3.0.3"
+
+// operation describes a route of the API for the OpenAPI specification.
+type operation struct {
+	// Route pattern as registered in registerRoutes (e.g., "GET /students/{id}").
+	Pattern string
+
+	// Short description of the operation.
+	Summary string
+
+	// Group of the operation (usually the resource).
+	Tag string
+
+	// Whether the route is public (no authentication required).
+	Public bool
+
+	// Supported query parameters.
+	Query []string
+
+	// Related objects that can be embedded with the include query parameter.
+	Include []string
+
+	// Request body type, nil if the route has no body.
+	Request any
+
+	// Whether the request body is a multipart/form-data upload of a single
+	// file in the "file" field, along with the fields of Request if any.
+	Upload bool
+
+	// Success status code and response body type, nil if the route has no body.
+	Status   int
+	Response any
+
+	// Media type of the response body, "application/json" if empty.
+	ContentType string
+
+	// Documented responses other than the success and error ones. Nil
+	// bodies document responses without content, such as redirects.
+	Extra map[int]any
+
+	// Whether the resource has an ETag: successful responses with a body
+	// return it, and updates and deletes require an If-Match precondition.
+	Versioned bool
+}
+
+// operations lists every route served by the API.
+// Request and response values are only used for their types.
+var operations = []operation{
+	{Pattern: "GET /openapi.json", Summary: "Especificación OpenAPI de la API", Tag: "meta", Public: true, Status: http.StatusOK, Response: map[string]any{}},
+	{Pattern: "GET /metrics", Summary: "Métricas en formato de texto de Prometheus", Tag: "meta", Public: true, Status: http.StatusOK, Response: "", ContentType: "text/plain"},
+	{Pattern: "GET /healthz", Summary: "Verificar que el proceso está vivo", Tag: "meta", Public: true, Status: http.StatusOK, Response: HealthResponse{}},
+	{Pattern: "GET /readyz", Summary: "Verificar que el servidor puede recibir tráfico", Tag: "meta", Public: true, Status: http.StatusOK, Response: HealthResponse{}, Extra: map[int]any{http.StatusServiceUnavailable: HealthResponse{}}},
+
+	// Auth
+	{Pattern: "POST /auth/login", Summary: "Iniciar sesión con email y contraseña", Tag: "auth", Public: true, Request: LoginRequest{}, Status: http.StatusOK, Response: LoginResponse{}},
+	{Pattern: "POST /auth/license", Summary: "Validar una llave de licencia", Tag: "auth", Public: true, Request: LicenseLoginRequest{}, Status: http.StatusOK, Response: LicenseLoginResponse{}},
+
+	// Files
+	{Pattern: "GET " + FilesPath + "/{key...}", Summary: "Descargar un archivo con una URL firmada", Tag: "files", Public: true, Query: []string{"expires", "signature"}, Status: http.StatusOK, Response: "", ContentType: "application/octet-stream"},
+
+	// Accounts
+	{Pattern: "GET /accounts", Summary: "Listar cuentas", Tag: "accounts", Query: []string{"name", "email", "active"}, Status: http.StatusOK, Response: []AccountResponse{}},
+	{Pattern: "GET /accounts/{id}", Summary: "Obtener una cuenta", Tag: "accounts", Status: http.StatusOK, Response: AccountResponse{}, Versioned: true},
+	{Pattern: "POST /accounts", Summary: "Crear una cuenta", Tag: "accounts", Request: CreateAccountRequest{}, Status: http.StatusCreated, Response: AccountResponse{}, Versioned: true},
+	{Pattern: "PUT /accounts/{id}", Summary: "Actualizar una cuenta", Tag: "accounts", Request: UpdateAccountRequest{}, Status: http.StatusOK, Response: AccountResponse{}, Versioned: true},
+	{Pattern: "PATCH /accounts/{id}", Summary: "Actualizar parcialmente una cuenta (JSON Merge Patch)", Tag: "accounts", Request: UpdateAccountRequest{}, Status: http.StatusOK, Response: AccountResponse{}, Versioned: true},
+	{Pattern: "GET /accounts/{id}/avatar", Summary: "Redirigir a la URL firmada del avatar de una cuenta", Tag: "accounts", Status: http.StatusTemporaryRedirect},
+	{Pattern: "PUT /accounts/{id}/avatar", Summary: "Subir el avatar de una cuenta (PNG, JPEG o WebP)", Tag: "accounts", Upload: true, Status: http.StatusOK, Response: AccountResponse{}, Versioned: true},
+	{Pattern: "DELETE /accounts/{id}/avatar", Summary: "Eliminar el avatar de una cuenta", Tag: "accounts", Status: http.StatusNoContent},
+	{Pattern: "DELETE /accounts/{id}", Summary: "Eliminar una cuenta", Tag: "accounts", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+
+	// Students
+	{Pattern: "GET /students", Summary: "Listar alumnos", Tag: "students", Query: []string{"career_id", "semester", "student_id", "name"}, Include: studentIncludes, Status: http.StatusOK, Response: []StudentResponse{}},
+	{Pattern: "GET /students/{id}", Summary: "Obtener un alumno", Tag: "students", Include: studentIncludes, Status: http.StatusOK, Response: StudentResponse{}, Versioned: true},
+	{Pattern: "POST /students", Summary: "Registrar un alumno", Tag: "students", Include: studentIncludes, Request: CreateStudentRequest{}, Status: http.StatusCreated, Response: StudentResponse{}, Versioned: true},
+	{Pattern: "PUT /students/{id}", Summary: "Actualizar un alumno", Tag: "students", Include: studentIncludes, Request: UpdateStudentRequest{}, Status: http.StatusOK, Response: StudentResponse{}, Versioned: true},
+	{Pattern: "PATCH /students/{id}", Summary: "Actualizar parcialmente un alumno (JSON Merge Patch)", Tag: "students", Include: studentIncludes, Request: UpdateStudentRequest{}, Status: http.StatusOK, Response: StudentResponse{}, Versioned: true},
+	{Pattern: "DELETE /students/{id}", Summary: "Eliminar un alumno", Tag: "students", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+
+	// Guardians
+	{Pattern: "GET /guardians", Summary: "Listar tutores", Tag: "guardians", Query: []string{"name", "student_id"}, Include: guardianLinkIncludes, Status: http.StatusOK, Response: []GuardianResponse{}},
+	{Pattern: "GET /guardians/{id}", Summary: "Obtener un tutor", Tag: "guardians", Include: guardianLinkIncludes, Status: http.StatusOK, Response: GuardianResponse{}},
+	{Pattern: "POST /guardians", Summary: "Invitar a un tutor", Tag: "guardians", Include: guardianLinkIncludes, Request: CreateGuardianRequest{}, Status: http.StatusCreated, Response: GuardianResponse{}},
+	{Pattern: "POST /guardians/{id}/students", Summary: "Vincular un alumno a un tutor", Tag: "guardians", Include: guardianLinkIncludes, Request: LinkGuardianStudentRequest{}, Status: http.StatusCreated, Response: GuardianLinkResponse{}},
+	{Pattern: "DELETE /guardians/{id}/students/{student_id}", Summary: "Desvincular un alumno de un tutor", Tag: "guardians", Status: http.StatusNoContent},
+
+	// Teachers
+	{Pattern: "GET /teachers", Summary: "Listar docentes", Tag: "teachers", Query: []string{"name", "account_id"}, Include: teacherIncludes, Status: http.StatusOK, Response: []TeacherResponse{}},
+	{Pattern: "GET /teachers/{id}", Summary: "Obtener un docente", Tag: "teachers", Include: teacherIncludes, Status: http.StatusOK, Response: TeacherResponse{}, Versioned: true},
+	{Pattern: "POST /teachers", Summary: "Registrar un docente", Tag: "teachers", Include: teacherIncludes, Request: CreateTeacherRequest{}, Status: http.StatusCreated, Response: TeacherResponse{}, Versioned: true},
+	{Pattern: "PUT /teachers/{id}", Summary: "Actualizar un docente", Tag: "teachers", Include: teacherIncludes, Request: UpdateTeacherRequest{}, Status: http.StatusOK, Response: TeacherResponse{}, Versioned: true},
+	{Pattern: "PATCH /teachers/{id}", Summary: "Actualizar parcialmente un docente (JSON Merge Patch)", Tag: "teachers", Include: teacherIncludes, Request: UpdateTeacherRequest{}, Status: http.StatusOK, Response: TeacherResponse{}, Versioned: true},
+	{Pattern: "DELETE /teachers/{id}", Summary: "Eliminar un docente", Tag: "teachers", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+
+	// Careers
+	{Pattern: "GET /careers", Summary: "Listar carreras", Tag: "careers", Query: []string{"name", "code", "active"}, Status: http.StatusOK, Response: []CareerResponse{}},
+	{Pattern: "GET /careers/{id}", Summary: "Obtener una carrera", Tag: "careers", Status: http.StatusOK, Response: CareerResponse{}, Versioned: true},
+	{Pattern: "POST /careers", Summary: "Crear una carrera", Tag: "careers", Request: CreateCareerRequest{}, Status: http.StatusCreated, Response: CareerResponse{}, Versioned: true},
+	{Pattern: "PUT /careers/{id}", Summary: "Actualizar una carrera", Tag: "careers", Request: UpdateCareerRequest{}, Status: http.StatusOK, Response: CareerResponse{}, Versioned: true},
+	{Pattern: "PATCH /careers/{id}", Summary: "Actualizar parcialmente una carrera (JSON Merge Patch)", Tag: "careers", Request: UpdateCareerRequest{}, Status: http.StatusOK, Response: CareerResponse{}, Versioned: true},
+	{Pattern: "DELETE /careers/{id}", Summary: "Eliminar una carrera", Tag: "careers", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+
+	// Subjects
+	{Pattern: "GET /subjects", Summary: "Listar materias", Tag: "subjects", Query: []string{"name", "code", "teacher_id", "career_id", "semester"}, Include: subjectIncludes, Status: http.StatusOK, Response: []SubjectResponse{}},
+	{Pattern: "GET /subjects/{id}", Summary: "Obtener una materia", Tag: "subjects", Include: subjectIncludes, Status: http.StatusOK, Response: SubjectResponse{}, Versioned: true},
+	{Pattern: "POST /subjects", Summary: "Crear una materia", Tag: "subjects", Include: subjectIncludes, Request: CreateSubjectRequest{}, Status: http.StatusCreated, Response: SubjectResponse{}, Versioned: true},
+	{Pattern: "PUT /subjects/{id}", Summary: "Actualizar una materia", Tag: "subjects", Include: subjectIncludes, Request: UpdateSubjectRequest{}, Status: http.StatusOK, Response: SubjectResponse{}, Versioned: true},
+	{Pattern: "PATCH /subjects/{id}", Summary: "Actualizar parcialmente una materia (JSON Merge Patch)", Tag: "subjects", Include: subjectIncludes, Request: UpdateSubjectRequest{}, Status: http.StatusOK, Response: SubjectResponse{}, Versioned: true},
+	{Pattern: "DELETE /subjects/{id}", Summary: "Eliminar una materia", Tag: "subjects", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+	{Pattern: "GET /subjects/{id}/students", Summary: "Listar alumnos inscritos en una materia", Tag: "subjects", Include: studentIncludes, Status: http.StatusOK, Response: []StudentResponse{}},
+
+	// Sections
+	{Pattern: "GET /sections", Summary: "Listar grupos", Tag: "sections", Query: []string{"subject_id", "teacher_id"}, Include: sectionIncludes, Status: http.StatusOK, Response: []SectionResponse{}},
+	{Pattern: "GET /sections/{id}", Summary: "Obtener un grupo", Tag: "sections", Include: sectionIncludes, Status: http.StatusOK, Response: SectionResponse{}, Versioned: true},
+	{Pattern: "POST /sections", Summary: "Crear un grupo de una materia", Tag: "sections", Include: sectionIncludes, Request: CreateSectionRequest{}, Status: http.StatusCreated, Response: SectionResponse{}, Versioned: true},
+	{Pattern: "PUT /sections/{id}", Summary: "Actualizar un grupo", Tag: "sections", Include: sectionIncludes, Request: UpdateSectionRequest{}, Status: http.StatusOK, Response: SectionResponse{}, Versioned: true},
+	{Pattern: "PATCH /sections/{id}", Summary: "Actualizar parcialmente un grupo (JSON Merge Patch)", Tag: "sections", Include: sectionIncludes, Request: UpdateSectionRequest{}, Status: http.StatusOK, Response: SectionResponse{}, Versioned: true},
+	{Pattern: "DELETE /sections/{id}", Summary: "Eliminar un grupo", Tag: "sections", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+	{Pattern: "GET /sections/{id}/students", Summary: "Listar alumnos inscritos y en lista de espera de un grupo", Tag: "sections", Include: enrollmentIncludes, Status: http.StatusOK, Response: []EnrollmentResponse{}},
+	{Pattern: "POST /sections/{id}/students", Summary: "Inscribir un alumno en un grupo o en su lista de espera", Tag: "sections", Include: enrollmentIncludes, Request: EnrollStudentRequest{}, Status: http.StatusCreated, Response: EnrollmentResponse{}},
+	{Pattern: "DELETE /sections/{id}/students/{student_id}", Summary: "Dar de baja a un alumno de un grupo", Tag: "sections", Status: http.StatusNoContent},
+
+	// Topics
+	{Pattern: "GET /topics", Summary: "Listar temas", Tag: "topics", Query: []string{"subject_id"}, Include: topicIncludes, Status: http.StatusOK, Response: []TopicResponse{}},
+	{Pattern: "GET /topics/{id}", Summary: "Obtener un tema", Tag: "topics", Include: topicIncludes, Status: http.StatusOK, Response: TopicResponse{}, Versioned: true},
+	{Pattern: "POST /topics", Summary: "Crear un tema", Tag: "topics", Include: topicIncludes, Request: CreateTopicRequest{}, Status: http.StatusCreated, Response: TopicResponse{}, Versioned: true},
+	{Pattern: "PUT /topics/{id}", Summary: "Actualizar un tema", Tag: "topics", Include: topicIncludes, Request: UpdateTopicRequest{}, Status: http.StatusOK, Response: TopicResponse{}, Versioned: true},
+	{Pattern: "PATCH /topics/{id}", Summary: "Actualizar parcialmente un tema (JSON Merge Patch)", Tag: "topics", Include: topicIncludes, Request: UpdateTopicRequest{}, Status: http.StatusOK, Response: TopicResponse{}, Versioned: true},
+	{Pattern: "DELETE /topics/{id}", Summary: "Eliminar un tema", Tag: "topics", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+	{Pattern: "POST /topics/{id}/publish", Summary: "Publicar las calificaciones en borrador de un tema", Tag: "topics", Status: http.StatusOK, Response: PublishTopicGradesResponse{}},
+
+	// Attendances
+	{Pattern: "GET /attendances", Summary: "Listar asistencias", Tag: "attendances", Query: []string{"student_id", "subject_id", "section_id", "date", "justification_id"}, Include: attendanceIncludes, Status: http.StatusOK, Response: []AttendanceResponse{}},
+	{Pattern: "GET /attendances/{id}", Summary: "Obtener una asistencia", Tag: "attendances", Include: attendanceIncludes, Status: http.StatusOK, Response: AttendanceResponse{}, Versioned: true},
+	{Pattern: "POST /attendances", Summary: "Registrar una asistencia", Tag: "attendances", Include: attendanceIncludes, Request: CreateAttendanceRequest{}, Status: http.StatusCreated, Response: AttendanceResponse{}, Versioned: true},
+	{Pattern: "PUT /attendances/{id}", Summary: "Actualizar una asistencia", Tag: "attendances", Include: attendanceIncludes, Request: UpdateAttendanceRequest{}, Status: http.StatusOK, Response: AttendanceResponse{}, Versioned: true},
+	{Pattern: "PATCH /attendances/{id}", Summary: "Actualizar parcialmente una asistencia (JSON Merge Patch)", Tag: "attendances", Include: attendanceIncludes, Request: UpdateAttendanceRequest{}, Status: http.StatusOK, Response: AttendanceResponse{}, Versioned: true},
+	{Pattern: "DELETE /attendances/{id}", Summary: "Eliminar una asistencia", Tag: "attendances", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+
+	// Absence justifications
+	{Pattern: "GET /justifications", Summary: "Listar justificaciones de inasistencias", Tag: "justifications", Query: []string{"student_id", "status"}, Include: justificationIncludes, Status: http.StatusOK, Response: []JustificationResponse{}},
+	{Pattern: "GET /justifications/{id}", Summary: "Obtener una justificación", Tag: "justifications", Include: justificationIncludes, Status: http.StatusOK, Response: JustificationResponse{}},
+	{Pattern: "POST /justifications", Summary: "Solicitar la justificación de inasistencias con un documento (PDF, PNG, JPEG o WebP)", Tag: "justifications", Include: justificationIncludes, Request: CreateJustificationRequest{}, Upload: true, Status: http.StatusCreated, Response: JustificationResponse{}},
+	{Pattern: "GET /justifications/{id}/document", Summary: "Redirigir a la URL firmada del documento de una justificación", Tag: "justifications", Status: http.StatusTemporaryRedirect},
+	{Pattern: "POST /justifications/{id}/approve", Summary: "Aprobar una justificación y justificar las inasistencias", Tag: "justifications", Request: ReviewJustificationRequest{}, Status: http.StatusOK, Response: ReviewJustificationResponse{}},
+	{Pattern: "POST /justifications/{id}/reject", Summary: "Rechazar una justificación", Tag: "justifications", Request: ReviewJustificationRequest{}, Status: http.StatusOK, Response: ReviewJustificationResponse{}},
+
+	// Notifications
+	{Pattern: "GET /notifications", Summary: "Listar la bandeja de notificaciones", Tag: "notifications", Query: []string{"unread"}, Status: http.StatusOK, Response: []InboxMessageResponse{}},
+	{Pattern: "PUT /notifications/{id}/read", Summary: "Marcar una notificación como leída", Tag: "notifications", Status: http.StatusOK, Response: InboxMessageResponse{}},
+	{Pattern: "GET /notifications/preferences", Summary: "Listar preferencias de notificación", Tag: "notifications", Status: http.StatusOK, Response: []NotificationPreferenceResponse{}},
+	{Pattern: "PUT /notifications/preferences", Summary: "Actualizar la preferencia de un evento", Tag: "notifications", Request: UpdateNotificationPreferenceRequest{}, Status: http.StatusOK, Response: NotificationPreferenceResponse{}},
+
+	// Tenant settings and logo
+	{Pattern: "GET /tenant/settings", Summary: "Obtener la configuración de la institución", Tag: "tenant", Status: http.StatusOK, Response: TenantSettingsResponse{}},
+	{Pattern: "PUT /tenant/settings", Summary: "Actualizar la configuración de la institución", Tag: "tenant", Request: UpdateTenantSettingsRequest{}, Status: http.StatusOK, Response: TenantSettingsResponse{}},
+	{Pattern: "GET /tenant/logo", Summary: "Redirigir a la URL firmada del logo de la institución", Tag: "tenant", Status: http.StatusTemporaryRedirect},
+	{Pattern: "PUT /tenant/logo", Summary: "Subir el logo de la institución (PNG, JPEG o WebP)", Tag: "tenant", Upload: true, Status: http.StatusNoContent},
+	{Pattern: "DELETE /tenant/logo", Summary: "Eliminar el logo de la institución", Tag: "tenant", Status: http.StatusNoContent},
+
+	// Webhooks
+	{Pattern: "GET /webhooks", Summary: "Listar webhooks", Tag: "webhooks", Status: http.StatusOK, Response: []WebhookResponse{}},
+	{Pattern: "GET /webhooks/{id}", Summary: "Obtener un webhook", Tag: "webhooks", Status: http.StatusOK, Response: WebhookResponse{}, Versioned: true},
+	{Pattern: "POST /webhooks", Summary: "Registrar un webhook", Tag: "webhooks", Request: CreateWebhookRequest{}, Status: http.StatusCreated, Response: WebhookResponse{}, Versioned: true},
+	{Pattern: "PUT /webhooks/{id}", Summary: "Actualizar un webhook", Tag: "webhooks", Request: UpdateWebhookRequest{}, Status: http.StatusOK, Response: WebhookResponse{}, Versioned: true},
+	{Pattern: "PATCH /webhooks/{id}", Summary: "Actualizar parcialmente un webhook (JSON Merge Patch)", Tag: "webhooks", Request: UpdateWebhookRequest{}, Status: http.StatusOK, Response: WebhookResponse{}, Versioned: true},
+	{Pattern: "DELETE /webhooks/{id}", Summary: "Eliminar un webhook", Tag: "webhooks", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+	{Pattern: "GET /webhooks/{id}/deliveries", Summary: "Listar entregas de un webhook", Tag: "webhooks", Query: []string{"status", "event"}, Status: http.StatusOK, Response: []WebhookDeliveryResponse{}},
+	{Pattern: "POST /webhooks/{id}/deliveries/{delivery_id}/replay", Summary: "Reenviar una entrega", Tag: "webhooks", Status: http.StatusAccepted, Response: WebhookDeliveryResponse{}},
+
+	// API keys
+	{Pattern: "GET /api-keys", Summary: "Listar llaves de API", Tag: "api-keys", Status: http.StatusOK, Response: []APIKeyResponse{}},
+	{Pattern: "POST /api-keys", Summary: "Crear una llave de API", Tag: "api-keys", Request: CreateAPIKeyRequest{}, Status: http.StatusCreated, Response: APIKeyResponse{}},
+	{Pattern: "DELETE /api-keys/{id}", Summary: "Revocar una llave de API", Tag: "api-keys", Status: http.StatusNoContent},
+
+	// Trash
+	{Pattern: "GET /trash", Summary: "Listar registros eliminados", Tag: "trash", Query: []string{"type"}, Status: http.StatusOK, Response: []TrashItemResponse{}},
+	{Pattern: "POST /trash/{type}/{id}/restore", Summary: "Restaurar un registro eliminado con sus asociaciones", Tag: "trash", Status: http.StatusOK, Response: []TrashItemResponse{}},
+	{Pattern: "DELETE /trash/{type}/{id}", Summary: "Eliminar permanentemente un registro", Tag: "trash", Status: http.StatusNoContent},
+
+	// Grades
+	{Pattern: "GET /grades", Summary: "Listar calificaciones", Tag: "grades", Query: []string{"student_id", "topic_id", "section_id", "status"}, Include: gradeIncludes, Status: http.StatusOK, Response: []GradeResponse{}},
+	{Pattern: "GET /grades/{id}", Summary: "Obtener una calificación", Tag: "grades", Include: gradeIncludes, Status: http.StatusOK, Response: GradeResponse{}, Versioned: true},
+	{Pattern: "POST /grades", Summary: "Registrar una calificación", Tag: "grades", Include: gradeIncludes, Request: CreateGradeRequest{}, Status: http.StatusCreated, Response: GradeResponse{}, Versioned: true},
+	{Pattern: "PUT /grades/{id}", Summary: "Actualizar una calificación", Tag: "grades", Include: gradeIncludes, Request: UpdateGradeRequest{}, Status: http.StatusOK, Response: GradeResponse{}, Versioned: true},
+	{Pattern: "PATCH /grades/{id}", Summary: "Actualizar parcialmente una calificación (JSON Merge Patch)", Tag: "grades", Include: gradeIncludes, Request: UpdateGradeRequest{}, Status: http.StatusOK, Response: GradeResponse{}, Versioned: true},
+	{Pattern: "DELETE /grades/{id}", Summary: "Eliminar una calificación", Tag: "grades", Query: []string{"preview"}, Status: http.StatusNoContent, Extra: map[int]any{http.StatusOK: edutrack.DeletePreview{}}, Versioned: true},
+	{Pattern: "GET /grades/{id}/amendments", Summary: "Listar las modificaciones de una calificación bloqueada", Tag: "grades", Status: http.StatusOK, Response: []GradeAmendmentResponse{}},
+
+	// Grade appeals
+	{Pattern: "GET /appeals", Summary: "Listar apelaciones de calificaciones", Tag: "appeals", Query: []string{"grade_id", "student_id", "status"}, Include: appealIncludes, Status: http.StatusOK, Response: []GradeAppealResponse{}},
+	{Pattern: "GET /appeals/{id}", Summary: "Obtener una apelación con sus mensajes", Tag: "appeals", Include: appealIncludes, Status: http.StatusOK, Response: GradeAppealResponse{}},
+	{Pattern: "POST /grades/{id}/appeals", Summary: "Apelar una calificación publicada", Tag: "appeals", Include: appealIncludes, Request: CreateAppealRequest{}, Status: http.StatusCreated, Response: GradeAppealResponse{}},
+	{Pattern: "POST /appeals/{id}/messages", Summary: "Agregar un mensaje a una apelación", Tag: "appeals", Request: AppealMessageRequest{}, Status: http.StatusCreated, Response: GradeAppealMessageResponse{}},
+	{Pattern: "POST /appeals/{id}/escalate", Summary: "Turnar una apelación a la decisión de los secretarios", Tag: "appeals", Request: EscalateAppealRequest{}, Status: http.StatusOK, Response: GradeAppealResponse{}},
+	{Pattern: "POST /appeals/{id}/accept", Summary: "Aceptar una apelación y opcionalmente cambiar la calificación", Tag: "appeals", Request: AcceptAppealRequest{}, Status: http.StatusOK, Response: GradeAppealResponse{}},
+	{Pattern: "POST /appeals/{id}/reject", Summary: "Rechazar una apelación", Tag: "appeals", Request: AppealMessageRequest{}, Status: http.StatusOK, Response: GradeAppealResponse{}},
+
+	// Exam attempts
+	{Pattern: "GET /exams", Summary: "Listar exámenes ordinarios, extraordinarios y especiales", Tag: "exams", Query: []string{"student_id", "subject_id", "kind"}, Include: examIncludes, Status: http.StatusOK, Response: []ExamAttemptResponse{}},
+	{Pattern: "GET /exams/{id}", Summary: "Obtener un examen", Tag: "exams", Include: examIncludes, Status: http.StatusOK, Response: ExamAttemptResponse{}},
+	{Pattern: "POST /exams", Summary: "Programar un examen de un alumno", Tag: "exams", Include: examIncludes, Request: CreateExamRequest{}, Status: http.StatusCreated, Response: ExamAttemptResponse{}},
+	{Pattern: "POST /exams/{id}/grade", Summary: "Registrar la calificación de un examen", Tag: "exams", Request: GradeExamRequest{}, Status: http.StatusOK, Response: ExamAttemptResponse{}},
+	{Pattern: "DELETE /exams/{id}", Summary: "Cancelar el último examen de un alumno en una materia", Tag: "exams", Status: http.StatusNoContent},
+}
+
+var (
+	openAPISpecOnce sync.Once
+	openAPISpec     map[string]any
+)
+
+// OpenAPISpec returns the OpenAPI document describing every route of the API.
+// Schemas are generated from the Go request and response types, so they
+// follow the JSON encoding of the handlers.
+func OpenAPISpec() map[string]any {
+	openAPISpecOnce.Do(func() {
+		openAPISpec = buildOpenAPISpec()
+	})
+	return openAPISpec
+}
+
+// handleOpenAPI handles GET /openapi.json.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, http.StatusOK, OpenAPISpec())
+}
+
+// buildOpenAPISpec generates the OpenAPI document from the operations table.
+func buildOpenAPISpec() map[string]any {
+	schemas := newSchemaGenerator()
+	errorSchema := schemas.schemaOf(reflect.TypeOf(ErrorResponse{}))
+
+	paths := map[string]any{}
+	for _, op := range operations {
+		method, path, _ := strings.Cut(op.Pattern, " ")
+		// Wildcards matching the rest of the path ({key...}) are plain
+		// parameters in OpenAPI.
+		path = strings.ReplaceAll(path, "...}", "}")
+
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[path] = item
+		}
+
+		var params []any
+		for _, name := range pathParameters(path) {
+			// Path parameters are IDs, except for names such as {type}.
+			schema := map[string]any{"type": "string"}
+			if name == "id" || strings.HasSuffix(name, "_id") {
+				schema = map[string]any{"type": "integer", "minimum": 0}
+			}
+			params = append(params, map[string]any{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   schema,
+			})
+		}
+		for _, name := range op.Query {
+			params = append(params, map[string]any{
+				"name":   name,
+				"in":     "query",
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+
+		if op.Include != nil {
+			params = append(params, map[string]any{
+				"name":        "include",
+				"in":          "query",
+				"description": "Objetos relacionados a incluir, separados por comas: " + strings.Join(op.Include, ", ") + ".",
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+
+		locales := make([]string, 0, len(i18n.Locales()))
+		for _, locale := range i18n.Locales() {
+			locales = append(locales, string(locale))
+		}
+		params = append(params, map[string]any{
+			"name":        "Accept-Language",
+			"in":          "header",
+			"description": "Idioma de los mensajes (" + strings.Join(locales, ", ") + "); la preferencia de la cuenta tiene prioridad.",
+			"schema":      map[string]any{"type": "string"},
+		})
+
+		conditional := op.Versioned && (method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete)
+		if conditional {
+			params = append(params, map[string]any{
+				"name":        "If-Match",
+				"in":          "header",
+				"required":    true,
+				"description": "ETag de la versión del recurso que se modifica.",
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+
+		success := map[string]any{"description": http.StatusText(op.Status)}
+		if op.Versioned && op.Response != nil {
+			success["headers"] = map[string]any{
+				"ETag": map[string]any{
+					"description": "Versión del recurso, para el encabezado If-Match.",
+					"schema":      map[string]any{"type": "string"},
+				},
+			}
+		}
+		if op.Response != nil {
+			contentType := op.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			success["content"] = map[string]any{
+				contentType: map[string]any{"schema": schemas.schemaOf(reflect.TypeOf(op.Response))},
+			}
+		}
+
+		responses := map[string]any{
+			fmt.Sprint(op.Status): success,
+			"default": map[string]any{
+				"description": "Error",
+				"content":     jsonContent(errorSchema),
+			},
+		}
+		if conditional {
+			for _, status := range []int{http.StatusPreconditionFailed, http.StatusPreconditionRequired} {
+				responses[fmt.Sprint(status)] = map[string]any{
+					"description": http.StatusText(status),
+					"content":     jsonContent(errorSchema),
+				}
+			}
+		}
+		for status, body := range op.Extra {
+			response := map[string]any{"description": http.StatusText(status)}
+			if body != nil {
+				response["content"] = jsonContent(schemas.schemaOf(reflect.TypeOf(body)))
+			}
+			responses[fmt.Sprint(status)] = response
+		}
+
+		spec := map[string]any{
+			"operationId": operationID(method, path),
+			"summary":     op.Summary,
+			"tags":        []string{op.Tag},
+			"responses":   responses,
+		}
+		if params != nil {
+			spec["parameters"] = params
+		}
+		if op.Request != nil {
+			content := jsonContent(schemas.schemaOf(reflect.TypeOf(op.Request)))
+			if method == http.MethodPatch {
+				content = map[string]any{MergePatchContentType: content["application/json"]}
+			}
+			spec["requestBody"] = map[string]any{
+				"required": true,
+				"content":  content,
+			}
+		}
+		if op.Upload {
+			var schema any = map[string]any{
+				"type":     "object",
+				"required": []string{"file"},
+				"properties": map[string]any{
+					"file": map[string]any{"type": "string", "format": "binary"},
+				},
+			}
+			if op.Request != nil {
+				schema = map[string]any{"allOf": []any{schemas.schemaOf(reflect.TypeOf(op.Request)), schema}}
+			}
+			spec["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"multipart/form-data": map[string]any{"schema": schema},
+				},
+			}
+		}
+		if op.Public {
+			spec["security"] = []any{}
+		}
+
+		item[strings.ToLower(method)] = spec
+	}
+
+	return map[string]any{
+		"openapi": OpenAPIVersion,
+		"info": map[string]any{
+			"title":       "EduTrack API",
+			"version":     "1.0.0",
+			"description": "API de EduTrack para la gestión escolar.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "Token JWT de /auth/login o llave de API (et_...).",
+				},
+			},
+		},
+		"security": []any{map[string]any{"bearerAuth": []any{}}},
+	}
+}
+
+// jsonContent returns the content map of a JSON body with the given schema.
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{
+		"application/json": map[string]any{"schema": schema},
+	}
+}
+
+// pathParameters returns the names of the wildcards in a route path.
+func pathParameters(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.Trim(segment, "{}"))
+		}
+	}
+	return names
+}
+
+// operationID builds a unique identifier for an operation
+// (e.g., "GET /students/{id}" becomes "getStudentsById").
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// schemaGenerator builds JSON schemas from Go types, following the rules of
+// encoding/json. Named structs are stored as reusable components.
+type schemaGenerator struct {
+	components map[string]any
+	types      map[string]reflect.Type
+}
+
+// newSchemaGenerator returns an empty schema generator.
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components: map[string]any{},
+		types:      map[string]reflect.Type{},
+	}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+)
+
+// schemaOf returns the schema of the given type.
+func (g *schemaGenerator) schemaOf(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case deletedAtType:
+		return map[string]any{"type": "string", "format": "date-time", "nullable": true}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schemaOf(t.Elem()))
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		// Nil slices are encoded as null.
+		return nullable(map[string]any{"type": "array", "items": g.schemaOf(t.Elem())})
+	case reflect.Map:
+		return nullable(map[string]any{"type": "object", "additionalProperties": g.schemaOf(t.Elem())})
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		// Interfaces can hold any value.
+		return map[string]any{}
+	}
+}
+
+// structRef returns a reference to the component of a struct type,
+// generating the component the first time the type is seen.
+func (g *schemaGenerator) structRef(t reflect.Type) map[string]any {
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+
+	name := t.Name()
+	if seen, ok := g.types[name]; ok {
+		if seen != t {
+			panic(fmt.Sprintf("openapi: schema name %q used by %s and %s", name, seen, t))
+		}
+	} else {
+		g.types[name] = t
+
+		// Reserve the name before generating the fields so recursive types
+		// reference the component instead of recursing forever.
+		g.components[name] = nil
+		g.components[name] = g.structSchema(t)
+	}
+
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// structSchema returns the object schema of a struct type.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	g.addFields(t, properties, &required)
+	sort.Strings(required)
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the JSON fields of a struct to the properties, flattening
+// embedded structs like encoding/json does.
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema := g.schemaOf(field.Type)
+		validationSchema(schema, field.Tag.Get("validate"))
+		properties[name] = schema
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable marks a schema as accepting null values.
+func nullable(schema map[string]any) map[string]any {
+	if _, ok := schema["$ref"]; ok {
+		return map[string]any{"allOf": []any{schema}, "nullable": true}
+	}
+	schema["nullable"] = true
+	return schema
+}
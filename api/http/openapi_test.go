@@ -0,0 +1,527 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// setupOpenAPITestDB creates an in-memory SQLite database for testing.
+func setupOpenAPITestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// loadOpenAPITestSpec returns the served specification decoded as generic JSON.
+func loadOpenAPITestSpec(t *testing.T, server *Server) map[string]any {
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var spec map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&spec); err != nil {
+		t.Fatalf("Failed to decode specification: %v", err)
+	}
+	return spec
+}
+
+// validateOpenAPIResponse checks the recorded response of a route matches the
+// response documented in the specification for its status code.
+func validateOpenAPIResponse(t *testing.T, spec map[string]any, pattern string, w *httptest.ResponseRecorder) {
+	t.Helper()
+
+	method, path, _ := strings.Cut(pattern, " ")
+	item, _ := spec["paths"].(map[string]any)[path].(map[string]any)
+	op, ok := item[strings.ToLower(method)].(map[string]any)
+	if !ok {
+		t.Fatalf("%s: operation not documented", pattern)
+	}
+
+	responses := op["responses"].(map[string]any)
+	response, ok := responses[fmt.Sprint(w.Code)].(map[string]any)
+	if !ok {
+		response = responses["default"].(map[string]any)
+	}
+
+	content, ok := response["content"].(map[string]any)
+	if !ok {
+		if w.Body.Len() != 0 {
+			t.Errorf("%s: expected empty body for status %d, got %s", pattern, w.Code, w.Body.String())
+		}
+		return
+	}
+
+	media, ok := content["application/json"].(map[string]any)
+	if !ok {
+		// Only JSON bodies are validated.
+		return
+	}
+	schema := media["schema"]
+
+	var body any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: invalid JSON body: %v", pattern, err)
+	}
+
+	if err := validateOpenAPISchema(spec, schema, body, "body"); err != nil {
+		t.Errorf("%s (%d): %v", pattern, w.Code, err)
+	}
+}
+
+// validateOpenAPISchema validates a decoded JSON value against a schema of the
+// specification. It supports the subset of OpenAPI the generator produces.
+func validateOpenAPISchema(spec map[string]any, schema any, value any, at string) error {
+	s, _ := schema.(map[string]any)
+
+	if ref, ok := s["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		target, ok := spec["components"].(map[string]any)["schemas"].(map[string]any)[name]
+		if !ok {
+			return fmt.Errorf("%s: unresolved reference %s", at, ref)
+		}
+		return validateOpenAPISchema(spec, target, value, at)
+	}
+
+	if value == nil {
+		if s["nullable"] == true || len(s) == 0 {
+			return nil
+		}
+		return fmt.Errorf("%s: unexpected null", at)
+	}
+
+	if allOf, ok := s["allOf"].([]any); ok {
+		for _, sub := range allOf {
+			if err := validateOpenAPISchema(spec, sub, value, at); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch s["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", at, value)
+		}
+		for _, name := range asStrings(s["required"]) {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", at, name)
+			}
+		}
+		properties, _ := s["properties"].(map[string]any)
+		for name, v := range obj {
+			if prop, ok := properties[name]; ok {
+				if err := validateOpenAPISchema(spec, prop, v, at+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := s["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s: undocumented property %q", at, name)
+				}
+			case map[string]any:
+				if err := validateOpenAPISchema(spec, additional, v, at+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", at, value)
+		}
+		for i, item := range items {
+			if err := validateOpenAPISchema(spec, s["items"], item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string, got %T", at, value)
+		}
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: invalid date-time %q", at, str)
+			}
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected integer, got %v", at, value)
+		}
+		if min, ok := s["minimum"].(float64); ok && n < min {
+			return fmt.Errorf("%s: %v is below the minimum %v", at, n, min)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %T", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", at, value)
+		}
+	}
+
+	return nil
+}
+
+// asStrings converts a decoded JSON array of strings.
+func asStrings(v any) []string {
+	items, _ := v.([]any)
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// collectOpenAPIRefs returns every $ref in a decoded JSON value.
+func collectOpenAPIRefs(v any, refs map[string]bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if ref, ok := item.(string); ok && k == "$ref" {
+				refs[ref] = true
+				continue
+			}
+			collectOpenAPIRefs(item, refs)
+		}
+	case []any:
+		for _, item := range v {
+			collectOpenAPIRefs(item, refs)
+		}
+	}
+}
+
+func TestOpenAPISpec_CoversAllRoutes(t *testing.T) {
+	db := setupOpenAPITestDB(t)
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	documented := map[string]bool{}
+	for _, op := range operations {
+		if documented[op.Pattern] {
+			t.Errorf("Route %q documented twice", op.Pattern)
+		}
+		documented[op.Pattern] = true
+	}
+
+	registered := map[string]bool{}
+	for _, pattern := range server.routes {
+		registered[pattern] = true
+		if !documented[pattern] {
+			t.Errorf("Route %q is not documented in the OpenAPI specification", pattern)
+		}
+	}
+
+	for pattern := range documented {
+		if !registered[pattern] {
+			t.Errorf("Documented route %q is not registered", pattern)
+		}
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	db := setupOpenAPITestDB(t)
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	spec := loadOpenAPITestSpec(t, server)
+
+	if spec["openapi"] != OpenAPIVersion {
+		t.Errorf("Expected openapi %q, got %v", OpenAPIVersion, spec["openapi"])
+	}
+
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	for _, name := range []string{"LoginRequest", "LoginResponse", "CreateGradeRequest", "GradeResponse", "StudentResponse", "ErrorResponse"} {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("Expected schema %q in components", name)
+		}
+	}
+
+	refs := map[string]bool{}
+	collectOpenAPIRefs(spec, refs)
+	for ref := range refs {
+		if _, ok := schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; !ok {
+			t.Errorf("Unresolved reference %q", ref)
+		}
+	}
+
+	ids := map[string]bool{}
+	for path, item := range spec["paths"].(map[string]any) {
+		for method, op := range item.(map[string]any) {
+			id := op.(map[string]any)["operationId"].(string)
+			if ids[id] {
+				t.Errorf("Duplicate operationId %q (%s %s)", id, method, path)
+			}
+			ids[id] = true
+		}
+	}
+}
+
+func TestOpenAPISpec_SchemasFollowJSONEncoding(t *testing.T) {
+	spec := OpenAPISpec()
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+
+	grade := schemas["CreateGradeRequest"].(map[string]any)["properties"].(map[string]any)
+	for _, name := range []string{"value", "notes", "student_id", "topic_id"} {
+		if _, ok := grade[name]; !ok {
+			t.Errorf("Expected CreateGradeRequest property %q", name)
+		}
+	}
+
+	// Embedded fields are flattened.
+	guardian := schemas["GuardianResponse"].(map[string]any)["properties"].(map[string]any)
+	for _, name := range []string{"id", "email", "students"} {
+		if _, ok := guardian[name]; !ok {
+			t.Errorf("Expected GuardianResponse property %q", name)
+		}
+	}
+
+	// Models are not documented, as no response encodes them.
+	for _, name := range []string{"Account", "Student", "Tenant", "License", "Webhook"} {
+		if _, ok := schemas[name]; ok {
+			t.Errorf("Expected no %s schema", name)
+		}
+	}
+
+	// Embedded gorm.Model fields are flattened, and fields hidden from JSON
+	// are not documented.
+	generator := newSchemaGenerator()
+	generator.schemaOf(reflect.TypeOf(edutrack.Webhook{}))
+	webhook := generator.components["Webhook"].(map[string]any)["properties"].(map[string]any)
+	for _, name := range []string{"ID", "CreatedAt", "DeletedAt", "URL"} {
+		if _, ok := webhook[name]; !ok {
+			t.Errorf("Expected Webhook property %q", name)
+		}
+	}
+	if _, ok := webhook["Secret"]; ok {
+		t.Error("Expected Webhook secret to be hidden")
+	}
+
+	// Omitted-if-empty fields are optional.
+	response := schemas["WebhookResponse"].(map[string]any)
+	required := response["required"].([]string)
+	if i := sort.SearchStrings(required, "secret"); i < len(required) && required[i] == "secret" {
+		t.Error("Expected WebhookResponse secret to be optional")
+	}
+}
+
+func TestOpenAPI_Contract(t *testing.T) {
+	db := setupOpenAPITestDB(t)
+
+	tenant, err := edutrack.NewTenant("Test Institution", edutrack.LicenseTypeTrial, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create test tenant: %v", err)
+	}
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatalf("Failed to save test tenant: %v", err)
+	}
+
+	hash, _ := edutrack.HashPassword("secret")
+	secretary := &edutrack.Account{Name: "Admin", Email: "admin@test.com", Password: hash, Role: edutrack.RoleSecretary, Active: true, TenantID: tenant.ID}
+	if err := db.Create(secretary).Error; err != nil {
+		t.Fatalf("Failed to save test account: %v", err)
+	}
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+	spec := loadOpenAPITestSpec(t, server)
+
+	token, err := server.generateToken(secretary)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// ids collects the IDs of the created resources for later steps, and
+	// etags the last ETag of every resource path.
+	ids := map[string]string{}
+	etags := map[string]string{}
+
+	steps := []struct {
+		pattern string
+		path    string
+		body    any
+		public  bool
+		want    int
+		save    string
+
+		// If-Match header: the last ETag of the path if empty, none if "-".
+		ifMatch string
+	}{
+		{pattern: "POST /auth/license", body: LicenseLoginRequest{LicenseKey: tenant.License.Key}, public: true, want: http.StatusOK},
+		{pattern: "POST /auth/login", body: LoginRequest{Email: "admin@test.com", Password: "secret"}, public: true, want: http.StatusOK},
+		{pattern: "POST /auth/login", body: LoginRequest{Email: "admin@test.com", Password: "wrong"}, public: true, want: http.StatusUnauthorized},
+		{pattern: "GET /students", public: true, want: http.StatusUnauthorized},
+
+		{pattern: "POST /webhooks", body: CreateWebhookRequest{URL: "https://erp.example.com/hook", Events: []edutrack.WebhookEvent{edutrack.WebhookGradeCreated}}, want: http.StatusCreated, save: "webhook"},
+		{pattern: "GET /webhooks", want: http.StatusOK},
+		{pattern: "PUT /webhooks/{id}", path: "/webhooks/{webhook}", body: map[string]any{"description": "ERP"}, want: http.StatusOK},
+
+		{pattern: "POST /careers", body: CreateCareerRequest{Name: "Sistemas", Code: "ISC", Duration: 9}, want: http.StatusCreated, save: "career"},
+		{pattern: "GET /careers", want: http.StatusOK},
+		{pattern: "GET /careers/{id}", path: "/careers/{career}", want: http.StatusOK},
+		{pattern: "PUT /careers/{id}", path: "/careers/{career}", body: map[string]any{"description": "Ingeniería"}, want: http.StatusOK},
+		{pattern: "PATCH /careers/{id}", path: "/careers/{career}", body: map[string]any{"description": nil, "duration": 8}, want: http.StatusOK},
+		{pattern: "PUT /careers/{id}", path: "/careers/{career}", body: map[string]any{"duration": 10}, ifMatch: `"1.0"`, want: http.StatusPreconditionFailed},
+		{pattern: "DELETE /careers/{id}", path: "/careers/{career}", ifMatch: "-", want: http.StatusPreconditionRequired},
+
+		{pattern: "POST /accounts", body: CreateAccountRequest{Name: "Docente", Email: "teacher@test.com", Password: "secret", Role: "teacher"}, want: http.StatusCreated, save: "account"},
+		{pattern: "GET /accounts", want: http.StatusOK},
+		{pattern: "GET /accounts/{id}", path: "/accounts/{account}", want: http.StatusOK},
+		{pattern: "PUT /accounts/{id}", path: "/accounts/{account}", body: map[string]any{"name": "Docente Titular"}, want: http.StatusOK},
+
+		{pattern: "POST /teachers", body: map[string]any{"account_id": "{account}"}, want: http.StatusCreated, save: "teacher"},
+		{pattern: "GET /teachers", want: http.StatusOK},
+		{pattern: "GET /teachers/{id}", path: "/teachers/{teacher}", want: http.StatusOK},
+
+		{pattern: "POST /subjects", body: map[string]any{"name": "Cálculo", "code": "MAT-1", "credits": 5, "career_id": "{career}", "teacher_id": "{teacher}", "semester": 1}, want: http.StatusCreated, save: "subject"},
+		{pattern: "GET /subjects", want: http.StatusOK},
+		{pattern: "GET /subjects/{id}", path: "/subjects/{subject}?include=career,teacher.account", want: http.StatusOK},
+
+		{pattern: "POST /topics", body: map[string]any{"name": "Límites", "subject_id": "{subject}"}, want: http.StatusCreated, save: "topic"},
+		{pattern: "GET /topics", want: http.StatusOK},
+		{pattern: "GET /topics/{id}", path: "/topics/{topic}", want: http.StatusOK},
+
+		{pattern: "POST /students", body: map[string]any{"student_id": "A001", "name": "Alumno", "email": "student@test.com", "password": "secret", "career_id": "{career}", "semester": 1}, want: http.StatusCreated, save: "student"},
+		{pattern: "GET /students", want: http.StatusOK},
+		{pattern: "GET /students/{id}", path: "/students/{student}?include=account,career", want: http.StatusOK},
+		{pattern: "GET /students/{id}", path: "/students/999", want: http.StatusNotFound},
+		{pattern: "POST /sections", body: map[string]any{"name": "A", "capacity": 30, "schedule": "Lunes 8:00", "subject_id": "{subject}", "teacher_id": "{teacher}"}, want: http.StatusCreated, save: "section"},
+		{pattern: "GET /sections", want: http.StatusOK},
+		{pattern: "GET /sections/{id}", path: "/sections/{section}?include=subject,teacher.account", want: http.StatusOK},
+		{pattern: "PATCH /sections/{id}", path: "/sections/{section}", body: map[string]any{"capacity": 25}, want: http.StatusOK},
+		{pattern: "POST /sections/{id}/students", path: "/sections/{section}/students", body: map[string]any{"student_id": "{student}"}, want: http.StatusCreated},
+		{pattern: "GET /sections/{id}/students", path: "/sections/{section}/students?include=student.account", want: http.StatusOK},
+		{pattern: "GET /subjects/{id}/students", path: "/subjects/{subject}/students", want: http.StatusOK},
+
+		{pattern: "POST /grades", body: map[string]any{"value": 95, "student_id": "{student}", "topic_id": "{topic}"}, want: http.StatusCreated, save: "grade"},
+		{pattern: "PUT /grades/{id}", path: "/grades/{grade}", body: map[string]any{"value": 90}, want: http.StatusOK},
+		{pattern: "GET /grades", want: http.StatusOK},
+		{pattern: "GET /grades/{id}", path: "/grades/{grade}?include=student.account,topic.subject", want: http.StatusOK},
+
+		{pattern: "POST /attendances", body: map[string]any{"date": "2026-01-15", "status": "absent", "student_id": "{student}", "subject_id": "{subject}"}, want: http.StatusCreated, save: "attendance"},
+		{pattern: "GET /attendances", want: http.StatusOK},
+		{pattern: "GET /attendances/{id}", path: "/attendances/{attendance}", want: http.StatusOK},
+
+		{pattern: "POST /guardians", body: map[string]any{"name": "Tutor", "email": "guardian@test.com", "password": "secret", "relationship": "madre", "student_ids": []any{"{student}"}}, want: http.StatusCreated, save: "guardian"},
+		{pattern: "GET /guardians", want: http.StatusOK},
+		{pattern: "GET /guardians/{id}", path: "/guardians/{guardian}", want: http.StatusOK},
+
+		{pattern: "GET /webhooks/{id}/deliveries", path: "/webhooks/{webhook}/deliveries", want: http.StatusOK},
+
+		{pattern: "POST /api-keys", body: CreateAPIKeyRequest{Name: "SIS", Scopes: []string{"grades:read"}}, want: http.StatusCreated, save: "apikey"},
+		{pattern: "GET /api-keys", want: http.StatusOK},
+		{pattern: "DELETE /api-keys/{id}", path: "/api-keys/{apikey}", want: http.StatusNoContent},
+
+		{pattern: "GET /notifications", want: http.StatusOK},
+		{pattern: "GET /notifications/preferences", want: http.StatusOK},
+		{pattern: "PUT /notifications/preferences", body: map[string]any{"event": "grade.posted", "email": false}, want: http.StatusOK},
+
+		{pattern: "DELETE /grades/{id}", path: "/grades/{grade}", want: http.StatusNoContent},
+		{pattern: "GET /grades/{id}", path: "/grades/{grade}", want: http.StatusNotFound},
+	}
+
+	// expand replaces the {name} placeholders with the saved IDs.
+	expand := func(s string) string {
+		for name, id := range ids {
+			s = strings.ReplaceAll(s, "{"+name+"}", id)
+		}
+		return s
+	}
+
+	for _, step := range steps {
+		path := step.path
+		if path == "" {
+			_, path, _ = strings.Cut(step.pattern, " ")
+		}
+		path = expand(path)
+		method, _, _ := strings.Cut(step.pattern, " ")
+
+		var body []byte
+		if step.body != nil {
+			encoded, _ := json.Marshal(step.body)
+			// Placeholders are quoted in the request; IDs are numbers.
+			body = []byte(expand(string(encoded)))
+			for _, id := range ids {
+				body = bytes.ReplaceAll(body, []byte(`"`+id+`"`), []byte(id))
+			}
+		}
+
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if method == http.MethodPatch {
+			req.Header.Set("Content-Type", MergePatchContentType)
+		}
+		if !step.public {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete {
+			switch step.ifMatch {
+			case "":
+				req.Header.Set("If-Match", etags[path])
+			case "-":
+			default:
+				req.Header.Set("If-Match", step.ifMatch)
+			}
+		}
+		w := httptest.NewRecorder()
+
+		server.router.ServeHTTP(w, req)
+
+		if w.Code != step.want {
+			t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, step.want, w.Code, w.Body.String())
+		}
+
+		validateOpenAPIResponse(t, spec, step.pattern, w)
+		if etag := w.Header().Get("ETag"); etag != "" && method != http.MethodPost {
+			etags[path] = etag
+		}
+
+		if step.save != "" {
+			var created struct {
+				ID uint `json:"ID"`
+				Id uint `json:"id"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &created)
+			id := created.ID
+			if id == 0 {
+				id = created.Id
+			}
+			ids[step.save] = fmt.Sprint(id)
+			if etag := w.Header().Get("ETag"); etag != "" {
+				etags[path+"/"+ids[step.save]] = etag
+			}
+		}
+	}
+}
@@ -0,0 +1,998 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+// The handlers never encode the models: responses are built from the types
+// below, which only expose the fields meant for clients. Password hashes,
+// license keys, API key hashes and webhook secrets have no field in them.
+
+// include is the set of related objects embedded in a response, requested
+// with the include query parameter (e.g., ?include=career,account). Nested
+// objects are named by their path (e.g., "topic.subject").
+type include map[string]bool
+
+// Related objects that can be embedded in the responses of each resource.
+var (
+	studentIncludes       = []string{"account", "career"}
+	teacherIncludes       = []string{"account"}
+	subjectIncludes       = []string{"career", "teacher", "teacher.account"}
+	sectionIncludes       = []string{"subject", "teacher", "teacher.account"}
+	enrollmentIncludes    = []string{"student", "student.account", "student.career"}
+	topicIncludes         = []string{"subject"}
+	attendanceIncludes    = []string{"student", "student.account", "subject", "justification"}
+	justificationIncludes = []string{"student", "student.account"}
+	gradeIncludes         = []string{"student", "student.account", "topic", "topic.subject"}
+	appealIncludes        = []string{"grade", "grade.topic", "grade.topic.subject", "student", "student.account"}
+	examIncludes          = []string{"student", "student.account", "subject"}
+	guardianLinkIncludes  = []string{"student", "student.account"}
+)
+
+// parseInclude returns the related objects requested by the include query
+// parameter, which may only name the given ones. Naming a nested object
+// includes its parents.
+func parseInclude(r *http.Request, allowed []string) (include, error) {
+	inc := include{}
+	for _, name := range strings.Split(r.URL.Query().Get("include"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(allowed, name) {
+			return nil, edutrack.InvalidField("include", edutrack.FieldInvalidChoice,
+				"field.include.invalid", name, strings.Join(allowed, ", "))
+		}
+		for path := name; path != ""; path, _ = cutLast(path, ".") {
+			inc[path] = true
+		}
+	}
+	return inc, nil
+}
+
+// includeAll returns an include embedding every given object, used for the
+// payloads of the webhooks.
+func includeAll(names []string) include {
+	inc := include{}
+	for _, name := range names {
+		inc[name] = true
+	}
+	return inc
+}
+
+// nested returns the objects included in the given one, without its prefix.
+func (inc include) nested(name string) include {
+	nested := include{}
+	for path := range inc {
+		if rest, ok := strings.CutPrefix(path, name+"."); ok {
+			nested[rest] = true
+		}
+	}
+	return nested
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):]
+	}
+	return "", s
+}
+
+// AccountResponse represents an account in API responses.
+type AccountResponse struct {
+	ID        uint          `json:"id"`
+	Name      string        `json:"name"`
+	Email     string        `json:"email"`
+	Role      edutrack.Role `json:"role"`
+	Active    bool          `json:"active"`
+	Locale    i18n.Locale   `json:"locale"`
+	TenantID  string        `json:"tenant_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+
+	// Path redirecting to the avatar, if uploaded.
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// newAccountResponse builds the response for an account.
+func newAccountResponse(account *edutrack.Account) AccountResponse {
+	var avatarURL string
+	if account.AvatarKey != "" {
+		avatarURL = fmt.Sprintf("/accounts/%d/avatar", account.ID)
+	}
+	return AccountResponse{
+		ID:        account.ID,
+		Name:      account.Name,
+		Email:     account.Email,
+		Role:      account.Role,
+		Active:    account.Active,
+		Locale:    account.Locale,
+		TenantID:  account.TenantID,
+		CreatedAt: account.CreatedAt,
+		UpdatedAt: account.UpdatedAt,
+		AvatarURL: avatarURL,
+	}
+}
+
+// CareerResponse represents a career in API responses.
+type CareerResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	Duration    int       `json:"duration"`
+	Active      bool      `json:"active"`
+	TenantID    string    `json:"tenant_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// newCareerResponse builds the response for a career.
+func newCareerResponse(career *edutrack.Career) CareerResponse {
+	return CareerResponse{
+		ID:          career.ID,
+		Name:        career.Name,
+		Code:        career.Code,
+		Description: career.Description,
+		Duration:    career.Duration,
+		Active:      career.Active,
+		TenantID:    career.TenantID,
+		CreatedAt:   career.CreatedAt,
+		UpdatedAt:   career.UpdatedAt,
+	}
+}
+
+// StudentResponse represents a student in API responses.
+type StudentResponse struct {
+	ID        uint      `json:"id"`
+	StudentID string    `json:"student_id"`
+	Semester  int       `json:"semester"`
+	AccountID uint      `json:"account_id"`
+	CareerID  uint      `json:"career_id"`
+	TenantID  string    `json:"tenant_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Included with ?include=account and ?include=career.
+	Account *AccountResponse `json:"account,omitempty"`
+	Career  *CareerResponse  `json:"career,omitempty"`
+
+	// Averages of the grades and attendance summary of the student in the
+	// current academic period, only in the responses of a single student.
+	OverallAverage  *float64                   `json:"overall_average,omitempty"`
+	SubjectAverages []SubjectAverageResponse   `json:"subject_averages,omitempty"`
+	Attendance      *AttendanceSummaryResponse `json:"attendance,omitempty"`
+}
+
+// SubjectAverageResponse represents the average grade of a student in a
+// subject in API responses.
+type SubjectAverageResponse struct {
+	SubjectID   uint    `json:"subject_id"`
+	SubjectName string  `json:"subject_name"`
+	Average     float64 `json:"average"`
+
+	// Final grade of the subject: the grade of the last graded exam
+	// attempt, whose kind is attempt, or the average without attempts.
+	FinalGrade float64           `json:"final_grade"`
+	Attempt    edutrack.ExamKind `json:"attempt"`
+
+	Passed bool `json:"passed"`
+}
+
+// AttendanceSummaryResponse represents the attendance summary of a student
+// in API responses.
+type AttendanceSummaryResponse struct {
+	Present  int `json:"present"`
+	Absent   int `json:"absent"`
+	Late     int `json:"late"`
+	Excused  int `json:"excused"`
+	Absences int `json:"absences"`
+}
+
+// newStudentResponse builds the response for a student, embedding the
+// included objects, which must be loaded.
+func newStudentResponse(student *edutrack.Student, inc include) StudentResponse {
+	response := StudentResponse{
+		ID:        student.ID,
+		StudentID: student.StudentID,
+		Semester:  student.Semester,
+		AccountID: student.AccountID,
+		CareerID:  student.CareerID,
+		TenantID:  student.TenantID,
+		CreatedAt: student.CreatedAt,
+		UpdatedAt: student.UpdatedAt,
+	}
+	if inc["account"] {
+		account := newAccountResponse(&student.Account)
+		response.Account = &account
+	}
+	if inc["career"] {
+		career := newCareerResponse(&student.Career)
+		response.Career = &career
+	}
+	if student.SubjectAverages != nil {
+		response.OverallAverage = &student.OverallAverage
+		for _, average := range student.SubjectAverages {
+			response.SubjectAverages = append(response.SubjectAverages, SubjectAverageResponse{
+				SubjectID:   average.SubjectID,
+				SubjectName: average.SubjectName,
+				Average:     average.Average,
+				FinalGrade:  average.Final,
+				Attempt:     average.Attempt,
+				Passed:      average.Passed,
+			})
+		}
+		attendance := AttendanceSummaryResponse(student.Attendance)
+		response.Attendance = &attendance
+	}
+	return response
+}
+
+// newStudentResponses builds the responses for a list of students.
+func newStudentResponses(students []edutrack.Student, inc include) []StudentResponse {
+	response := make([]StudentResponse, 0, len(students))
+	for i := range students {
+		response = append(response, newStudentResponse(&students[i], inc))
+	}
+	return response
+}
+
+// TeacherResponse represents a teacher in API responses.
+type TeacherResponse struct {
+	ID        uint      `json:"id"`
+	AccountID uint      `json:"account_id"`
+	TenantID  string    `json:"tenant_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Included with ?include=account.
+	Account *AccountResponse `json:"account,omitempty"`
+}
+
+// newTeacherResponse builds the response for a teacher, embedding the
+// included objects, which must be loaded.
+func newTeacherResponse(teacher *edutrack.Teacher, inc include) TeacherResponse {
+	response := TeacherResponse{
+		ID:        teacher.ID,
+		AccountID: teacher.AccountID,
+		TenantID:  teacher.TenantID,
+		CreatedAt: teacher.CreatedAt,
+		UpdatedAt: teacher.UpdatedAt,
+	}
+	if inc["account"] {
+		account := newAccountResponse(&teacher.Account)
+		response.Account = &account
+	}
+	return response
+}
+
+// SubjectResponse represents a subject in API responses.
+type SubjectResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	Credits     int       `json:"credits"`
+	Semester    int       `json:"semester"`
+	CareerID    uint      `json:"career_id"`
+	TeacherID   *uint     `json:"teacher_id"`
+	TenantID    string    `json:"tenant_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Included with ?include=career and ?include=teacher. Subjects without
+	// a teacher have none.
+	Career  *CareerResponse  `json:"career,omitempty"`
+	Teacher *TeacherResponse `json:"teacher,omitempty"`
+}
+
+// newSubjectResponse builds the response for a subject, embedding the
+// included objects, which must be loaded.
+func newSubjectResponse(subject *edutrack.Subject, inc include) SubjectResponse {
+	response := SubjectResponse{
+		ID:          subject.ID,
+		Name:        subject.Name,
+		Code:        subject.Code,
+		Description: subject.Description,
+		Credits:     subject.Credits,
+		Semester:    subject.Semester,
+		CareerID:    subject.CareerID,
+		TeacherID:   subject.TeacherID,
+		TenantID:    subject.TenantID,
+		CreatedAt:   subject.CreatedAt,
+		UpdatedAt:   subject.UpdatedAt,
+	}
+	if inc["career"] {
+		career := newCareerResponse(&subject.Career)
+		response.Career = &career
+	}
+	if inc["teacher"] && subject.Teacher != nil {
+		teacher := newTeacherResponse(subject.Teacher, inc.nested("teacher"))
+		response.Teacher = &teacher
+	}
+	return response
+}
+
+// SectionResponse represents a section of a subject in API responses.
+type SectionResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Capacity  int       `json:"capacity"`
+	Schedule  string    `json:"schedule"`
+	SubjectID uint      `json:"subject_id"`
+	TeacherID *uint     `json:"teacher_id"`
+	TenantID  string    `json:"tenant_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Number of enrolled and waitlisted students.
+	Enrolled   int `json:"enrolled"`
+	Waitlisted int `json:"waitlisted"`
+
+	// Included with ?include=subject and ?include=teacher. Sections without
+	// a teacher have none.
+	Subject *SubjectResponse `json:"subject,omitempty"`
+	Teacher *TeacherResponse `json:"teacher,omitempty"`
+}
+
+// newSectionResponse builds the response for a section, embedding the
+// included objects, which must be loaded.
+func newSectionResponse(section *edutrack.Section, inc include) SectionResponse {
+	response := SectionResponse{
+		ID:         section.ID,
+		Name:       section.Name,
+		Capacity:   section.Capacity,
+		Schedule:   section.Schedule,
+		SubjectID:  section.SubjectID,
+		TeacherID:  section.TeacherID,
+		TenantID:   section.TenantID,
+		CreatedAt:  section.CreatedAt,
+		UpdatedAt:  section.UpdatedAt,
+		Enrolled:   section.Enrolled,
+		Waitlisted: section.Waitlisted,
+	}
+	if inc["subject"] {
+		subject := newSubjectResponse(&section.Subject, nil)
+		response.Subject = &subject
+	}
+	if inc["teacher"] && section.Teacher != nil {
+		teacher := newTeacherResponse(section.Teacher, inc.nested("teacher"))
+		response.Teacher = &teacher
+	}
+	return response
+}
+
+// EnrollmentResponse represents the enrollment of a student in a section in
+// API responses.
+type EnrollmentResponse struct {
+	Status    edutrack.EnrollmentStatus `json:"status"`
+	SectionID uint                      `json:"section_id"`
+	StudentID uint                      `json:"student_id"`
+	CreatedAt time.Time                 `json:"created_at"`
+
+	// Position in the waitlist, starting at 1; 0 for enrolled students.
+	Position int `json:"position"`
+
+	// Included with ?include=student.
+	Student *StudentResponse `json:"student,omitempty"`
+}
+
+// newEnrollmentResponse builds the response for an enrollment, embedding the
+// included objects, which must be loaded.
+func newEnrollmentResponse(enrollment *edutrack.Enrollment, inc include) EnrollmentResponse {
+	response := EnrollmentResponse{
+		Status:    enrollment.Status,
+		SectionID: enrollment.SectionID,
+		StudentID: enrollment.StudentID,
+		CreatedAt: enrollment.CreatedAt,
+		Position:  enrollment.Position,
+	}
+	if inc["student"] {
+		student := newStudentResponse(&enrollment.Student, inc.nested("student"))
+		response.Student = &student
+	}
+	return response
+}
+
+// TopicResponse represents a topic in API responses.
+type TopicResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	SubjectID   uint      `json:"subject_id"`
+	TenantID    string    `json:"tenant_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Deadline after which the grades of the topic are locked, if any.
+	GradesLockAt *time.Time `json:"grades_lock_at"`
+
+	// Included with ?include=subject.
+	Subject *SubjectResponse `json:"subject,omitempty"`
+}
+
+// newTopicResponse builds the response for a topic, embedding the included
+// objects, which must be loaded.
+func newTopicResponse(topic *edutrack.Topic, inc include) TopicResponse {
+	response := TopicResponse{
+		ID:          topic.ID,
+		Name:        topic.Name,
+		Description: topic.Description,
+		SubjectID:   topic.SubjectID,
+		TenantID:    topic.TenantID,
+		CreatedAt:   topic.CreatedAt,
+		UpdatedAt:   topic.UpdatedAt,
+
+		GradesLockAt: topic.GradesLockAt,
+	}
+	if inc["subject"] {
+		subject := newSubjectResponse(&topic.Subject, inc.nested("subject"))
+		response.Subject = &subject
+	}
+	return response
+}
+
+// AttendanceResponse represents an attendance record in API responses.
+type AttendanceResponse struct {
+	ID uint `json:"id"`
+
+	// Format: "2006-01-02", as in requests.
+	Date string `json:"date"`
+
+	Status    edutrack.AttendanceStatus `json:"status"`
+	Notes     string                    `json:"notes"`
+	StudentID uint                      `json:"student_id"`
+	SubjectID uint                      `json:"subject_id"`
+	TenantID  string                    `json:"tenant_id"`
+	CreatedAt time.Time                 `json:"created_at"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+
+	// Section of the subject the student attended, if any.
+	SectionID *uint `json:"section_id"`
+
+	// Approved justification that excused the record, if any.
+	JustificationID *uint `json:"justification_id"`
+
+	// Included with ?include=student, ?include=subject and
+	// ?include=justification.
+	Student       *StudentResponse       `json:"student,omitempty"`
+	Subject       *SubjectResponse       `json:"subject,omitempty"`
+	Justification *JustificationResponse `json:"justification,omitempty"`
+}
+
+// newAttendanceResponse builds the response for an attendance record,
+// embedding the included objects, which must be loaded.
+func newAttendanceResponse(attendance *edutrack.Attendance, inc include) AttendanceResponse {
+	response := AttendanceResponse{
+		ID:        attendance.ID,
+		Date:      attendance.Date.Format("2006-01-02"),
+		Status:    attendance.Status,
+		Notes:     attendance.Notes,
+		StudentID: attendance.StudentID,
+		SubjectID: attendance.SubjectID,
+		TenantID:  attendance.TenantID,
+		CreatedAt: attendance.CreatedAt,
+		UpdatedAt: attendance.UpdatedAt,
+
+		SectionID:       attendance.SectionID,
+		JustificationID: attendance.JustificationID,
+	}
+	if inc["student"] {
+		student := newStudentResponse(&attendance.Student, inc.nested("student"))
+		response.Student = &student
+	}
+	if inc["subject"] {
+		subject := newSubjectResponse(&attendance.Subject, inc.nested("subject"))
+		response.Subject = &subject
+	}
+	if inc["justification"] && attendance.Justification != nil {
+		justification := newJustificationResponse(attendance.Justification, nil)
+		response.Justification = &justification
+	}
+	return response
+}
+
+// JustificationResponse represents an absence justification in API
+// responses.
+type JustificationResponse struct {
+	ID uint `json:"id"`
+
+	// Format: "2006-01-02", as in requests.
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+
+	Reason        string                       `json:"reason"`
+	Status        edutrack.JustificationStatus `json:"status"`
+	ReviewNotes   string                       `json:"review_notes"`
+	ReviewedAt    *time.Time                   `json:"reviewed_at"`
+	ReviewerID    *uint                        `json:"reviewer_id"`
+	SubmittedByID *uint                        `json:"submitted_by_id"`
+	StudentID     uint                         `json:"student_id"`
+	TenantID      string                       `json:"tenant_id"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at"`
+
+	// Route redirecting to a signed URL of the supporting document.
+	DocumentURL string `json:"document_url,omitempty"`
+
+	// Included with ?include=student.
+	Student *StudentResponse `json:"student,omitempty"`
+}
+
+// newJustificationResponse builds the response for a justification,
+// embedding the included objects, which must be loaded.
+func newJustificationResponse(justification *edutrack.Justification, inc include) JustificationResponse {
+	response := JustificationResponse{
+		ID:            justification.ID,
+		StartDate:     justification.StartDate.Format("2006-01-02"),
+		EndDate:       justification.EndDate.Format("2006-01-02"),
+		Reason:        justification.Reason,
+		Status:        justification.Status,
+		ReviewNotes:   justification.ReviewNotes,
+		ReviewedAt:    justification.ReviewedAt,
+		ReviewerID:    justification.ReviewerID,
+		SubmittedByID: justification.SubmittedByID,
+		StudentID:     justification.StudentID,
+		TenantID:      justification.TenantID,
+		CreatedAt:     justification.CreatedAt,
+		UpdatedAt:     justification.UpdatedAt,
+	}
+	if justification.DocumentKey != "" {
+		response.DocumentURL = fmt.Sprintf("/justifications/%d/document", justification.ID)
+	}
+	if inc["student"] {
+		student := newStudentResponse(&justification.Student, inc.nested("student"))
+		response.Student = &student
+	}
+	return response
+}
+
+// GradeResponse represents a grade in API responses.
+type GradeResponse struct {
+	ID        uint                 `json:"id"`
+	Value     float64              `json:"value"`
+	Notes     string               `json:"notes"`
+	Status    edutrack.GradeStatus `json:"status"`
+	StudentID uint                 `json:"student_id"`
+	TopicID   uint                 `json:"topic_id"`
+	TenantID  string               `json:"tenant_id"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+
+	// When the grade was published, null for drafts.
+	PublishedAt *time.Time `json:"published_at"`
+
+	// Section of the subject the student attended when graded, if any.
+	SectionID *uint `json:"section_id"`
+
+	// Included with ?include=student and ?include=topic.
+	Student *StudentResponse `json:"student,omitempty"`
+	Topic   *TopicResponse   `json:"topic,omitempty"`
+}
+
+// newGradeResponse builds the response for a grade, embedding the included
+// objects, which must be loaded.
+func newGradeResponse(grade *edutrack.Grade, inc include) GradeResponse {
+	response := GradeResponse{
+		ID:        grade.ID,
+		Value:     grade.Value,
+		Notes:     grade.Notes,
+		Status:    grade.Status,
+		StudentID: grade.StudentID,
+		TopicID:   grade.TopicID,
+		TenantID:  grade.TenantID,
+		CreatedAt: grade.CreatedAt,
+		UpdatedAt: grade.UpdatedAt,
+
+		PublishedAt: grade.PublishedAt,
+		SectionID:   grade.SectionID,
+	}
+	if inc["student"] {
+		student := newStudentResponse(&grade.Student, inc.nested("student"))
+		response.Student = &student
+	}
+	if inc["topic"] {
+		topic := newTopicResponse(&grade.Topic, inc.nested("topic"))
+		response.Topic = &topic
+	}
+	return response
+}
+
+// GradeAmendmentResponse represents the amendment of a locked grade in API
+// responses.
+type GradeAmendmentResponse struct {
+	ID            uint      `json:"id"`
+	Reason        string    `json:"reason"`
+	PreviousValue *float64  `json:"previous_value"`
+	Value         float64   `json:"value"`
+	PreviousNotes string    `json:"previous_notes"`
+	Notes         string    `json:"notes"`
+	GradeID       uint      `json:"grade_id"`
+	AccountID     *uint     `json:"account_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// newGradeAmendmentResponse builds the response for a grade amendment.
+func newGradeAmendmentResponse(amendment *edutrack.GradeAmendment) GradeAmendmentResponse {
+	return GradeAmendmentResponse{
+		ID:            amendment.ID,
+		Reason:        amendment.Reason,
+		PreviousValue: amendment.PreviousValue,
+		Value:         amendment.Value,
+		PreviousNotes: amendment.PreviousNotes,
+		Notes:         amendment.Notes,
+		GradeID:       amendment.GradeID,
+		AccountID:     amendment.AccountID,
+		CreatedAt:     amendment.CreatedAt,
+	}
+}
+
+// GradeAppealResponse represents a grade appeal in API responses.
+type GradeAppealResponse struct {
+	ID            uint                  `json:"id"`
+	Reason        string                `json:"reason"`
+	Status        edutrack.AppealStatus `json:"status"`
+	OriginalValue float64               `json:"original_value"`
+	ResolvedValue *float64              `json:"resolved_value"`
+	ResolvedAt    *time.Time            `json:"resolved_at"`
+	ResolverID    *uint                 `json:"resolver_id"`
+	GradeID       uint                  `json:"grade_id"`
+	StudentID     uint                  `json:"student_id"`
+	TenantID      string                `json:"tenant_id"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+
+	// The thread, oldest first, in the responses of a single appeal.
+	Messages []GradeAppealMessageResponse `json:"messages,omitempty"`
+
+	// Included with ?include=grade and ?include=student.
+	Grade   *GradeResponse   `json:"grade,omitempty"`
+	Student *StudentResponse `json:"student,omitempty"`
+}
+
+// newGradeAppealResponse builds the response for a grade appeal, embedding
+// the included objects and the messages, which must be loaded.
+func newGradeAppealResponse(appeal *edutrack.GradeAppeal, inc include) GradeAppealResponse {
+	response := GradeAppealResponse{
+		ID:            appeal.ID,
+		Reason:        appeal.Reason,
+		Status:        appeal.Status,
+		OriginalValue: appeal.OriginalValue,
+		ResolvedValue: appeal.ResolvedValue,
+		ResolvedAt:    appeal.ResolvedAt,
+		ResolverID:    appeal.ResolverID,
+		GradeID:       appeal.GradeID,
+		StudentID:     appeal.StudentID,
+		TenantID:      appeal.TenantID,
+		CreatedAt:     appeal.CreatedAt,
+		UpdatedAt:     appeal.UpdatedAt,
+	}
+	for i := range appeal.Messages {
+		response.Messages = append(response.Messages, newGradeAppealMessageResponse(&appeal.Messages[i]))
+	}
+	if inc["grade"] {
+		grade := newGradeResponse(&appeal.Grade, inc.nested("grade"))
+		response.Grade = &grade
+	}
+	if inc["student"] {
+		student := newStudentResponse(&appeal.Student, inc.nested("student"))
+		response.Student = &student
+	}
+	return response
+}
+
+// GradeAppealMessageResponse represents a message of the thread of a grade
+// appeal in API responses.
+type GradeAppealMessageResponse struct {
+	ID   uint   `json:"id"`
+	Body string `json:"body"`
+
+	// State the appeal moved to with the message, empty for comments.
+	Status edutrack.AppealStatus `json:"status"`
+
+	AccountID *uint     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newGradeAppealMessageResponse builds the response for a message of a
+// grade appeal.
+func newGradeAppealMessageResponse(message *edutrack.GradeAppealMessage) GradeAppealMessageResponse {
+	return GradeAppealMessageResponse{
+		ID:        message.ID,
+		Body:      message.Body,
+		Status:    message.Status,
+		AccountID: message.AccountID,
+		CreatedAt: message.CreatedAt,
+	}
+}
+
+// ExamAttemptResponse represents an exam attempt in API responses.
+type ExamAttemptResponse struct {
+	ID   uint              `json:"id"`
+	Kind edutrack.ExamKind `json:"kind"`
+
+	// Format: "2006-01-02", as in requests.
+	Date string `json:"date"`
+
+	// Grade of the exam, null until it is recorded.
+	Value *float64 `json:"value"`
+
+	Notes     string     `json:"notes"`
+	GradedAt  *time.Time `json:"graded_at"`
+	GraderID  *uint      `json:"grader_id"`
+	StudentID uint       `json:"student_id"`
+	SubjectID uint       `json:"subject_id"`
+	TenantID  string     `json:"tenant_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Included with ?include=student and ?include=subject.
+	Student *StudentResponse `json:"student,omitempty"`
+	Subject *SubjectResponse `json:"subject,omitempty"`
+}
+
+// newExamAttemptResponse builds the response for an exam attempt, embedding
+// the included objects, which must be loaded.
+func newExamAttemptResponse(attempt *edutrack.ExamAttempt, inc include) ExamAttemptResponse {
+	response := ExamAttemptResponse{
+		ID:        attempt.ID,
+		Kind:      attempt.Kind,
+		Date:      attempt.Date.Format("2006-01-02"),
+		Value:     attempt.Value,
+		Notes:     attempt.Notes,
+		GradedAt:  attempt.GradedAt,
+		GraderID:  attempt.GraderID,
+		StudentID: attempt.StudentID,
+		SubjectID: attempt.SubjectID,
+		TenantID:  attempt.TenantID,
+		CreatedAt: attempt.CreatedAt,
+		UpdatedAt: attempt.UpdatedAt,
+	}
+	if inc["student"] {
+		student := newStudentResponse(&attempt.Student, inc.nested("student"))
+		response.Student = &student
+	}
+	if inc["subject"] {
+		subject := newSubjectResponse(&attempt.Subject, inc.nested("subject"))
+		response.Subject = &subject
+	}
+	return response
+}
+
+// GuardianLinkResponse represents the link of a guardian to a student in
+// API responses.
+type GuardianLinkResponse struct {
+	ID           uint      `json:"id"`
+	Relationship string    `json:"relationship"`
+	AccountID    uint      `json:"account_id"`
+	StudentID    uint      `json:"student_id"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Included with ?include=student.
+	Student *StudentResponse `json:"student,omitempty"`
+}
+
+// newGuardianLinkResponse builds the response for a guardian link,
+// embedding the included objects, which must be loaded.
+func newGuardianLinkResponse(link *edutrack.GuardianLink, inc include) GuardianLinkResponse {
+	response := GuardianLinkResponse{
+		ID:           link.ID,
+		Relationship: link.Relationship,
+		AccountID:    link.AccountID,
+		StudentID:    link.StudentID,
+		CreatedAt:    link.CreatedAt,
+	}
+	if inc["student"] {
+		student := newStudentResponse(&link.Student, inc.nested("student"))
+		response.Student = &student
+	}
+	return response
+}
+
+// GuardianResponse represents a guardian account with its linked students.
+type GuardianResponse struct {
+	AccountResponse
+	Students []GuardianLinkResponse `json:"students"`
+}
+
+// newGuardianResponse builds the response for a guardian and their links,
+// embedding the included objects of the links, which must be loaded.
+func newGuardianResponse(guardian *edutrack.Account, links []edutrack.GuardianLink, inc include) GuardianResponse {
+	response := GuardianResponse{
+		AccountResponse: newAccountResponse(guardian),
+		Students:        make([]GuardianLinkResponse, 0, len(links)),
+	}
+	for i := range links {
+		response.Students = append(response.Students, newGuardianLinkResponse(&links[i], inc))
+	}
+	return response
+}
+
+// InboxMessageResponse represents a message of the in-app inbox in API
+// responses.
+type InboxMessageResponse struct {
+	ID        uint                       `json:"id"`
+	Event     edutrack.NotificationEvent `json:"event"`
+	Subject   string                     `json:"subject"`
+	Body      string                     `json:"body"`
+	ReadAt    *time.Time                 `json:"read_at"`
+	CreatedAt time.Time                  `json:"created_at"`
+}
+
+// newInboxMessageResponse builds the response for an inbox message.
+func newInboxMessageResponse(message *edutrack.InboxMessage) InboxMessageResponse {
+	return InboxMessageResponse{
+		ID:        message.ID,
+		Event:     message.Event,
+		Subject:   message.Subject,
+		Body:      message.Body,
+		ReadAt:    message.ReadAt,
+		CreatedAt: message.CreatedAt,
+	}
+}
+
+// NotificationPreferenceResponse represents the channels of an event in API
+// responses.
+type NotificationPreferenceResponse struct {
+	Event      edutrack.NotificationEvent `json:"event"`
+	Email      bool                       `json:"email"`
+	Inbox      bool                       `json:"inbox"`
+	WebhookURL string                     `json:"webhook_url"`
+}
+
+// newNotificationPreferenceResponse builds the response for a notification
+// preference.
+func newNotificationPreferenceResponse(pref *edutrack.NotificationPreference) NotificationPreferenceResponse {
+	return NotificationPreferenceResponse{
+		Event:      pref.Event,
+		Email:      pref.Email,
+		Inbox:      pref.Inbox,
+		WebhookURL: pref.WebhookURL,
+	}
+}
+
+// TenantSettingsResponse represents the settings of an institution in API
+// responses.
+type TenantSettingsResponse struct {
+	TenantID        string      `json:"tenant_id"`
+	Timezone        string      `json:"timezone"`
+	Locale          i18n.Locale `json:"locale"`
+	GradeMin        float64     `json:"grade_min"`
+	GradeMax        float64     `json:"grade_max"`
+	PassingGrade    float64     `json:"passing_grade"`
+	LatesPerAbsence int         `json:"lates_per_absence"`
+
+	// Start of the academic period as YYYY-MM-DD, empty if not set.
+	AcademicStart string `json:"academic_start"`
+
+	// Days after publication a grade can be appealed, 0 if appeals are
+	// disabled.
+	AppealDays int `json:"appeal_days"`
+
+	// Exam attempts a student can take to pass a failed subject, 0 if the
+	// kind of exam is disabled.
+	ExtraordinaryAttempts int `json:"extraordinary_attempts"`
+	SpecialAttempts       int `json:"special_attempts"`
+}
+
+// newTenantSettingsResponse builds the response for the settings of an
+// institution.
+func newTenantSettingsResponse(settings *edutrack.TenantSettings) TenantSettingsResponse {
+	response := TenantSettingsResponse{
+		TenantID:        settings.TenantID,
+		Timezone:        settings.Timezone,
+		Locale:          settings.Locale,
+		GradeMin:        settings.GradeMin,
+		GradeMax:        settings.GradeMax,
+		PassingGrade:    settings.PassingGrade,
+		LatesPerAbsence: settings.LatesPerAbsence,
+		AppealDays:      settings.AppealDays,
+
+		ExtraordinaryAttempts: settings.ExtraordinaryAttempts,
+		SpecialAttempts:       settings.SpecialAttempts,
+	}
+	if !settings.AcademicStart.IsZero() {
+		response.AcademicStart = settings.AcademicStart.Format("2006-01-02")
+	}
+	return response
+}
+
+// WebhookDeliveryResponse represents a delivery of a webhook in API
+// responses.
+type WebhookDeliveryResponse struct {
+	ID             uint                           `json:"id"`
+	Event          edutrack.WebhookEvent          `json:"event"`
+	Payload        string                         `json:"payload"`
+	Status         edutrack.WebhookDeliveryStatus `json:"status"`
+	Attempts       int                            `json:"attempts"`
+	NextAttemptAt  time.Time                      `json:"next_attempt_at"`
+	ResponseStatus int                            `json:"response_status"`
+	LastError      string                         `json:"last_error"`
+	DeliveredAt    *time.Time                     `json:"delivered_at"`
+	WebhookID      uint                           `json:"webhook_id"`
+	CreatedAt      time.Time                      `json:"created_at"`
+}
+
+// newWebhookDeliveryResponse builds the response for a webhook delivery.
+func newWebhookDeliveryResponse(delivery *edutrack.WebhookDelivery) WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		Event:          delivery.Event,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		WebhookID:      delivery.WebhookID,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+// TrashItemResponse represents a deleted record in API responses.
+type TrashItemResponse struct {
+	Type      string    `json:"type"`
+	ID        uint      `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+
+	// The deleted record, in the representation of its type (e.g.,
+	// StudentResponse for "students").
+	Record any `json:"record"`
+}
+
+// newTrashItemResponses builds the responses for the records of the trash.
+func newTrashItemResponses(items []edutrack.TrashItem) []TrashItemResponse {
+	response := make([]TrashItemResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, TrashItemResponse{
+			Type:      item.Type,
+			ID:        item.ID,
+			DeletedAt: item.DeletedAt,
+			Record:    newRecordResponse(item.Record),
+		})
+	}
+	return response
+}
+
+// newRecordResponse builds the response for a record of the trash, without
+// its related objects. Records of unknown types are omitted.
+func newRecordResponse(record any) any {
+	switch record := record.(type) {
+	case *edutrack.Account:
+		return newAccountResponse(record)
+	case *edutrack.Career:
+		return newCareerResponse(record)
+	case *edutrack.Teacher:
+		return newTeacherResponse(record, nil)
+	case *edutrack.Subject:
+		return newSubjectResponse(record, nil)
+	case *edutrack.Section:
+		return newSectionResponse(record, nil)
+	case *edutrack.Topic:
+		return newTopicResponse(record, nil)
+	case *edutrack.Student:
+		return newStudentResponse(record, nil)
+	case *edutrack.Justification:
+		return newJustificationResponse(record, nil)
+	case *edutrack.Attendance:
+		return newAttendanceResponse(record, nil)
+	case *edutrack.Grade:
+		return newGradeResponse(record, nil)
+	case *edutrack.GradeAppeal:
+		return newGradeAppealResponse(record, nil)
+	case *edutrack.ExamAttempt:
+		return newExamAttemptResponse(record, nil)
+	case *edutrack.Webhook:
+		return newWebhookResponse(record)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+func TestParseInclude(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    include
+		wantErr bool
+	}{
+		{"", include{}, false},
+		{"?include=account", include{"account": true}, false},
+		{"?include=career,+account", include{"account": true, "career": true}, false},
+		{"?include=topic.subject", include{"topic": true, "topic.subject": true}, false},
+		{"?include=password", nil, true},
+		{"?include=account.password", nil, true},
+	}
+
+	allowed := []string{"account", "career", "topic", "topic.subject"}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			got, err := parseInclude(r, allowed)
+			if tt.wantErr {
+				if edutrack.ErrorCode(err) != edutrack.EINVALID {
+					t.Errorf("parseInclude() error = %v, want EINVALID", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseInclude() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseInclude() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInclude_Nested(t *testing.T) {
+	inc := include{"student": true, "student.account": true, "topic": true}
+	if got, want := inc.nested("student"), (include{"account": true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("nested(student) = %v, want %v", got, want)
+	}
+	if got := inc.nested("topic"); len(got) != 0 {
+		t.Errorf("nested(topic) = %v, want none", got)
+	}
+}
+
+// TestResponses_NoSensitiveFields requests every route, embedding every
+// related object allowed, and checks that no response exposes a secret.
+func TestResponses_NoSensitiveFields(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+
+	const (
+		apiKeyHash    = "api-key-hash-do-not-leak"
+		webhookSecret = "webhook-secret-do-not-leak"
+	)
+	db.Model(tenant.APIKey).Update("hash", apiKeyHash)
+	db.Model(tenant.Webhook).Update("secret", webhookSecret)
+
+	// Deleted records are listed in the trash.
+	deleted := []any{
+		&edutrack.Account{Name: "Borrada", Email: "deleted@example.com", Password: tenant.Secretary.Password, Role: edutrack.RoleGuardian, TenantID: tenant.Tenant.ID},
+		&edutrack.Webhook{URL: "https://example.com/deleted", Secret: webhookSecret, TenantID: tenant.Tenant.ID},
+	}
+	for _, record := range deleted {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("Failed to create %T: %v", record, err)
+		}
+		if err := db.Delete(record).Error; err != nil {
+			t.Fatalf("Failed to delete %T: %v", record, err)
+		}
+	}
+	if err := db.Delete(tenant.Grade).Error; err != nil {
+		t.Fatalf("Failed to delete grade: %v", err)
+	}
+
+	secrets := map[string]string{
+		"password hash": "$2a$",
+		"license key":   tenant.Tenant.License.Key,
+		"API key hash":  apiKeyHash,
+	}
+
+	for _, op := range operations {
+		method, path, _ := strings.Cut(op.Pattern, " ")
+		if method == http.MethodDelete || op.Public {
+			continue
+		}
+		for name, id := range tenant.pathIDs(op.Pattern) {
+			path = strings.ReplaceAll(path, "{"+name+"}", fmt.Sprint(id))
+		}
+		path = strings.ReplaceAll(path, "{type}", "grades")
+		if len(op.Include) > 0 {
+			path += "?include=" + strings.Join(op.Include, ",")
+		}
+
+		t.Run(op.Pattern, func(t *testing.T) {
+			var body any
+			if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
+				body = tenant.foreignBody(op.Pattern)
+			}
+
+			w := isolationRequest(t, server, tenant, method, path, body)
+			for name, secret := range secrets {
+				if strings.Contains(w.Body.String(), secret) {
+					t.Errorf("%s %s exposed the %s: %s", method, path, name, w.Body.String())
+				}
+			}
+			// The secret of a webhook is only returned when it is created.
+			if op.Pattern != "POST /webhooks" && strings.Contains(w.Body.String(), webhookSecret) {
+				t.Errorf("%s %s exposed the webhook secret: %s", method, path, w.Body.String())
+			}
+			if strings.Contains(strings.ToLower(w.Body.String()), `"password":`) {
+				t.Errorf("%s %s exposed a password field: %s", method, path, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewRecordResponse_TrashModels(t *testing.T) {
+	for _, model := range edutrack.TrashModels() {
+		if newRecordResponse(model) == nil {
+			t.Errorf("newRecordResponse(%T) = nil, want the response of the record", model)
+		}
+	}
+}
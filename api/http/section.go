@@ -0,0 +1,357 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// handleListSections handles GET /sections.
+func (s *Server) handleListSections(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, sectionIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	var filter edutrack.SectionFilter
+	for param, dst := range map[string]*uint{
+		"subject_id": &filter.SubjectID,
+		"teacher_id": &filter.TeacherID,
+	} {
+		if value := r.URL.Query().Get(param); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+				return
+			}
+			*dst = uint(id)
+		}
+	}
+
+	sections, err := edutrack.FindSections(r.Context(), s.DB, filter)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	response := make([]SectionResponse, 0, len(sections))
+	for i := range sections {
+		response = append(response, newSectionResponse(&sections[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// findSection finds the section of a route. On failure it writes the error
+// response and returns nil.
+func (s *Server) findSection(w http.ResponseWriter, r *http.Request) *edutrack.Section {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return nil
+	}
+
+	section, err := edutrack.FindSectionByID(r.Context(), s.DB, uint(id))
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return nil
+	}
+	return section
+}
+
+// handleGetSection handles GET /sections/{id}.
+func (s *Server) handleGetSection(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, sectionIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	section := s.findSection(w, r)
+	if section == nil {
+		return
+	}
+
+	setETag(w, section.Model)
+	sendJSON(w, http.StatusOK, newSectionResponse(section, inc))
+}
+
+// CreateSectionRequest represents the request body for creating a section.
+type CreateSectionRequest struct {
+	Name      string `json:"name" validate:"required,max=50"`
+	Capacity  int    `json:"capacity" validate:"min=0"`
+	Schedule  string `json:"schedule" validate:"max=500"`
+	SubjectID uint   `json:"subject_id" validate:"required"`
+	TeacherID *uint  `json:"teacher_id"`
+}
+
+// handleCreateSection handles POST /sections.
+func (s *Server) handleCreateSection(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, sectionIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	var req CreateSectionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	section, err := edutrack.CreateSection(r.Context(), s.DB, edutrack.SectionCreate{
+		Name:      req.Name,
+		Capacity:  req.Capacity,
+		Schedule:  req.Schedule,
+		SubjectID: req.SubjectID,
+		TeacherID: req.TeacherID,
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	setETag(w, section.Model)
+	sendJSON(w, http.StatusCreated, newSectionResponse(section, inc))
+}
+
+// UpdateSectionRequest represents the request body for updating a section.
+type UpdateSectionRequest struct {
+	Name     *string `json:"name" validate:"required,max=50"`
+	Capacity *int    `json:"capacity" validate:"min=0"`
+	Schedule *string `json:"schedule" validate:"max=500"`
+
+	// A null value in a merge patch unassigns the teacher.
+	TeacherID **uint `json:"teacher_id"`
+}
+
+// handleUpdateSection handles PUT and PATCH /sections/{id}.
+// Raising the capacity enrolls waitlisted students, who are notified.
+func (s *Server) handleUpdateSection(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, sectionIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	var req UpdateSectionRequest
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	var section *edutrack.Section
+	var promoted []edutrack.Enrollment
+	err = s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		var err error
+		section, promoted, err = edutrack.UpdateSection(r.Context(), tx, uint(id), edutrack.SectionUpdate{
+			Name:      req.Name,
+			Capacity:  req.Capacity,
+			Schedule:  req.Schedule,
+			TeacherID: req.TeacherID,
+		})
+		if err != nil {
+			return err
+		}
+		return s.notifyPromoted(tx, section, promoted)
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	setETag(w, section.Model)
+	sendJSON(w, http.StatusOK, newSectionResponse(section, inc))
+}
+
+// handleDeleteSection handles DELETE /sections/{id}.
+func (s *Server) handleDeleteSection(w http.ResponseWriter, r *http.Request) {
+	section := s.findSection(w, r)
+	if section == nil {
+		return
+	}
+
+	if err := edutrack.CheckIfMatch(r.Context(), section.Model); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	// Sections with students cannot be deleted.
+	if isDeletePreview(r) {
+		preview, err := edutrack.PreviewDeleteSection(r.Context(), s.DB, section)
+		if err != nil {
+			s.sendAppError(w, r, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, preview)
+		return
+	}
+
+	if err := edutrack.DeleteSection(r.Context(), s.DB, section); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListSectionStudents handles GET /sections/{id}/students.
+// It lists the enrolled students of a section and then its waitlist.
+// Access is granted to secretaries and the teachers of the section and its
+// subject.
+func (s *Server) handleListSectionStudents(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, enrollmentIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	section := s.findSection(w, r)
+	if section == nil {
+		return
+	}
+
+	enrollments, err := edutrack.FindEnrollments(r.Context(), s.DB, section)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	response := make([]EnrollmentResponse, 0, len(enrollments))
+	for i := range enrollments {
+		response = append(response, newEnrollmentResponse(&enrollments[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// EnrollStudentRequest represents the request body for enrolling a student
+// in a section.
+type EnrollStudentRequest struct {
+	StudentID uint `json:"student_id" validate:"required"`
+}
+
+// handleEnrollStudent handles POST /sections/{id}/students.
+// It enrolls a student in a section, or in its waitlist if it is full.
+func (s *Server) handleEnrollStudent(w http.ResponseWriter, r *http.Request) {
+	inc, err := parseInclude(r, enrollmentIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	var req EnrollStudentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	section := s.findSection(w, r)
+	if section == nil {
+		return
+	}
+
+	var enrollment *edutrack.Enrollment
+	err = s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		var err error
+		enrollment, err = edutrack.EnrollStudent(r.Context(), tx, section, req.StudentID)
+		if err != nil {
+			return err
+		}
+		return s.emitWebhook(tx, section.TenantID, edutrack.WebhookEnrollmentChanged, edutrack.EnrollmentChange{
+			Action:    string(enrollment.Status),
+			SubjectID: section.SubjectID,
+			SectionID: section.ID,
+			StudentID: enrollment.StudentID,
+		})
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, newEnrollmentResponse(enrollment, inc))
+}
+
+// handleUnenrollStudent handles DELETE /sections/{id}/students/{student_id}.
+// It removes a student from a section or its waitlist, giving the place to
+// the first waitlisted student.
+func (s *Server) handleUnenrollStudent(w http.ResponseWriter, r *http.Request) {
+	studentID, err := strconv.ParseUint(r.PathValue("student_id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	section := s.findSection(w, r)
+	if section == nil {
+		return
+	}
+
+	err = s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		promoted, err := edutrack.UnenrollStudent(r.Context(), tx, section, uint(studentID))
+		if err != nil {
+			return err
+		}
+		err = s.emitWebhook(tx, section.TenantID, edutrack.WebhookEnrollmentChanged, edutrack.EnrollmentChange{
+			Action:    "removed",
+			SubjectID: section.SubjectID,
+			SectionID: section.ID,
+			StudentID: uint(studentID),
+		})
+		if err != nil {
+			return err
+		}
+		return s.notifyPromoted(tx, section, promoted)
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// notifyPromoted notifies the waitlisted students given a place in a
+// section, which must have its subject loaded, and emits their enrollment.
+func (s *Server) notifyPromoted(tx *gorm.DB, section *edutrack.Section, promoted []edutrack.Enrollment) error {
+	for _, enrollment := range promoted {
+		err := s.emitWebhook(tx, section.TenantID, edutrack.WebhookEnrollmentChanged, edutrack.EnrollmentChange{
+			Action:    string(edutrack.EnrollmentEnrolled),
+			SubjectID: section.SubjectID,
+			SectionID: section.ID,
+			StudentID: enrollment.StudentID,
+		})
+		if err != nil {
+			return err
+		}
+		err = s.notifyStudent(tx, enrollment.StudentID, edutrack.NotificationEnrollmentPromoted,
+			section.Name, section.Subject.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// createSectionStudent creates a student of the career of a tenant.
+func createSectionStudent(t *testing.T, server *Server, seed *isolationTenant, number string) *StudentResponse {
+	t.Helper()
+
+	w := isolationRequest(t, server, seed, http.MethodPost, "/students", map[string]any{
+		"student_id": number,
+		"name":       "Alumno " + number,
+		"email":      number + "@example.com",
+		"password":   "password123",
+		"career_id":  seed.Career.ID,
+		"semester":   1,
+	})
+	var student StudentResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &student); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST /students status = %d: %s", w.Code, w.Body.String())
+	}
+	return &student
+}
+
+func TestSectionEnrollmentWorkflow(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+	first := createSectionStudent(t, server, tenant, "20260101")
+	second := createSectionStudent(t, server, tenant, "20260102")
+	third := createSectionStudent(t, server, tenant, "20260103")
+
+	enroll := func(sectionID, studentID uint) *EnrollmentResponse {
+		t.Helper()
+		path := fmt.Sprintf("/sections/%d/students", sectionID)
+		w := isolationRequest(t, server, tenant, http.MethodPost, path, EnrollStudentRequest{StudentID: studentID})
+		var enrollment EnrollmentResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &enrollment); err != nil || w.Code != http.StatusCreated {
+			t.Fatalf("POST %s status = %d: %s", path, w.Code, w.Body.String())
+		}
+		return &enrollment
+	}
+	isSubjectStudent := func(studentID uint) bool {
+		var count int64
+		db.Table("student_subjects").Where("subject_id = ? AND student_id = ?", tenant.Subject.ID, studentID).Count(&count)
+		return count > 0
+	}
+
+	// A second section of the subject with a single place.
+	w := isolationRequest(t, server, tenant, http.MethodPost, "/sections", CreateSectionRequest{
+		Name: "B", Capacity: 1, Schedule: "Mar y Jue 10:00-12:00", SubjectID: tenant.Subject.ID,
+	})
+	var section SectionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &section); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST /sections status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := isolationRequest(t, server, tenant, http.MethodPost, "/sections", CreateSectionRequest{Name: "B", SubjectID: tenant.Subject.ID}); w.Code != http.StatusConflict {
+		t.Errorf("POST /sections with a taken name status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	// The first student takes the place and the second one waits.
+	if enrollment := enroll(section.ID, first.ID); enrollment.Status != edutrack.EnrollmentEnrolled || enrollment.Position != 0 {
+		t.Errorf("Enrollment = %+v, want enrolled", enrollment)
+	}
+	if enrollment := enroll(section.ID, second.ID); enrollment.Status != edutrack.EnrollmentWaitlisted || enrollment.Position != 1 {
+		t.Errorf("Enrollment = %+v, want waitlisted first", enrollment)
+	}
+	if !isSubjectStudent(first.ID) || isSubjectStudent(second.ID) {
+		t.Error("Only the enrolled students should be students of the subject")
+	}
+
+	// Students are in a single section of a subject.
+	path := fmt.Sprintf("/sections/%d/students", section.ID)
+	if w := isolationRequest(t, server, tenant, http.MethodPost, path, EnrollStudentRequest{StudentID: tenant.Student.ID}); w.Code != http.StatusConflict {
+		t.Errorf("POST %s of a student of another section status = %d, want %d", path, w.Code, http.StatusConflict)
+	}
+
+	var roster []EnrollmentResponse
+	w = isolationRequest(t, server, tenant, http.MethodGet, path+"?include=student.account", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &roster); err != nil {
+		t.Fatalf("GET %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if len(roster) != 2 || roster[0].StudentID != first.ID || roster[1].StudentID != second.ID || roster[1].Position != 1 || roster[0].Student == nil {
+		t.Errorf("Roster = %+v, want the enrolled student and then the waitlisted one", roster)
+	}
+
+	// The teacher of the subject sees the roster; students do not.
+	var teacher edutrack.Account
+	db.First(&teacher, tenant.Teacher.AccountID)
+	if w := accountRequest(t, server, &teacher, http.MethodGet, path, nil); w.Code != http.StatusOK {
+		t.Errorf("GET %s as teacher status = %d, want %d", path, w.Code, http.StatusOK)
+	}
+	if w := accountRequest(t, server, studentAccount(t, server, tenant), http.MethodGet, path, nil); w.Code != http.StatusForbidden {
+		t.Errorf("GET %s as student status = %d, want %d", path, w.Code, http.StatusForbidden)
+	}
+
+	// Removing the enrolled student gives the place to the waitlisted one,
+	// who is notified.
+	if w := isolationRequest(t, server, tenant, http.MethodDelete, fmt.Sprintf("%s/%d", path, first.ID), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE %s/%d status = %d: %s", path, first.ID, w.Code, w.Body.String())
+	}
+	if isSubjectStudent(first.ID) || !isSubjectStudent(second.ID) {
+		t.Error("The waitlisted student should replace the removed one in the subject")
+	}
+	var notified int64
+	db.Model(&edutrack.Notification{}).
+		Where("account_id = ? AND event = ?", second.AccountID, edutrack.NotificationEnrollmentPromoted).
+		Count(&notified)
+	if notified == 0 {
+		t.Error("The promoted student was not notified")
+	}
+	if w := isolationRequest(t, server, tenant, http.MethodDelete, fmt.Sprintf("%s/%d", path, first.ID), nil); w.Code != http.StatusNotFound {
+		t.Errorf("DELETE of a student not in the section status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// Raising the capacity enrolls the waitlist; it cannot be lowered below
+	// the enrolled students.
+	if enrollment := enroll(section.ID, third.ID); enrollment.Status != edutrack.EnrollmentWaitlisted {
+		t.Errorf("Enrollment = %+v, want waitlisted", enrollment)
+	}
+	sectionPath := fmt.Sprintf("/sections/%d", section.ID)
+	w = isolationRequest(t, server, tenant, http.MethodPatch, sectionPath, map[string]any{"capacity": 2})
+	if err := json.Unmarshal(w.Body.Bytes(), &section); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("PATCH %s status = %d: %s", sectionPath, w.Code, w.Body.String())
+	}
+	if section.Enrolled != 2 || section.Waitlisted != 0 || !isSubjectStudent(third.ID) {
+		t.Errorf("Section = %+v, want the waitlisted student enrolled", section)
+	}
+	if w := isolationRequest(t, server, tenant, http.MethodPatch, sectionPath, map[string]any{"capacity": 1}); w.Code != http.StatusConflict {
+		t.Errorf("PATCH %s below the enrolled students status = %d, want %d", sectionPath, w.Code, http.StatusConflict)
+	}
+
+	// Attendance and grades are recorded in the section of the student.
+	w = isolationRequest(t, server, tenant, http.MethodPost, "/attendances", map[string]any{
+		"date": "2026-03-03", "status": "present", "student_id": second.ID, "subject_id": tenant.Subject.ID,
+	})
+	var attendance AttendanceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &attendance); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST /attendances status = %d: %s", w.Code, w.Body.String())
+	}
+	if attendance.SectionID == nil || *attendance.SectionID != section.ID {
+		t.Errorf("Attendance SectionID = %v, want %d", attendance.SectionID, section.ID)
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodPost, "/grades", map[string]any{
+		"value": 8, "student_id": second.ID, "topic_id": tenant.Topic.ID,
+	})
+	var grade GradeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &grade); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST /grades status = %d: %s", w.Code, w.Body.String())
+	}
+	if grade.SectionID == nil || *grade.SectionID != section.ID {
+		t.Errorf("Grade SectionID = %v, want %d", grade.SectionID, section.ID)
+	}
+
+	var grades []GradeResponse
+	w = isolationRequest(t, server, tenant, http.MethodGet, fmt.Sprintf("/grades?section_id=%d", section.ID), nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &grades); err != nil || len(grades) != 1 || grades[0].ID != grade.ID {
+		t.Errorf("GET /grades?section_id=%d = %s, want the grade of the section", section.ID, w.Body.String())
+	}
+}
+
+func TestDeleteSection_WithStudents(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+	path := fmt.Sprintf("/sections/%d", tenant.Section.ID)
+
+	// The seeded section has a student, who would stay in the subject.
+	if w := isolationRequest(t, server, tenant, http.MethodDelete, path, nil); w.Code != http.StatusConflict {
+		t.Fatalf("DELETE %s with students status = %d, want %d", path, w.Code, http.StatusConflict)
+	}
+	var preview edutrack.DeletePreview
+	w := isolationRequest(t, server, tenant, http.MethodDelete, path+"?preview=true", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("DELETE %s?preview=true status = %d: %s", path, w.Code, w.Body.String())
+	}
+	want := edutrack.DeleteEffect{Type: "enrollments", Field: "section_id", Rule: edutrack.DeleteRestrict, Count: 1}
+	if preview.Allowed || !slices.Contains(preview.Effects, want) {
+		t.Errorf("Preview = %+v, want it restricted by the enrollment", preview)
+	}
+
+	// Once the students are unenrolled, it can be deleted.
+	studentPath := fmt.Sprintf("%s/students/%d", path, tenant.Student.ID)
+	if w := isolationRequest(t, server, tenant, http.MethodDelete, studentPath, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE %s status = %d: %s", studentPath, w.Code, w.Body.String())
+	}
+	if w := isolationRequest(t, server, tenant, http.MethodDelete, path, nil); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+
+	var count int64
+	db.Table("student_subjects").Where("subject_id = ? AND student_id = ?", tenant.Subject.ID, tenant.Student.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("The student is still in the subject of the deleted section")
+	}
+}
+
+func TestHandleUpdateSection_UnassignTeacher(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+	path := fmt.Sprintf("/sections/%d", tenant.Section.ID)
+
+	w := etagRequest(t, server, tenant, http.MethodPatch, path, "*", MergePatchContentType, `{"teacher_id": null}`)
+	var section SectionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &section); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("PATCH %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if section.TeacherID != nil {
+		t.Errorf("Response teacher_id = %d, want null", *section.TeacherID)
+	}
+
+	var stored edutrack.Section
+	db.First(&stored, tenant.Section.ID)
+	if stored.TeacherID != nil {
+		t.Errorf("TeacherID = %d, want it unassigned", *stored.TeacherID)
+	}
+	if stored.Name != tenant.Section.Name || stored.Capacity != tenant.Section.Capacity {
+		t.Error("Members absent from the patch should not change")
+	}
+}
+
+func TestMigrate_Sections(t *testing.T) {
+	db := setupIsolationTestDB(t)
+	tenant := seedIsolationTenant(t, db, "TENANT-A-SECRET")
+
+	// Drop the sections, as in databases of previous versions.
+	if err := db.Migrator().DropTable(&edutrack.Enrollment{}, &edutrack.Section{}); err != nil {
+		t.Fatalf("Failed to drop sections: %v", err)
+	}
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	// The students of each subject are enrolled in a section of its teacher.
+	var sections []edutrack.Section
+	db.Find(&sections)
+	if len(sections) != 1 || sections[0].SubjectID != tenant.Subject.ID || sections[0].Name != "A" ||
+		sections[0].TeacherID == nil || *sections[0].TeacherID != tenant.Teacher.ID {
+		t.Fatalf("Sections = %+v, want a section A of the subject", sections)
+	}
+	var enrollments []edutrack.Enrollment
+	db.Find(&enrollments)
+	if len(enrollments) != 1 || enrollments[0].StudentID != tenant.Student.ID || enrollments[0].Status != edutrack.EnrollmentEnrolled {
+		t.Errorf("Enrollments = %+v, want the student of the subject enrolled", enrollments)
+	}
+
+	// Later migrations leave the sections alone.
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	var count int64
+	db.Model(&edutrack.Section{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Sections after migrating again = %d, want 1", count)
+	}
+}
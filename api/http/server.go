@@ -3,13 +3,21 @@ package http
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/gorm"
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/storage"
 )
 
+// DefaultTokenLifetime is the lifetime of the issued tokens by default.
+const DefaultTokenLifetime = 24 * time.Hour
+
 // Server represents the HTTP server for the API.
 type Server struct {
 	server *http.Server
@@ -18,150 +26,454 @@ type Server struct {
 	// Database connection.
 	DB *gorm.DB
 
+	// Services of the domain layer, backed by DB by default. Only students,
+	// grades and the trash go through a service; the handlers of the other
+	// resources still query DB directly.
+	StudentService edutrack.StudentService
+	GradeService   edutrack.GradeService
+	TrashService   edutrack.TrashService
+
 	// JWT secret key for authentication.
 	JWTSecret []byte
 
 	// CORS configuration.
 	CORSConfig *CORSConfig
+
+	// Lifetime of the issued tokens.
+	TokenLifetime time.Duration
+
+	// Structured logger for access logs and errors.
+	Logger *slog.Logger
+
+	// Request and authentication metrics exposed at /metrics.
+	Metrics *Metrics
+
+	// Time to keep serving after readiness starts failing on shutdown, so
+	// load balancers stop sending traffic before connections are drained.
+	ShutdownDelay time.Duration
+
+	// Certificate served over HTTPS, if enabled.
+	TLS *CertReloader
+
+	// Backend storing the uploaded files. Nil disables uploads.
+	Storage storage.Storage
+
+	// Lifetime of the signed download URLs of files.
+	FileURLLifetime time.Duration
+
+	// Plain HTTP listener redirecting to HTTPS, if enabled.
+	redirect *http.Server
+
+	// Patterns of the registered routes, in registration order.
+	routes []string
+
+	// Whether the server is shutting down (readiness fails).
+	shuttingDown atomic.Bool
 }
 
-// NewServer creates a new HTTP server.
-func NewServer(addr string, db *gorm.DB, jwtSecret []byte) *Server {
-	s := &Server{
-		router:     http.NewServeMux(),
-		DB:         db,
-		JWTSecret:  jwtSecret,
-		CORSConfig: DefaultCORSConfig(),
-	}
+// Options configures a new HTTP server. Zero values use the defaults.
+type Options struct {
+	// Address to listen on (e.g., ":8080").
+	Addr string
 
-	s.registerRoutes()
+	// JWT secret key for authentication.
+	JWTSecret []byte
 
-	// Wrap the router with CORS middleware.
-	handler := withCORS(s.CORSConfig, s.router)
+	// CORS configuration. Nil uses DefaultCORSConfig.
+	CORS *CORSConfig
 
-	s.server = &http.Server{
-		Addr:         addr,
-		Handler:      handler,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
+	// Lifetime of the issued tokens. Defaults to 24 hours.
+	TokenLifetime time.Duration
 
-	return s
+	// Timeouts of the underlying http.Server. Default to 10s, 30s and 60s.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	// Structured logger. Defaults to JSON on standard output.
+	Logger *slog.Logger
+
+	// Time to keep serving after readiness starts failing on shutdown.
+	ShutdownDelay time.Duration
+
+	// Certificate to serve HTTPS with. Nil serves plain HTTP.
+	TLS *CertReloader
+
+	// Address of the plain HTTP listener redirecting to HTTPS. Empty
+	// disables it. Only used with TLS.
+	RedirectAddr string
+
+	// Max age of the Strict-Transport-Security header sent over HTTPS.
+	// Zero disables HSTS.
+	HSTSMaxAge time.Duration
+
+	// Backend storing the uploaded files. Nil disables uploads.
+	Storage storage.Storage
+
+	// Lifetime of the signed download URLs of files. Defaults to 15 minutes.
+	FileURLLifetime time.Duration
+}
+
+// NewServer creates a new HTTP server.
+func NewServer(addr string, db *gorm.DB, jwtSecret []byte) *Server {
+	return NewServerWithOptions(db, Options{Addr: addr, JWTSecret: jwtSecret})
 }
 
 // NewServerWithCORS creates a new HTTP server with custom CORS configuration.
 func NewServerWithCORS(addr string, db *gorm.DB, jwtSecret []byte, corsConfig *CORSConfig) *Server {
+	return NewServerWithOptions(db, Options{Addr: addr, JWTSecret: jwtSecret, CORS: corsConfig})
+}
+
+// NewServerWithOptions creates a new HTTP server with the given options.
+func NewServerWithOptions(db *gorm.DB, opts Options) *Server {
 	s := &Server{
-		router:     http.NewServeMux(),
-		DB:         db,
-		JWTSecret:  jwtSecret,
-		CORSConfig: corsConfig,
+		router:        http.NewServeMux(),
+		DB:            db,
+		JWTSecret:     opts.JWTSecret,
+		CORSConfig:    opts.CORS,
+		TokenLifetime: opts.TokenLifetime,
+		Logger:        opts.Logger,
+		Metrics:       NewMetrics(),
+		ShutdownDelay: opts.ShutdownDelay,
+		TLS:           opts.TLS,
+
+		Storage:         opts.Storage,
+		FileURLLifetime: opts.FileURLLifetime,
+
+		StudentService: edutrack.NewStudentService(db),
+		GradeService:   edutrack.NewGradeService(db),
+		TrashService:   edutrack.NewTrashService(db),
 	}
 
 	if s.CORSConfig == nil {
 		s.CORSConfig = DefaultCORSConfig()
 	}
+	if s.TokenLifetime <= 0 {
+		s.TokenLifetime = DefaultTokenLifetime
+	}
+	if s.FileURLLifetime <= 0 {
+		s.FileURLLifetime = DefaultFileURLLifetime
+	}
+	if s.Logger == nil {
+		s.Logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	}
+
+	if db != nil {
+		if err := edutrack.RegisterTenantScope(db); err != nil {
+			s.Logger.Error("failed to register tenant scope", "error", err)
+		}
+	}
 
 	s.registerRoutes()
 
-	// Wrap the router with CORS middleware.
-	handler := withCORS(s.CORSConfig, s.router)
+	// Wrap the router with CORS, security headers, access log and request
+	// ID middlewares.
+	handler := withRequestID(s.withAccessLog(withSecurityHeaders(opts.HSTSMaxAge, withCORS(s.CORSConfig, s.router))))
 
 	s.server = &http.Server{
-		Addr:         addr,
+		Addr:         opts.Addr,
 		Handler:      handler,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  orDefault(opts.ReadTimeout, 10*time.Second),
+		WriteTimeout: orDefault(opts.WriteTimeout, 30*time.Second),
+		IdleTimeout:  orDefault(opts.IdleTimeout, 60*time.Second),
+	}
+
+	if s.TLS != nil && opts.RedirectAddr != "" {
+		s.redirect = &http.Server{
+			Addr:         opts.RedirectAddr,
+			Handler:      redirectToHTTPS(opts.Addr),
+			ReadTimeout:  s.server.ReadTimeout,
+			WriteTimeout: s.server.WriteTimeout,
+			IdleTimeout:  s.server.IdleTimeout,
+		}
 	}
 
 	return s
 }
 
-// Start starts the HTTP server.
+// orDefault returns d, or def if d is not positive.
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// Start starts the HTTP server, and the HTTPS redirect listener if enabled.
 func (s *Server) Start() error {
-	log.Printf("Starting server on %s", s.server.Addr)
-	return s.server.ListenAndServe()
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+
+	if s.redirect != nil {
+		go func() {
+			s.Logger.Info("starting HTTPS redirect", "addr", s.redirect.Addr)
+			if err := s.redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.Logger.Error("HTTPS redirect failed", "error", err)
+			}
+		}()
+	}
+
+	s.Logger.Info("starting server", "addr", s.server.Addr, "tls", s.TLS != nil)
+	return s.Serve(ln)
+}
+
+// Serve serves requests on the listener, over HTTPS if TLS is enabled.
+func (s *Server) Serve(ln net.Listener) error {
+	if s.TLS != nil {
+		s.server.TLSConfig = s.TLS.tlsConfig()
+		return s.server.ServeTLS(ln, "", "")
+	}
+	return s.server.Serve(ln)
 }
 
 // Close shuts down the HTTP server immediately.
 func (s *Server) Close() error {
+	if s.redirect != nil {
+		_ = s.redirect.Close()
+	}
 	return s.server.Close()
 }
 
 // Shutdown gracefully shuts down the HTTP server.
+// Readiness starts failing first; after ShutdownDelay the server stops
+// accepting connections and waits for the active ones to finish.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.shuttingDown.Store(true)
+
+	if s.ShutdownDelay > 0 {
+		select {
+		case <-time.After(s.ShutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	if s.redirect != nil {
+		_ = s.redirect.Shutdown(ctx)
+	}
 	return s.server.Shutdown(ctx)
 }
 
 // registerRoutes registers all API routes.
 func (s *Server) registerRoutes() {
+	// API specification, metrics and health (public)
+	s.handleFunc("GET /openapi.json", s.handleOpenAPI)
+	s.handleFunc("GET /metrics", s.handleMetrics)
+	s.handleFunc("GET /healthz", s.handleHealthz)
+	s.handleFunc("GET /readyz", s.handleReadyz)
+
 	// Auth (public)
-	s.router.HandleFunc("POST /auth/login", s.handleLogin)
-	s.router.HandleFunc("POST /auth/license", s.handleLicenseLogin)
+	s.handleFunc("POST /auth/login", s.handleLogin)
+	s.handleFunc("POST /auth/license", s.handleLicenseLogin)
+
+	// Files of the local storage (public, with a signed URL)
+	s.handleFunc("GET "+FilesPath+"/{key...}", s.handleGetFile)
 
 	// Protected routes (require authentication)
 	protected := s.withAuth
 
+	// Protected routes closed to guardians.
+	restricted := s.withoutGuardian
+
+	// Updates (PUT, and PATCH with a JSON Merge Patch) and deletes of the
+	// resources with an ETag require an If-Match precondition.
+	versioned := withIfMatch
+
 	// Accounts
-	s.router.HandleFunc("GET /accounts", protected(s.handleListAccounts))
-	s.router.HandleFunc("GET /accounts/{id}", protected(s.handleGetAccount))
-	s.router.HandleFunc("POST /accounts", protected(s.handleCreateAccount))
-	s.router.HandleFunc("PUT /accounts/{id}", protected(s.handleUpdateAccount))
-	s.router.HandleFunc("DELETE /accounts/{id}", protected(s.handleDeleteAccount))
+	s.handleFunc("GET /accounts", restricted(s.handleListAccounts))
+	s.handleFunc("GET /accounts/{id}", restricted(s.handleGetAccount))
+	s.handleFunc("POST /accounts", restricted(s.handleCreateAccount))
+	s.handleFunc("PUT /accounts/{id}", restricted(versioned(s.handleUpdateAccount)))
+	s.handleFunc("PATCH /accounts/{id}", restricted(versioned(withMergePatch(s.handleUpdateAccount))))
+	s.handleFunc("DELETE /accounts/{id}", restricted(versioned(s.handleDeleteAccount)))
+	s.handleFunc("GET /accounts/{id}/avatar", protected(s.handleGetAccountAvatar))
+	s.handleFunc("PUT /accounts/{id}/avatar", protected(s.handleUploadAccountAvatar))
+	s.handleFunc("DELETE /accounts/{id}/avatar", protected(s.handleDeleteAccountAvatar))
 
 	// Students
-	s.router.HandleFunc("GET /students", protected(s.handleListStudents))
-	s.router.HandleFunc("GET /students/{id}", protected(s.handleGetStudent))
-	s.router.HandleFunc("POST /students", protected(s.handleCreateStudent))
-	s.router.HandleFunc("PUT /students/{id}", protected(s.handleUpdateStudent))
-	s.router.HandleFunc("DELETE /students/{id}", protected(s.handleDeleteStudent))
+	s.handleFunc("GET /students", protected(s.handleListStudents))
+	s.handleFunc("GET /students/{id}", protected(s.handleGetStudent))
+	s.handleFunc("POST /students", restricted(s.handleCreateStudent))
+	s.handleFunc("PUT /students/{id}", restricted(versioned(s.handleUpdateStudent)))
+	s.handleFunc("PATCH /students/{id}", restricted(versioned(withMergePatch(s.handleUpdateStudent))))
+	s.handleFunc("DELETE /students/{id}", restricted(versioned(s.handleDeleteStudent)))
+
+	// Guardians
+	s.handleFunc("GET /guardians", protected(s.handleListGuardians))
+	s.handleFunc("GET /guardians/{id}", protected(s.handleGetGuardian))
+	s.handleFunc("POST /guardians", protected(s.handleCreateGuardian))
+	s.handleFunc("POST /guardians/{id}/students", protected(s.handleLinkGuardianStudent))
+	s.handleFunc("DELETE /guardians/{id}/students/{student_id}", protected(s.handleUnlinkGuardianStudent))
 
 	// Teachers
-	s.router.HandleFunc("GET /teachers", protected(s.handleListTeachers))
-	s.router.HandleFunc("GET /teachers/{id}", protected(s.handleGetTeacher))
-	s.router.HandleFunc("POST /teachers", protected(s.handleCreateTeacher))
-	s.router.HandleFunc("PUT /teachers/{id}", protected(s.handleUpdateTeacher))
-	s.router.HandleFunc("DELETE /teachers/{id}", protected(s.handleDeleteTeacher))
+	s.handleFunc("GET /teachers", restricted(s.handleListTeachers))
+	s.handleFunc("GET /teachers/{id}", restricted(s.handleGetTeacher))
+	s.handleFunc("POST /teachers", restricted(s.handleCreateTeacher))
+	s.handleFunc("PUT /teachers/{id}", restricted(versioned(s.handleUpdateTeacher)))
+	s.handleFunc("PATCH /teachers/{id}", restricted(versioned(withMergePatch(s.handleUpdateTeacher))))
+	s.handleFunc("DELETE /teachers/{id}", restricted(versioned(s.handleDeleteTeacher)))
 
 	// Careers
-	s.router.HandleFunc("GET /careers", protected(s.handleListCareers))
-	s.router.HandleFunc("GET /careers/{id}", protected(s.handleGetCareer))
-	s.router.HandleFunc("POST /careers", protected(s.handleCreateCareer))
-	s.router.HandleFunc("PUT /careers/{id}", protected(s.handleUpdateCareer))
-	s.router.HandleFunc("DELETE /careers/{id}", protected(s.handleDeleteCareer))
+	s.handleFunc("GET /careers", restricted(s.handleListCareers))
+	s.handleFunc("GET /careers/{id}", restricted(s.handleGetCareer))
+	s.handleFunc("POST /careers", restricted(s.handleCreateCareer))
+	s.handleFunc("PUT /careers/{id}", restricted(versioned(s.handleUpdateCareer)))
+	s.handleFunc("PATCH /careers/{id}", restricted(versioned(withMergePatch(s.handleUpdateCareer))))
+	s.handleFunc("DELETE /careers/{id}", restricted(versioned(s.handleDeleteCareer)))
 
 	// Subjects
-	s.router.HandleFunc("GET /subjects", protected(s.handleListSubjects))
-	s.router.HandleFunc("GET /subjects/{id}", protected(s.handleGetSubject))
-	s.router.HandleFunc("POST /subjects", protected(s.handleCreateSubject))
-	s.router.HandleFunc("PUT /subjects/{id}", protected(s.handleUpdateSubject))
-	s.router.HandleFunc("DELETE /subjects/{id}", protected(s.handleDeleteSubject))
-	s.router.HandleFunc("GET /subjects/{id}/students", protected(s.handleListSubjectStudents))
-	s.router.HandleFunc("POST /subjects/{id}/students", protected(s.handleAddStudentToSubject))
-	s.router.HandleFunc("DELETE /subjects/{id}/students/{student_id}", protected(s.handleRemoveStudentFromSubject))
+	s.handleFunc("GET /subjects", restricted(s.handleListSubjects))
+	s.handleFunc("GET /subjects/{id}", restricted(s.handleGetSubject))
+	s.handleFunc("POST /subjects", restricted(s.handleCreateSubject))
+	s.handleFunc("PUT /subjects/{id}", restricted(versioned(s.handleUpdateSubject)))
+	s.handleFunc("PATCH /subjects/{id}", restricted(versioned(withMergePatch(s.handleUpdateSubject))))
+	s.handleFunc("DELETE /subjects/{id}", restricted(versioned(s.handleDeleteSubject)))
+	s.handleFunc("GET /subjects/{id}/students", restricted(s.handleListSubjectStudents))
+
+	// Sections
+	s.handleFunc("GET /sections", restricted(s.handleListSections))
+	s.handleFunc("GET /sections/{id}", restricted(s.handleGetSection))
+	s.handleFunc("POST /sections", s.withSecretary(s.handleCreateSection))
+	s.handleFunc("PUT /sections/{id}", s.withSecretary(versioned(s.handleUpdateSection)))
+	s.handleFunc("PATCH /sections/{id}", s.withSecretary(versioned(withMergePatch(s.handleUpdateSection))))
+	s.handleFunc("DELETE /sections/{id}", s.withSecretary(versioned(s.handleDeleteSection)))
+	s.handleFunc("GET /sections/{id}/students", restricted(s.handleListSectionStudents))
+	s.handleFunc("POST /sections/{id}/students", s.withSecretary(s.handleEnrollStudent))
+	s.handleFunc("DELETE /sections/{id}/students/{student_id}", s.withSecretary(s.handleUnenrollStudent))
 
 	// Topics
-	s.router.HandleFunc("GET /topics", protected(s.handleListTopics))
-	s.router.HandleFunc("GET /topics/{id}", protected(s.handleGetTopic))
-	s.router.HandleFunc("POST /topics", protected(s.handleCreateTopic))
-	s.router.HandleFunc("PUT /topics/{id}", protected(s.handleUpdateTopic))
-	s.router.HandleFunc("DELETE /topics/{id}", protected(s.handleDeleteTopic))
+	s.handleFunc("GET /topics", restricted(s.handleListTopics))
+	s.handleFunc("GET /topics/{id}", restricted(s.handleGetTopic))
+	s.handleFunc("POST /topics", restricted(s.handleCreateTopic))
+	s.handleFunc("PUT /topics/{id}", restricted(versioned(s.handleUpdateTopic)))
+	s.handleFunc("PATCH /topics/{id}", restricted(versioned(withMergePatch(s.handleUpdateTopic))))
+	s.handleFunc("DELETE /topics/{id}", restricted(versioned(s.handleDeleteTopic)))
+	s.handleFunc("POST /topics/{id}/publish", restricted(s.handlePublishTopicGrades))
 
 	// Attendances
-	s.router.HandleFunc("GET /attendances", protected(s.handleListAttendances))
-	s.router.HandleFunc("GET /attendances/{id}", protected(s.handleGetAttendance))
-	s.router.HandleFunc("POST /attendances", protected(s.handleCreateAttendance))
-	s.router.HandleFunc("PUT /attendances/{id}", protected(s.handleUpdateAttendance))
-	s.router.HandleFunc("DELETE /attendances/{id}", protected(s.handleDeleteAttendance))
+	s.handleFunc("GET /attendances", protected(s.handleListAttendances))
+	s.handleFunc("GET /attendances/{id}", protected(s.handleGetAttendance))
+	s.handleFunc("POST /attendances", restricted(s.handleCreateAttendance))
+	s.handleFunc("PUT /attendances/{id}", restricted(versioned(s.handleUpdateAttendance)))
+	s.handleFunc("PATCH /attendances/{id}", restricted(versioned(withMergePatch(s.handleUpdateAttendance))))
+	s.handleFunc("DELETE /attendances/{id}", restricted(versioned(s.handleDeleteAttendance)))
+
+	// Absence justifications
+	s.handleFunc("GET /justifications", protected(s.handleListJustifications))
+	s.handleFunc("GET /justifications/{id}", protected(s.handleGetJustification))
+	s.handleFunc("POST /justifications", protected(s.handleCreateJustification))
+	s.handleFunc("GET /justifications/{id}/document", protected(s.handleGetJustificationDocument))
+	s.handleFunc("POST /justifications/{id}/approve", s.withSecretary(s.handleApproveJustification))
+	s.handleFunc("POST /justifications/{id}/reject", s.withSecretary(s.handleRejectJustification))
+
+	// Notifications
+	s.handleFunc("GET /notifications", protected(s.handleListNotifications))
+	s.handleFunc("PUT /notifications/{id}/read", protected(s.handleReadNotification))
+	s.handleFunc("GET /notifications/preferences", protected(s.handleListNotificationPreferences))
+	s.handleFunc("PUT /notifications/preferences", protected(s.handleUpdateNotificationPreference))
+
+	// Tenant settings and logo
+	s.handleFunc("GET /tenant/settings", protected(s.handleGetTenantSettings))
+	s.handleFunc("PUT /tenant/settings", s.withSecretary(s.handleUpdateTenantSettings))
+	s.handleFunc("GET /tenant/logo", protected(s.handleGetTenantLogo))
+	s.handleFunc("PUT /tenant/logo", s.withSecretary(s.handleUploadTenantLogo))
+	s.handleFunc("DELETE /tenant/logo", s.withSecretary(s.handleDeleteTenantLogo))
+
+	// Webhooks
+	s.handleFunc("GET /webhooks", s.withSecretary(s.handleListWebhooks))
+	s.handleFunc("GET /webhooks/{id}", s.withSecretary(s.handleGetWebhook))
+	s.handleFunc("POST /webhooks", s.withSecretary(s.handleCreateWebhook))
+	s.handleFunc("PUT /webhooks/{id}", s.withSecretary(versioned(s.handleUpdateWebhook)))
+	s.handleFunc("PATCH /webhooks/{id}", s.withSecretary(versioned(withMergePatch(s.handleUpdateWebhook))))
+	s.handleFunc("DELETE /webhooks/{id}", s.withSecretary(versioned(s.handleDeleteWebhook)))
+	s.handleFunc("GET /webhooks/{id}/deliveries", s.withSecretary(s.handleListWebhookDeliveries))
+	s.handleFunc("POST /webhooks/{id}/deliveries/{delivery_id}/replay", s.withSecretary(s.handleReplayWebhookDelivery))
+
+	// API keys
+	s.handleFunc("GET /api-keys", s.withSecretary(s.handleListAPIKeys))
+	s.handleFunc("POST /api-keys", s.withSecretary(s.handleCreateAPIKey))
+	s.handleFunc("DELETE /api-keys/{id}", s.withSecretary(s.handleRevokeAPIKey))
+
+	// Trash
+	s.handleFunc("GET /trash", s.withSecretary(s.handleListTrash))
+	s.handleFunc("POST /trash/{type}/{id}/restore", s.withSecretary(s.handleRestoreTrash))
+	s.handleFunc("DELETE /trash/{type}/{id}", s.withSecretary(s.handlePurgeTrash))
 
 	// Grades
-	s.router.HandleFunc("GET /grades", protected(s.handleListGrades))
-	s.router.HandleFunc("GET /grades/{id}", protected(s.handleGetGrade))
-	s.router.HandleFunc("POST /grades", protected(s.handleCreateGrade))
-	s.router.HandleFunc("PUT /grades/{id}", protected(s.handleUpdateGrade))
-	s.router.HandleFunc("DELETE /grades/{id}", protected(s.handleDeleteGrade))
+	s.handleFunc("GET /grades", protected(s.handleListGrades))
+	s.handleFunc("GET /grades/{id}", protected(s.handleGetGrade))
+	s.handleFunc("POST /grades", restricted(s.handleCreateGrade))
+	s.handleFunc("PUT /grades/{id}", restricted(versioned(s.handleUpdateGrade)))
+	s.handleFunc("PATCH /grades/{id}", restricted(versioned(withMergePatch(s.handleUpdateGrade))))
+	s.handleFunc("DELETE /grades/{id}", restricted(versioned(s.handleDeleteGrade)))
+	s.handleFunc("GET /grades/{id}/amendments", restricted(s.handleListGradeAmendments))
+
+	// Grade appeals
+	s.handleFunc("GET /appeals", protected(s.handleListAppeals))
+	s.handleFunc("GET /appeals/{id}", protected(s.handleGetAppeal))
+	s.handleFunc("POST /grades/{id}/appeals", protected(s.handleCreateAppeal))
+	s.handleFunc("POST /appeals/{id}/messages", protected(s.handleCreateAppealMessage))
+	s.handleFunc("POST /appeals/{id}/escalate", s.withSecretary(s.handleEscalateAppeal))
+	s.handleFunc("POST /appeals/{id}/accept", restricted(s.handleAcceptAppeal))
+	s.handleFunc("POST /appeals/{id}/reject", restricted(s.handleRejectAppeal))
+
+	// Exam attempts
+	s.handleFunc("GET /exams", protected(s.handleListExams))
+	s.handleFunc("GET /exams/{id}", protected(s.handleGetExam))
+	s.handleFunc("POST /exams", s.withSecretary(s.handleCreateExam))
+	s.handleFunc("POST /exams/{id}/grade", restricted(s.handleGradeExam))
+	s.handleFunc("DELETE /exams/{id}", s.withSecretary(s.handleDeleteExam))
+}
+
+// handleFunc registers the handler for the given pattern and records the
+// route so it can be checked against the API specification.
+func (s *Server) handleFunc(pattern string, handler http.HandlerFunc) {
+	s.routes = append(s.routes, pattern)
+	s.router.HandleFunc(pattern, handler)
+}
+
+// tenantDB returns a database session scoped to the tenant of the
+// authenticated account of the request.
+func (s *Server) tenantDB(r *http.Request) *gorm.DB {
+	return edutrack.TenantDB(r.Context(), s.DB)
+}
+
+// inTransaction runs fn in a transaction scoped to the tenant of the
+// request. The request given to fn carries the transaction, so the services
+// it calls write in it too; fn must not read or write outside of them.
+func (s *Server) inTransaction(r *http.Request, fn func(tx *gorm.DB, r *http.Request) error) error {
+	return s.tenantDB(r).Transaction(func(tx *gorm.DB) error {
+		return fn(tx, r.WithContext(edutrack.NewContextWithTx(r.Context(), tx)))
+	})
+}
+
+// isDeletePreview reports whether a DELETE request asks for what the
+// deletion would do instead of deleting (?preview=true).
+func isDeletePreview(r *http.Request) bool {
+	return r.URL.Query().Get("preview") == "true"
+}
+
+// deleteRecord deletes a record of the request tenant applying the
+// integrity rules, responding with 204, or with the edutrack.DeletePreview
+// of the deletion for previews.
+func (s *Server) deleteRecord(w http.ResponseWriter, r *http.Request, record any) {
+	if isDeletePreview(r) {
+		preview, err := edutrack.PreviewDelete(s.tenantDB(r), record)
+		if err != nil {
+			s.sendAppError(w, r, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, preview)
+		return
+	}
+
+	if err := edutrack.DeleteRecord(s.tenantDB(r), record); err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // decodeJSON decodes a JSON request body into the given destination.
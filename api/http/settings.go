@@ -0,0 +1,120 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+// handleGetTenantSettings handles GET /tenant/settings.
+// Returns the settings of the institution of the authenticated account.
+func (s *Server) handleGetTenantSettings(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	settings, err := edutrack.FindTenantSettings(s.tenantDB(r), account.TenantID)
+	if err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newTenantSettingsResponse(&settings))
+}
+
+// UpdateTenantSettingsRequest represents the request body for updating the
+// settings of an institution. Absent fields are kept.
+type UpdateTenantSettingsRequest struct {
+	Timezone        *string  `json:"timezone"`
+	Locale          *string  `json:"locale" validate:"oneof=es en"`
+	GradeMin        *float64 `json:"grade_min"`
+	GradeMax        *float64 `json:"grade_max"`
+	PassingGrade    *float64 `json:"passing_grade"`
+	LatesPerAbsence *int     `json:"lates_per_absence" validate:"min=0"`
+
+	// Start of the academic period, as YYYY-MM-DD; empty includes all the
+	// records in reports.
+	AcademicStart *string `json:"academic_start" validate:"omitempty,date"`
+
+	// Days after publication a grade can be appealed; 0 disables appeals.
+	AppealDays *int `json:"appeal_days" validate:"min=0"`
+
+	// Exam attempts a student can take to pass a failed subject; 0 disables
+	// the kind of exam.
+	ExtraordinaryAttempts *int `json:"extraordinary_attempts" validate:"min=0"`
+	SpecialAttempts       *int `json:"special_attempts" validate:"min=0"`
+}
+
+// handleUpdateTenantSettings handles PUT /tenant/settings.
+func (s *Server) handleUpdateTenantSettings(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	var req UpdateTenantSettingsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	settings, err := edutrack.FindTenantSettings(s.tenantDB(r), account.TenantID)
+	if err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	if req.Timezone != nil {
+		settings.Timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		settings.Locale = i18n.Locale(*req.Locale)
+	}
+	if req.GradeMin != nil {
+		settings.GradeMin = *req.GradeMin
+	}
+	if req.GradeMax != nil {
+		settings.GradeMax = *req.GradeMax
+	}
+	if req.PassingGrade != nil {
+		settings.PassingGrade = *req.PassingGrade
+	}
+	if req.LatesPerAbsence != nil {
+		settings.LatesPerAbsence = *req.LatesPerAbsence
+	}
+	if req.AcademicStart != nil {
+		settings.AcademicStart = time.Time{}
+		if *req.AcademicStart != "" {
+			settings.AcademicStart, _ = time.Parse("2006-01-02", *req.AcademicStart)
+		}
+	}
+	if req.AppealDays != nil {
+		settings.AppealDays = *req.AppealDays
+	}
+	if req.ExtraordinaryAttempts != nil {
+		settings.ExtraordinaryAttempts = *req.ExtraordinaryAttempts
+	}
+	if req.SpecialAttempts != nil {
+		settings.SpecialAttempts = *req.SpecialAttempts
+	}
+	if err := settings.Validate(); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	if err := s.tenantDB(r).Save(&settings).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newTenantSettingsResponse(&settings))
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+// decodeTenantSettings decodes the settings of a successful response.
+func decodeTenantSettings(t *testing.T, w *httptest.ResponseRecorder) TenantSettingsResponse {
+	t.Helper()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var settings TenantSettingsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("Failed to decode settings: %v", err)
+	}
+	return settings
+}
+
+func TestHandleTenantSettings(t *testing.T) {
+	server, _, tenantA, tenantB := setupIsolationTest(t)
+
+	defaults := TenantSettingsResponse{
+		TenantID:     tenantA.Tenant.ID,
+		Timezone:     edutrack.DefaultTimezone,
+		Locale:       i18n.Default,
+		GradeMax:     100,
+		PassingGrade: 70,
+		AppealDays:   edutrack.DefaultAppealDays,
+
+		ExtraordinaryAttempts: edutrack.DefaultExtraordinaryAttempts,
+		SpecialAttempts:       edutrack.DefaultSpecialAttempts,
+	}
+	if got := decodeTenantSettings(t, isolationRequest(t, server, tenantA, http.MethodGet, "/tenant/settings", nil)); got != defaults {
+		t.Errorf("GET /tenant/settings = %+v, want the defaults %+v", got, defaults)
+	}
+
+	w := isolationRequest(t, server, tenantA, http.MethodPut, "/tenant/settings", map[string]any{
+		"timezone":          "America/Mexico_City",
+		"locale":            "en",
+		"grade_max":         10,
+		"passing_grade":     6,
+		"lates_per_absence": 3,
+		"academic_start":    "2026-01-12",
+		"appeal_days":       0,
+
+		"extraordinary_attempts": 0,
+		"special_attempts":       2,
+	})
+	want := TenantSettingsResponse{
+		TenantID:        tenantA.Tenant.ID,
+		Timezone:        "America/Mexico_City",
+		Locale:          i18n.English,
+		GradeMax:        10,
+		PassingGrade:    6,
+		LatesPerAbsence: 3,
+		AcademicStart:   "2026-01-12",
+		SpecialAttempts: 2,
+	}
+	if got := decodeTenantSettings(t, w); got != want {
+		t.Errorf("PUT /tenant/settings = %+v, want %+v", got, want)
+	}
+	if got := decodeTenantSettings(t, isolationRequest(t, server, tenantA, http.MethodGet, "/tenant/settings", nil)); got != want {
+		t.Errorf("GET /tenant/settings = %+v, want %+v", got, want)
+	}
+
+	// Absent fields are kept, and an empty start clears it.
+	want.AcademicStart = ""
+	want.PassingGrade = 7
+	want.AppealDays = 10
+	w = isolationRequest(t, server, tenantA, http.MethodPut, "/tenant/settings", map[string]any{"passing_grade": 7, "academic_start": "", "appeal_days": 10})
+	if got := decodeTenantSettings(t, w); got != want {
+		t.Errorf("PUT /tenant/settings = %+v, want %+v", got, want)
+	}
+
+	// Other institutions keep their settings.
+	defaults.TenantID = tenantB.Tenant.ID
+	if got := decodeTenantSettings(t, isolationRequest(t, server, tenantB, http.MethodGet, "/tenant/settings", nil)); got != defaults {
+		t.Errorf("GET /tenant/settings of another tenant = %+v, want the defaults", got)
+	}
+}
+
+func TestHandleUpdateTenantSettings_Invalid(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+
+	tests := []struct {
+		name string
+		body map[string]any
+		want map[string]string
+	}{
+		{"locale", map[string]any{"locale": "fr"}, map[string]string{"locale": edutrack.FieldInvalidChoice}},
+		{"lates", map[string]any{"lates_per_absence": -1}, map[string]string{"lates_per_absence": edutrack.FieldTooSmall}},
+		{"appeal days", map[string]any{"appeal_days": -1}, map[string]string{"appeal_days": edutrack.FieldTooSmall}},
+		{"attempts", map[string]any{"special_attempts": -1}, map[string]string{"special_attempts": edutrack.FieldTooSmall}},
+		{"date", map[string]any{"academic_start": "12/01/2026"}, map[string]string{"academic_start": edutrack.FieldInvalidFormat}},
+		{"timezone", map[string]any{"timezone": "Mars/Olympus"}, map[string]string{"timezone": edutrack.FieldInvalidChoice}},
+		{"passing grade", map[string]any{"passing_grade": 101}, map[string]string{"passing_grade": edutrack.FieldTooLarge}},
+		{"scale", map[string]any{"grade_min": 10, "grade_max": 5}, map[string]string{"grade_max": edutrack.FieldTooSmall}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := isolationRequest(t, server, tenant, http.MethodPut, "/tenant/settings", tt.body)
+			if got := fieldCodes(errorResponse(t, w, http.StatusBadRequest)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantSettings_Honored(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+
+	w := isolationRequest(t, server, tenant, http.MethodPut, "/tenant/settings", map[string]any{
+		"timezone":          "America/Mexico_City",
+		"locale":            "en",
+		"grade_max":         10,
+		"passing_grade":     6,
+		"lates_per_absence": 2,
+		"academic_start":    "2026-03-05",
+	})
+	decodeTenantSettings(t, w)
+
+	// Grades must be within the scale, and messages default to the locale of
+	// the institution.
+	w = isolationRequest(t, server, tenant, http.MethodPost, "/grades", map[string]any{"value": 11, "student_id": tenant.Student.ID, "topic_id": tenant.Topic.ID})
+	response := errorResponse(t, w, http.StatusBadRequest)
+	if len(response.Fields) != 1 || response.Fields[0].Code != edutrack.FieldTooLarge || response.Fields[0].Message != "Must be less than or equal to 10." {
+		t.Errorf("Fields = %+v, want value too large in English", response.Fields)
+	}
+
+	for _, date := range []string{"2026-03-10", "2026-03-11"} {
+		body := map[string]any{"date": date, "status": "late", "student_id": tenant.Student.ID, "subject_id": tenant.Subject.ID}
+		if w := isolationRequest(t, server, tenant, http.MethodPost, "/attendances", body); w.Code != http.StatusCreated {
+			t.Fatalf("POST /attendances status = %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// Records without a date are for today in the timezone of the
+	// institution.
+	body := map[string]any{"status": "present", "student_id": tenant.Student.ID, "subject_id": tenant.Subject.ID}
+	w = isolationRequest(t, server, tenant, http.MethodPost, "/attendances", body)
+	var attendance AttendanceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &attendance); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST /attendances status = %d: %s", w.Code, w.Body.String())
+	}
+	mexico, _ := time.LoadLocation("America/Mexico_City")
+	if today := time.Now().In(mexico).Format("2006-01-02"); attendance.Date != today {
+		t.Errorf("Date = %q, want today in Mexico City %q", attendance.Date, today)
+	}
+
+	// The report converts late arrivals and passes with the passing grade.
+	var student StudentResponse
+	w = isolationRequest(t, server, tenant, http.MethodGet, fmt.Sprintf("/students/%d", tenant.Student.ID), nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &student); err != nil {
+		t.Fatalf("Failed to decode student: %v", err)
+	}
+	// The absence of the seed predates the academic period.
+	wantAttendance := AttendanceSummaryResponse{Present: 1, Late: 2, Absences: 1}
+	if student.Attendance == nil || *student.Attendance != wantAttendance {
+		t.Errorf("Attendance = %+v, want %+v", student.Attendance, wantAttendance)
+	}
+	if len(student.SubjectAverages) != 1 || !student.SubjectAverages[0].Passed {
+		t.Errorf("SubjectAverages = %+v, want a passed subject", student.SubjectAverages)
+	}
+}
+
+func TestMigrate_TenantSettingsDefaults(t *testing.T) {
+	db := setupIsolationTestDB(t)
+	tenant := seedIsolationTenant(t, db, "TENANT-A-SECRET")
+
+	// Zero disables appeals and exams, and is saved as is.
+	settings := edutrack.DefaultTenantSettings(tenant.Tenant.ID)
+	settings.AppealDays = 0
+	settings.SpecialAttempts = 0
+	if err := db.Create(&settings).Error; err != nil {
+		t.Fatalf("Failed to create settings: %v", err)
+	}
+	db.First(&settings, settings.ID)
+	if settings.AppealDays != 0 || settings.SpecialAttempts != 0 {
+		t.Fatalf("Settings = %+v, want the zero values saved", settings)
+	}
+
+	// Settings of previous versions lack the exam attempts, which get their
+	// default; the other settings are kept.
+	for _, column := range []string{"extraordinary_attempts", "special_attempts"} {
+		if err := db.Migrator().DropColumn(&edutrack.TenantSettings{}, column); err != nil {
+			t.Fatalf("Failed to drop %s: %v", column, err)
+		}
+	}
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var migrated edutrack.TenantSettings
+	db.First(&migrated, settings.ID)
+	if migrated.AppealDays != 0 || migrated.ExtraordinaryAttempts != edutrack.DefaultExtraordinaryAttempts ||
+		migrated.SpecialAttempts != edutrack.DefaultSpecialAttempts {
+		t.Errorf("Settings = %+v, want the default exam attempts and no appeals", migrated)
+	}
+}
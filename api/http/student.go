@@ -4,266 +4,193 @@ import (
 	"net/http"
 	"strconv"
 
+	"gorm.io/gorm"
+
 	edutrack "lahuerta.tecmm.edu.mx/edutrack"
 )
 
 // handleListStudents handles GET /students.
 func (s *Server) handleListStudents(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+	inc, err := parseInclude(r, studentIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	var students []edutrack.Student
+	var filter edutrack.StudentFilter
 
-	if account.IsStudent() {
-		// Students can only see their own information.
-		var student edutrack.Student
-		if err := s.DB.Preload("Account").Preload("Career").Where("account_id = ? AND tenant_id = ?", account.ID, account.TenantID).First(&student).Error; err != nil {
-			sendError(w, http.StatusNotFound, ErrNotFound)
+	// Optional filters for teachers and secretaries.
+	query := r.URL.Query()
+	if careerID := query.Get("career_id"); careerID != "" {
+		id, err := strconv.ParseUint(careerID, 10, 64)
+		if err != nil {
+			sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 			return
 		}
-		student.CalculateAverages(s.DB)
-		students = []edutrack.Student{student}
-	} else {
-		// Teachers and secretaries can see all students with filters.
-		query := s.DB.Where("students.tenant_id = ?", account.TenantID)
-
-		// Optional filters.
-		if careerID := r.URL.Query().Get("career_id"); careerID != "" {
-			query = query.Where("career_id = ?", careerID)
-		}
-		if semester := r.URL.Query().Get("semester"); semester != "" {
-			query = query.Where("semester = ?", semester)
-		}
-		if studentID := r.URL.Query().Get("student_id"); studentID != "" {
-			query = query.Where("student_id LIKE ?", "%"+studentID+"%")
-		}
-		if name := r.URL.Query().Get("name"); name != "" {
-			query = query.Joins("Account").Where("Account.name LIKE ?", "%"+name+"%")
-		}
-
-		if err := query.Preload("Account").Preload("Career").Find(&students).Error; err != nil {
-			sendError(w, http.StatusInternalServerError, ErrInternalServer)
+		filter.CareerID = uint(id)
+	}
+	if semester := query.Get("semester"); semester != "" {
+		n, err := strconv.Atoi(semester)
+		if err != nil {
+			sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 			return
 		}
+		filter.Semester = n
+	}
+	filter.StudentID = query.Get("student_id")
+	filter.Name = query.Get("name")
 
-		// Calculate averages for each student.
-		for i := range students {
-			students[i].CalculateAverages(s.DB)
-		}
+	students, err := s.StudentService.FindStudents(r.Context(), filter)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
 	}
 
-	sendJSON(w, http.StatusOK, students)
+	sendJSON(w, http.StatusOK, newStudentResponses(students, inc))
 }
 
 // handleGetStudent handles GET /students/{id}.
 func (s *Server) handleGetStudent(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+	inc, err := parseInclude(r, studentIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if account.IsStudent() {
-		// Students can only access their own student record.
-		var ownStudent edutrack.Student
-		if err := s.DB.Where("account_id = ? AND tenant_id = ?", account.ID, account.TenantID).First(&ownStudent).Error; err != nil {
-			sendError(w, http.StatusNotFound, ErrNotFound)
-			return
-		}
-		if ownStudent.ID != uint(id) {
-			sendError(w, http.StatusForbidden, ErrForbidden)
-			return
-		}
-	}
-
-	var student edutrack.Student
-	if err := s.DB.Preload("Account").Preload("Career").First(&student, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	if student.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	student, err := s.StudentService.FindStudentByID(r.Context(), uint(id))
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	// Calculate the student's averages.
-	student.CalculateAverages(s.DB)
-
-	sendJSON(w, http.StatusOK, student)
+	setETag(w, student.Model)
+	sendJSON(w, http.StatusOK, newStudentResponse(student, inc))
 }
 
 // CreateStudentRequest represents the request body for creating a student.
 type CreateStudentRequest struct {
-	StudentID string `json:"student_id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	CareerID  uint   `json:"career_id"`
-	Semester  int    `json:"semester"`
+	StudentID string `json:"student_id" validate:"required"`
+	Name      string `json:"name" validate:"required"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required"`
+	CareerID  uint   `json:"career_id" validate:"required"`
+	Semester  int    `json:"semester" validate:"required,min=1"`
 }
 
 // handleCreateStudent handles POST /students.
 func (s *Server) handleCreateStudent(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+	inc, err := parseInclude(r, studentIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req CreateStudentRequest
 	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
-
-	if req.StudentID == "" || req.Name == "" || req.Email == "" || req.Password == "" {
-		sendErrorMessage(w, http.StatusBadRequest, "El ID de estudiante, nombre, email y contraseña son requeridos.")
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	if req.Semester <= 0 {
-		sendErrorMessage(w, http.StatusBadRequest, "El semestre debe ser un número positivo.")
-		return
-	}
-
-	// Hash the password.
-	hashedPassword, err := edutrack.HashPassword(req.Password)
+	var student *edutrack.Student
+	err = s.inTransaction(r, func(tx *gorm.DB, r *http.Request) error {
+		var err error
+		student, err = s.StudentService.CreateStudent(r.Context(), edutrack.StudentCreate{
+			StudentID: req.StudentID,
+			Name:      req.Name,
+			Email:     req.Email,
+			Password:  req.Password,
+			CareerID:  req.CareerID,
+			Semester:  req.Semester,
+		})
+		if err != nil {
+			return err
+		}
+		return s.emitWebhook(tx, student.TenantID, edutrack.WebhookStudentCreated, newStudentResponse(student, includeAll(studentIncludes)))
+	})
 	if err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
-		return
-	}
-
-	// Create the account first.
-	newAccount := &edutrack.Account{
-		Name:     req.Name,
-		Email:    req.Email,
-		Password: hashedPassword,
-		Role:     edutrack.RoleStudent,
-		Active:   true,
-		TenantID: account.TenantID,
-	}
-
-	if err := s.DB.Create(newAccount).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
-		return
-	}
-
-	// Now create the student linked to the account.
-	student := &edutrack.Student{
-		StudentID: req.StudentID,
-		AccountID: newAccount.ID,
-		CareerID:  req.CareerID,
-		Semester:  req.Semester,
-		TenantID:  account.TenantID,
-	}
-
-	if err := s.DB.Create(student).Error; err != nil {
-		// If student creation fails, delete the account.
-		s.DB.Delete(newAccount)
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	sendJSON(w, http.StatusCreated, student)
+	setETag(w, student.Model)
+	sendJSON(w, http.StatusCreated, newStudentResponse(student, inc))
 }
 
 // UpdateStudentRequest represents the request body for updating a student.
 type UpdateStudentRequest struct {
-	StudentID *string `json:"student_id"`
-	CareerID  *uint   `json:"career_id"`
-	Semester  *int    `json:"semester"`
+	StudentID *string `json:"student_id" validate:"required"`
+	CareerID  *uint   `json:"career_id" validate:"required"`
+	Semester  *int    `json:"semester" validate:"min=1"`
 }
 
-// handleUpdateStudent handles PUT /students/{id}.
+// handleUpdateStudent handles PUT and PATCH /students/{id}.
 func (s *Server) handleUpdateStudent(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+	inc, err := parseInclude(r, studentIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
-		return
-	}
-
-	var student edutrack.Student
-	if err := s.DB.First(&student, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	if student.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var req UpdateStudentRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
-
-	if req.StudentID != nil {
-		student.StudentID = *req.StudentID
-	}
-	if req.CareerID != nil {
-		student.CareerID = *req.CareerID
-	}
-	if req.Semester != nil {
-		if *req.Semester <= 0 {
-			sendErrorMessage(w, http.StatusBadRequest, "El semestre debe ser un número positivo.")
-			return
-		}
-		student.Semester = *req.Semester
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
 	}
 
-	if err := s.DB.Save(&student).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	student, err := s.StudentService.UpdateStudent(r.Context(), uint(id), edutrack.StudentUpdate{
+		StudentID: req.StudentID,
+		CareerID:  req.CareerID,
+		Semester:  req.Semester,
+	})
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, student)
+	setETag(w, student.Model)
+	sendJSON(w, http.StatusOK, newStudentResponse(student, inc))
 }
 
 // handleDeleteStudent handles DELETE /students/{id}.
 func (s *Server) handleDeleteStudent(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
-		return
-	}
-
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
-		return
-	}
-
-	var student edutrack.Student
-	if err := s.DB.First(&student, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if student.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if isDeletePreview(r) {
+		preview, err := s.StudentService.PreviewDeleteStudent(r.Context(), uint(id))
+		if err != nil {
+			s.sendAppError(w, r, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, preview)
 		return
 	}
 
-	if err := s.DB.Delete(&student).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.StudentService.DeleteStudent(r.Context(), uint(id)); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
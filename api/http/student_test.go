@@ -2,7 +2,9 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -141,7 +143,7 @@ func TestHandleListStudents_Success(t *testing.T) {
 		t.Errorf("handleListStudents() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var students []edutrack.Student
+	var students []StudentResponse
 	if err := json.NewDecoder(w.Body).Decode(&students); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -181,7 +183,7 @@ func TestHandleListStudents_FilterByCareerID(t *testing.T) {
 		t.Errorf("handleListStudents() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var students []edutrack.Student
+	var students []StudentResponse
 	if err := json.NewDecoder(w.Body).Decode(&students); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -214,7 +216,7 @@ func TestHandleListStudents_FilterBySemester(t *testing.T) {
 		t.Errorf("handleListStudents() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var students []edutrack.Student
+	var students []StudentResponse
 	if err := json.NewDecoder(w.Body).Decode(&students); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -247,7 +249,7 @@ func TestHandleListStudents_FilterByStudentID(t *testing.T) {
 		t.Errorf("handleListStudents() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var students []edutrack.Student
+	var students []StudentResponse
 	if err := json.NewDecoder(w.Body).Decode(&students); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -296,7 +298,7 @@ func TestHandleListStudents_TenantIsolation(t *testing.T) {
 
 	server.handleListStudents(w, req)
 
-	var students []edutrack.Student
+	var students []StudentResponse
 	json.NewDecoder(w.Body).Decode(&students)
 
 	if len(students) != 1 {
@@ -324,7 +326,7 @@ func TestHandleGetStudent_Success(t *testing.T) {
 		t.Errorf("handleGetStudent() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var found edutrack.Student
+	var found StudentResponse
 	if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -370,7 +372,7 @@ func TestHandleGetStudent_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleGetStudent_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleGetStudent_CrossTenant(t *testing.T) {
 	db := setupStudentTestDB(t)
 
 	tenant1 := createStudentTestTenant(t, db)
@@ -391,8 +393,8 @@ func TestHandleGetStudent_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleGetStudent(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleGetStudent() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleGetStudent() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -423,7 +425,7 @@ func TestHandleCreateStudent_Success(t *testing.T) {
 		t.Errorf("handleCreateStudent() status = %d, want %d", w.Code, http.StatusCreated)
 	}
 
-	var created edutrack.Student
+	var created StudentResponse
 	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -519,7 +521,7 @@ func TestHandleUpdateStudent_Success(t *testing.T) {
 		t.Errorf("handleUpdateStudent() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Student
+	var updated StudentResponse
 	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -567,7 +569,7 @@ func TestHandleUpdateStudent_UpdateCareer(t *testing.T) {
 		t.Errorf("handleUpdateStudent() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Student
+	var updated StudentResponse
 	json.NewDecoder(w.Body).Decode(&updated)
 
 	if updated.CareerID != career2.ID {
@@ -597,7 +599,7 @@ func TestHandleUpdateStudent_NotFound(t *testing.T) {
 	}
 }
 
-func TestHandleUpdateStudent_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleUpdateStudent_CrossTenant(t *testing.T) {
 	db := setupStudentTestDB(t)
 
 	tenant1 := createStudentTestTenant(t, db)
@@ -622,8 +624,8 @@ func TestHandleUpdateStudent_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleUpdateStudent(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleUpdateStudent() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleUpdateStudent() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -691,7 +693,7 @@ func TestHandleDeleteStudent_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleDeleteStudent_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleDeleteStudent_CrossTenant(t *testing.T) {
 	db := setupStudentTestDB(t)
 
 	tenant1 := createStudentTestTenant(t, db)
@@ -712,8 +714,159 @@ func TestHandleDeleteStudent_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleDeleteStudent(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleDeleteStudent() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleDeleteStudent() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleCreateStudent_DuplicateStudentID(t *testing.T) {
+	db := setupStudentTestDB(t)
+	tenant := createStudentTestTenant(t, db)
+	account := createStudentTestAccount(t, db, tenant.ID, "admin@test.com", "Admin", edutrack.RoleSecretary)
+	career := createStudentTestCareer(t, db, tenant.ID)
+	existing := createStudentTestAccount(t, db, tenant.ID, "existing@test.com", "Existing", edutrack.RoleStudent)
+	createTestStudent(t, db, tenant.ID, "2024001", existing.ID, career.ID, 1)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateStudentRequest{
+		StudentID: "2024001",
+		Name:      "Duplicate Student",
+		Email:     "duplicate@test.com",
+		Password:  "password123",
+		CareerID:  career.ID,
+		Semester:  1,
+	})
+
+	req := makeStudentAuthenticatedRequest(t, http.MethodPost, "/students", body, account)
+	w := httptest.NewRecorder()
+
+	server.handleCreateStudent(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("handleCreateStudent() status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	// The account of the rejected student is rolled back.
+	var count int64
+	db.Model(&edutrack.Account{}).Where("email = ?", "duplicate@test.com").Count(&count)
+	if count != 0 {
+		t.Errorf("handleCreateStudent() left %d orphan accounts", count)
+	}
+}
+
+// fakeStudentService is a StudentService returning fixed results, to test
+// the handlers without a database.
+type fakeStudentService struct {
+	student *edutrack.Student
+	err     error
+
+	// Arguments of the last call.
+	filter edutrack.StudentFilter
+	id     uint
+}
+
+func (f *fakeStudentService) FindStudents(ctx context.Context, filter edutrack.StudentFilter) ([]edutrack.Student, error) {
+	f.filter = filter
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []edutrack.Student{*f.student}, nil
+}
+
+func (f *fakeStudentService) FindStudentByID(ctx context.Context, id uint) (*edutrack.Student, error) {
+	f.id = id
+	return f.student, f.err
+}
+
+func (f *fakeStudentService) CreateStudent(ctx context.Context, create edutrack.StudentCreate) (*edutrack.Student, error) {
+	return f.student, f.err
+}
+
+func (f *fakeStudentService) UpdateStudent(ctx context.Context, id uint, update edutrack.StudentUpdate) (*edutrack.Student, error) {
+	f.id = id
+	return f.student, f.err
+}
+
+func (f *fakeStudentService) DeleteStudent(ctx context.Context, id uint) error {
+	f.id = id
+	return f.err
+}
+
+func (f *fakeStudentService) PreviewDeleteStudent(ctx context.Context, id uint) (*edutrack.DeletePreview, error) {
+	f.id = id
+	return &edutrack.DeletePreview{Allowed: true}, f.err
+}
+
+func TestStudentHandlers_DomainErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{"not found", &edutrack.Error{Code: edutrack.ENOTFOUND}, http.StatusNotFound, "Recurso no encontrado."},
+		{"forbidden", &edutrack.Error{Code: edutrack.EFORBIDDEN}, http.StatusForbidden, "Acceso denegado."},
+		{"conflict", &edutrack.Error{Code: edutrack.ECONFLICT}, http.StatusConflict, "El recurso ya existe."},
+		{"unauthorized", &edutrack.Error{Code: edutrack.EUNAUTHORIZED}, http.StatusUnauthorized, "No autorizado."},
+		{"invalid with message", edutrack.Errorf(edutrack.EINVALID, "student.semester.too_small"), http.StatusBadRequest, "El semestre debe ser un número positivo."},
+		{"wrapped", fmt.Errorf("loading: %w", &edutrack.Error{Code: edutrack.ENOTFOUND}), http.StatusNotFound, "Recurso no encontrado."},
+		{"internal hides details", errors.New("database is locked"), http.StatusInternalServerError, "Error interno del servidor."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(":8080", nil, []byte("test-secret"))
+			server.StudentService = &fakeStudentService{err: tt.err}
+
+			req := httptest.NewRequest(http.MethodDelete, "/students/1", nil)
+			req.SetPathValue("id", "1")
+			w := httptest.NewRecorder()
+
+			server.handleDeleteStudent(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("handleDeleteStudent() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			var response ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Message != tt.wantMessage {
+				t.Errorf("handleDeleteStudent() message = %q, want %q", response.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestHandleListStudents_Filters(t *testing.T) {
+	service := &fakeStudentService{student: &edutrack.Student{StudentID: "2024001"}}
+	server := NewServer(":8080", nil, []byte("test-secret"))
+	server.StudentService = service
+
+	req := httptest.NewRequest(http.MethodGet, "/students?career_id=3&semester=2&student_id=2024&name=Ana", nil)
+	w := httptest.NewRecorder()
+
+	server.handleListStudents(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleListStudents() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	want := edutrack.StudentFilter{CareerID: 3, Semester: 2, StudentID: "2024", Name: "Ana"}
+	if service.filter != want {
+		t.Errorf("handleListStudents() filter = %+v, want %+v", service.filter, want)
+	}
+
+	// Malformed numeric filters are rejected.
+	req = httptest.NewRequest(http.MethodGet, "/students?semester=first", nil)
+	w = httptest.NewRecorder()
+
+	server.handleListStudents(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleListStudents() status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
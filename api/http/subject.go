@@ -11,11 +11,17 @@ import (
 func (s *Server) handleListSubjects(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
-	query := s.DB.Where("tenant_id = ?", account.TenantID)
+	inc, err := parseInclude(r, subjectIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	query := s.tenantDB(r)
 
 	// Optional filters.
 	if name := r.URL.Query().Get("name"); name != "" {
@@ -35,69 +41,82 @@ func (s *Server) handleListSubjects(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var subjects []edutrack.Subject
-	if err := query.Preload("Teacher").Preload("Career").Find(&subjects).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := query.Preload("Teacher.Account").Preload("Career").Find(&subjects).Error; err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, subjects)
+	response := make([]SubjectResponse, 0, len(subjects))
+	for i := range subjects {
+		response = append(response, newSubjectResponse(&subjects[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
 }
 
 // handleGetSubject handles GET /subjects/{id}.
 func (s *Server) handleGetSubject(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
-	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	inc, err := parseInclude(r, subjectIncludes)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	var subject edutrack.Subject
-	if err := s.DB.Preload("Teacher").Preload("Career").First(&subject, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if subject.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	var subject edutrack.Subject
+	if err := s.tenantDB(r).Preload("Teacher.Account").Preload("Career").First(&subject, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, subject)
+	setETag(w, subject.Model)
+	sendJSON(w, http.StatusOK, newSubjectResponse(&subject, inc))
 }
 
 // CreateSubjectRequest represents the request body for creating a subject.
 type CreateSubjectRequest struct {
-	Name        string `json:"name"`
-	Code        string `json:"code"`
+	Name        string `json:"name" validate:"required"`
+	Code        string `json:"code" validate:"required"`
 	Description string `json:"description"`
-	Credits     int    `json:"credits"`
+	Credits     int    `json:"credits" validate:"min=0"`
 	TeacherID   *uint  `json:"teacher_id"`
-	CareerID    uint   `json:"career_id"`
-	Semester    int    `json:"semester"`
+	CareerID    uint   `json:"career_id" validate:"required"`
+	Semester    int    `json:"semester" validate:"required,min=1"`
 }
 
 // handleCreateSubject handles POST /subjects.
 func (s *Server) handleCreateSubject(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, subjectIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req CreateSubjectRequest
 	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if req.Name == "" || req.Code == "" || req.CareerID == 0 || req.Semester <= 0 {
-		sendErrorMessage(w, http.StatusBadRequest, "Nombre, código, ID de carrera y un semestre positivo son requeridos.")
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
@@ -112,56 +131,69 @@ func (s *Server) handleCreateSubject(w http.ResponseWriter, r *http.Request) {
 		TenantID:    account.TenantID,
 	}
 
-	if err := s.DB.Create(subject).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Create(subject).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
 	// Reload with associations.
-	s.DB.Preload("Teacher").Preload("Career").First(subject, subject.ID)
+	s.tenantDB(r).Preload("Teacher.Account").Preload("Career").First(subject, subject.ID)
 
-	sendJSON(w, http.StatusCreated, subject)
+	setETag(w, subject.Model)
+	sendJSON(w, http.StatusCreated, newSubjectResponse(subject, inc))
 }
 
 // UpdateSubjectRequest represents the request body for updating a subject.
 type UpdateSubjectRequest struct {
-	Name        *string `json:"name"`
-	Code        *string `json:"code"`
+	Name        *string `json:"name" validate:"required"`
+	Code        *string `json:"code" validate:"required"`
 	Description *string `json:"description"`
-	Credits     *int    `json:"credits"`
-	TeacherID   *uint   `json:"teacher_id"`
-	CareerID    *uint   `json:"career_id"`
-	Semester    *int    `json:"semester"`
+	Credits     *int    `json:"credits" validate:"min=0"`
+	CareerID    *uint   `json:"career_id" validate:"required"`
+	Semester    *int    `json:"semester" validate:"min=1"`
+
+	// A null value in a merge patch unassigns the teacher.
+	TeacherID **uint `json:"teacher_id"`
 }
 
-// handleUpdateSubject handles PUT /subjects/{id}.
+// handleUpdateSubject handles PUT and PATCH /subjects/{id}.
 func (s *Server) handleUpdateSubject(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, subjectIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var subject edutrack.Subject
-	if err := s.DB.First(&subject, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	if err := s.tenantDB(r).First(&subject, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if subject.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := edutrack.CheckIfMatch(r.Context(), subject.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req UpdateSubjectRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
@@ -178,61 +210,53 @@ func (s *Server) handleUpdateSubject(w http.ResponseWriter, r *http.Request) {
 		subject.Credits = *req.Credits
 	}
 	if req.TeacherID != nil {
-		subject.TeacherID = req.TeacherID
+		subject.TeacherID = *req.TeacherID
 	}
 	if req.CareerID != nil {
 		subject.CareerID = *req.CareerID
 	}
 	if req.Semester != nil {
-		if *req.Semester <= 0 {
-			sendErrorMessage(w, http.StatusBadRequest, "El semestre debe ser un número positivo.")
-			return
-		}
 		subject.Semester = *req.Semester
 	}
 
-	if err := s.DB.Save(&subject).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Save(&subject).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
 	// Reload with associations.
-	s.DB.Preload("Teacher").Preload("Career").First(&subject, subject.ID)
+	s.tenantDB(r).Preload("Teacher.Account").Preload("Career").First(&subject, subject.ID)
 
-	sendJSON(w, http.StatusOK, subject)
+	setETag(w, subject.Model)
+	sendJSON(w, http.StatusOK, newSubjectResponse(&subject, inc))
 }
 
 // handleDeleteSubject handles DELETE /subjects/{id}.
 func (s *Server) handleDeleteSubject(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var subject edutrack.Subject
-	if err := s.DB.First(&subject, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	if subject.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := s.tenantDB(r).First(&subject, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if err := s.DB.Delete(&subject).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := edutrack.CheckIfMatch(r.Context(), subject.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	s.deleteRecord(w, r, &subject)
 }
 
 // handleListSubjectStudents handles GET /subjects/{id}/students.
@@ -241,24 +265,25 @@ func (s *Server) handleDeleteSubject(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleListSubjectStudents(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
-	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	inc, err := parseInclude(r, studentIncludes)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	var subject edutrack.Subject
-	if err := s.DB.Preload("Teacher.Account").First(&subject, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if subject.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	var subject edutrack.Subject
+	if err := s.tenantDB(r).Preload("Teacher.Account").First(&subject, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
@@ -267,125 +292,16 @@ func (s *Server) handleListSubjectStudents(w http.ResponseWriter, r *http.Reques
 	isTeacherOfSubject := subject.Teacher != nil && subject.Teacher.AccountID == account.ID
 
 	if !isSecretary && !isTeacherOfSubject {
-		sendError(w, http.StatusForbidden, ErrForbidden)
-		return
-	}
-
-	if err := s.DB.Model(&subject).Association("Students").Find(&subject.Students); err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
-		return
-	}
-
-	sendJSON(w, http.StatusOK, subject.Students)
-}
-
-// AddStudentToSubjectRequest represents the request body for adding a student to a subject.
-type AddStudentToSubjectRequest struct {
-	StudentID uint `json:"student_id"`
-}
-
-// handleAddStudentToSubject handles POST /subjects/{id}/students.
-// It enrolls a student in a subject. Only secretaries can perform this action.
-func (s *Server) handleAddStudentToSubject(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
-		return
-	}
-
-	// Only secretaries can enroll students.
-	if !account.IsSecretary() {
-		sendError(w, http.StatusForbidden, ErrForbidden)
-		return
-	}
-
-	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
-	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
-		return
-	}
-
-	var req AddStudentToSubjectRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
-		return
-	}
-
-	var subject edutrack.Subject
-	if err := s.DB.First(&subject, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	var student edutrack.Student
-	if err := s.DB.First(&student, req.StudentID).Error; err != nil {
-		sendErrorMessage(w, http.StatusBadRequest, "Estudiante no encontrado.")
-		return
-	}
-
-	// Ensure both are in the same tenant.
-	if subject.TenantID != account.TenantID || student.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
-		return
-	}
-
-	if err := s.DB.Model(&subject).Association("Students").Append(&student); err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-// handleRemoveStudentFromSubject handles DELETE /subjects/{id}/students/{student_id}.
-// It removes a student from a subject. Only secretaries can perform this action.
-func (s *Server) handleRemoveStudentFromSubject(w http.ResponseWriter, r *http.Request) {
-	account := edutrack.AccountFromContext(r.Context())
-	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
-		return
-	}
-
-	// Only secretaries can un-enroll students.
-	if !account.IsSecretary() {
-		sendError(w, http.StatusForbidden, ErrForbidden)
-		return
-	}
-
-	subjectID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
-	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
-		return
-	}
-
-	studentID, err := strconv.ParseUint(r.PathValue("student_id"), 10, 64)
-	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
-		return
-	}
-
-	var subject edutrack.Subject
-	if err := s.DB.First(&subject, subjectID).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	var student edutrack.Student
-	if err := s.DB.First(&student, studentID).Error; err != nil {
-		sendErrorMessage(w, http.StatusBadRequest, "Estudiante no encontrado.")
-		return
-	}
-
-	// Ensure both are in the same tenant.
-	if subject.TenantID != account.TenantID || student.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+		sendError(w, r, http.StatusForbidden, ErrForbidden)
 		return
 	}
 
-	if err := s.DB.Model(&subject).Association("Students").Delete(&student); err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	var students []edutrack.Student
+	enrolled := s.tenantDB(r).Table("student_subjects").Select("student_id").Where("subject_id = ?", subject.ID)
+	if err := s.tenantDB(r).Preload("Account").Preload("Career").Where("id IN (?)", enrolled).Find(&students).Error; err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	sendJSON(w, http.StatusOK, newStudentResponses(students, inc))
 }
@@ -155,7 +155,7 @@ func TestHandleListSubjects_Success(t *testing.T) {
 		t.Errorf("handleListSubjects() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var subjects []edutrack.Subject
+	var subjects []SubjectResponse
 	if err := json.NewDecoder(w.Body).Decode(&subjects); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -185,7 +185,7 @@ func TestHandleListSubjects_FilterByName(t *testing.T) {
 		t.Errorf("handleListSubjects() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var subjects []edutrack.Subject
+	var subjects []SubjectResponse
 	if err := json.NewDecoder(w.Body).Decode(&subjects); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -215,7 +215,7 @@ func TestHandleListSubjects_FilterByCode(t *testing.T) {
 		t.Errorf("handleListSubjects() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var subjects []edutrack.Subject
+	var subjects []SubjectResponse
 	if err := json.NewDecoder(w.Body).Decode(&subjects); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -247,7 +247,7 @@ func TestHandleListSubjects_FilterByTeacherID(t *testing.T) {
 		t.Errorf("handleListSubjects() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var subjects []edutrack.Subject
+	var subjects []SubjectResponse
 	if err := json.NewDecoder(w.Body).Decode(&subjects); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -278,7 +278,7 @@ func TestHandleListSubjects_FilterByCareerID(t *testing.T) {
 		t.Errorf("handleListSubjects() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var subjects []edutrack.Subject
+	var subjects []SubjectResponse
 	if err := json.NewDecoder(w.Body).Decode(&subjects); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -308,7 +308,7 @@ func TestHandleListSubjects_FilterBySemester(t *testing.T) {
 		t.Errorf("handleListSubjects() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var subjects []edutrack.Subject
+	var subjects []SubjectResponse
 	if err := json.NewDecoder(w.Body).Decode(&subjects); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -353,7 +353,7 @@ func TestHandleListSubjects_TenantIsolation(t *testing.T) {
 
 	server.handleListSubjects(w, req)
 
-	var subjects []edutrack.Subject
+	var subjects []SubjectResponse
 	json.NewDecoder(w.Body).Decode(&subjects)
 
 	if len(subjects) != 1 {
@@ -380,7 +380,7 @@ func TestHandleGetSubject_Success(t *testing.T) {
 		t.Errorf("handleGetSubject() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var found edutrack.Subject
+	var found SubjectResponse
 	if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -426,7 +426,7 @@ func TestHandleGetSubject_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleGetSubject_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleGetSubject_CrossTenant(t *testing.T) {
 	db := setupSubjectTestDB(t)
 
 	tenant1 := createSubjectTestTenant(t, db)
@@ -445,8 +445,8 @@ func TestHandleGetSubject_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleGetSubject(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleGetSubject() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleGetSubject() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -477,7 +477,7 @@ func TestHandleCreateSubject_Success(t *testing.T) {
 		t.Errorf("handleCreateSubject() status = %d, want %d", w.Code, http.StatusCreated)
 	}
 
-	var created edutrack.Subject
+	var created SubjectResponse
 	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -528,7 +528,7 @@ func TestHandleCreateSubject_WithTeacher(t *testing.T) {
 		t.Errorf("handleCreateSubject() status = %d, want %d", w.Code, http.StatusCreated)
 	}
 
-	var created edutrack.Subject
+	var created SubjectResponse
 	json.NewDecoder(w.Body).Decode(&created)
 
 	if created.TeacherID == nil || *created.TeacherID != teacher.ID {
@@ -612,7 +612,7 @@ func TestHandleUpdateSubject_Success(t *testing.T) {
 		t.Errorf("handleUpdateSubject() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Subject
+	var updated SubjectResponse
 	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -639,13 +639,14 @@ func TestHandleUpdateSubject_UpdateAllFields(t *testing.T) {
 	newDescription := "Updated description"
 	newCredits := 8
 	newSemester := 2
+	newTeacherID := &teacher.ID
 
 	reqBody := UpdateSubjectRequest{
 		Name:        &newName,
 		Code:        &newCode,
 		Description: &newDescription,
 		Credits:     &newCredits,
-		TeacherID:   &teacher.ID,
+		TeacherID:   &newTeacherID,
 		CareerID:    &career2.ID,
 		Semester:    &newSemester,
 	}
@@ -661,7 +662,7 @@ func TestHandleUpdateSubject_UpdateAllFields(t *testing.T) {
 		t.Errorf("handleUpdateSubject() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Subject
+	var updated SubjectResponse
 	json.NewDecoder(w.Body).Decode(&updated)
 
 	if updated.Name != newName {
@@ -706,7 +707,7 @@ func TestHandleUpdateSubject_NotFound(t *testing.T) {
 	}
 }
 
-func TestHandleUpdateSubject_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleUpdateSubject_CrossTenant(t *testing.T) {
 	db := setupSubjectTestDB(t)
 
 	tenant1 := createSubjectTestTenant(t, db)
@@ -729,8 +730,8 @@ func TestHandleUpdateSubject_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleUpdateSubject(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleUpdateSubject() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleUpdateSubject() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -797,7 +798,7 @@ func TestHandleDeleteSubject_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleDeleteSubject_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleDeleteSubject_CrossTenant(t *testing.T) {
 	db := setupSubjectTestDB(t)
 
 	tenant1 := createSubjectTestTenant(t, db)
@@ -816,60 +817,8 @@ func TestHandleDeleteSubject_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleDeleteSubject(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleDeleteSubject() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
-	}
-}
-
-func TestHandleAddStudentToSubject_Success(t *testing.T) {
-	db := setupSubjectTestDB(t)
-	tenant := createSubjectTestTenant(t, db)
-	secretary := createSubjectTestAccount(t, db, tenant.ID, "admin@test.com", "Admin", edutrack.RoleSecretary)
-	career := createSubjectTestCareer(t, db, tenant.ID)
-	studentAcc := createStudentTestAccount(t, db, tenant.ID, "student@test.com", "Student", edutrack.RoleTeacher)
-	student := createTestStudent(t, db, tenant.ID, "2024001", studentAcc.ID, career.ID, 1)
-	subject := createTestSubject(t, db, tenant.ID, "Matemáticas I", "MAT101", nil, career.ID, 1)
-
-	server := NewServer(":8080", db, []byte("test-secret"))
-
-	body, _ := json.Marshal(AddStudentToSubjectRequest{StudentID: student.ID})
-	req := makeSubjectAuthenticatedRequest(t, http.MethodPost, fmt.Sprintf("/subjects/%d/students", subject.ID), body, secretary)
-	req.SetPathValue("id", fmt.Sprintf("%d", subject.ID))
-	w := httptest.NewRecorder()
-
-	server.handleAddStudentToSubject(w, req)
-
-	if w.Code != http.StatusNoContent {
-		t.Errorf("handleAddStudentToSubject() status = %d, want %d", w.Code, http.StatusNoContent)
-	}
-
-	var updatedSubject edutrack.Subject
-	db.Preload("Students").First(&updatedSubject, subject.ID)
-	if len(updatedSubject.Students) != 1 {
-		t.Errorf("student was not added to subject, count = %d, want 1", len(updatedSubject.Students))
-	}
-}
-
-func TestHandleAddStudentToSubject_ForbiddenForTeacher(t *testing.T) {
-	db := setupSubjectTestDB(t)
-	tenant := createSubjectTestTenant(t, db)
-	teacher := createSubjectTestAccount(t, db, tenant.ID, "teacher@test.com", "Teacher", edutrack.RoleTeacher)
-	career := createSubjectTestCareer(t, db, tenant.ID)
-	studentAcc := createStudentTestAccount(t, db, tenant.ID, "student@test.com", "Student", edutrack.RoleTeacher)
-	student := createTestStudent(t, db, tenant.ID, "2024001", studentAcc.ID, career.ID, 1)
-	subject := createTestSubject(t, db, tenant.ID, "Matemáticas I", "MAT101", nil, career.ID, 1)
-
-	server := NewServer(":8080", db, []byte("test-secret"))
-
-	body, _ := json.Marshal(AddStudentToSubjectRequest{StudentID: student.ID})
-	req := makeSubjectAuthenticatedRequest(t, http.MethodPost, fmt.Sprintf("/subjects/%d/students", subject.ID), body, teacher)
-	req.SetPathValue("id", fmt.Sprintf("%d", subject.ID))
-	w := httptest.NewRecorder()
-
-	server.handleAddStudentToSubject(w, req)
-
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleAddStudentToSubject() status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleDeleteSubject() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -895,7 +844,7 @@ func TestHandleListSubjectStudents_Success(t *testing.T) {
 		t.Errorf("handleListSubjectStudents() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var students []edutrack.Student
+	var students []StudentResponse
 	if err := json.NewDecoder(w.Body).Decode(&students); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -925,36 +874,6 @@ func TestHandleListSubjectStudents_ForbiddenForOtherTeacher(t *testing.T) {
 	}
 }
 
-func TestHandleRemoveStudentFromSubject_Success(t *testing.T) {
-	db := setupSubjectTestDB(t)
-	tenant := createSubjectTestTenant(t, db)
-	secretary := createSubjectTestAccount(t, db, tenant.ID, "admin@test.com", "Admin", edutrack.RoleSecretary)
-	career := createSubjectTestCareer(t, db, tenant.ID)
-	studentAcc := createStudentTestAccount(t, db, tenant.ID, "student@test.com", "Student", edutrack.RoleTeacher)
-	student := createTestStudent(t, db, tenant.ID, "2024001", studentAcc.ID, career.ID, 1)
-	subject := createTestSubject(t, db, tenant.ID, "Matemáticas I", "MAT101", nil, career.ID, 1)
-	db.Model(&subject).Association("Students").Append(student)
-
-	server := NewServer(":8080", db, []byte("test-secret"))
-
-	req := makeSubjectAuthenticatedRequest(t, http.MethodDelete, fmt.Sprintf("/subjects/%d/students/%d", subject.ID, student.ID), nil, secretary)
-	req.SetPathValue("id", fmt.Sprintf("%d", subject.ID))
-	req.SetPathValue("student_id", fmt.Sprintf("%d", student.ID))
-	w := httptest.NewRecorder()
-
-	server.handleRemoveStudentFromSubject(w, req)
-
-	if w.Code != http.StatusNoContent {
-		t.Errorf("handleRemoveStudentFromSubject() status = %d, want %d", w.Code, http.StatusNoContent)
-	}
-
-	var updatedSubject edutrack.Subject
-	db.Preload("Students").First(&updatedSubject, subject.ID)
-	if len(updatedSubject.Students) != 0 {
-		t.Errorf("student was not removed from subject, count = %d, want 0", len(updatedSubject.Students))
-	}
-}
-
 func BenchmarkHandleListSubjects(b *testing.B) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
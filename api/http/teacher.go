@@ -11,11 +11,17 @@ import (
 func (s *Server) handleListTeachers(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
-	query := s.DB.Where("tenant_id = ?", account.TenantID).Preload("Account")
+	inc, err := parseInclude(r, teacherIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	query := s.tenantDB(r).Preload("Account")
 
 	// Optional filters.
 	if name := r.URL.Query().Get("name"); name != "" {
@@ -28,74 +34,82 @@ func (s *Server) handleListTeachers(w http.ResponseWriter, r *http.Request) {
 
 	var teachers []edutrack.Teacher
 	if err := query.Find(&teachers).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, teachers)
+	response := make([]TeacherResponse, 0, len(teachers))
+	for i := range teachers {
+		response = append(response, newTeacherResponse(&teachers[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
 }
 
 // handleGetTeacher handles GET /teachers/{id}.
 func (s *Server) handleGetTeacher(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
-	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	inc, err := parseInclude(r, teacherIncludes)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	var teacher edutrack.Teacher
-	if err := s.DB.Preload("Account").First(&teacher, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if teacher.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	var teacher edutrack.Teacher
+	if err := s.tenantDB(r).Preload("Account").First(&teacher, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, teacher)
+	setETag(w, teacher.Model)
+	sendJSON(w, http.StatusOK, newTeacherResponse(&teacher, inc))
 }
 
 // CreateTeacherRequest represents the request body for creating a teacher.
 type CreateTeacherRequest struct {
-	AccountID uint `json:"account_id"`
+	AccountID uint `json:"account_id" validate:"required"`
 }
 
 // handleCreateTeacher handles POST /teachers.
 func (s *Server) handleCreateTeacher(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, teacherIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req CreateTeacherRequest
 	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if req.AccountID == 0 {
-		sendErrorMessage(w, http.StatusBadRequest, "El ID de cuenta es requerido.")
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	// Verify the account exists and belongs to the same tenant.
 	var linkedAccount edutrack.Account
-	if err := s.DB.First(&linkedAccount, req.AccountID).Error; err != nil {
-		sendErrorMessage(w, http.StatusBadRequest, "La cuenta especificada no existe.")
-		return
-	}
-
-	if linkedAccount.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := s.tenantDB(r).First(&linkedAccount, req.AccountID).Error; err != nil {
+		sendFieldError(w, r, "account_id", edutrack.FieldNotFound, "account.not_found")
 		return
 	}
 
@@ -104,109 +118,111 @@ func (s *Server) handleCreateTeacher(w http.ResponseWriter, r *http.Request) {
 		TenantID:  account.TenantID,
 	}
 
-	if err := s.DB.Create(teacher).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Create(teacher).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
 	// Load the account relationship for the response.
-	s.DB.Preload("Account").First(teacher, teacher.ID)
+	s.tenantDB(r).Preload("Account").First(teacher, teacher.ID)
 
-	sendJSON(w, http.StatusCreated, teacher)
+	setETag(w, teacher.Model)
+	sendJSON(w, http.StatusCreated, newTeacherResponse(teacher, inc))
 }
 
 // UpdateTeacherRequest represents the request body for updating a teacher.
 type UpdateTeacherRequest struct {
-	AccountID *uint `json:"account_id"`
+	AccountID *uint `json:"account_id" validate:"required"`
 }
 
-// handleUpdateTeacher handles PUT /teachers/{id}.
+// handleUpdateTeacher handles PUT and PATCH /teachers/{id}.
 func (s *Server) handleUpdateTeacher(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, teacherIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var teacher edutrack.Teacher
-	if err := s.DB.First(&teacher, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	if err := s.tenantDB(r).First(&teacher, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if teacher.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := edutrack.CheckIfMatch(r.Context(), teacher.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req UpdateTeacherRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	if req.AccountID != nil {
 		// Verify the new account exists and belongs to the same tenant.
 		var linkedAccount edutrack.Account
-		if err := s.DB.First(&linkedAccount, *req.AccountID).Error; err != nil {
-			sendErrorMessage(w, http.StatusBadRequest, "La cuenta especificada no existe.")
-			return
-		}
-
-		if linkedAccount.TenantID != account.TenantID {
-			sendError(w, http.StatusForbidden, ErrForbidden)
+		if err := s.tenantDB(r).First(&linkedAccount, *req.AccountID).Error; err != nil {
+			sendFieldError(w, r, "account_id", edutrack.FieldNotFound, "account.not_found")
 			return
 		}
 
 		teacher.AccountID = *req.AccountID
 	}
 
-	if err := s.DB.Save(&teacher).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Save(&teacher).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
 	// Load the account relationship for the response.
-	s.DB.Preload("Account").First(&teacher, teacher.ID)
+	s.tenantDB(r).Preload("Account").First(&teacher, teacher.ID)
 
-	sendJSON(w, http.StatusOK, teacher)
+	setETag(w, teacher.Model)
+	sendJSON(w, http.StatusOK, newTeacherResponse(&teacher, inc))
 }
 
 // handleDeleteTeacher handles DELETE /teachers/{id}.
 func (s *Server) handleDeleteTeacher(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var teacher edutrack.Teacher
-	if err := s.DB.First(&teacher, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	if teacher.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := s.tenantDB(r).First(&teacher, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if err := s.DB.Delete(&teacher).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := edutrack.CheckIfMatch(r.Context(), teacher.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	s.deleteRecord(w, r, &teacher)
 }
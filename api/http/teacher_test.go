@@ -119,7 +119,7 @@ func TestHandleListTeachers_Success(t *testing.T) {
 		t.Errorf("handleListTeachers() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var teachers []edutrack.Teacher
+	var teachers []TeacherResponse
 	if err := json.NewDecoder(w.Body).Decode(&teachers); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -151,7 +151,7 @@ func TestHandleListTeachers_FilterByAccountID(t *testing.T) {
 		t.Errorf("handleListTeachers() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var teachers []edutrack.Teacher
+	var teachers []TeacherResponse
 	if err := json.NewDecoder(w.Body).Decode(&teachers); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -197,7 +197,7 @@ func TestHandleListTeachers_TenantIsolation(t *testing.T) {
 
 	server.handleListTeachers(w, req)
 
-	var teachers []edutrack.Teacher
+	var teachers []TeacherResponse
 	json.NewDecoder(w.Body).Decode(&teachers)
 
 	if len(teachers) != 1 {
@@ -224,7 +224,7 @@ func TestHandleGetTeacher_Success(t *testing.T) {
 		t.Errorf("handleGetTeacher() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var found edutrack.Teacher
+	var found TeacherResponse
 	if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -270,7 +270,7 @@ func TestHandleGetTeacher_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleGetTeacher_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleGetTeacher_CrossTenant(t *testing.T) {
 	db := setupTeacherTestDB(t)
 
 	tenant1 := createTeacherTestTenant(t, db)
@@ -289,8 +289,8 @@ func TestHandleGetTeacher_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleGetTeacher(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleGetTeacher() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleGetTeacher() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -316,7 +316,7 @@ func TestHandleCreateTeacher_Success(t *testing.T) {
 		t.Errorf("handleCreateTeacher() status = %d, want %d", w.Code, http.StatusCreated)
 	}
 
-	var created edutrack.Teacher
+	var created TeacherResponse
 	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -413,8 +413,8 @@ func TestHandleCreateTeacher_CrossTenantAccount(t *testing.T) {
 
 	server.handleCreateTeacher(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleCreateTeacher() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleCreateTeacher() cross-tenant status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
 
@@ -444,7 +444,7 @@ func TestHandleUpdateTeacher_Success(t *testing.T) {
 		t.Errorf("handleUpdateTeacher() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Teacher
+	var updated TeacherResponse
 	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -476,7 +476,7 @@ func TestHandleUpdateTeacher_NotFound(t *testing.T) {
 	}
 }
 
-func TestHandleUpdateTeacher_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleUpdateTeacher_CrossTenant(t *testing.T) {
 	db := setupTeacherTestDB(t)
 
 	tenant1 := createTeacherTestTenant(t, db)
@@ -499,8 +499,8 @@ func TestHandleUpdateTeacher_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleUpdateTeacher(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleUpdateTeacher() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleUpdateTeacher() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -591,7 +591,7 @@ func TestHandleDeleteTeacher_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleDeleteTeacher_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleDeleteTeacher_CrossTenant(t *testing.T) {
 	db := setupTeacherTestDB(t)
 
 	tenant1 := createTeacherTestTenant(t, db)
@@ -610,8 +610,8 @@ func TestHandleDeleteTeacher_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleDeleteTeacher(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleDeleteTeacher() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleDeleteTeacher() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// CertReloader serves a TLS certificate loaded from files, which can be
+// reloaded (e.g., on SIGHUP after a renewal) without restarting the server.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// NewCertReloader creates a certificate reloader and loads the certificate.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	c := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the certificate files again. On error the previous
+// certificate keeps being served.
+func (c *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	c.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate returns the current certificate. It is meant to be used as
+// tls.Config.GetCertificate.
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// tlsConfig returns the TLS configuration served with the certificate.
+func (c *CertReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.GetCertificate,
+	}
+}
+
+// withSecurityHeaders is a middleware that sets the security headers of an
+// API serving JSON. Strict-Transport-Security is only sent over HTTPS and
+// when hstsMaxAge is positive, as browsers ignore it over plain HTTP.
+func withSecurityHeaders(hstsMaxAge time.Duration, next http.Handler) http.Handler {
+	hsts := "max-age=" + strconv.Itoa(int(hstsMaxAge.Seconds())) + "; includeSubDomains"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+
+		if r.TLS != nil && hstsMaxAge > 0 {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// redirectToHTTPS returns a handler that permanently redirects every request
+// to the same URL over HTTPS, on the port of httpsAddr.
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		// 308 keeps the method and body of non-GET requests.
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
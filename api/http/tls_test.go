@@ -0,0 +1,314 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// setupTLSTestDB creates an in-memory SQLite database for testing.
+func setupTLSTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// writeTestCert generates a self-signed certificate for 127.0.0.1 with the
+// given common name, writes it to certFile and keyFile and returns it.
+func writeTestCert(t *testing.T, certFile, keyFile, commonName string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+// servedCommonName returns the common name of the certificate served.
+func servedCommonName(t *testing.T, c *CertReloader) string {
+	cert, err := c.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate() error = %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse served certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, "first")
+
+	certs, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+	if name := servedCommonName(t, certs); name != "first" {
+		t.Errorf("served certificate = %q, want first", name)
+	}
+
+	// A renewed certificate is served after reloading.
+	writeTestCert(t, certFile, keyFile, "second")
+	if err := certs.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if name := servedCommonName(t, certs); name != "second" {
+		t.Errorf("served certificate = %q, want second", name)
+	}
+
+	// A broken certificate is rejected and the previous one kept.
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := certs.Reload(); err == nil {
+		t.Error("Reload() with a broken certificate should fail")
+	}
+	if name := servedCommonName(t, certs); name != "second" {
+		t.Errorf("served certificate = %q, want second", name)
+	}
+}
+
+func TestNewCertReloader_MissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewCertReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")); err == nil {
+		t.Error("NewCertReloader() with missing files should fail")
+	}
+}
+
+func TestServer_ServeTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	first := writeTestCert(t, certFile, keyFile, "first")
+
+	certs, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+
+	server := NewServerWithOptions(setupTLSTestDB(t), Options{
+		JWTSecret:  []byte("test-secret"),
+		TLS:        certs,
+		HSTSMaxAge: 24 * time.Hour,
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() { _ = server.Serve(ln) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	roots := x509.NewCertPool()
+	roots.AddCert(first)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		DisableKeepAlives: true,
+	}}
+	url := "https://" + ln.Addr().String() + "/healthz"
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET over HTTPS error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := resp.Header.Get("Strict-Transport-Security"); got != "max-age=86400; includeSubDomains" {
+		t.Errorf("Strict-Transport-Security = %q", got)
+	}
+	if got := resp.TLS.PeerCertificates[0].Subject.CommonName; got != "first" {
+		t.Errorf("served certificate = %q, want first", got)
+	}
+
+	// New connections get the reloaded certificate without restarting.
+	second := writeTestCert(t, certFile, keyFile, "second")
+	if err := certs.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	roots.AddCert(second)
+
+	resp, err = client.Get(url)
+	if err != nil {
+		t.Fatalf("GET over HTTPS after reload error = %v", err)
+	}
+	resp.Body.Close()
+
+	if got := resp.TLS.PeerCertificates[0].Subject.CommonName; got != "second" {
+		t.Errorf("served certificate = %q, want second", got)
+	}
+
+	// Plain HTTP is refused on the HTTPS listener.
+	resp, err = http.Get("http://" + ln.Addr().String() + "/healthz")
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Error("plain HTTP request should not be served")
+		}
+	}
+}
+
+func TestWithSecurityHeaders(t *testing.T) {
+	server := NewServerWithOptions(setupTLSTestDB(t), Options{
+		JWTSecret:  []byte("test-secret"),
+		HSTSMaxAge: time.Hour,
+	})
+
+	t.Run("plain HTTP", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		for header, want := range map[string]string{
+			"X-Content-Type-Options":  "nosniff",
+			"X-Frame-Options":         "DENY",
+			"Referrer-Policy":         "no-referrer",
+			"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+		} {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("%s = %q, want %q", header, got, want)
+			}
+		}
+		if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+			t.Errorf("Strict-Transport-Security over HTTP = %q, want none", got)
+		}
+	})
+
+	t.Run("HTTPS", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.TLS = &tls.ConnectionState{}
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=3600; includeSubDomains" {
+			t.Errorf("Strict-Transport-Security = %q", got)
+		}
+	})
+
+	t.Run("HSTS disabled", func(t *testing.T) {
+		server := NewServerWithOptions(setupTLSTestDB(t), Options{JWTSecret: []byte("test-secret")})
+
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.TLS = &tls.ConnectionState{}
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+			t.Errorf("Strict-Transport-Security = %q, want none", got)
+		}
+	})
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsAddr string
+		target    string
+		want      string
+	}{
+		{"default port", ":443", "http://example.com/students?page=2", "https://example.com/students?page=2"},
+		{"strips HTTP port", ":443", "http://example.com:80/healthz", "https://example.com/healthz"},
+		{"custom port", ":8443", "http://example.com:8080/healthz", "https://example.com:8443/healthz"},
+		{"IPv6", ":8443", "http://[::1]:8080/", "https://[::1]:8443/"},
+		{"IPv6 default port", ":443", "http://[::1]/", "https://[::1]/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			redirectToHTTPS(tt.httpsAddr).ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, nil))
+
+			if w.Code != http.StatusPermanentRedirect {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusPermanentRedirect)
+			}
+			if got := w.Header().Get("Location"); got != tt.want {
+				t.Errorf("Location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewServerWithOptions_Redirect(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, "edutrack")
+
+	certs, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+	db := setupTLSTestDB(t)
+
+	server := NewServerWithOptions(db, Options{Addr: ":8443", TLS: certs, RedirectAddr: ":8080"})
+	if server.redirect == nil || server.redirect.Addr != ":8080" {
+		t.Errorf("redirect listener = %v, want one on :8080", server.redirect)
+	}
+
+	// The redirect listener is ignored without TLS.
+	server = NewServerWithOptions(db, Options{Addr: ":8443", RedirectAddr: ":8080"})
+	if server.redirect != nil {
+		t.Error("redirect listener should not be created without TLS")
+	}
+}
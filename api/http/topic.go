@@ -3,6 +3,7 @@ package http
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	edutrack "lahuerta.tecmm.edu.mx/edutrack"
 )
@@ -11,11 +12,17 @@ import (
 func (s *Server) handleListTopics(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
-	query := s.DB.Where("tenant_id = ?", account.TenantID)
+	inc, err := parseInclude(r, topicIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	query := s.tenantDB(r)
 
 	// Optional filters.
 	if subjectID := r.URL.Query().Get("subject_id"); subjectID != "" {
@@ -24,75 +31,87 @@ func (s *Server) handleListTopics(w http.ResponseWriter, r *http.Request) {
 
 	var topics []edutrack.Topic
 	if err := query.Preload("Subject").Find(&topics).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, topics)
+	response := make([]TopicResponse, 0, len(topics))
+	for i := range topics {
+		response = append(response, newTopicResponse(&topics[i], inc))
+	}
+
+	sendJSON(w, http.StatusOK, response)
 }
 
 // handleGetTopic handles GET /topics/{id}.
 func (s *Server) handleGetTopic(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
-	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	inc, err := parseInclude(r, topicIncludes)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	var topic edutrack.Topic
-	if err := s.DB.Preload("Subject").First(&topic, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if topic.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	var topic edutrack.Topic
+	if err := s.tenantDB(r).Preload("Subject").First(&topic, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	sendJSON(w, http.StatusOK, topic)
+	setETag(w, topic.Model)
+	sendJSON(w, http.StatusOK, newTopicResponse(&topic, inc))
 }
 
 // CreateTopicRequest represents the request body for creating a topic.
 type CreateTopicRequest struct {
-	Name        string `json:"name"`
+	Name        string `json:"name" validate:"required"`
 	Description string `json:"description"`
-	SubjectID   uint   `json:"subject_id"`
+	SubjectID   uint   `json:"subject_id" validate:"required"`
+
+	// Deadline after which the grades of the topic are locked, optional.
+	GradesLockAt *time.Time `json:"grades_lock_at"`
 }
 
 // handleCreateTopic handles POST /topics.
 func (s *Server) handleCreateTopic(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, topicIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req CreateTopicRequest
 	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
-	if req.SubjectID == 0 || req.Name == "" {
-		sendErrorMessage(w, http.StatusBadRequest, "El nombre y el tema son requeridos.")
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	// Verify subject exists and belongs to the same tenant.
 	var subject edutrack.Subject
-	if err := s.DB.First(&subject, req.SubjectID).Error; err != nil {
-		sendErrorMessage(w, http.StatusBadRequest, "El tema especificado no existe.")
-		return
-	}
-	if subject.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := s.tenantDB(r).First(&subject, req.SubjectID).Error; err != nil {
+		sendFieldError(w, r, "subject_id", edutrack.FieldNotFound, "subject.not_found")
 		return
 	}
 
@@ -101,53 +120,69 @@ func (s *Server) handleCreateTopic(w http.ResponseWriter, r *http.Request) {
 		Description: req.Description,
 		SubjectID:   req.SubjectID,
 		TenantID:    account.TenantID,
+
+		GradesLockAt: req.GradesLockAt,
 	}
 
-	if err := s.DB.Create(topic).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Create(topic).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
 	// Reload with associations.
-	s.DB.Preload("Subject").First(topic, topic.ID)
+	s.tenantDB(r).Preload("Subject").First(topic, topic.ID)
 
-	sendJSON(w, http.StatusCreated, topic)
+	setETag(w, topic.Model)
+	sendJSON(w, http.StatusCreated, newTopicResponse(topic, inc))
 }
 
 // UpdateTopicRequest represents the request body for updating a topic.
 type UpdateTopicRequest struct {
-	Name        *string `json:"name"`
+	Name        *string `json:"name" validate:"required"`
 	Description *string `json:"description"`
+
+	// A null value in a merge patch removes the deadline.
+	GradesLockAt *time.Time `json:"grades_lock_at"`
 }
 
-// handleUpdateTopic handles PUT /topics/{id}.
+// handleUpdateTopic handles PUT and PATCH /topics/{id}.
 func (s *Server) handleUpdateTopic(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	inc, err := parseInclude(r, topicIncludes)
+	if err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var topic edutrack.Topic
-	if err := s.DB.First(&topic, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
+	if err := s.tenantDB(r).First(&topic, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if topic.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := edutrack.CheckIfMatch(r.Context(), topic.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
 	var req UpdateTopicRequest
-	if err := decodeJSON(r, &req); err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
@@ -157,47 +192,58 @@ func (s *Server) handleUpdateTopic(w http.ResponseWriter, r *http.Request) {
 	if req.Description != nil {
 		topic.Description = *req.Description
 	}
+	if req.GradesLockAt != nil {
+		lockAt := req.GradesLockAt
+		if lockAt.IsZero() {
+			lockAt = nil
+		}
+		changed := (lockAt == nil) != (topic.GradesLockAt == nil) ||
+			lockAt != nil && !lockAt.Equal(*topic.GradesLockAt)
+
+		// Once the deadline passed, only secretaries can move it.
+		if changed && topic.GradesLocked(time.Now()) && !account.IsSecretary() {
+			sendError(w, r, http.StatusForbidden, ErrForbidden)
+			return
+		}
+		topic.GradesLockAt = lockAt
+	}
 
-	if err := s.DB.Save(&topic).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := s.tenantDB(r).Save(&topic).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
 		return
 	}
 
 	// Reload with associations.
-	s.DB.Preload("Subject").First(&topic, topic.ID)
+	s.tenantDB(r).Preload("Subject").First(&topic, topic.ID)
 
-	sendJSON(w, http.StatusOK, topic)
+	setETag(w, topic.Model)
+	sendJSON(w, http.StatusOK, newTopicResponse(&topic, inc))
 }
 
 // handleDeleteTopic handles DELETE /topics/{id}.
 func (s *Server) handleDeleteTopic(w http.ResponseWriter, r *http.Request) {
 	account := edutrack.AccountFromContext(r.Context())
 	if account == nil {
-		sendError(w, http.StatusUnauthorized, ErrUnauthorized)
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
-		sendError(w, http.StatusBadRequest, ErrBadRequest)
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
 		return
 	}
 
 	var topic edutrack.Topic
-	if err := s.DB.First(&topic, id).Error; err != nil {
-		sendError(w, http.StatusNotFound, ErrNotFound)
-		return
-	}
-
-	if topic.TenantID != account.TenantID {
-		sendError(w, http.StatusForbidden, ErrForbidden)
+	if err := s.tenantDB(r).First(&topic, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
 		return
 	}
 
-	if err := s.DB.Delete(&topic).Error; err != nil {
-		sendError(w, http.StatusInternalServerError, ErrInternalServer)
+	if err := edutrack.CheckIfMatch(r.Context(), topic.Model); err != nil {
+		s.sendAppError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	s.deleteRecord(w, r, &topic)
 }
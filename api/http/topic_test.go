@@ -133,7 +133,7 @@ func TestHandleListTopics_Success(t *testing.T) {
 		t.Errorf("handleListTopics() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var topics []edutrack.Topic
+	var topics []TopicResponse
 	if err := json.NewDecoder(w.Body).Decode(&topics); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -165,7 +165,7 @@ func TestHandleListTopics_FilterBySubjectID(t *testing.T) {
 		t.Errorf("handleListTopics() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var topics []edutrack.Topic
+	var topics []TopicResponse
 	if err := json.NewDecoder(w.Body).Decode(&topics); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -214,7 +214,7 @@ func TestHandleListTopics_TenantIsolation(t *testing.T) {
 
 	server.handleListTopics(w, req)
 
-	var topics []edutrack.Topic
+	var topics []TopicResponse
 	json.NewDecoder(w.Body).Decode(&topics)
 
 	if len(topics) != 1 {
@@ -243,7 +243,7 @@ func TestHandleGetTopic_Success(t *testing.T) {
 		t.Errorf("handleGetTopic() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var found edutrack.Topic
+	var found TopicResponse
 	if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -289,7 +289,7 @@ func TestHandleGetTopic_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleGetTopic_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleGetTopic_CrossTenant(t *testing.T) {
 	db := setupTopicTestDB(t)
 
 	tenant1 := createTopicTestTenant(t, db)
@@ -311,8 +311,8 @@ func TestHandleGetTopic_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleGetTopic(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleGetTopic() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleGetTopic() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -341,7 +341,7 @@ func TestHandleCreateTopic_Success(t *testing.T) {
 		t.Errorf("handleCreateTopic() status = %d, want %d", w.Code, http.StatusCreated)
 	}
 
-	var created edutrack.Topic
+	var created TopicResponse
 	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -430,7 +430,7 @@ func TestHandleUpdateTopic_Success(t *testing.T) {
 		t.Errorf("handleUpdateTopic() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Topic
+	var updated TopicResponse
 	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
@@ -467,7 +467,7 @@ func TestHandleUpdateTopic_UpdateDescription(t *testing.T) {
 		t.Errorf("handleUpdateTopic() status = %d, want %d", w.Code, http.StatusOK)
 	}
 
-	var updated edutrack.Topic
+	var updated TopicResponse
 	json.NewDecoder(w.Body).Decode(&updated)
 
 	if updated.Description != newDesc {
@@ -497,7 +497,7 @@ func TestHandleUpdateTopic_NotFound(t *testing.T) {
 	}
 }
 
-func TestHandleUpdateTopic_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleUpdateTopic_CrossTenant(t *testing.T) {
 	db := setupTopicTestDB(t)
 
 	tenant1 := createTopicTestTenant(t, db)
@@ -523,8 +523,8 @@ func TestHandleUpdateTopic_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleUpdateTopic(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleUpdateTopic() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleUpdateTopic() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -593,7 +593,7 @@ func TestHandleDeleteTopic_InvalidID(t *testing.T) {
 	}
 }
 
-func TestHandleDeleteTopic_ForbiddenCrossTenant(t *testing.T) {
+func TestHandleDeleteTopic_CrossTenant(t *testing.T) {
 	db := setupTopicTestDB(t)
 
 	tenant1 := createTopicTestTenant(t, db)
@@ -615,8 +615,8 @@ func TestHandleDeleteTopic_ForbiddenCrossTenant(t *testing.T) {
 
 	server.handleDeleteTopic(w, req)
 
-	if w.Code != http.StatusForbidden {
-		t.Errorf("handleDeleteTopic() cross-tenant status = %d, want %d", w.Code, http.StatusForbidden)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleDeleteTopic() cross-tenant status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
 
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// handleListTrash handles GET /trash.
+func (s *Server) handleListTrash(w http.ResponseWriter, r *http.Request) {
+	items, err := s.TrashService.FindTrash(r.Context(), r.URL.Query().Get("type"))
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newTrashItemResponses(items))
+}
+
+// handleRestoreTrash handles POST /trash/{type}/{id}/restore.
+func (s *Server) handleRestoreTrash(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	restored, err := s.TrashService.RestoreTrash(r.Context(), r.PathValue("type"), uint(id))
+	if err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newTrashItemResponses(restored))
+}
+
+// handlePurgeTrash handles DELETE /trash/{type}/{id}.
+func (s *Server) handlePurgeTrash(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	if err := s.TrashService.PurgeTrash(r.Context(), r.PathValue("type"), uint(id)); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
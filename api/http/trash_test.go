@@ -0,0 +1,353 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// trashItems decodes the trash items of a response.
+func trashItems(t *testing.T, body string) []edutrack.TrashItem {
+	t.Helper()
+
+	var items []edutrack.TrashItem
+	if err := json.Unmarshal([]byte(body), &items); err != nil {
+		t.Fatalf("Failed to decode trash items: %v", err)
+	}
+	return items
+}
+
+// trashContains checks if the trash items include a record.
+func trashContains(items []edutrack.TrashItem, typ string, id uint) bool {
+	return slices.ContainsFunc(items, func(item edutrack.TrashItem) bool {
+		return item.Type == typ && item.ID == id
+	})
+}
+
+// isTrashed checks if a record is soft-deleted, failing if it was purged.
+func isTrashed(t *testing.T, db *gorm.DB, model any, id uint) bool {
+	t.Helper()
+
+	if isPurged(db, model, id) {
+		t.Fatalf("%T %d was purged", model, id)
+	}
+	var count int64
+	db.Unscoped().Model(model).Where("id = ? AND deleted_at IS NOT NULL", id).Count(&count)
+	return count > 0
+}
+
+// isPurged checks if a record was permanently deleted.
+func isPurged(db *gorm.DB, model any, id uint) bool {
+	var count int64
+	db.Unscoped().Model(model).Where("id = ?", id).Count(&count)
+	return count == 0
+}
+
+func TestTrash_ListAndRestore(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+
+	w := isolationRequest(t, server, tenant, http.MethodDelete, fmt.Sprintf("/students/%d", tenant.Student.ID), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE student status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodGet, "/trash?type=students", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /trash status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	items := trashItems(t, w.Body.String())
+	if len(items) != 1 || !trashContains(items, "students", tenant.Student.ID) || items[0].DeletedAt.IsZero() {
+		t.Fatalf("GET /trash?type=students = %+v, want the deleted student", items)
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodGet, "/trash", nil)
+	if !trashContains(trashItems(t, w.Body.String()), "students", tenant.Student.ID) {
+		t.Error("GET /trash should list the deleted records of every type")
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodPost, fmt.Sprintf("/trash/students/%d/restore", tenant.Student.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Restore status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if isTrashed(t, db, &edutrack.Student{}, tenant.Student.ID) {
+		t.Error("Restored student should not be deleted")
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodGet, fmt.Sprintf("/students/%d", tenant.Student.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET restored student status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var count int64
+	db.Table("student_subjects").Where("student_id = ?", tenant.Student.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("Restored student has %d enrollments, want 1", count)
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodPost, fmt.Sprintf("/trash/students/%d/restore", tenant.Student.ID), nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Restore of a record not deleted status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestTrash_ListTeachers(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+
+	w := isolationRequest(t, server, tenant, http.MethodDelete, fmt.Sprintf("/teachers/%d", tenant.Teacher.ID), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE teacher status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodGet, "/trash?type=teachers", nil)
+	var items []TrashItemResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GET /trash?type=teachers status = %d: %s", w.Code, w.Body.String())
+	}
+	if len(items) != 1 || items[0].ID != tenant.Teacher.ID {
+		t.Fatalf("GET /trash?type=teachers = %+v, want the deleted teacher", items)
+	}
+	record, ok := items[0].Record.(map[string]any)
+	if !ok || record["account_id"] != float64(tenant.Teacher.AccountID) {
+		t.Errorf("Record = %v, want the deleted teacher", items[0].Record)
+	}
+}
+
+func TestTrash_RestoreAssociations(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+
+	// The grade references the topic, which references the subject.
+	for _, record := range []any{tenant.Subject, tenant.Topic, tenant.Grade} {
+		if err := db.Delete(record).Error; err != nil {
+			t.Fatalf("Failed to delete %T: %v", record, err)
+		}
+	}
+
+	// Records deleted along with the subject are restored with it.
+	at := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	db.Unscoped().Model(tenant.Attendance).Update("deleted_at", at)
+	db.Unscoped().Model(tenant.Subject).Update("deleted_at", at)
+
+	w := isolationRequest(t, server, tenant, http.MethodPost, fmt.Sprintf("/trash/grades/%d/restore", tenant.Grade.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Restore status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	items := trashItems(t, w.Body.String())
+	for _, want := range []struct {
+		typ   string
+		id    uint
+		model any
+	}{
+		{"grades", tenant.Grade.ID, &edutrack.Grade{}},
+		{"topics", tenant.Topic.ID, &edutrack.Topic{}},
+		{"subjects", tenant.Subject.ID, &edutrack.Subject{}},
+		{"attendances", tenant.Attendance.ID, &edutrack.Attendance{}},
+	} {
+		if !trashContains(items, want.typ, want.id) {
+			t.Errorf("Restored records %+v should include %s %d", items, want.typ, want.id)
+		}
+		if isTrashed(t, db, want.model, want.id) {
+			t.Errorf("%s %d should be restored", want.typ, want.id)
+		}
+	}
+}
+
+func TestTrash_UniqueValues(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+
+	if err := db.Delete(tenant.Career).Error; err != nil {
+		t.Fatalf("Failed to delete career: %v", err)
+	}
+
+	// The code of a deleted career can be reused.
+	w := isolationRequest(t, server, tenant, http.MethodPost, "/careers", map[string]any{
+		"name": "Nueva carrera", "code": tenant.Career.Code, "duration": 8,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /careers with the code of a deleted career status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	// So the deleted career cannot be restored while it is in use.
+	w = isolationRequest(t, server, tenant, http.MethodPost, fmt.Sprintf("/trash/careers/%d/restore", tenant.Career.ID), nil)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Restore of a career with a reused code status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if !isTrashed(t, db, &edutrack.Career{}, tenant.Career.ID) {
+		t.Error("Career with a reused code should stay in the trash")
+	}
+}
+
+func TestTrash_Purge(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+
+	if err := db.Delete(tenant.Student).Error; err != nil {
+		t.Fatalf("Failed to delete student: %v", err)
+	}
+	path := fmt.Sprintf("/trash/students/%d", tenant.Student.ID)
+
+	w := isolationRequest(t, server, tenant, http.MethodDelete, path, nil)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Purge of a student with grades status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+	}
+	if !isTrashed(t, db, &edutrack.Student{}, tenant.Student.ID) {
+		t.Error("Student still referenced should stay in the trash")
+	}
+
+	// Deleted records referencing it are purged with it.
+	db.Delete(tenant.Grade)
+	db.Delete(tenant.Attendance)
+	db.Delete(tenant.Justification)
+	db.Delete(tenant.Appeal)
+	db.Delete(tenant.Exam)
+
+	w = isolationRequest(t, server, tenant, http.MethodDelete, path, nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Purge status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+	for _, purged := range []struct {
+		model any
+		id    uint
+	}{
+		{&edutrack.Student{}, tenant.Student.ID},
+		{&edutrack.Grade{}, tenant.Grade.ID},
+		{&edutrack.Attendance{}, tenant.Attendance.ID},
+		{&edutrack.Justification{}, tenant.Justification.ID},
+		{&edutrack.GradeAppeal{}, tenant.Appeal.ID},
+		{&edutrack.ExamAttempt{}, tenant.Exam.ID},
+	} {
+		if !isPurged(db, purged.model, purged.id) {
+			t.Errorf("%T %d should be purged", purged.model, purged.id)
+		}
+	}
+
+	var links, enrollments int64
+	db.Unscoped().Model(&edutrack.GuardianLink{}).Where("student_id = ?", tenant.Student.ID).Count(&links)
+	db.Table("student_subjects").Where("student_id = ?", tenant.Student.ID).Count(&enrollments)
+	if links != 0 || enrollments != 0 {
+		t.Errorf("Purged student kept %d guardian links and %d enrollments, want none", links, enrollments)
+	}
+
+	w = isolationRequest(t, server, tenant, http.MethodDelete, path, nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Purge of a purged record status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestTrash_Access(t *testing.T) {
+	server, db, tenantA, tenantB := setupIsolationTest(t)
+
+	if err := db.Delete(tenantB.Grade).Error; err != nil {
+		t.Fatalf("Failed to delete grade: %v", err)
+	}
+
+	w := isolationRequest(t, server, tenantA, http.MethodGet, "/trash", nil)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), tenantB.Marker) {
+		t.Errorf("GET /trash status = %d, want %d without the records of another tenant: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		path := fmt.Sprintf("/trash/grades/%d", tenantB.Grade.ID)
+		if method == http.MethodPost {
+			path += "/restore"
+		}
+		w := isolationRequest(t, server, tenantA, method, path, nil)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s %s of another tenant status = %d, want %d", method, path, w.Code, http.StatusNotFound)
+		}
+	}
+	if !isTrashed(t, db, &edutrack.Grade{}, tenantB.Grade.ID) {
+		t.Error("Grade of another tenant should stay in the trash")
+	}
+
+	w = isolationRequest(t, server, tenantA, http.MethodGet, "/trash?type=licenses", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("GET /trash with an unknown type status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	var teacher edutrack.Account
+	db.First(&teacher, tenantA.Teacher.AccountID)
+	token, err := server.generateToken(&teacher)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/trash", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("GET /trash as a teacher status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	db := setupIsolationTestDB(t)
+	tenant := seedIsolationTenant(t, db, "TENANT-A-SECRET")
+
+	expired := time.Now().Add(-40 * 24 * time.Hour)
+	recent := time.Now().Add(-24 * time.Hour)
+	db.Unscoped().Model(tenant.Grade).Update("deleted_at", expired)
+	db.Unscoped().Model(tenant.Appeal).Update("deleted_at", expired)
+	db.Unscoped().Model(tenant.Webhook).Update("deleted_at", recent)
+
+	// The attendance was deleted recently, so the expired student it
+	// references is kept until it is purged.
+	db.Unscoped().Model(tenant.Attendance).Update("deleted_at", recent)
+	db.Unscoped().Model(tenant.Student).Update("deleted_at", expired)
+
+	purged, err := edutrack.PurgeExpiredTrash(context.Background(), db, time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeExpiredTrash() error = %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("PurgeExpiredTrash() = %d, want 2", purged)
+	}
+	if !isPurged(db, &edutrack.Grade{}, tenant.Grade.ID) || !isPurged(db, &edutrack.GradeAppeal{}, tenant.Appeal.ID) {
+		t.Error("Expired grade and its appeal should be purged")
+	}
+	for _, kept := range []struct {
+		model any
+		id    uint
+	}{
+		{&edutrack.Webhook{}, tenant.Webhook.ID},
+		{&edutrack.Attendance{}, tenant.Attendance.ID},
+		{&edutrack.Student{}, tenant.Student.ID},
+	} {
+		if !isTrashed(t, db, kept.model, kept.id) {
+			t.Errorf("%T %d should stay in the trash", kept.model, kept.id)
+		}
+	}
+}
+
+func TestMigrate_LegacyIndexes(t *testing.T) {
+	db := setupIsolationTestDB(t)
+
+	if err := db.Exec("CREATE UNIQUE INDEX idx_career_tenant ON careers (code, tenant_id)").Error; err != nil {
+		t.Fatalf("Failed to create legacy index: %v", err)
+	}
+	pending, err := edutrack.PendingMigrations(db)
+	if err != nil {
+		t.Fatalf("PendingMigrations() error = %v", err)
+	}
+	if !slices.Contains(pending, "-idx_career_tenant") {
+		t.Errorf("PendingMigrations() = %v, want the legacy index", pending)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if db.Migrator().HasIndex(&edutrack.Career{}, "idx_career_tenant") {
+		t.Error("Migrate() should drop the legacy index")
+	}
+	if pending, _ := edutrack.PendingMigrations(db); len(pending) != 0 {
+		t.Errorf("PendingMigrations() after Migrate() = %v, want none", pending)
+	}
+}
@@ -0,0 +1,227 @@
+package http
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// validate checks a request against the rules of the validate tags of its
+// fields. It returns an EINVALID error listing every rejected field, named
+// as in JSON, or nil if the request is valid.
+//
+// Rules are separated by commas:
+//
+//	required    strings must not be blank, numbers not zero and slices not
+//	            empty
+//	omitempty   blank values skip the other rules, even if set
+//	min=N       numbers must be at least N, and strings and slices have at
+//	            least N characters or elements
+//	max=N       numbers must be at most N, and strings and slices have at
+//	            most N characters or elements
+//	oneof=a b   strings must be one of the values separated by spaces
+//	email       strings must be email addresses
+//	date        strings must be dates in the YYYY-MM-DD format
+//	url         strings must be absolute http or https URLs
+//
+// The rules of strings apply to every element of slices. Nil pointers are
+// fields left unset, so they skip every rule; zero values of other fields
+// skip every rule but required.
+func validate(req any) error {
+	v := reflect.Indirect(reflect.ValueOf(req))
+	t := v.Type()
+
+	var fields []edutrack.FieldError
+	for i := range t.NumField() {
+		field := t.Field(i)
+		rules := field.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		if err := checkRules(v.Field(i), rules); err != nil {
+			err.Field = name
+			fields = append(fields, *err)
+		}
+	}
+
+	if fields != nil {
+		return edutrack.Invalid(fields...)
+	}
+	return nil
+}
+
+// checkRules returns the error of the first rule a value breaks, or nil.
+func checkRules(value reflect.Value, rules string) *edutrack.FieldError {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	} else if isBlank(value) && !strings.Contains(rules, "required") {
+		return nil
+	}
+	if isBlank(value) && strings.Contains(rules, "omitempty") {
+		return nil
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if err := checkRule(value, name, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRule returns the error of a value breaking a rule, or nil.
+func checkRule(value reflect.Value, rule, arg string) *edutrack.FieldError {
+	switch rule {
+	case "omitempty":
+		// Applied by checkRules, before every rule.
+
+	case "required":
+		if isBlank(value) {
+			return &edutrack.FieldError{Code: edutrack.FieldRequired, Message: "field.required"}
+		}
+
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: invalid %s rule %q", rule, arg))
+		}
+		size, unit := measure(value)
+		if rule == "min" && size < limit {
+			return &edutrack.FieldError{Code: edutrack.FieldTooSmall, Message: "field." + edutrack.FieldTooSmall + unit, Args: []any{arg}}
+		}
+		if rule == "max" && size > limit {
+			return &edutrack.FieldError{Code: edutrack.FieldTooLarge, Message: "field." + edutrack.FieldTooLarge + unit, Args: []any{arg}}
+		}
+
+	case "oneof":
+		choices := strings.Fields(arg)
+		if !eachString(value, func(s string) bool { return slices.Contains(choices, s) }) {
+			return &edutrack.FieldError{Code: edutrack.FieldInvalidChoice, Message: "field.invalid_choice", Args: []any{strings.Join(choices, ", ")}}
+		}
+
+	case "email":
+		if !eachString(value, isEmail) {
+			return &edutrack.FieldError{Code: edutrack.FieldInvalidFormat, Message: "field.invalid_format.email"}
+		}
+
+	case "date":
+		if !eachString(value, isDate) {
+			return &edutrack.FieldError{Code: edutrack.FieldInvalidFormat, Message: "field.invalid_format.date"}
+		}
+
+	case "url":
+		if !eachString(value, isValidWebhookURL) {
+			return &edutrack.FieldError{Code: edutrack.FieldInvalidFormat, Message: "field.invalid_format.url"}
+		}
+
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", rule))
+	}
+	return nil
+}
+
+// isBlank checks if a value is blank for the required rule.
+func isBlank(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+// measure returns the number compared by the min and max rules, and the
+// suffix of the key of their messages: ".length" for strings, ".items" for
+// slices and none for numbers.
+func measure(value reflect.Value) (float64, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), ".length"
+	case reflect.Slice:
+		return float64(value.Len()), ".items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), ""
+	}
+	panic(fmt.Sprintf("validate: cannot measure %s", value.Type()))
+}
+
+// eachString checks a string, or every string of a slice, with a function.
+func eachString(value reflect.Value, ok func(string) bool) bool {
+	if value.Kind() == reflect.Slice {
+		for i := range value.Len() {
+			if !ok(value.Index(i).String()) {
+				return false
+			}
+		}
+		return true
+	}
+	return ok(value.String())
+}
+
+// isEmail checks a string is a bare email address.
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+// isDate checks a string is a date in the YYYY-MM-DD format.
+func isDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+// validationSchema adds the constraints of the validate rules of a field to
+// its schema for the OpenAPI specification.
+func validationSchema(schema map[string]any, rules string) {
+	if _, ok := schema["$ref"]; ok || rules == "" {
+		return
+	}
+	target := schema
+	if items, ok := schema["items"].(map[string]any); ok {
+		target = items
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "max":
+			limit, _ := strconv.ParseFloat(arg, 64)
+			keyword := map[string]string{"string": "Length", "array": "Items"}[schema["type"].(string)]
+			if keyword == "" {
+				keyword = map[string]string{"min": "minimum", "max": "maximum"}[name]
+			} else {
+				keyword = name + keyword
+			}
+			schema[keyword] = limit
+		case "oneof":
+			target["enum"] = strings.Fields(arg)
+		case "email":
+			target["format"] = "email"
+		case "date":
+			target["format"] = "date"
+		case "url":
+			target["format"] = "uri"
+		}
+	}
+}
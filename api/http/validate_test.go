@@ -0,0 +1,198 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+func TestValidate(t *testing.T) {
+	type request struct {
+		Name     string   `json:"name" validate:"required,max=5"`
+		Email    string   `json:"email" validate:"email"`
+		Semester int      `json:"semester" validate:"required,min=1"`
+		Credits  *int     `json:"credits" validate:"min=0"`
+		Title    *string  `json:"title" validate:"required"`
+		Status   string   `json:"status" validate:"oneof=present absent"`
+		Date     string   `json:"date" validate:"date"`
+		URL      string   `json:"url" validate:"url"`
+		Tags     []string `json:"tags" validate:"min=1,oneof=a b"`
+		Notes    string   `json:"notes"`
+	}
+	negative, blank := -1, " "
+
+	tests := []struct {
+		name string
+		req  request
+		want []edutrack.FieldError
+	}{
+		{
+			name: "valid",
+			req:  request{Name: "Ana", Semester: 1, Status: "absent", Date: "2026-03-02", URL: "https://example.com", Tags: []string{"a", "b"}},
+		},
+		{
+			name: "missing",
+			req:  request{Name: "  "},
+			want: []edutrack.FieldError{
+				{Field: "name", Code: edutrack.FieldRequired, Message: "Es requerido."},
+				{Field: "semester", Code: edutrack.FieldRequired, Message: "Es requerido."},
+			},
+		},
+		{
+			name: "out of range",
+			req:  request{Name: "Anabel", Semester: -2, Credits: &negative},
+			want: []edutrack.FieldError{
+				{Field: "name", Code: edutrack.FieldTooLarge, Message: "Debe tener como máximo 5 caracteres."},
+				{Field: "semester", Code: edutrack.FieldTooSmall, Message: "Debe ser mayor o igual a 1."},
+				{Field: "credits", Code: edutrack.FieldTooSmall, Message: "Debe ser mayor o igual a 0."},
+			},
+		},
+		{
+			name: "set pointers are checked",
+			req:  request{Name: "Ana", Semester: 1, Title: &blank},
+			want: []edutrack.FieldError{
+				{Field: "title", Code: edutrack.FieldRequired, Message: "Es requerido."},
+			},
+		},
+		{
+			name: "formats",
+			req:  request{Name: "Ana", Semester: 1, Email: "Ana <ana@example.com>", Status: "late", Date: "02/03/2026", URL: "ftp://example.com", Tags: []string{"a", "c"}},
+			want: []edutrack.FieldError{
+				{Field: "email", Code: edutrack.FieldInvalidFormat, Message: "Debe ser un email válido."},
+				{Field: "status", Code: edutrack.FieldInvalidChoice, Message: "Debe ser uno de: present, absent."},
+				{Field: "date", Code: edutrack.FieldInvalidFormat, Message: "Debe ser una fecha con formato YYYY-MM-DD."},
+				{Field: "url", Code: edutrack.FieldInvalidFormat, Message: "Debe ser una URL http o https."},
+				{Field: "tags", Code: edutrack.FieldInvalidChoice, Message: "Debe ser uno de: a, b."},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(&tt.req)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if edutrack.ErrorCode(err) != edutrack.EINVALID {
+				t.Fatalf("validate() error = %v, want EINVALID", err)
+			}
+			if got := edutrack.ErrorFields(err, i18n.Default); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validate() fields = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// errorResponse decodes the error of a response, checking its status.
+func errorResponse(t *testing.T, w *httptest.ResponseRecorder, status int) ErrorResponse {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("Status = %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	var response ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode error: %v", err)
+	}
+	return response
+}
+
+// fieldCodes returns the codes of the rejected fields of an error.
+func fieldCodes(response ErrorResponse) map[string]string {
+	codes := map[string]string{}
+	for _, field := range response.Fields {
+		codes[field.Field] = field.Code
+	}
+	return codes
+}
+
+func TestHandleCreateSubject_FieldErrors(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+
+	response := errorResponse(t, isolationRequest(t, server, tenant, http.MethodPost, "/subjects", map[string]any{"semester": -1}), http.StatusBadRequest)
+	if response.Code != edutrack.EINVALID {
+		t.Errorf("Code = %q, want %q", response.Code, edutrack.EINVALID)
+	}
+	want := map[string]string{
+		"name":      edutrack.FieldRequired,
+		"code":      edutrack.FieldRequired,
+		"career_id": edutrack.FieldRequired,
+		"semester":  edutrack.FieldTooSmall,
+	}
+	if got := fieldCodes(response); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields = %v, want %v", got, want)
+	}
+}
+
+func TestHandleCreateCareer_DuplicateField(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+
+	body := map[string]any{"name": "Otra", "code": tenant.Career.Code}
+	response := errorResponse(t, isolationRequest(t, server, tenant, http.MethodPost, "/careers", body), http.StatusConflict)
+	if response.Code != edutrack.ECONFLICT {
+		t.Errorf("Code = %q, want %q", response.Code, edutrack.ECONFLICT)
+	}
+	if got, want := fieldCodes(response), (map[string]string{"code": edutrack.FieldTaken}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields = %v, want %v", got, want)
+	}
+}
+
+func TestHandleCreateAttendance_MissingReference(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+
+	body := map[string]any{"date": "2026-03-03", "status": "present", "student_id": tenant.Student.ID, "subject_id": 9999}
+	response := errorResponse(t, isolationRequest(t, server, tenant, http.MethodPost, "/attendances", body), http.StatusBadRequest)
+	if got, want := fieldCodes(response), (map[string]string{"subject_id": edutrack.FieldNotFound}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields = %v, want %v", got, want)
+	}
+}
+
+func TestErrorResponse_Codes(t *testing.T) {
+	server, _, tenant, _ := setupIsolationTest(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		status int
+		code   string
+	}{
+		{"body not an object", http.MethodPost, "/careers", "{", http.StatusBadRequest, CodeBadRequest},
+		{"not found", http.MethodGet, "/careers/9999", nil, http.StatusNotFound, edutrack.ENOTFOUND},
+		{"custom message", http.MethodDelete, fmt.Sprintf("/accounts/%d", tenant.Secretary.ID), nil, http.StatusBadRequest, edutrack.EINVALID},
+		{"invalid include", http.MethodGet, "/students?include=password", nil, http.StatusBadRequest, edutrack.EINVALID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := errorResponse(t, isolationRequest(t, server, tenant, tt.method, tt.path, tt.body), tt.status)
+			if response.Code != tt.code {
+				t.Errorf("Code = %q, want %q", response.Code, tt.code)
+			}
+			if response.Message == "" {
+				t.Error("Expected a message")
+			}
+		})
+	}
+
+	// Login failures tell apart their causes.
+	body, _ := json.Marshal(LoginRequest{Email: tenant.Secretary.Email, Password: "wrong"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	if response := errorResponse(t, w, http.StatusUnauthorized); response.Code != CodeInvalidCredentials {
+		t.Errorf("Login code = %q, want %q", response.Code, CodeInvalidCredentials)
+	}
+}
@@ -0,0 +1,342 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// emitWebhook enqueues a delivery of the event for the tenant's webhooks in
+// the transaction of the change, so it is only delivered if it commits.
+func (s *Server) emitWebhook(tx *gorm.DB, tenantID string, event edutrack.WebhookEvent, data any) error {
+	return edutrack.EmitWebhookEvent(tx, tenantID, event, data)
+}
+
+// WebhookResponse represents a webhook in API responses.
+type WebhookResponse struct {
+	ID          uint                    `json:"id"`
+	URL         string                  `json:"url"`
+	Events      []edutrack.WebhookEvent `json:"events"`
+	Active      bool                    `json:"active"`
+	Description string                  `json:"description"`
+	CreatedAt   time.Time               `json:"created_at"`
+
+	// Secret is only included when the webhook is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+// newWebhookResponse builds the response for a webhook without its secret.
+func newWebhookResponse(webhook *edutrack.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:          webhook.ID,
+		URL:         webhook.URL,
+		Events:      webhook.EventList(),
+		Active:      webhook.Active,
+		Description: webhook.Description,
+		CreatedAt:   webhook.CreatedAt,
+	}
+}
+
+// handleListWebhooks handles GET /webhooks.
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	var webhooks []edutrack.Webhook
+	if err := s.tenantDB(r).Find(&webhooks).Error; err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	response := make([]WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		response = append(response, newWebhookResponse(&webhooks[i]))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// handleGetWebhook handles GET /webhooks/{id}.
+func (s *Server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	webhook, ok := s.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	setETag(w, webhook.Model)
+	sendJSON(w, http.StatusOK, newWebhookResponse(webhook))
+}
+
+// CreateWebhookRequest represents the request body for registering a webhook.
+type CreateWebhookRequest struct {
+	URL         string                  `json:"url" validate:"required,url"`
+	Events      []edutrack.WebhookEvent `json:"events" validate:"required"`
+	Description string                  `json:"description"`
+}
+
+// handleCreateWebhook handles POST /webhooks.
+// The response is the only time the signing secret is returned.
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+	if !areValidWebhookEvents(req.Events) {
+		sendFieldError(w, r, "events", edutrack.FieldInvalidChoice, "field.events.invalid")
+		return
+	}
+
+	secret, err := edutrack.GenerateWebhookSecret()
+	if err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	webhook := &edutrack.Webhook{
+		URL:         req.URL,
+		Secret:      secret,
+		Active:      true,
+		Description: req.Description,
+		TenantID:    account.TenantID,
+	}
+	webhook.SetEvents(req.Events)
+
+	if err := s.tenantDB(r).Create(webhook).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
+		return
+	}
+
+	response := newWebhookResponse(webhook)
+	response.Secret = webhook.Secret
+
+	setETag(w, webhook.Model)
+	sendJSON(w, http.StatusCreated, response)
+}
+
+// UpdateWebhookRequest represents the request body for updating a webhook.
+type UpdateWebhookRequest struct {
+	URL         *string                 `json:"url" validate:"required,url"`
+	Events      []edutrack.WebhookEvent `json:"events"`
+	Active      *bool                   `json:"active"`
+	Description *string                 `json:"description"`
+}
+
+// handleUpdateWebhook handles PUT and PATCH /webhooks/{id}.
+func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	webhook, ok := s.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := edutrack.CheckIfMatch(r.Context(), webhook.Model); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := decodeUpdate(r, &req); err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+	if err := validate(&req); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		if !areValidWebhookEvents(req.Events) {
+			sendFieldError(w, r, "events", edutrack.FieldInvalidChoice, "field.events.invalid")
+			return
+		}
+		webhook.SetEvents(req.Events)
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	if req.Description != nil {
+		webhook.Description = *req.Description
+	}
+
+	if err := s.tenantDB(r).Save(webhook).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
+		return
+	}
+
+	setETag(w, webhook.Model)
+	sendJSON(w, http.StatusOK, newWebhookResponse(webhook))
+}
+
+// handleDeleteWebhook handles DELETE /webhooks/{id}.
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	webhook, ok := s.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := edutrack.CheckIfMatch(r.Context(), webhook.Model); err != nil {
+		s.sendAppError(w, r, err)
+		return
+	}
+
+	s.deleteRecord(w, r, webhook)
+}
+
+// handleListWebhookDeliveries handles GET /webhooks/{id}/deliveries.
+// It returns the delivery log of a webhook, newest first.
+func (s *Server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	webhook, ok := s.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	query := s.tenantDB(r).Where("webhook_id = ?", webhook.ID)
+
+	// Optional filters.
+	if status := r.URL.Query().Get("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if event := r.URL.Query().Get("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	var deliveries []edutrack.WebhookDelivery
+	if err := query.Order("id DESC").Limit(100).Find(&deliveries).Error; err != nil {
+		sendError(w, r, http.StatusInternalServerError, ErrInternalServer)
+		return
+	}
+
+	response := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		response = append(response, newWebhookDeliveryResponse(&deliveries[i]))
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// handleReplayWebhookDelivery handles POST /webhooks/{id}/deliveries/{delivery_id}/replay.
+// It enqueues a new delivery with the same payload, keeping the original in the log.
+func (s *Server) handleReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	account := edutrack.AccountFromContext(r.Context())
+	if account == nil {
+		sendError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+
+	webhook, ok := s.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseUint(r.PathValue("delivery_id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return
+	}
+
+	var original edutrack.WebhookDelivery
+	if err := s.tenantDB(r).Where("webhook_id = ?", webhook.ID).First(&original, deliveryID).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	replay := &edutrack.WebhookDelivery{
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        edutrack.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+		WebhookID:     webhook.ID,
+		TenantID:      webhook.TenantID,
+	}
+
+	if err := s.tenantDB(r).Create(replay).Error; err != nil {
+		s.sendAppError(w, r, edutrack.TranslateDBError(err))
+		return
+	}
+
+	sendJSON(w, http.StatusAccepted, newWebhookDeliveryResponse(replay))
+}
+
+// findWebhook loads the webhook in the {id} path value from the tenant of the
+// request. It writes the error response and returns false when the webhook
+// cannot be used.
+func (s *Server) findWebhook(w http.ResponseWriter, r *http.Request) (*edutrack.Webhook, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		sendError(w, r, http.StatusBadRequest, ErrBadRequest)
+		return nil, false
+	}
+
+	var webhook edutrack.Webhook
+	if err := s.tenantDB(r).First(&webhook, id).Error; err != nil {
+		sendError(w, r, http.StatusNotFound, ErrNotFound)
+		return nil, false
+	}
+
+	return &webhook, true
+}
+
+// isValidWebhookURL checks the URL is an absolute HTTP(S) URL.
+func isValidWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// areValidWebhookEvents checks there is at least one event and all are known.
+func areValidWebhookEvents(events []edutrack.WebhookEvent) bool {
+	if len(events) == 0 {
+		return false
+	}
+	for _, e := range events {
+		if !edutrack.IsValidWebhookEvent(e) {
+			return false
+		}
+	}
+	return true
+}
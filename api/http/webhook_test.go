@@ -0,0 +1,401 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// setupWebhookTestDB creates an in-memory SQLite database for testing.
+func setupWebhookTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// createWebhookTestTenant creates a test tenant with a valid license.
+func createWebhookTestTenant(t *testing.T, db *gorm.DB) *edutrack.Tenant {
+	tenant, err := edutrack.NewTenant("Test Institution", edutrack.LicenseTypeTrial, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create test tenant: %v", err)
+	}
+
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatalf("Failed to save test tenant: %v", err)
+	}
+
+	return tenant
+}
+
+// createWebhookTestAccount creates a test account for a tenant.
+func createWebhookTestAccount(t *testing.T, db *gorm.DB, tenantID, email string, role edutrack.Role) *edutrack.Account {
+	account := &edutrack.Account{
+		Name:     "Test User",
+		Email:    email,
+		Role:     role,
+		Active:   true,
+		TenantID: tenantID,
+	}
+
+	if err := db.Create(account).Error; err != nil {
+		t.Fatalf("Failed to save test account: %v", err)
+	}
+
+	return account
+}
+
+// createTestWebhook registers a webhook for a tenant subscribed to the given events.
+func createTestWebhook(t *testing.T, db *gorm.DB, tenantID string, events ...edutrack.WebhookEvent) *edutrack.Webhook {
+	webhook := &edutrack.Webhook{
+		URL:      "https://erp.example.com/hook",
+		Secret:   "whsec_test",
+		Active:   true,
+		TenantID: tenantID,
+	}
+	webhook.SetEvents(events)
+
+	if err := db.Create(webhook).Error; err != nil {
+		t.Fatalf("Failed to create test webhook: %v", err)
+	}
+
+	return webhook
+}
+
+// makeWebhookAuthenticatedRequest creates an HTTP request with the account in context.
+func makeWebhookAuthenticatedRequest(t *testing.T, method, path string, body []byte, account *edutrack.Account) *http.Request {
+	var req *http.Request
+	if body != nil {
+		req = httptest.NewRequest(method, path, bytes.NewReader(body))
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	ctx := edutrack.NewContextWithAccount(req.Context(), account)
+	return req.WithContext(ctx)
+}
+
+func TestHandleCreateWebhook_Success(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	tenant := createWebhookTestTenant(t, db)
+	secretary := createWebhookTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(CreateWebhookRequest{
+		URL:    "https://erp.example.com/hook",
+		Events: []edutrack.WebhookEvent{edutrack.WebhookGradeCreated, edutrack.WebhookGradeUpdated},
+	})
+	req := makeWebhookAuthenticatedRequest(t, http.MethodPost, "/webhooks", body, secretary)
+	w := httptest.NewRecorder()
+
+	server.handleCreateWebhook(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("handleCreateWebhook() status = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	var response WebhookResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if !strings.HasPrefix(response.Secret, "whsec_") {
+		t.Errorf("handleCreateWebhook() secret = %q, want a generated secret", response.Secret)
+	}
+	if len(response.Events) != 2 {
+		t.Errorf("handleCreateWebhook() events = %v, want 2 events", response.Events)
+	}
+}
+
+func TestHandleCreateWebhook_InvalidInput(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	tenant := createWebhookTestTenant(t, db)
+	secretary := createWebhookTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	tests := []struct {
+		name string
+		req  CreateWebhookRequest
+	}{
+		{"missing url", CreateWebhookRequest{Events: []edutrack.WebhookEvent{edutrack.WebhookGradeCreated}}},
+		{"relative url", CreateWebhookRequest{URL: "/hook", Events: []edutrack.WebhookEvent{edutrack.WebhookGradeCreated}}},
+		{"no events", CreateWebhookRequest{URL: "https://erp.example.com"}},
+		{"unknown event", CreateWebhookRequest{URL: "https://erp.example.com", Events: []edutrack.WebhookEvent{"grade.deleted"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.req)
+			req := makeWebhookAuthenticatedRequest(t, http.MethodPost, "/webhooks", body, secretary)
+			w := httptest.NewRecorder()
+
+			server.handleCreateWebhook(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("handleCreateWebhook() status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestHandleListWebhooks_HidesSecret(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	tenant := createWebhookTestTenant(t, db)
+	secretary := createWebhookTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	createTestWebhook(t, db, tenant.ID, edutrack.WebhookGradeCreated)
+
+	other := createWebhookTestTenant(t, db)
+	createTestWebhook(t, db, other.ID, edutrack.WebhookGradeCreated)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeWebhookAuthenticatedRequest(t, http.MethodGet, "/webhooks", nil, secretary)
+	w := httptest.NewRecorder()
+
+	server.handleListWebhooks(w, req)
+
+	if strings.Contains(w.Body.String(), "whsec_test") {
+		t.Error("handleListWebhooks() leaked the webhook secret")
+	}
+
+	var webhooks []WebhookResponse
+	if err := json.NewDecoder(w.Body).Decode(&webhooks); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(webhooks) != 1 {
+		t.Errorf("handleListWebhooks() returned %d webhooks, want 1", len(webhooks))
+	}
+}
+
+func TestHandleGetWebhook_CrossTenant(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	tenant := createWebhookTestTenant(t, db)
+	other := createWebhookTestTenant(t, db)
+	secretary := createWebhookTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	webhook := createTestWebhook(t, db, other.ID, edutrack.WebhookGradeCreated)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeWebhookAuthenticatedRequest(t, http.MethodGet, fmt.Sprintf("/webhooks/%d", webhook.ID), nil, secretary)
+	req.SetPathValue("id", fmt.Sprintf("%d", webhook.ID))
+	w := httptest.NewRecorder()
+
+	server.handleGetWebhook(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handleGetWebhook() status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestWebhookRoutes_SecretaryOnly(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	tenant := createWebhookTestTenant(t, db)
+	teacher := createWebhookTestAccount(t, db, tenant.ID, "teacher@test.com", edutrack.RoleTeacher)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	token, _ := server.generateToken(teacher)
+	req := httptest.NewRequest(http.MethodGet, "/webhooks", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("GET /webhooks status = %d, want %d for teacher", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleEnrollStudent_EmitsEnrollmentChanged(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	tenant := createWebhookTestTenant(t, db)
+	secretary := createWebhookTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	webhook := createTestWebhook(t, db, tenant.ID, edutrack.WebhookEnrollmentChanged)
+
+	student := &edutrack.Student{StudentID: "STU-1", TenantID: tenant.ID}
+	db.Create(student)
+	subject := &edutrack.Subject{Name: "Matemáticas I", Code: "MAT-1", TenantID: tenant.ID}
+	db.Create(subject)
+	section := &edutrack.Section{Name: "A", SubjectID: subject.ID, TenantID: tenant.ID}
+	db.Create(section)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(EnrollStudentRequest{StudentID: student.ID})
+	req := makeWebhookAuthenticatedRequest(t, http.MethodPost, fmt.Sprintf("/sections/%d/students", section.ID), body, secretary)
+	req.SetPathValue("id", fmt.Sprintf("%d", section.ID))
+	w := httptest.NewRecorder()
+
+	server.handleEnrollStudent(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("handleEnrollStudent() status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	var delivery edutrack.WebhookDelivery
+	if err := db.Where("webhook_id = ?", webhook.ID).First(&delivery).Error; err != nil {
+		t.Fatalf("no delivery was enqueued: %v", err)
+	}
+
+	var envelope struct {
+		Event edutrack.WebhookEvent     `json:"event"`
+		Data  edutrack.EnrollmentChange `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(delivery.Payload), &envelope); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+
+	if envelope.Event != edutrack.WebhookEnrollmentChanged || envelope.Data.Action != "enrolled" ||
+		envelope.Data.StudentID != student.ID || envelope.Data.SectionID != section.ID {
+		t.Errorf("payload = %+v", envelope)
+	}
+}
+
+func TestHandleEnrollStudent_DeliveryInTransaction(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	tenant := createWebhookTestTenant(t, db)
+	secretary := createWebhookTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	createTestWebhook(t, db, tenant.ID, edutrack.WebhookEnrollmentChanged)
+
+	student := &edutrack.Student{StudentID: "STU-1", TenantID: tenant.ID}
+	db.Create(student)
+	subject := &edutrack.Subject{Name: "Matemáticas I", Code: "MAT-1", TenantID: tenant.ID}
+	db.Create(subject)
+	section := &edutrack.Section{Name: "A", SubjectID: subject.ID, TenantID: tenant.ID}
+	db.Create(section)
+
+	// Recording the delivery fails, so the enrollment must not be saved.
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_deliveries", func(tx *gorm.DB) {
+		if tx.Statement.Table == "webhook_deliveries" {
+			_ = tx.AddError(fmt.Errorf("outbox unavailable"))
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to register callback: %v", err)
+	}
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	body, _ := json.Marshal(EnrollStudentRequest{StudentID: student.ID})
+	req := makeWebhookAuthenticatedRequest(t, http.MethodPost, fmt.Sprintf("/sections/%d/students", section.ID), body, secretary)
+	req.SetPathValue("id", fmt.Sprintf("%d", section.ID))
+	w := httptest.NewRecorder()
+
+	server.handleEnrollStudent(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("handleEnrollStudent() status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+
+	var enrollments int64
+	db.Model(&edutrack.Enrollment{}).Where("section_id = ?", section.ID).Count(&enrollments)
+	if enrollments != 0 {
+		t.Errorf("Enrollments after a failed delivery = %d, want 0", enrollments)
+	}
+}
+
+func TestGradeWebhooks_SkipDrafts(t *testing.T) {
+	server, db, tenant, _ := setupIsolationTest(t)
+	webhook := createTestWebhook(t, db, tenant.Tenant.ID, edutrack.WebhookGradeCreated, edutrack.WebhookGradeUpdated)
+
+	events := func() []edutrack.WebhookEvent {
+		var deliveries []edutrack.WebhookDelivery
+		db.Where("webhook_id = ?", webhook.ID).Order("id").Find(&deliveries)
+		events := make([]edutrack.WebhookEvent, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			events = append(events, delivery.Event)
+		}
+		return events
+	}
+
+	// Drafts and their changes are not emitted.
+	w := isolationRequest(t, server, tenant, http.MethodPost, "/grades", map[string]any{
+		"value": 7, "student_id": tenant.Student.ID, "topic_id": tenant.Topic.ID,
+	})
+	var grade GradeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &grade); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("POST /grades status = %d: %s", w.Code, w.Body.String())
+	}
+	path := fmt.Sprintf("/grades/%d", grade.ID)
+	if w := isolationRequest(t, server, tenant, http.MethodPatch, path, map[string]any{"value": 8}); w.Code != http.StatusOK {
+		t.Fatalf("PATCH %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if got := events(); len(got) != 0 {
+		t.Fatalf("Events of a draft = %v, want none", got)
+	}
+
+	// Publishing posts the grade, and later changes update it.
+	if w := isolationRequest(t, server, tenant, http.MethodPatch, path, map[string]any{"status": "published"}); w.Code != http.StatusOK {
+		t.Fatalf("PATCH %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+	if w := isolationRequest(t, server, tenant, http.MethodPatch, path, map[string]any{"value": 9}); w.Code != http.StatusOK {
+		t.Fatalf("PATCH %s status = %d: %s", path, w.Code, w.Body.String())
+	}
+	want := []edutrack.WebhookEvent{edutrack.WebhookGradeCreated, edutrack.WebhookGradeUpdated}
+	if got := events(); !slices.Equal(got, want) {
+		t.Errorf("Events = %v, want %v", got, want)
+	}
+}
+
+func TestHandleReplayWebhookDelivery(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	tenant := createWebhookTestTenant(t, db)
+	secretary := createWebhookTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+	webhook := createTestWebhook(t, db, tenant.ID, edutrack.WebhookGradeCreated)
+
+	original := &edutrack.WebhookDelivery{
+		Event:     edutrack.WebhookGradeCreated,
+		Payload:   `{"event":"grade.created"}`,
+		Status:    edutrack.WebhookDeliveryFailed,
+		Attempts:  5,
+		WebhookID: webhook.ID,
+		TenantID:  tenant.ID,
+	}
+	db.Create(original)
+
+	server := NewServer(":8080", db, []byte("test-secret"))
+
+	req := makeWebhookAuthenticatedRequest(t, http.MethodPost, "/webhooks/x/deliveries/y/replay", nil, secretary)
+	req.SetPathValue("id", fmt.Sprintf("%d", webhook.ID))
+	req.SetPathValue("delivery_id", fmt.Sprintf("%d", original.ID))
+	w := httptest.NewRecorder()
+
+	server.handleReplayWebhookDelivery(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("handleReplayWebhookDelivery() status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+
+	var deliveries []edutrack.WebhookDelivery
+	db.Where("webhook_id = ?", webhook.ID).Order("id").Find(&deliveries)
+
+	if len(deliveries) != 2 {
+		t.Fatalf("webhook has %d deliveries, want 2 (original and replay)", len(deliveries))
+	}
+	if deliveries[1].Status != edutrack.WebhookDeliveryPending || deliveries[1].Payload != original.Payload {
+		t.Errorf("replay = %+v, want a pending copy of the original payload", deliveries[1])
+	}
+}
@@ -0,0 +1,122 @@
+package i18n
+
+// english holds the messages in English.
+var english = catalog{
+	// Error codes.
+	"error.unauthorized":           "Unauthorized.",
+	"error.forbidden":              "Access denied.",
+	"error.not_found":              "Resource not found.",
+	"error.method_not_allowed":     "Method not allowed.",
+	"error.bad_request":            "Invalid request.",
+	"error.invalid":                "One or more fields are invalid.",
+	"error.internal":               "Internal server error.",
+	"error.conflict":               "The resource already exists.",
+	"error.unprocessable":          "The request could not be processed.",
+	"error.invalid_credentials":    "Invalid credentials.",
+	"error.account_inactive":       "The account is disabled.",
+	"error.invalid_license":        "Invalid license key.",
+	"error.license_expired":        "The license of the institution has expired.",
+	"error.license_inactive":       "The license is disabled.",
+	"error.insufficient_scope":     "The API key is not allowed to access this resource.",
+	"error.precondition_failed":    "The resource was modified by another request.",
+	"error.precondition_required":  "The If-Match header with the ETag of the resource is required.",
+	"error.unsupported_media_type": "Unsupported content type; use application/merge-patch+json.",
+
+	// Field error codes.
+	"field.required":              "Is required.",
+	"field.too_small":             "Must be greater than or equal to %s.",
+	"field.too_small.length":      "Must have at least %s characters.",
+	"field.too_small.items":       "Must have at least %s elements.",
+	"field.too_large":             "Must be less than or equal to %s.",
+	"field.too_large.length":      "Must have at most %s characters.",
+	"field.too_large.items":       "Must have at most %s elements.",
+	"field.invalid_choice":        "Must be one of: %s.",
+	"field.invalid_format.email":  "Must be a valid email.",
+	"field.invalid_format.date":   "Must be a date in the YYYY-MM-DD format.",
+	"field.invalid_format.url":    "Must be an http or https URL.",
+	"field.not_found":             "The record referenced by %s does not exist.",
+	"field.taken":                 "Is already in use.",
+	"field.include.invalid":       "Cannot include %q; the allowed values are: %s.",
+	"field.trash.type.invalid":    "Unknown record type: %s.",
+	"field.scopes.invalid":        "Invalid permission: %s.",
+	"field.events.invalid":        "At least one valid event is required.",
+	"field.event.invalid":         "Invalid notification event.",
+	"field.webhook_url.invalid":   "Invalid webhook URL.",
+	"field.password.student_only": "Only the password and the language can be updated.",
+	"field.file.too_large":        "The file must be at most %s.",
+
+	// Records.
+	"account.not_found":          "The specified account does not exist.",
+	"account.delete_self":        "You cannot delete your own account.",
+	"student.not_found":          "The specified student does not exist.",
+	"student.some_not_found":     "One or more students do not exist.",
+	"student.already_linked":     "The student is already linked to the guardian.",
+	"student.student_id.missing": "The student ID is required.",
+	"student.name.missing":       "The name is required.",
+	"student.email.missing":      "The email is required.",
+	"student.password.missing":   "The password is required.",
+	"student.semester.too_small": "The semester must be a positive number.",
+	"subject.not_found":          "The specified subject does not exist.",
+	"teacher.not_found":          "The specified teacher does not exist.",
+	"topic.not_found":            "The specified topic does not exist.",
+	"grade.student_id.missing":   "The student is required.",
+	"grade.topic_id.missing":     "The topic is required.",
+	"grade.locked":               "The grade is locked; only a secretary can change it, giving a reason.",
+	"grade.reason.missing":       "Give the reason for changing a locked grade.",
+	"grade.status.unpublish":     "A published grade cannot go back to draft.",
+	"record.modified":            "The record was modified by another request; fetch it again.",
+	"record.referenced":          "The record cannot be deleted: it is still referenced by %d record(s) of %s.",
+	"record.in_use":              "The record cannot be deleted: it is still referenced by other records.",
+	"record.reference_missing":   "A referenced record does not exist.",
+	"trash.purged_reference":     "The record referenced by %s was permanently deleted.",
+	"trash.referenced":           "The record is still referenced by records of %s.",
+	"trash.referenced_deleted":   "The record is still referenced by deleted records of %s.",
+	"settings.timezone.invalid":  "Must be an IANA time zone, such as America/Mexico_City.",
+	"justification.too_long":     "A justification can cover at most %s days.",
+	"justification.reviewed":     "The justification was already reviewed.",
+	"appeal.disabled":            "The institution does not accept grade appeals.",
+	"appeal.window_closed":       "The deadline to appeal the grade was %s.",
+	"appeal.exists":              "The grade was already appealed.",
+	"appeal.resolved":            "The appeal was already resolved.",
+	"appeal.not_escalable":       "Only open appeals or those rejected by the teacher can be escalated.",
+	"exam.not_enrolled":          "The student is not enrolled in the subject.",
+	"exam.pending":               "The student has an exam of the subject awaiting its grade.",
+	"exam.passed":                "The student already passed the subject.",
+	"exam.ordinary_taken":        "The ordinary exam must be the first one of the subject.",
+	"exam.special_taken":         "Extraordinary exams cannot follow a special one.",
+	"exam.extraordinary_pending": "A special exam requires using up the extraordinary exams.",
+	"exam.limit":                 "The limit of %d exam(s) of this kind was reached.",
+	"exam.superseded":            "Only the last exam of the student in the subject can be changed.",
+	"section.enrolled":           "The student is already enrolled or waitlisted in a section of the subject.",
+	"section.not_enrolled":       "The student is neither enrolled nor waitlisted in the section.",
+	"section.capacity":           "The capacity cannot be lower than the %d enrolled students.",
+
+	// Licenses.
+	"license.tenant_not_found": "No institution is associated with this license.",
+	"license.valid":            "Valid license. Sign in with your account.",
+	"license.valid_first_use":  "Valid license. A secretary account must be created.",
+
+	// Notifications.
+	"notification.grade.posted.subject":           "New grade posted",
+	"notification.grade.posted.body":              "A grade of %.2f was posted in %s (%s).",
+	"notification.attendance.absent.subject":      "Absence recorded",
+	"notification.attendance.absent.body":         "An absence was recorded in %s on %s.",
+	"notification.justification.approved.subject": "Justification approved",
+	"notification.justification.approved.body":    "The absence justification from %s to %s was approved; %d record(s) were excused.",
+	"notification.justification.rejected.subject": "Justification rejected",
+	"notification.justification.rejected.body":    "The absence justification from %s to %s was rejected. %s",
+	"notification.appeal.filed.subject":           "New grade appeal",
+	"notification.appeal.filed.body":              "%s appealed the grade of %.2f in %s (%s).",
+	"notification.appeal.accepted.subject":        "Appeal accepted",
+	"notification.appeal.accepted.body":           "Your appeal of the grade in %s (%s) was accepted; the grade is %.2f. %s",
+	"notification.appeal.rejected.subject":        "Appeal rejected",
+	"notification.appeal.rejected.body":           "Your appeal of the grade in %s (%s) was rejected. %s",
+	"notification.exam.scheduled.subject":         "Exam scheduled",
+	"notification.exam.scheduled.body":            "An exam of %s was scheduled for %s.",
+	"notification.exam.graded.subject":            "Exam grade recorded",
+	"notification.exam.graded.body":               "The grade of the %s exam of %s was recorded: %.2f.",
+	"notification.enrollment.promoted.subject":    "Place assigned in the section",
+	"notification.enrollment.promoted.body":       "A place opened up and you are now enrolled in section %s of %s.",
+	"notification.license.expiring.subject":       "The license is about to expire",
+	"notification.license.expiring.body":          "The license of %s expires on %s (%d days left). Contact support to renew it.",
+}
@@ -0,0 +1,123 @@
+package i18n
+
+// spanish holds the messages in Spanish. Every message must be here, as it
+// is the Default locale.
+var spanish = catalog{
+	// Error codes.
+	"error.unauthorized":           "No autorizado.",
+	"error.forbidden":              "Acceso denegado.",
+	"error.not_found":              "Recurso no encontrado.",
+	"error.method_not_allowed":     "Método no permitido.",
+	"error.bad_request":            "Solicitud inválida.",
+	"error.invalid":                "Uno o más campos son inválidos.",
+	"error.internal":               "Error interno del servidor.",
+	"error.conflict":               "El recurso ya existe.",
+	"error.unprocessable":          "No se pudo procesar la solicitud.",
+	"error.invalid_credentials":    "Credenciales inválidas.",
+	"error.account_inactive":       "La cuenta está desactivada.",
+	"error.invalid_license":        "Llave de licencia inválida.",
+	"error.license_expired":        "La licencia de la institución ha expirado.",
+	"error.license_inactive":       "La licencia está desactivada.",
+	"error.insufficient_scope":     "La llave de API no tiene permiso para este recurso.",
+	"error.precondition_failed":    "El recurso fue modificado por otra solicitud.",
+	"error.precondition_required":  "Se requiere el encabezado If-Match con la ETag del recurso.",
+	"error.unsupported_media_type": "Tipo de contenido no soportado; use application/merge-patch+json.",
+
+	// Field error codes.
+	"field.required":              "Es requerido.",
+	"field.too_small":             "Debe ser mayor o igual a %s.",
+	"field.too_small.length":      "Debe tener al menos %s caracteres.",
+	"field.too_small.items":       "Debe tener al menos %s elementos.",
+	"field.too_large":             "Debe ser menor o igual a %s.",
+	"field.too_large.length":      "Debe tener como máximo %s caracteres.",
+	"field.too_large.items":       "Debe tener como máximo %s elementos.",
+	"field.invalid_choice":        "Debe ser uno de: %s.",
+	"field.invalid_format.email":  "Debe ser un email válido.",
+	"field.invalid_format.date":   "Debe ser una fecha con formato YYYY-MM-DD.",
+	"field.invalid_format.url":    "Debe ser una URL http o https.",
+	"field.not_found":             "El registro indicado en %s no existe.",
+	"field.taken":                 "Ya está en uso.",
+	"field.include.invalid":       "No se puede incluir %q; los valores permitidos son: %s.",
+	"field.trash.type.invalid":    "Tipo de registro desconocido: %s.",
+	"field.scopes.invalid":        "Permiso inválido: %s.",
+	"field.events.invalid":        "Se requiere al menos un evento válido.",
+	"field.event.invalid":         "Evento de notificación inválido.",
+	"field.webhook_url.invalid":   "URL de webhook inválida.",
+	"field.password.student_only": "Solo se puede actualizar la contraseña y el idioma.",
+	"field.file.too_large":        "El archivo debe pesar como máximo %s.",
+
+	// Records.
+	"account.not_found":          "La cuenta especificada no existe.",
+	"account.delete_self":        "No puedes eliminar tu propia cuenta.",
+	"student.not_found":          "El estudiante especificado no existe.",
+	"student.some_not_found":     "Uno o más estudiantes no existen.",
+	"student.already_linked":     "El estudiante ya está vinculado al tutor.",
+	"student.student_id.missing": "El ID de estudiante es requerido.",
+	"student.name.missing":       "El nombre es requerido.",
+	"student.email.missing":      "El email es requerido.",
+	"student.password.missing":   "La contraseña es requerida.",
+	"student.semester.too_small": "El semestre debe ser un número positivo.",
+	"subject.not_found":          "La materia especificada no existe.",
+	"teacher.not_found":          "El docente especificado no existe.",
+	"topic.not_found":            "El tema especificado no existe.",
+	"grade.student_id.missing":   "El estudiante es requerido.",
+	"grade.topic_id.missing":     "El tema es requerido.",
+	"grade.locked":               "La calificación está bloqueada; solo un secretario puede modificarla indicando el motivo.",
+	"grade.reason.missing":       "Indique el motivo de la modificación de una calificación bloqueada.",
+	"grade.status.unpublish":     "Una calificación publicada no puede volver a borrador.",
+	"record.modified":            "El registro fue modificado por otra solicitud; vuelva a consultarlo.",
+	"record.referenced":          "No se puede eliminar el registro: aún es referenciado por %d registro(s) de %s.",
+	"record.in_use":              "No se puede eliminar el registro: aún es referenciado por otros registros.",
+	"record.reference_missing":   "Un registro referenciado no existe.",
+	"trash.purged_reference":     "El registro indicado en %s fue eliminado permanentemente.",
+	"trash.referenced":           "El registro aún es referenciado por registros de %s.",
+	"trash.referenced_deleted":   "El registro aún es referenciado por registros eliminados de %s.",
+	"settings.timezone.invalid":  "Debe ser una zona horaria IANA, como America/Mexico_City.",
+	"justification.too_long":     "Una justificación puede cubrir como máximo %s días.",
+	"justification.reviewed":     "La justificación ya fue revisada.",
+	"appeal.disabled":            "La institución no admite apelaciones de calificaciones.",
+	"appeal.window_closed":       "El plazo para apelar la calificación venció el %s.",
+	"appeal.exists":              "La calificación ya fue apelada.",
+	"appeal.resolved":            "La apelación ya fue resuelta.",
+	"appeal.not_escalable":       "Solo se pueden turnar apelaciones abiertas o rechazadas por el docente.",
+	"exam.not_enrolled":          "El estudiante no está inscrito en la materia.",
+	"exam.pending":               "El estudiante tiene un examen de la materia sin calificar.",
+	"exam.passed":                "El estudiante ya aprobó la materia.",
+	"exam.ordinary_taken":        "El examen ordinario debe ser el primero de la materia.",
+	"exam.special_taken":         "No se pueden programar exámenes extraordinarios después de un especial.",
+	"exam.extraordinary_pending": "El examen especial requiere agotar los exámenes extraordinarios.",
+	"exam.limit":                 "Se alcanzó el límite de %d examen(es) de este tipo.",
+	"exam.superseded":            "Solo se puede modificar el último examen del estudiante en la materia.",
+	"section.enrolled":           "El estudiante ya está inscrito o en lista de espera en un grupo de la materia.",
+	"section.not_enrolled":       "El estudiante no está inscrito ni en lista de espera en el grupo.",
+	"section.capacity":           "El cupo no puede ser menor a los %d estudiantes inscritos.",
+
+	// Licenses.
+	"license.tenant_not_found": "No se encontró la institución asociada a esta licencia.",
+	"license.valid":            "Licencia válida. Inicie sesión con su cuenta.",
+	"license.valid_first_use":  "Licencia válida. Es necesario crear una cuenta de secretario.",
+
+	// Notifications.
+	"notification.grade.posted.subject":           "Nueva calificación publicada",
+	"notification.grade.posted.body":              "Se publicó una calificación de %.2f en %s (%s).",
+	"notification.attendance.absent.subject":      "Inasistencia registrada",
+	"notification.attendance.absent.body":         "Se registró una inasistencia en %s el %s.",
+	"notification.justification.approved.subject": "Justificación aprobada",
+	"notification.justification.approved.body":    "Se aprobó la justificación de inasistencias del %s al %s; se justificaron %d registro(s).",
+	"notification.justification.rejected.subject": "Justificación rechazada",
+	"notification.justification.rejected.body":    "Se rechazó la justificación de inasistencias del %s al %s. %s",
+	"notification.appeal.filed.subject":           "Nueva apelación de calificación",
+	"notification.appeal.filed.body":              "%s apeló la calificación de %.2f en %s (%s).",
+	"notification.appeal.accepted.subject":        "Apelación aceptada",
+	"notification.appeal.accepted.body":           "Se aceptó tu apelación de la calificación de %s (%s); la calificación es %.2f. %s",
+	"notification.appeal.rejected.subject":        "Apelación rechazada",
+	"notification.appeal.rejected.body":           "Se rechazó tu apelación de la calificación de %s (%s). %s",
+	"notification.exam.scheduled.subject":         "Examen programado",
+	"notification.exam.scheduled.body":            "Se programó un examen de %s para el %s.",
+	"notification.exam.graded.subject":            "Calificación de examen registrada",
+	"notification.exam.graded.body":               "Se registró la calificación del examen de %s del %s: %.2f.",
+	"notification.enrollment.promoted.subject":    "Lugar asignado en el grupo",
+	"notification.enrollment.promoted.body":       "Se liberó un lugar y quedaste inscrito en el grupo %s de %s.",
+	"notification.license.expiring.subject":       "La licencia está por vencer",
+	"notification.license.expiring.body":          "La licencia de %s vence el %s (%d días restantes). Contacte a soporte para renovarla.",
+}
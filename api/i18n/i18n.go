@@ -0,0 +1,99 @@
+// Package i18n holds the catalog of the messages shown to end users, in
+// every supported locale.
+//
+// Messages are identified by keys (e.g., "error.not_found"): the messages
+// of the error codes are keyed by "error.<code>", those of the field error
+// codes by "field.<code>" and the notifications by
+// "notification.<event>.subject" and "notification.<event>.body". Messages
+// may hold fmt verbs, filled with the arguments given when rendering them.
+package i18n
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Locale represents a language messages are rendered in, as the primary
+// subtag of its BCP 47 language tag.
+type Locale string
+
+const (
+	// Spanish is the language of the institutions and the default locale.
+	Spanish Locale = "es"
+
+	// English is the language of the training programs given in English.
+	English Locale = "en"
+)
+
+// Default is the locale used when no supported locale was requested, and
+// for the messages missing in other locales.
+const Default = Spanish
+
+// catalog maps the keys of the messages to their text in a locale.
+type catalog map[string]string
+
+// catalogs holds the messages of every supported locale.
+var catalogs = map[Locale]catalog{
+	Spanish: spanish,
+	English: english,
+}
+
+// Locales returns all the supported locales.
+func Locales() []Locale {
+	return []Locale{Spanish, English}
+}
+
+// Parse returns the supported locale of a BCP 47 language tag, ignoring
+// its region and case (e.g., "en-US" is English). It returns false if the
+// language is not supported.
+func Parse(tag string) (Locale, bool) {
+	primary, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	locale := Locale(strings.ToLower(primary))
+	return locale, slices.Contains(Locales(), locale)
+}
+
+// Negotiate returns the supported locale preferred by an Accept-Language
+// header (e.g., "en-US,en;q=0.9,es;q=0.8"), or fallback if it accepts none.
+func Negotiate(acceptLanguage string, fallback Locale) Locale {
+	best, bestQuality := fallback, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		locale, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		if quality > bestQuality {
+			best, bestQuality = locale, quality
+		}
+	}
+	return best
+}
+
+// Message renders the message with the given key in a locale, falling back
+// to the Default locale when it has no translation. Unknown keys are
+// rendered as the message itself, so plain text may be given as a key.
+func Message(locale Locale, key string, args ...any) string {
+	format, ok := catalogs[locale][key]
+	if !ok {
+		format, ok = catalogs[Default][key]
+	}
+	if !ok {
+		format = key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
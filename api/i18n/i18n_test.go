@@ -0,0 +1,99 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want Locale
+		ok   bool
+	}{
+		{"es", Spanish, true},
+		{"es-MX", Spanish, true},
+		{"EN-us", English, true},
+		{" en ", English, true},
+		{"fr", "fr", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := Parse(tt.tag)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Parse(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Locale
+	}{
+		{"", Default},
+		{"en", English},
+		{"en-US,en;q=0.9,es;q=0.8", English},
+		{"es-MX,en;q=0.5", Spanish},
+		{"fr-FR,fr;q=0.9,en;q=0.7", English},
+		{"es;q=0.3, en;q=0.8", English},
+		{"en;q=0", Default},
+		{"en;q=oops", Default},
+		{"fr, *;q=0.5", Default},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := Negotiate(tt.header, Default); got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+
+	if got := Negotiate("fr", English); got != English {
+		t.Errorf("Negotiate(%q) = %q, want the fallback %q", "fr", got, English)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if got := Message(English, "error.not_found"); got != "Resource not found." {
+		t.Errorf("Message(en) = %q", got)
+	}
+	if got := Message(Spanish, "field.too_small", "1"); got != "Debe ser mayor o igual a 1." {
+		t.Errorf("Message(es) = %q", got)
+	}
+	if got := Message("fr", "error.not_found"); got != "Recurso no encontrado." {
+		t.Errorf("Message() of an unsupported locale = %q, want the default", got)
+	}
+	if got := Message(English, "Sin %s.", "clave"); got != "Sin clave." {
+		t.Errorf("Message() of an unknown key = %q, want the key formatted", got)
+	}
+}
+
+// TestCatalogs checks every locale translates every message of the default
+// locale with the same verbs.
+func TestCatalogs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+	for _, locale := range Locales() {
+		for key, message := range catalogs[Default] {
+			translation, ok := catalogs[locale][key]
+			if !ok {
+				t.Errorf("%s: missing %q", locale, key)
+				continue
+			}
+			if want, got := verbs.FindAllString(message, -1), verbs.FindAllString(translation, -1); !slices.Equal(got, want) {
+				t.Errorf("%s: %q has verbs %v, want %v", locale, key, got, want)
+			}
+		}
+		for key := range catalogs[locale] {
+			if _, ok := catalogs[Default][key]; !ok {
+				t.Errorf("%s: %q is not in the default locale", locale, key)
+			}
+		}
+	}
+}
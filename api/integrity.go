@@ -0,0 +1,355 @@
+package edutrack
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DeleteRule is the integrity rule applied to the records referencing a
+// record when it is deleted. Rules are declared with the constraint tags of
+// the model associations, so the database foreign keys enforce them as well.
+type DeleteRule string
+
+const (
+	// DeleteRestrict prevents deleting a record while it is referenced.
+	DeleteRestrict DeleteRule = "restrict"
+
+	// DeleteCascade deletes the referencing records along with the record.
+	DeleteCascade DeleteRule = "cascade"
+
+	// DeleteNullify clears the references to the record.
+	DeleteNullify DeleteRule = "nullify"
+)
+
+// DeleteEffect represents the records of a type affected by a deletion
+// through one of their foreign keys.
+type DeleteEffect struct {
+	// Type of the records, e.g., "topics".
+	Type string `json:"type"`
+
+	// Foreign key referencing the deleted record, e.g., "subject_id".
+	Field string `json:"field"`
+
+	Rule  DeleteRule `json:"rule"`
+	Count int        `json:"count"`
+}
+
+// DeletePreview represents what deleting a record would do.
+type DeletePreview struct {
+	// Whether the record can be deleted: no record restricts it.
+	Allowed bool `json:"allowed"`
+
+	Effects []DeleteEffect `json:"effects"`
+}
+
+// PreviewDelete returns what DeleteRecord would do to a record, without
+// deleting anything.
+func PreviewDelete(db *gorm.DB, record any) (*DeletePreview, error) {
+	plan, err := planDelete(db, record)
+	if err != nil {
+		return nil, err
+	}
+	return &DeletePreview{Allowed: plan.restricted() == nil, Effects: plan.effects}, nil
+}
+
+// DeleteRecord deletes a record of the trash applying the integrity rules
+// of the records referencing it: it fails with ECONFLICT if a record
+// restricts it, deletes the cascading records with the same deletion time,
+// so restoring the record restores them too, and clears the nullified
+// references. Records without a trash (e.g., enrollments) are kept until
+// the record is purged.
+func DeleteRecord(db *gorm.DB, record any) error {
+	now := db.NowFunc()
+	db = db.Session(&gorm.Session{NowFunc: func() time.Time { return now }})
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		plan, err := planDelete(tx, record)
+		if err != nil {
+			return err
+		}
+		if effect := plan.restricted(); effect != nil {
+			return Errorf(ECONFLICT, "record.referenced", effect.Count, effect.Type)
+		}
+
+		for _, step := range plan.steps {
+			model := reflect.New(step.kind.ModelType).Interface()
+			switch step.rule {
+			case DeleteNullify:
+				err = tx.Model(model).Where(columnIn(step.column, step.ids)).Update(step.column, nil).Error
+			case DeleteCascade:
+				err = tx.Where(columnIn("id", step.ids)).Delete(model).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(record).Error
+	})
+}
+
+// deletePlan collects the effects of deleting a record of the trash.
+type deletePlan struct {
+	tx      *gorm.DB
+	kinds   []*schema.Schema
+	keys    []foreignKey
+	seen    map[string]bool
+	effects []DeleteEffect
+	steps   []deleteStep
+}
+
+// deleteStep is a change to the records referencing a deleted record.
+type deleteStep struct {
+	kind   *schema.Schema
+	column string
+	rule   DeleteRule
+	ids    []uint
+}
+
+// planDelete returns the plan of deleting a record of the trash.
+func planDelete(tx *gorm.DB, record any) (*deletePlan, error) {
+	kind, err := parseModel(tx, record)
+	if err != nil {
+		return nil, err
+	}
+	kinds, err := trashKinds(tx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := foreignKeys(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &deletePlan{tx: tx, kinds: kinds, keys: keys, seen: map[string]bool{}, effects: []DeleteEffect{}}
+	id := trashModel(record).ID
+	plan.seen[fmt.Sprintf("%s:%d", kind.Table, id)] = true
+	if err := plan.visit(kind, []uint{id}); err != nil {
+		return nil, err
+	}
+	plan.pruneNullified()
+	return plan, nil
+}
+
+// visit adds the effects of deleting records of a kind on the records of
+// the trash referencing them, recursively for the cascading ones.
+func (p *deletePlan) visit(kind *schema.Schema, ids []uint) error {
+	for _, key := range p.keys {
+		if key.Referenced != kind.Table {
+			continue
+		}
+		childKind := findKind(p.kinds, key.Table)
+		if childKind == nil {
+			continue
+		}
+
+		var childIDs []uint
+		err := p.tx.Model(reflect.New(childKind.ModelType).Interface()).
+			Where(columnIn(key.Column, ids)).Pluck("id", &childIDs).Error
+		if err != nil {
+			return err
+		}
+		if key.Rule == DeleteCascade {
+			childIDs = p.unseen(childKind, childIDs)
+		}
+		if len(childIDs) == 0 {
+			continue
+		}
+
+		p.addEffect(DeleteEffect{Type: childKind.Table, Field: key.Column, Rule: key.Rule, Count: len(childIDs)})
+		if key.Rule == DeleteRestrict {
+			continue
+		}
+		p.steps = append(p.steps, deleteStep{kind: childKind, column: key.Column, rule: key.Rule, ids: childIDs})
+		if key.Rule == DeleteCascade {
+			if err := p.visit(childKind, childIDs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pruneNullified removes from the nullified references those of the records
+// deleted in cascade, which keep them so restoring them restores them too.
+// Visiting in any order, a record may be nullified before it is found to
+// cascade, e.g., the grades of a section of a deleted subject.
+func (p *deletePlan) pruneNullified() {
+	steps := p.steps[:0]
+	for _, step := range p.steps {
+		if step.rule == DeleteNullify {
+			var ids []uint
+			for _, id := range step.ids {
+				if !p.seen[fmt.Sprintf("%s:%d", step.kind.Table, id)] {
+					ids = append(ids, id)
+				}
+			}
+			p.removeEffect(DeleteEffect{Type: step.kind.Table, Field: step.column, Rule: step.rule, Count: len(step.ids) - len(ids)})
+			if len(ids) == 0 {
+				continue
+			}
+			step.ids = ids
+		}
+		steps = append(steps, step)
+	}
+	p.steps = steps
+}
+
+// unseen returns the IDs of the records not visited yet, marking them.
+func (p *deletePlan) unseen(kind *schema.Schema, ids []uint) []uint {
+	var unseen []uint
+	for _, id := range ids {
+		key := fmt.Sprintf("%s:%d", kind.Table, id)
+		if !p.seen[key] {
+			p.seen[key] = true
+			unseen = append(unseen, id)
+		}
+	}
+	return unseen
+}
+
+// addEffect adds an effect to the plan, merged with the one of the same
+// records and rule if any.
+func (p *deletePlan) addEffect(effect DeleteEffect) {
+	for i, e := range p.effects {
+		if e.Type == effect.Type && e.Field == effect.Field && e.Rule == effect.Rule {
+			p.effects[i].Count += effect.Count
+			return
+		}
+	}
+	p.effects = append(p.effects, effect)
+}
+
+// removeEffect subtracts an effect from the plan, removing it if it no
+// longer affects any record.
+func (p *deletePlan) removeEffect(effect DeleteEffect) {
+	if effect.Count == 0 {
+		return
+	}
+	for i, e := range p.effects {
+		if e.Type == effect.Type && e.Field == effect.Field && e.Rule == effect.Rule {
+			p.effects[i].Count -= effect.Count
+			if p.effects[i].Count <= 0 {
+				p.effects = append(p.effects[:i], p.effects[i+1:]...)
+			}
+			return
+		}
+	}
+}
+
+// restricted returns the first effect preventing the deletion, or nil.
+func (p *deletePlan) restricted() *DeleteEffect {
+	for i, effect := range p.effects {
+		if effect.Rule == DeleteRestrict {
+			return &p.effects[i]
+		}
+	}
+	return nil
+}
+
+// foreignKey represents a foreign key constraint of the models.
+type foreignKey struct {
+	// Name of the constraint, e.g., "fk_subjects_topics".
+	Name string
+
+	// Table and column of the foreign key, and the table it references.
+	Table      string
+	Column     string
+	Referenced string
+
+	Rule DeleteRule
+
+	// Model declaring the constraint and whether it is the model of a join
+	// table, which migrations need to change the constraint.
+	model any
+	join  bool
+
+	// ON DELETE action of the constraint in SQL, e.g., "SET NULL".
+	onDelete string
+}
+
+// migrator returns the migrator of the model declaring the constraint.
+func (k foreignKey) migrator(db *gorm.DB) gorm.Migrator {
+	if k.join {
+		return db.Session(&gorm.Session{}).Table(k.Table).Migrator()
+	}
+	return db.Migrator()
+}
+
+// foreignKeys returns the foreign key constraints of the models, including
+// the ones of the join tables, sorted by name.
+func foreignKeys(db *gorm.DB) ([]foreignKey, error) {
+	seen := map[string]bool{}
+	var keys []foreignKey
+	add := func(model any, rel *schema.Relationship, join bool) {
+		constraint := rel.ParseConstraint()
+		if constraint == nil || len(constraint.ForeignKeys) != 1 || seen[constraint.Name] {
+			return
+		}
+		seen[constraint.Name] = true
+
+		onDelete := strings.ToUpper(constraint.OnDelete)
+		rule := DeleteRestrict
+		switch onDelete {
+		case "CASCADE":
+			rule = DeleteCascade
+		case "SET NULL":
+			rule = DeleteNullify
+		case "":
+			onDelete = "NO ACTION"
+		}
+		keys = append(keys, foreignKey{
+			Name:       constraint.Name,
+			Table:      constraint.Schema.Table,
+			Column:     constraint.ForeignKeys[0].DBName,
+			Referenced: constraint.ReferenceSchema.Table,
+			Rule:       rule,
+			model:      model,
+			join:       join,
+			onDelete:   onDelete,
+		})
+	}
+
+	for _, model := range models() {
+		sch, err := parseModel(db, model)
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range sch.Relationships.Relations {
+			if rel.JoinTable == nil {
+				add(model, rel, false)
+				continue
+			}
+			joinModel := reflect.New(rel.JoinTable.ModelType).Interface()
+			for _, joinRel := range rel.JoinTable.Relationships.Relations {
+				add(joinModel, joinRel, true)
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys, nil
+}
+
+// findForeignKey returns the foreign key of a column of a table, or nil.
+func findForeignKey(keys []foreignKey, table, column string) *foreignKey {
+	for i, key := range keys {
+		if key.Table == table && key.Column == column {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+// columnIn returns the condition matching the rows whose column is one of
+// the given IDs.
+func columnIn(column string, ids []uint) clause.Expression {
+	return clause.Expr{SQL: "? IN ?", Vars: []any{clause.Column{Name: column}, ids}}
+}
@@ -0,0 +1,257 @@
+package edutrack
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JustificationStatus represents the review state of an absence
+// justification.
+type JustificationStatus string
+
+const (
+	// JustificationPending indicates the request awaits review.
+	JustificationPending JustificationStatus = "pending"
+
+	// JustificationApproved indicates the request was approved and the
+	// absences it covers were excused.
+	JustificationApproved JustificationStatus = "approved"
+
+	// JustificationRejected indicates the request was rejected.
+	JustificationRejected JustificationStatus = "rejected"
+)
+
+// MaxJustificationDays is the maximum number of days a justification can
+// cover.
+const MaxJustificationDays = 31
+
+// Justification is a request to excuse the absences of a student between
+// two dates, submitted by the student or a guardian with a supporting
+// document and reviewed by a secretary.
+type Justification struct {
+	gorm.Model
+
+	// First and last day covered, as calendar days (midnight UTC), like the
+	// dates of the attendance records.
+	StartDate time.Time
+	EndDate   time.Time
+
+	// Reason of the absence.
+	Reason string
+
+	// Storage key of the supporting document (e.g., a medical certificate).
+	DocumentKey string
+
+	// Status of the review.
+	Status JustificationStatus `gorm:"default:'pending';index"`
+
+	// Notes of the reviewer, e.g., why the request was rejected.
+	ReviewNotes string
+
+	// When the request was reviewed, nil while pending.
+	ReviewedAt *time.Time
+
+	// Foreign keys.
+
+	// SubmittedByID links to the account that submitted the request.
+	SubmittedByID *uint
+	SubmittedBy   *Account `gorm:"constraint:OnDelete:SET NULL"`
+
+	// ReviewerID links to the secretary that reviewed the request.
+	ReviewerID *uint
+	Reviewer   *Account `gorm:"constraint:OnDelete:SET NULL"`
+
+	// StudentID links to the student, deleted along with them.
+	StudentID uint    `gorm:"index"`
+	Student   Student `gorm:"constraint:OnDelete:CASCADE"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// Validate checks the dates of the justification.
+func (j *Justification) Validate() error {
+	if j.EndDate.Before(j.StartDate) {
+		return InvalidField("end_date", FieldTooSmall, "field.too_small", j.StartDate.Format("2006-01-02"))
+	}
+	if days := int(j.EndDate.Sub(j.StartDate).Hours()/24) + 1; days > MaxJustificationDays {
+		return InvalidField("end_date", FieldTooLarge, "justification.too_long", strconv.Itoa(MaxJustificationDays))
+	}
+	return nil
+}
+
+// JustificationFilter represents the filters of FindJustifications.
+type JustificationFilter struct {
+	StudentID uint
+	Status    JustificationStatus
+}
+
+// FindJustifications returns the justifications visible to the account of
+// the context: students see their own, guardians those of their linked
+// students, and staff all of the institution.
+func FindJustifications(ctx context.Context, db *gorm.DB, filter JustificationFilter) ([]Justification, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	query := db
+	switch {
+	case account.IsStudent():
+		student, err := findOwnStudent(db, account)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("student_id = ?", student.ID)
+	case account.IsGuardian():
+		ids, err := GuardianStudentIDs(db, account.ID, account.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("student_id IN ?", ids)
+	}
+
+	if filter.StudentID != 0 {
+		query = query.Where("student_id = ?", filter.StudentID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var justifications []Justification
+	if err := query.Preload("Student.Account").Order("start_date DESC, id DESC").Find(&justifications).Error; err != nil {
+		return nil, err
+	}
+	return justifications, nil
+}
+
+// FindJustificationByID returns a justification visible to the account of
+// the context.
+func FindJustificationByID(ctx context.Context, db *gorm.DB, id uint) (*Justification, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	var justification Justification
+	if err := db.Preload("Student.Account").First(&justification, id).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+	if err := checkStudentAccess(ctx, db, account, justification.StudentID); err != nil {
+		return nil, err
+	}
+	return &justification, nil
+}
+
+// SubmitJustification creates a pending justification as the account of
+// the context. Students submit them for themselves, guardians for their
+// linked students and secretaries for any student; teachers cannot.
+func SubmitJustification(ctx context.Context, db *gorm.DB, justification *Justification) error {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return &Error{Code: EUNAUTHORIZED}
+	}
+	if account.IsTeacher() {
+		return &Error{Code: EFORBIDDEN}
+	}
+	db = TenantDB(ctx, db)
+
+	if err := justification.Validate(); err != nil {
+		return err
+	}
+
+	var student Student
+	if err := db.First(&student, justification.StudentID).Error; err != nil {
+		return InvalidField("student_id", FieldNotFound, "student.not_found")
+	}
+	if err := checkStudentAccess(ctx, db, account, student.ID); err != nil {
+		return err
+	}
+
+	justification.Status = JustificationPending
+	justification.SubmittedByID = &account.ID
+	justification.TenantID = account.TenantID
+	if err := db.Create(justification).Error; err != nil {
+		return TranslateDBError(err)
+	}
+	return db.Preload("Student.Account").First(justification, justification.ID).Error
+}
+
+// checkStudentAccess checks the account can see the records of a student
+// of its institution: students only their own, guardians those of their
+// linked students.
+func checkStudentAccess(ctx context.Context, db *gorm.DB, account *Account, studentID uint) error {
+	switch {
+	case account.IsStudent():
+		student, err := findOwnStudent(db, account)
+		if err != nil {
+			return err
+		}
+		if student.ID != studentID {
+			return &Error{Code: EFORBIDDEN}
+		}
+	case account.IsGuardian():
+		linked, err := IsGuardianOf(ctx, db, account.ID, studentID)
+		if err != nil {
+			return err
+		}
+		if !linked {
+			return &Error{Code: EFORBIDDEN}
+		}
+	}
+	return nil
+}
+
+// ReviewJustification approves or rejects a pending justification as the
+// given secretary. Approval converts the absences and late arrivals of the
+// student within the dates to excused, linking them to the justification.
+// It returns the number of excused records, and fails with ECONFLICT if
+// the justification was already reviewed.
+func ReviewJustification(db *gorm.DB, justification *Justification, reviewer *Account, status JustificationStatus, notes string) (int64, error) {
+	var excused int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := tx.NowFunc()
+		// Only pending requests change, so concurrent reviews cannot both
+		// succeed.
+		result := tx.Model(&Justification{}).
+			Where("id = ? AND status = ?", justification.ID, JustificationPending).
+			Updates(map[string]any{
+				"status":       status,
+				"review_notes": notes,
+				"reviewed_at":  now,
+				"reviewer_id":  reviewer.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return Errorf(ECONFLICT, "justification.reviewed")
+		}
+
+		if status == JustificationApproved {
+			result = tx.Model(&Attendance{}).
+				Where("student_id = ? AND date >= ? AND date < ?", justification.StudentID,
+					justification.StartDate, justification.EndDate.AddDate(0, 0, 1)).
+				Where("status IN ?", []AttendanceStatus{AttendanceAbsent, AttendanceLate}).
+				Updates(map[string]any{"status": AttendanceExcused, "justification_id": justification.ID})
+			if result.Error != nil {
+				return result.Error
+			}
+			excused = result.RowsAffected
+		}
+
+		justification.Status = status
+		justification.ReviewNotes = notes
+		justification.ReviewedAt = &now
+		justification.UpdatedAt = now
+		justification.ReviewerID = &reviewer.ID
+		return nil
+	})
+	return excused, err
+}
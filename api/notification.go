@@ -0,0 +1,320 @@
+package edutrack
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+// NotificationEvent represents the kind of event that triggered a notification.
+type NotificationEvent string
+
+const (
+	// NotificationGradePosted is emitted when a grade is posted for a student.
+	NotificationGradePosted NotificationEvent = "grade.posted"
+
+	// NotificationAbsenceRecorded is emitted when a student is marked absent.
+	NotificationAbsenceRecorded NotificationEvent = "attendance.absent"
+
+	// NotificationJustificationApproved is emitted when an absence
+	// justification of a student is approved.
+	NotificationJustificationApproved NotificationEvent = "justification.approved"
+
+	// NotificationJustificationRejected is emitted when an absence
+	// justification of a student is rejected.
+	NotificationJustificationRejected NotificationEvent = "justification.rejected"
+
+	// NotificationAppealFiled is emitted to the teacher of the subject when
+	// a student appeals a grade.
+	NotificationAppealFiled NotificationEvent = "appeal.filed"
+
+	// NotificationAppealAccepted is emitted when a grade appeal of a student
+	// is accepted.
+	NotificationAppealAccepted NotificationEvent = "appeal.accepted"
+
+	// NotificationAppealRejected is emitted when a grade appeal of a student
+	// is rejected.
+	NotificationAppealRejected NotificationEvent = "appeal.rejected"
+
+	// NotificationExamScheduled is emitted when an exam attempt of a student
+	// is scheduled.
+	NotificationExamScheduled NotificationEvent = "exam.scheduled"
+
+	// NotificationExamGraded is emitted when the grade of an exam attempt of
+	// a student is recorded.
+	NotificationExamGraded NotificationEvent = "exam.graded"
+
+	// NotificationEnrollmentPromoted is emitted when a waitlisted student is
+	// given a place in a section.
+	NotificationEnrollmentPromoted NotificationEvent = "enrollment.promoted"
+
+	// NotificationLicenseExpiring is emitted when the tenant's license is about to expire.
+	NotificationLicenseExpiring NotificationEvent = "license.expiring"
+)
+
+// NotificationChannel represents a delivery channel for notifications.
+type NotificationChannel string
+
+const (
+	// ChannelEmail delivers notifications by email.
+	ChannelEmail NotificationChannel = "email"
+
+	// ChannelInbox delivers notifications to the in-app inbox.
+	ChannelInbox NotificationChannel = "inbox"
+
+	// ChannelWebhook delivers notifications to an HTTP endpoint.
+	ChannelWebhook NotificationChannel = "webhook"
+)
+
+// NotificationStatus represents the delivery state of an outbox entry.
+type NotificationStatus string
+
+const (
+	// NotificationPending is waiting to be delivered (or retried).
+	NotificationPending NotificationStatus = "pending"
+
+	// NotificationSending was claimed by a dispatcher that is delivering it.
+	NotificationSending NotificationStatus = "sending"
+
+	// NotificationSent was delivered successfully.
+	NotificationSent NotificationStatus = "sent"
+
+	// NotificationFailed exhausted all its delivery attempts.
+	NotificationFailed NotificationStatus = "failed"
+)
+
+// Notification is an outbox entry holding a single message for a single
+// recipient and channel. Entries are created in the same request that
+// emits the event and delivered asynchronously by a dispatcher.
+type Notification struct {
+	gorm.Model
+
+	// The event that triggered the notification.
+	Event NotificationEvent `gorm:"index"`
+
+	// The channel used to deliver the notification.
+	Channel NotificationChannel
+
+	// Channel-specific destination (email address or webhook URL).
+	// Empty for the in-app inbox.
+	Target string
+
+	// Short title of the notification.
+	Subject string
+
+	// Plain text body of the notification.
+	Body string
+
+	// Optional key used to avoid enqueuing the same notification twice.
+	DedupeKey string `gorm:"index"`
+
+	// Delivery state.
+	Status NotificationStatus `gorm:"index;default:'pending'"`
+
+	// Number of delivery attempts made so far.
+	Attempts int
+
+	// When the next delivery attempt is due.
+	NextAttemptAt time.Time `gorm:"index"`
+
+	// Error returned by the last failed attempt.
+	LastError string
+
+	// When the notification was delivered.
+	SentAt *time.Time
+
+	// Foreign keys.
+
+	// AccountID links to the recipient.
+	AccountID uint    `gorm:"index"`
+	Account   Account `gorm:"constraint:OnDelete:CASCADE"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// InboxMessage is a notification delivered to the in-app inbox of an account.
+type InboxMessage struct {
+	gorm.Model
+
+	// The event that triggered the message.
+	Event NotificationEvent
+
+	// Short title of the message.
+	Subject string
+
+	// Plain text body of the message.
+	Body string
+
+	// When the recipient read the message.
+	ReadAt *time.Time
+
+	// Foreign keys.
+
+	// AccountID links to the recipient.
+	AccountID uint    `gorm:"index"`
+	Account   Account `gorm:"constraint:OnDelete:CASCADE"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// NotificationPreference holds the channels an account wants to receive
+// for a given event. Accounts without a preference for an event receive
+// it by email and in the in-app inbox.
+type NotificationPreference struct {
+	gorm.Model
+
+	// The event this preference applies to.
+	Event NotificationEvent `gorm:"uniqueIndex:idx_notification_preference"`
+
+	// Whether to deliver by email.
+	Email bool
+
+	// Whether to deliver to the in-app inbox.
+	Inbox bool
+
+	// Endpoint to deliver to by webhook. Empty disables the channel. Only
+	// secretaries can use it, since the server posts to the endpoint.
+	WebhookURL string
+
+	// Foreign keys.
+
+	// AccountID links the preference to its owner.
+	AccountID uint    `gorm:"uniqueIndex:idx_notification_preference"`
+	Account   Account `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// DefaultNotificationPreference returns the preference used for accounts
+// that have not configured the given event.
+func DefaultNotificationPreference(accountID uint, event NotificationEvent) NotificationPreference {
+	return NotificationPreference{
+		Event:     event,
+		Email:     true,
+		Inbox:     true,
+		AccountID: accountID,
+	}
+}
+
+// NotificationEvents returns all the events an account can be notified of.
+func NotificationEvents() []NotificationEvent {
+	return []NotificationEvent{
+		NotificationGradePosted,
+		NotificationAbsenceRecorded,
+		NotificationJustificationApproved,
+		NotificationJustificationRejected,
+		NotificationAppealFiled,
+		NotificationAppealAccepted,
+		NotificationAppealRejected,
+		NotificationExamScheduled,
+		NotificationExamGraded,
+		NotificationEnrollmentPromoted,
+		NotificationLicenseExpiring,
+	}
+}
+
+// FindNotificationPreference returns the preference of an account for the
+// given event, falling back to the default when none was configured.
+func FindNotificationPreference(db *gorm.DB, accountID uint, event NotificationEvent) (NotificationPreference, error) {
+	var pref NotificationPreference
+	err := db.Where("account_id = ? AND event = ?", accountID, event).Limit(1).Find(&pref).Error
+	if err != nil {
+		return pref, err
+	}
+	if pref.ID == 0 {
+		return DefaultNotificationPreference(accountID, event), nil
+	}
+	return pref, nil
+}
+
+// EnqueueNotification stores one outbox entry per channel enabled in the
+// recipient's preferences, with the subject and body of the event rendered
+// in the recipient's locale, or else their institution's, from the i18n
+// catalog; args fill the body. When dedupeKey is not empty and an entry with
+// the same key already exists for the recipient, nothing is enqueued.
+func EnqueueNotification(db *gorm.DB, recipient *Account, event NotificationEvent, dedupeKey string, args ...any) error {
+	if dedupeKey != "" {
+		var count int64
+		err := db.Model(&Notification{}).Where("account_id = ? AND dedupe_key = ?", recipient.ID, dedupeKey).Count(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to check notification dedupe key: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+
+	pref, err := FindNotificationPreference(db, recipient.ID, event)
+	if err != nil {
+		return fmt.Errorf("failed to load notification preference: %w", err)
+	}
+
+	targets := make(map[NotificationChannel]string)
+	if pref.Email && recipient.Email != "" {
+		targets[ChannelEmail] = recipient.Email
+	}
+	if pref.Inbox {
+		targets[ChannelInbox] = ""
+	}
+	if pref.WebhookURL != "" && recipient.IsSecretary() {
+		targets[ChannelWebhook] = pref.WebhookURL
+	}
+
+	// Recipients without a language get the one of their institution.
+	locale := recipient.Locale
+	if locale == "" {
+		settings, err := FindTenantSettings(db, recipient.TenantID)
+		if err != nil {
+			return fmt.Errorf("failed to load tenant settings: %w", err)
+		}
+		locale = settings.Locale
+	}
+	subject := i18n.Message(locale, "notification."+string(event)+".subject")
+	body := i18n.Message(locale, "notification."+string(event)+".body", args...)
+
+	now := time.Now()
+	for channel, target := range targets {
+		notification := &Notification{
+			Event:         event,
+			Channel:       channel,
+			Target:        target,
+			Subject:       subject,
+			Body:          body,
+			DedupeKey:     dedupeKey,
+			Status:        NotificationPending,
+			NextAttemptAt: now,
+			AccountID:     recipient.ID,
+			TenantID:      recipient.TenantID,
+		}
+		if err := db.Create(notification).Error; err != nil {
+			return fmt.Errorf("failed to enqueue notification: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// StudentRecipients returns the accounts that must be notified about a
+// student: the student's own account and the accounts of their guardians.
+func StudentRecipients(db *gorm.DB, studentID uint) ([]Account, error) {
+	var student Student
+	if err := db.Preload("Account").First(&student, studentID).Error; err != nil {
+		return nil, err
+	}
+
+	recipients := []Account{student.Account}
+
+	var guardians []Account
+	err := db.Where("id IN (?)", db.Model(&GuardianLink{}).Select("account_id").Where("student_id = ?", studentID)).
+		Where("active = ?", true).
+		Find(&guardians).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return append(recipients, guardians...), nil
+}
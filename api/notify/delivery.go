@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// Headers sent with every tenant webhook delivery.
+const (
+	HeaderWebhookEvent     = "X-EduTrack-Event"
+	HeaderWebhookDelivery  = "X-EduTrack-Delivery"
+	HeaderWebhookSignature = "X-EduTrack-Signature"
+)
+
+// WebhookDeliverer posts the pending deliveries of tenant webhooks,
+// signing each payload with the webhook secret.
+type WebhookDeliverer struct {
+	DB *gorm.DB
+
+	// Client used to post the payloads. Defaults to a client with a 10 second
+	// timeout that refuses to connect to private addresses.
+	Client *http.Client
+
+	// Logger for delivery errors. Defaults to the standard logger.
+	Logger *log.Logger
+
+	// MaxAttempts is the number of attempts before a delivery is marked failed.
+	MaxAttempts int
+
+	// BatchSize is the number of deliveries processed per run.
+	BatchSize int
+
+	// Backoff returns the delay before a retry. Defaults to Backoff.
+	Backoff func(attempt int) time.Duration
+
+	// ClaimTimeout is how long a claimed delivery is reserved. Deliveries
+	// still being posted after it, e.g. by a deliverer that stopped, are
+	// claimed again.
+	ClaimTimeout time.Duration
+}
+
+// NewWebhookDeliverer creates a deliverer with the default settings.
+func NewWebhookDeliverer(db *gorm.DB) *WebhookDeliverer {
+	return &WebhookDeliverer{
+		DB:           db,
+		Client:       publicClient,
+		Logger:       log.Default(),
+		MaxAttempts:  DefaultMaxAttempts,
+		BatchSize:    DefaultBatchSize,
+		Backoff:      Backoff,
+		ClaimTimeout: DefaultClaimTimeout,
+	}
+}
+
+// Run processes the pending deliveries every interval until the context is cancelled.
+func (d *WebhookDeliverer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.ProcessPending(ctx); err != nil {
+			d.Logger.Printf("notify: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending posts the deliveries that are due and returns the number
+// of deliveries acknowledged by their endpoint. Each delivery is claimed
+// before it is posted, so deliverers running at the same time never post it
+// twice.
+func (d *WebhookDeliverer) ProcessPending(ctx context.Context) (int, error) {
+	var pending []edutrack.WebhookDelivery
+	err := d.DB.WithContext(ctx).
+		Preload("Webhook").
+		Where("status IN ? AND next_attempt_at <= ?",
+			[]edutrack.WebhookDeliveryStatus{edutrack.WebhookDeliveryPending, edutrack.WebhookDeliverySending}, time.Now()).
+		Order("id").
+		Limit(d.BatchSize).
+		Find(&pending).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pending webhook deliveries: %w", err)
+	}
+
+	delivered := 0
+	for i := range pending {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+		claimed, err := d.claim(ctx, &pending[i])
+		if err != nil {
+			return delivered, fmt.Errorf("failed to claim webhook delivery %d: %w", pending[i].ID, err)
+		}
+		if !claimed {
+			continue
+		}
+		if d.deliver(ctx, &pending[i]) {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// claim reserves a delivery for this deliverer and counts the attempt. It
+// reports false when another deliverer claimed the delivery first.
+func (d *WebhookDeliverer) claim(ctx context.Context, delivery *edutrack.WebhookDelivery) (bool, error) {
+	lease := time.Now().Add(d.ClaimTimeout)
+	result := d.DB.WithContext(ctx).Model(&edutrack.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, delivery.Status, delivery.Attempts).
+		Updates(map[string]any{
+			"status":          edutrack.WebhookDeliverySending,
+			"attempts":        delivery.Attempts + 1,
+			"next_attempt_at": lease,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return false, nil
+	}
+
+	delivery.Status = edutrack.WebhookDeliverySending
+	delivery.Attempts++
+	delivery.NextAttemptAt = lease
+	return true, nil
+}
+
+// deliver posts a claimed delivery and records the outcome.
+func (d *WebhookDeliverer) deliver(ctx context.Context, delivery *edutrack.WebhookDelivery) bool {
+	status, err := d.post(ctx, delivery)
+
+	updates := map[string]any{"response_status": status}
+	if err == nil {
+		updates["status"] = edutrack.WebhookDeliverySucceeded
+		updates["delivered_at"] = time.Now()
+		updates["last_error"] = ""
+	} else {
+		updates["last_error"] = err.Error()
+		if delivery.Attempts >= d.MaxAttempts {
+			updates["status"] = edutrack.WebhookDeliveryFailed
+		} else {
+			updates["status"] = edutrack.WebhookDeliveryPending
+			updates["next_attempt_at"] = time.Now().Add(d.Backoff(delivery.Attempts))
+		}
+		d.Logger.Printf("notify: webhook delivery %d attempt %d failed: %v", delivery.ID, delivery.Attempts, err)
+	}
+
+	// The delivery is only updated while this deliverer still holds the claim.
+	result := d.DB.WithContext(ctx).Model(&edutrack.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, edutrack.WebhookDeliverySending, delivery.Attempts).
+		Updates(updates)
+	if result.Error != nil {
+		d.Logger.Printf("notify: failed to update webhook delivery %d: %v", delivery.ID, result.Error)
+	} else if result.RowsAffected != 1 {
+		d.Logger.Printf("notify: webhook delivery %d was claimed again before its attempt %d finished", delivery.ID, delivery.Attempts)
+	}
+
+	return err == nil
+}
+
+// post sends the signed payload and returns the response status code.
+func (d *WebhookDeliverer) post(ctx context.Context, delivery *edutrack.WebhookDelivery) (int, error) {
+	webhook := delivery.Webhook
+	if webhook.ID == 0 {
+		return 0, fmt.Errorf("webhook %d no longer exists", delivery.WebhookID)
+	}
+
+	payload := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+	signature := edutrack.SignWebhookPayload(webhook.Secret, timestamp, payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, string(delivery.Event))
+	req.Header.Set(HeaderWebhookDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderWebhookSignature, fmt.Sprintf("t=%d,v1=%s", timestamp, signature))
+
+	client := d.Client
+	if client == nil {
+		client = publicClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
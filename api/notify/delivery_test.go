@@ -0,0 +1,251 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+func TestWebhookDeliverer_SignsPayload(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+
+	var gotBody []byte
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeader = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := &edutrack.Webhook{URL: server.URL, Secret: "whsec_test", Active: true, TenantID: tenant.ID}
+	webhook.SetEvents([]edutrack.WebhookEvent{edutrack.WebhookGradeCreated})
+	db.Create(webhook)
+
+	if err := edutrack.EmitWebhookEvent(db, tenant.ID, edutrack.WebhookGradeCreated, map[string]int{"value": 90}); err != nil {
+		t.Fatalf("EmitWebhookEvent() error = %v", err)
+	}
+
+	d := NewWebhookDeliverer(db)
+	d.Client = server.Client()
+
+	delivered, err := d.ProcessPending(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessPending() error = %v", err)
+	}
+	if delivered != 1 {
+		t.Fatalf("ProcessPending() delivered = %d, want 1", delivered)
+	}
+
+	if gotHeader.Get(HeaderWebhookEvent) != string(edutrack.WebhookGradeCreated) {
+		t.Errorf("%s = %q", HeaderWebhookEvent, gotHeader.Get(HeaderWebhookEvent))
+	}
+
+	var timestamp int64
+	var signature string
+	if _, err := fmt.Sscanf(strings.Replace(gotHeader.Get(HeaderWebhookSignature), ",v1=", " ", 1), "t=%d %s", &timestamp, &signature); err != nil {
+		t.Fatalf("invalid signature header %q: %v", gotHeader.Get(HeaderWebhookSignature), err)
+	}
+
+	if want := edutrack.SignWebhookPayload("whsec_test", timestamp, gotBody); signature != want {
+		t.Errorf("signature = %q, want %q", signature, want)
+	}
+
+	var delivery edutrack.WebhookDelivery
+	db.First(&delivery)
+	if delivery.Status != edutrack.WebhookDeliverySucceeded || delivery.ResponseStatus != http.StatusOK {
+		t.Errorf("delivery = %+v, want succeeded with status 200", delivery)
+	}
+}
+
+func TestWebhookDeliverer_RetriesAndFails(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	webhook := &edutrack.Webhook{URL: server.URL, Secret: "whsec_test", Active: true, TenantID: tenant.ID}
+	webhook.SetEvents([]edutrack.WebhookEvent{edutrack.WebhookStudentCreated})
+	db.Create(webhook)
+
+	edutrack.EmitWebhookEvent(db, tenant.ID, edutrack.WebhookStudentCreated, nil)
+
+	d := NewWebhookDeliverer(db)
+	d.Client = server.Client()
+	d.MaxAttempts = 2
+	d.Backoff = func(int) time.Duration { return 0 }
+
+	for i := 0; i < 3; i++ {
+		d.ProcessPending(context.Background())
+	}
+
+	var delivery edutrack.WebhookDelivery
+	db.First(&delivery)
+
+	if delivery.Status != edutrack.WebhookDeliveryFailed {
+		t.Errorf("delivery.Status = %q, want %q", delivery.Status, edutrack.WebhookDeliveryFailed)
+	}
+	if delivery.Attempts != 2 {
+		t.Errorf("delivery.Attempts = %d, want 2", delivery.Attempts)
+	}
+	if delivery.ResponseStatus != http.StatusBadGateway {
+		t.Errorf("delivery.ResponseStatus = %d, want %d", delivery.ResponseStatus, http.StatusBadGateway)
+	}
+}
+
+func TestWebhookDeliverer_ClaimsDeliveries(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+
+	// A second deliverer runs while the first one is posting the delivery.
+	var other *WebhookDeliverer
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		if posts == 1 {
+			if delivered, err := other.ProcessPending(context.Background()); err != nil || delivered != 0 {
+				t.Errorf("ProcessPending() while claimed = %d, %v, want 0", delivered, err)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := &edutrack.Webhook{URL: server.URL, Secret: "whsec_test", Active: true, TenantID: tenant.ID}
+	webhook.SetEvents([]edutrack.WebhookEvent{edutrack.WebhookGradeCreated})
+	db.Create(webhook)
+
+	edutrack.EmitWebhookEvent(db, tenant.ID, edutrack.WebhookGradeCreated, nil)
+
+	other = NewWebhookDeliverer(db)
+	other.Client = server.Client()
+	d := NewWebhookDeliverer(db)
+	d.Client = server.Client()
+
+	delivered, err := d.ProcessPending(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessPending() error = %v", err)
+	}
+	if delivered != 1 || posts != 1 {
+		t.Errorf("ProcessPending() delivered = %d with %d posts, want 1 post", delivered, posts)
+	}
+
+	var delivery edutrack.WebhookDelivery
+	db.First(&delivery)
+	if delivery.Status != edutrack.WebhookDeliverySucceeded || delivery.Attempts != 1 {
+		t.Errorf("delivery = %q after %d attempts, want succeeded after 1", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestWebhookDeliverer_ReclaimsExpiredClaims(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := &edutrack.Webhook{URL: server.URL, Secret: "whsec_test", Active: true, TenantID: tenant.ID}
+	webhook.SetEvents([]edutrack.WebhookEvent{edutrack.WebhookGradeCreated})
+	db.Create(webhook)
+
+	edutrack.EmitWebhookEvent(db, tenant.ID, edutrack.WebhookGradeCreated, nil)
+
+	// A deliverer claimed the delivery and stopped before posting it.
+	db.Model(&edutrack.WebhookDelivery{}).Where("webhook_id = ?", webhook.ID).Updates(map[string]any{
+		"status":          edutrack.WebhookDeliverySending,
+		"attempts":        1,
+		"next_attempt_at": time.Now().Add(-time.Minute),
+	})
+
+	d := NewWebhookDeliverer(db)
+	d.Client = server.Client()
+	if delivered, _ := d.ProcessPending(context.Background()); delivered != 1 {
+		t.Errorf("ProcessPending() delivered = %d, want 1", delivered)
+	}
+
+	var delivery edutrack.WebhookDelivery
+	db.First(&delivery)
+	if delivery.Status != edutrack.WebhookDeliverySucceeded || delivery.Attempts != 2 {
+		t.Errorf("delivery = %q after %d attempts, want succeeded after 2", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestWebhookDeliverer_PrivateAddress(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := &edutrack.Webhook{URL: server.URL, Secret: "whsec_test", Active: true, TenantID: tenant.ID}
+	webhook.SetEvents([]edutrack.WebhookEvent{edutrack.WebhookGradeCreated})
+	db.Create(webhook)
+
+	edutrack.EmitWebhookEvent(db, tenant.ID, edutrack.WebhookGradeCreated, nil)
+
+	// The default client refuses the loopback address of the test server.
+	d := NewWebhookDeliverer(db)
+	d.Logger = log.New(io.Discard, "", 0)
+	if delivered, _ := d.ProcessPending(context.Background()); delivered != 0 {
+		t.Errorf("ProcessPending() delivered = %d, want 0", delivered)
+	}
+	if called {
+		t.Error("ProcessPending() reached the loopback endpoint")
+	}
+
+	var delivery edutrack.WebhookDelivery
+	db.First(&delivery)
+	if delivery.Status != edutrack.WebhookDeliveryPending || !strings.Contains(delivery.LastError, "not public") {
+		t.Errorf("delivery = %q with error %q, want pending with a private address error", delivery.Status, delivery.LastError)
+	}
+}
+
+func TestEmitWebhookEvent_OnlySubscribedAndActive(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	other := createTestTenant(t, db, 30*24*time.Hour)
+
+	subscribed := &edutrack.Webhook{URL: "https://a.example.com", Active: true, TenantID: tenant.ID}
+	subscribed.SetEvents([]edutrack.WebhookEvent{edutrack.WebhookGradeCreated})
+	unsubscribed := &edutrack.Webhook{URL: "https://b.example.com", Active: true, TenantID: tenant.ID}
+	unsubscribed.SetEvents([]edutrack.WebhookEvent{edutrack.WebhookStudentCreated})
+	otherTenant := &edutrack.Webhook{URL: "https://c.example.com", Active: true, TenantID: other.ID}
+	otherTenant.SetEvents([]edutrack.WebhookEvent{edutrack.WebhookGradeCreated})
+	db.Create(subscribed)
+	db.Create(unsubscribed)
+	db.Create(otherTenant)
+
+	inactive := &edutrack.Webhook{URL: "https://d.example.com", Active: true, TenantID: tenant.ID}
+	inactive.SetEvents([]edutrack.WebhookEvent{edutrack.WebhookGradeCreated})
+	db.Create(inactive)
+	db.Model(inactive).Update("active", false)
+
+	if err := edutrack.EmitWebhookEvent(db, tenant.ID, edutrack.WebhookGradeCreated, nil); err != nil {
+		t.Fatalf("EmitWebhookEvent() error = %v", err)
+	}
+
+	var deliveries []edutrack.WebhookDelivery
+	db.Find(&deliveries)
+
+	if len(deliveries) != 1 || deliveries[0].WebhookID != subscribed.ID {
+		t.Errorf("EmitWebhookEvent() created %d deliveries, want 1 for the subscribed webhook", len(deliveries))
+	}
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// EmailChannel delivers notifications by email through an SMTP server.
+type EmailChannel struct {
+	// Host and port of the SMTP server (e.g., "smtp.example.com:587").
+	Addr string
+
+	// Credentials for PLAIN authentication. Leave empty to skip authentication.
+	Username string
+	Password string
+
+	// Sender address.
+	From string
+
+	// SendMail is the function used to send the message.
+	// Defaults to smtp.SendMail; replaced in tests.
+	SendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// Name implements Channel.
+func (c *EmailChannel) Name() edutrack.NotificationChannel {
+	return edutrack.ChannelEmail
+}
+
+// Send implements Channel.
+func (c *EmailChannel) Send(ctx context.Context, n *edutrack.Notification) error {
+	if n.Target == "" {
+		return fmt.Errorf("notification %d has no email address", n.ID)
+	}
+
+	var auth smtp.Auth
+	if c.Username != "" {
+		host, _, err := net.SplitHostPort(c.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address: %w", err)
+		}
+		auth = smtp.PlainAuth("", c.Username, c.Password, host)
+	}
+
+	send := c.SendMail
+	if send == nil {
+		send = smtp.SendMail
+	}
+
+	return send(c.Addr, auth, c.From, []string{n.Target}, c.message(n))
+}
+
+// message builds the RFC 5322 message for a notification.
+func (c *EmailChannel) message(n *edutrack.Notification) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.From)
+	fmt.Fprintf(&b, "To: %s\r\n", n.Target)
+	fmt.Fprintf(&b, "Subject: %s\r\n", sanitizeHeader(n.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(n.Body)
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// sanitizeHeader removes line breaks to prevent header injection.
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// InboxChannel delivers notifications to the in-app inbox stored in the database.
+type InboxChannel struct {
+	DB *gorm.DB
+}
+
+// Name implements Channel.
+func (c *InboxChannel) Name() edutrack.NotificationChannel {
+	return edutrack.ChannelInbox
+}
+
+// Send implements Channel.
+func (c *InboxChannel) Send(ctx context.Context, n *edutrack.Notification) error {
+	message := &edutrack.InboxMessage{
+		Event:     n.Event,
+		Subject:   n.Subject,
+		Body:      n.Body,
+		AccountID: n.AccountID,
+		TenantID:  n.TenantID,
+	}
+	return c.DB.WithContext(ctx).Create(message).Error
+}
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// DefaultLicenseWarning is how long before expiry secretaries are notified.
+const DefaultLicenseWarning = 15 * 24 * time.Hour
+
+// LicenseScanner notifies the secretaries of each tenant whose license
+// expires within the warning window.
+type LicenseScanner struct {
+	DB *gorm.DB
+
+	// Logger for scan errors. Defaults to the standard logger.
+	Logger *log.Logger
+
+	// Warning is how long before expiry the notification is sent.
+	Warning time.Duration
+}
+
+// NewLicenseScanner creates a scanner with the default warning window.
+func NewLicenseScanner(db *gorm.DB) *LicenseScanner {
+	return &LicenseScanner{
+		DB:      db,
+		Logger:  log.Default(),
+		Warning: DefaultLicenseWarning,
+	}
+}
+
+// Run scans the licenses every interval until the context is cancelled.
+func (s *LicenseScanner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Scan(ctx); err != nil {
+			s.Logger.Printf("notify: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan enqueues a notification for every secretary of the tenants whose
+// active license expires within the warning window. Each license expiry is
+// notified only once. It returns the number of tenants notified.
+func (s *LicenseScanner) Scan(ctx context.Context) (int, error) {
+	db := s.DB.WithContext(ctx)
+
+	now := time.Now()
+	var tenants []edutrack.Tenant
+	err := db.Joins("License").
+		Where("License.active = ? AND License.expiry_at > ? AND License.expiry_at <= ?", true, now, now.Add(s.Warning)).
+		Find(&tenants).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan licenses: %w", err)
+	}
+
+	for _, tenant := range tenants {
+		var secretaries []edutrack.Account
+		if err := db.Where("tenant_id = ? AND role = ? AND active = ?", tenant.ID, edutrack.RoleSecretary, true).Find(&secretaries).Error; err != nil {
+			return 0, fmt.Errorf("failed to load secretaries: %w", err)
+		}
+
+		expiry := tenant.License.ExpiryAt.Format("2006-01-02")
+		key := fmt.Sprintf("%s:%d:%s", edutrack.NotificationLicenseExpiring, tenant.License.ID, expiry)
+
+		for i := range secretaries {
+			err := edutrack.EnqueueNotification(db, &secretaries[i], edutrack.NotificationLicenseExpiring, key,
+				tenant.Name, expiry, tenant.License.DaysUntilExpiry())
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return len(tenants), nil
+}
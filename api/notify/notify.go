@@ -0,0 +1,209 @@
+// Package notify delivers the notifications stored in the outbox through
+// pluggable channels (email, in-app inbox and webhooks).
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// Channel delivers a notification through a specific medium.
+type Channel interface {
+	// Name returns the outbox channel handled by this implementation.
+	Name() edutrack.NotificationChannel
+
+	// Send delivers the notification. A returned error schedules a retry.
+	Send(ctx context.Context, n *edutrack.Notification) error
+}
+
+// DefaultMaxAttempts is the number of delivery attempts before giving up.
+const DefaultMaxAttempts = 5
+
+// DefaultBatchSize is the number of outbox entries processed per run.
+const DefaultBatchSize = 100
+
+// DefaultClaimTimeout is how long a claimed entry is reserved for the
+// dispatcher delivering it.
+const DefaultClaimTimeout = 10 * time.Minute
+
+// Backoff returns how long to wait before the given retry attempt.
+// It doubles from one minute up to a maximum of six hours.
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := time.Minute << (attempt - 1)
+	if delay > 6*time.Hour || delay <= 0 {
+		return 6 * time.Hour
+	}
+	return delay
+}
+
+// Dispatcher delivers pending outbox entries through the registered channels.
+type Dispatcher struct {
+	DB *gorm.DB
+
+	// Logger for delivery errors. Defaults to the standard logger.
+	Logger *log.Logger
+
+	// MaxAttempts is the number of attempts before an entry is marked failed.
+	MaxAttempts int
+
+	// BatchSize is the number of entries processed per run.
+	BatchSize int
+
+	// Backoff returns the delay before a retry. Defaults to Backoff.
+	Backoff func(attempt int) time.Duration
+
+	// ClaimTimeout is how long a claimed entry is reserved. Entries still
+	// being sent after it, e.g. by a dispatcher that stopped, are claimed
+	// again.
+	ClaimTimeout time.Duration
+
+	channels map[edutrack.NotificationChannel]Channel
+}
+
+// NewDispatcher creates a dispatcher with the given channels.
+func NewDispatcher(db *gorm.DB, channels ...Channel) *Dispatcher {
+	d := &Dispatcher{
+		DB:           db,
+		Logger:       log.Default(),
+		MaxAttempts:  DefaultMaxAttempts,
+		BatchSize:    DefaultBatchSize,
+		Backoff:      Backoff,
+		ClaimTimeout: DefaultClaimTimeout,
+		channels:     make(map[edutrack.NotificationChannel]Channel),
+	}
+
+	for _, c := range channels {
+		d.Register(c)
+	}
+
+	return d
+}
+
+// Register adds or replaces the channel used for its outbox channel name.
+func (d *Dispatcher) Register(c Channel) {
+	d.channels[c.Name()] = c
+}
+
+// Run processes the outbox every interval until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.ProcessPending(ctx); err != nil {
+			d.Logger.Printf("notify: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending delivers the pending entries that are due and returns the
+// number of entries delivered successfully. Each entry is claimed before it
+// is sent, so dispatchers running at the same time never send it twice.
+func (d *Dispatcher) ProcessPending(ctx context.Context) (int, error) {
+	var pending []edutrack.Notification
+	err := d.DB.WithContext(ctx).
+		Where("status IN ? AND next_attempt_at <= ?",
+			[]edutrack.NotificationStatus{edutrack.NotificationPending, edutrack.NotificationSending}, time.Now()).
+		Order("id").
+		Limit(d.BatchSize).
+		Find(&pending).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pending notifications: %w", err)
+	}
+
+	sent := 0
+	for i := range pending {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		claimed, err := d.claim(ctx, &pending[i])
+		if err != nil {
+			return sent, fmt.Errorf("failed to claim notification %d: %w", pending[i].ID, err)
+		}
+		if !claimed {
+			continue
+		}
+		if d.deliver(ctx, &pending[i]) {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// claim reserves an entry for this dispatcher and counts the attempt. It
+// reports false when another dispatcher claimed the entry first.
+func (d *Dispatcher) claim(ctx context.Context, n *edutrack.Notification) (bool, error) {
+	lease := time.Now().Add(d.ClaimTimeout)
+	result := d.DB.WithContext(ctx).Model(&edutrack.Notification{}).
+		Where("id = ? AND status = ? AND attempts = ?", n.ID, n.Status, n.Attempts).
+		Updates(map[string]any{
+			"status":          edutrack.NotificationSending,
+			"attempts":        n.Attempts + 1,
+			"next_attempt_at": lease,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return false, nil
+	}
+
+	n.Status = edutrack.NotificationSending
+	n.Attempts++
+	n.NextAttemptAt = lease
+	return true, nil
+}
+
+// deliver attempts to send a claimed entry and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, n *edutrack.Notification) bool {
+	var err error
+	if c, ok := d.channels[n.Channel]; ok {
+		err = c.Send(ctx, n)
+	} else {
+		err = fmt.Errorf("no channel registered for %q", n.Channel)
+	}
+
+	updates := map[string]any{}
+	if err == nil {
+		updates["status"] = edutrack.NotificationSent
+		updates["sent_at"] = time.Now()
+		updates["last_error"] = ""
+	} else {
+		updates["last_error"] = err.Error()
+		if n.Attempts >= d.MaxAttempts {
+			updates["status"] = edutrack.NotificationFailed
+		} else {
+			updates["status"] = edutrack.NotificationPending
+			updates["next_attempt_at"] = time.Now().Add(d.Backoff(n.Attempts))
+		}
+		d.Logger.Printf("notify: notification %d (%s) attempt %d failed: %v", n.ID, n.Channel, n.Attempts, err)
+	}
+
+	// The entry is only updated while this dispatcher still holds the claim.
+	result := d.DB.WithContext(ctx).Model(&edutrack.Notification{}).
+		Where("id = ? AND status = ? AND attempts = ?", n.ID, edutrack.NotificationSending, n.Attempts).
+		Updates(updates)
+	if result.Error != nil {
+		d.Logger.Printf("notify: failed to update notification %d: %v", n.ID, result.Error)
+	} else if result.RowsAffected != 1 {
+		d.Logger.Printf("notify: notification %d was claimed again before its attempt %d finished", n.ID, n.Attempts)
+	}
+
+	return err == nil
+}
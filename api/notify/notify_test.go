@@ -0,0 +1,534 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+// setupTestDB creates an in-memory SQLite database for testing.
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := edutrack.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// createTestTenant creates a test tenant whose license expires after the given duration.
+func createTestTenant(t *testing.T, db *gorm.DB, duration time.Duration) *edutrack.Tenant {
+	tenant, err := edutrack.NewTenant("Test Institution", edutrack.LicenseTypeTrial, duration)
+	if err != nil {
+		t.Fatalf("Failed to create test tenant: %v", err)
+	}
+
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatalf("Failed to save test tenant: %v", err)
+	}
+
+	return tenant
+}
+
+// createTestAccount creates a test account for a tenant.
+func createTestAccount(t *testing.T, db *gorm.DB, tenantID, email string, role edutrack.Role) *edutrack.Account {
+	account := &edutrack.Account{
+		Name:     "Test User",
+		Email:    email,
+		Role:     role,
+		Active:   true,
+		TenantID: tenantID,
+	}
+
+	if err := db.Create(account).Error; err != nil {
+		t.Fatalf("Failed to save test account: %v", err)
+	}
+
+	return account
+}
+
+// fakeChannel records the notifications it receives and fails on demand.
+type fakeChannel struct {
+	name   edutrack.NotificationChannel
+	err    error
+	sent   []edutrack.Notification
+	onSend func()
+}
+
+func (c *fakeChannel) Name() edutrack.NotificationChannel { return c.name }
+
+func (c *fakeChannel) Send(ctx context.Context, n *edutrack.Notification) error {
+	if c.onSend != nil {
+		c.onSend()
+	}
+	if c.err != nil {
+		return c.err
+	}
+	c.sent = append(c.sent, *n)
+	return nil
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{20, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestEnqueueNotification_DefaultPreference(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	if err := edutrack.EnqueueNotification(db, account, edutrack.NotificationGradePosted, "", 9.5, "Matemáticas I", "Unidad 1"); err != nil {
+		t.Fatalf("EnqueueNotification() error = %v", err)
+	}
+
+	var notifications []edutrack.Notification
+	db.Where("account_id = ?", account.ID).Find(&notifications)
+
+	if len(notifications) != 2 {
+		t.Fatalf("EnqueueNotification() created %d entries, want 2 (email and inbox)", len(notifications))
+	}
+
+	for _, n := range notifications {
+		if n.Status != edutrack.NotificationPending {
+			t.Errorf("Notification.Status = %q, want %q", n.Status, edutrack.NotificationPending)
+		}
+		if n.Channel == edutrack.ChannelEmail && n.Target != account.Email {
+			t.Errorf("Notification.Target = %q, want %q", n.Target, account.Email)
+		}
+	}
+}
+
+func TestEnqueueNotification_RespectsPreference(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+
+	db.Create(&edutrack.NotificationPreference{
+		Event:      edutrack.NotificationGradePosted,
+		Email:      false,
+		Inbox:      false,
+		WebhookURL: "https://example.com/hook",
+		AccountID:  account.ID,
+	})
+
+	if err := edutrack.EnqueueNotification(db, account, edutrack.NotificationGradePosted, "", 9.5, "Matemáticas I", "Unidad 1"); err != nil {
+		t.Fatalf("EnqueueNotification() error = %v", err)
+	}
+
+	var notifications []edutrack.Notification
+	db.Where("account_id = ?", account.ID).Find(&notifications)
+
+	if len(notifications) != 1 || notifications[0].Channel != edutrack.ChannelWebhook {
+		t.Fatalf("EnqueueNotification() = %+v, want a single webhook entry", notifications)
+	}
+}
+
+func TestEnqueueNotification_WebhookOnlyForSecretaries(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	// A preference saved before webhooks were restricted to secretaries.
+	db.Create(&edutrack.NotificationPreference{
+		Event:      edutrack.NotificationGradePosted,
+		Inbox:      true,
+		WebhookURL: "http://169.254.169.254/latest/meta-data",
+		AccountID:  account.ID,
+	})
+
+	if err := edutrack.EnqueueNotification(db, account, edutrack.NotificationGradePosted, "", 9.5, "Matemáticas I", "Unidad 1"); err != nil {
+		t.Fatalf("EnqueueNotification() error = %v", err)
+	}
+
+	var notifications []edutrack.Notification
+	db.Where("account_id = ?", account.ID).Find(&notifications)
+
+	if len(notifications) != 1 || notifications[0].Channel != edutrack.ChannelInbox {
+		t.Fatalf("EnqueueNotification() = %+v, want a single inbox entry", notifications)
+	}
+}
+
+func TestEnqueueNotification_Locale(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+	account.Locale = i18n.English
+
+	if err := edutrack.EnqueueNotification(db, account, edutrack.NotificationAbsenceRecorded, "", "Calculus I", "2026-03-02"); err != nil {
+		t.Fatalf("EnqueueNotification() error = %v", err)
+	}
+
+	var notification edutrack.Notification
+	db.Where("account_id = ?", account.ID).First(&notification)
+
+	if notification.Subject != "Absence recorded" || notification.Body != "An absence was recorded in Calculus I on 2026-03-02." {
+		t.Errorf("EnqueueNotification() = %q: %q, want the English message", notification.Subject, notification.Body)
+	}
+}
+
+func TestEnqueueNotification_Dedupe(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "admin@test.com", edutrack.RoleSecretary)
+
+	for i := 0; i < 3; i++ {
+		if err := edutrack.EnqueueNotification(db, account, edutrack.NotificationLicenseExpiring, "key", "Instituto", "2026-11-01", 14); err != nil {
+			t.Fatalf("EnqueueNotification() error = %v", err)
+		}
+	}
+
+	var count int64
+	db.Model(&edutrack.Notification{}).Where("account_id = ?", account.ID).Count(&count)
+
+	if count != 2 {
+		t.Errorf("EnqueueNotification() created %d entries, want 2", count)
+	}
+}
+
+func TestDispatcher_ProcessPending(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	if err := edutrack.EnqueueNotification(db, account, edutrack.NotificationGradePosted, "", 9.5, "Matemáticas I", "Unidad 1"); err != nil {
+		t.Fatalf("EnqueueNotification() error = %v", err)
+	}
+
+	email := &fakeChannel{name: edutrack.ChannelEmail}
+	d := NewDispatcher(db, email, &InboxChannel{DB: db})
+
+	sent, err := d.ProcessPending(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessPending() error = %v", err)
+	}
+	if sent != 2 {
+		t.Errorf("ProcessPending() sent = %d, want 2", sent)
+	}
+
+	if len(email.sent) != 1 {
+		t.Errorf("email channel received %d notifications, want 1", len(email.sent))
+	}
+
+	var inbox []edutrack.InboxMessage
+	db.Where("account_id = ?", account.ID).Find(&inbox)
+	if len(inbox) != 1 || inbox[0].Subject != "Nueva calificación publicada" {
+		t.Errorf("inbox = %+v, want a single message", inbox)
+	}
+
+	// Nothing left to deliver.
+	sent, _ = d.ProcessPending(context.Background())
+	if sent != 0 {
+		t.Errorf("ProcessPending() second run sent = %d, want 0", sent)
+	}
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	db.Create(&edutrack.NotificationPreference{
+		Event:     edutrack.NotificationGradePosted,
+		Email:     true,
+		AccountID: account.ID,
+	})
+
+	if err := edutrack.EnqueueNotification(db, account, edutrack.NotificationGradePosted, "", 9.5, "Matemáticas I", "Unidad 1"); err != nil {
+		t.Fatalf("EnqueueNotification() error = %v", err)
+	}
+
+	email := &fakeChannel{name: edutrack.ChannelEmail, err: errors.New("smtp down")}
+	d := NewDispatcher(db, email)
+	d.MaxAttempts = 3
+	d.Backoff = func(int) time.Duration { return 0 }
+
+	for i := 0; i < 5; i++ {
+		d.ProcessPending(context.Background())
+	}
+
+	var n edutrack.Notification
+	db.Where("account_id = ?", account.ID).First(&n)
+
+	if n.Status != edutrack.NotificationFailed {
+		t.Errorf("Notification.Status = %q, want %q", n.Status, edutrack.NotificationFailed)
+	}
+	if n.Attempts != 3 {
+		t.Errorf("Notification.Attempts = %d, want 3", n.Attempts)
+	}
+	if n.LastError != "smtp down" {
+		t.Errorf("Notification.LastError = %q, want %q", n.LastError, "smtp down")
+	}
+}
+
+func TestDispatcher_SchedulesRetry(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	db.Create(&edutrack.NotificationPreference{
+		Event:     edutrack.NotificationGradePosted,
+		Email:     true,
+		AccountID: account.ID,
+	})
+	edutrack.EnqueueNotification(db, account, edutrack.NotificationGradePosted, "", 9.5, "Matemáticas I", "Unidad 1")
+
+	email := &fakeChannel{name: edutrack.ChannelEmail, err: errors.New("smtp down")}
+	d := NewDispatcher(db, email)
+
+	d.ProcessPending(context.Background())
+
+	var n edutrack.Notification
+	db.Where("account_id = ?", account.ID).First(&n)
+
+	if n.Status != edutrack.NotificationPending {
+		t.Errorf("Notification.Status = %q, want %q", n.Status, edutrack.NotificationPending)
+	}
+	if !n.NextAttemptAt.After(time.Now()) {
+		t.Errorf("Notification.NextAttemptAt = %v, want a time in the future", n.NextAttemptAt)
+	}
+
+	// The retry is not due yet, so it must not be attempted again.
+	email.err = nil
+	d.ProcessPending(context.Background())
+	if len(email.sent) != 0 {
+		t.Errorf("email channel received %d notifications before backoff elapsed", len(email.sent))
+	}
+}
+
+func TestDispatcher_ClaimsEntries(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	db.Create(&edutrack.NotificationPreference{
+		Event:     edutrack.NotificationGradePosted,
+		Email:     true,
+		AccountID: account.ID,
+	})
+	edutrack.EnqueueNotification(db, account, edutrack.NotificationGradePosted, "", 9.5, "Matemáticas I", "Unidad 1")
+
+	// A second dispatcher runs while the first one is sending the entry.
+	email := &fakeChannel{name: edutrack.ChannelEmail}
+	other := NewDispatcher(db, email)
+	email.onSend = func() {
+		email.onSend = nil
+		if sent, err := other.ProcessPending(context.Background()); err != nil || sent != 0 {
+			t.Errorf("ProcessPending() while claimed = %d, %v, want 0", sent, err)
+		}
+	}
+
+	sent, err := NewDispatcher(db, email).ProcessPending(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessPending() error = %v", err)
+	}
+	if sent != 1 || len(email.sent) != 1 {
+		t.Errorf("email channel received %d notifications, want 1", len(email.sent))
+	}
+
+	var n edutrack.Notification
+	db.Where("account_id = ?", account.ID).First(&n)
+	if n.Status != edutrack.NotificationSent || n.Attempts != 1 {
+		t.Errorf("Notification = %q after %d attempts, want sent after 1", n.Status, n.Attempts)
+	}
+}
+
+func TestDispatcher_ReclaimsExpiredClaims(t *testing.T) {
+	db := setupTestDB(t)
+	tenant := createTestTenant(t, db, 30*24*time.Hour)
+	account := createTestAccount(t, db, tenant.ID, "student@test.com", edutrack.RoleStudent)
+
+	db.Create(&edutrack.NotificationPreference{
+		Event:     edutrack.NotificationGradePosted,
+		Email:     true,
+		AccountID: account.ID,
+	})
+	edutrack.EnqueueNotification(db, account, edutrack.NotificationGradePosted, "", 9.5, "Matemáticas I", "Unidad 1")
+
+	// A dispatcher claimed the entry and stopped before sending it.
+	db.Model(&edutrack.Notification{}).Where("account_id = ?", account.ID).Updates(map[string]any{
+		"status":          edutrack.NotificationSending,
+		"attempts":        1,
+		"next_attempt_at": time.Now().Add(-time.Minute),
+	})
+
+	email := &fakeChannel{name: edutrack.ChannelEmail}
+	if sent, _ := NewDispatcher(db, email).ProcessPending(context.Background()); sent != 1 {
+		t.Errorf("ProcessPending() sent = %d, want 1", sent)
+	}
+
+	var n edutrack.Notification
+	db.Where("account_id = ?", account.ID).First(&n)
+	if n.Status != edutrack.NotificationSent || n.Attempts != 2 {
+		t.Errorf("Notification = %q after %d attempts, want sent after 2", n.Status, n.Attempts)
+	}
+}
+
+func TestWebhookChannel_Send(t *testing.T) {
+	var payload WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c := &WebhookChannel{Client: server.Client()}
+	n := &edutrack.Notification{
+		Event:     edutrack.NotificationGradePosted,
+		Target:    server.URL,
+		Subject:   "Asunto",
+		AccountID: 7,
+	}
+
+	if err := c.Send(context.Background(), n); err != nil {
+		t.Fatalf("WebhookChannel.Send() error = %v", err)
+	}
+
+	if payload.Event != edutrack.NotificationGradePosted || payload.AccountID != 7 {
+		t.Errorf("WebhookChannel.Send() payload = %+v", payload)
+	}
+}
+
+func TestWebhookChannel_SendErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c := &WebhookChannel{Client: server.Client()}
+	n := &edutrack.Notification{Target: server.URL}
+
+	if err := c.Send(context.Background(), n); err == nil {
+		t.Error("WebhookChannel.Send() error = nil, want error for 500 response")
+	}
+}
+
+func TestWebhookChannel_SendPrivateAddress(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// The default client refuses the loopback address of the test server.
+	c := &WebhookChannel{}
+	n := &edutrack.Notification{Target: server.URL}
+
+	if err := c.Send(context.Background(), n); err == nil {
+		t.Error("WebhookChannel.Send() error = nil, want error for a loopback address")
+	}
+	if called {
+		t.Error("WebhookChannel.Send() reached the loopback endpoint")
+	}
+}
+
+func TestEmailChannel_Send(t *testing.T) {
+	var gotTo []string
+	var gotMsg string
+
+	c := &EmailChannel{
+		Addr: "smtp.example.com:587",
+		From: "noreply@example.com",
+		SendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			gotTo = to
+			gotMsg = string(msg)
+			return nil
+		},
+	}
+
+	n := &edutrack.Notification{
+		Target:  "student@example.com",
+		Subject: "Nueva calificación\r\nBcc: attacker@example.com",
+		Body:    "Cuerpo",
+	}
+
+	if err := c.Send(context.Background(), n); err != nil {
+		t.Fatalf("EmailChannel.Send() error = %v", err)
+	}
+
+	if len(gotTo) != 1 || gotTo[0] != "student@example.com" {
+		t.Errorf("EmailChannel.Send() to = %v", gotTo)
+	}
+
+	if strings.Contains(gotMsg, "\r\nBcc:") {
+		t.Error("EmailChannel.Send() allowed header injection through the subject")
+	}
+}
+
+func TestLicenseScanner_Scan(t *testing.T) {
+	db := setupTestDB(t)
+	expiring := createTestTenant(t, db, 5*24*time.Hour)
+	healthy := createTestTenant(t, db, 300*24*time.Hour)
+
+	secretary := createTestAccount(t, db, expiring.ID, "admin@expiring.com", edutrack.RoleSecretary)
+	createTestAccount(t, db, expiring.ID, "teacher@expiring.com", edutrack.RoleTeacher)
+	other := createTestAccount(t, db, healthy.ID, "admin@healthy.com", edutrack.RoleSecretary)
+
+	scanner := NewLicenseScanner(db)
+
+	for i := 0; i < 2; i++ {
+		notified, err := scanner.Scan(context.Background())
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		if notified != 1 {
+			t.Errorf("Scan() notified = %d, want 1", notified)
+		}
+	}
+
+	var count int64
+	db.Model(&edutrack.Notification{}).Where("account_id = ?", secretary.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("secretary has %d notifications, want 2 (email and inbox, once)", count)
+	}
+
+	db.Model(&edutrack.Notification{}).Where("account_id = ?", other.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("healthy tenant secretary has %d notifications, want 0", count)
+	}
+
+	var n edutrack.Notification
+	db.Where("account_id = ?", secretary.ID).First(&n)
+	if !strings.Contains(n.DedupeKey, fmt.Sprint(expiring.License.ID)) {
+		t.Errorf("Notification.DedupeKey = %q, want it to reference the license", n.DedupeKey)
+	}
+}
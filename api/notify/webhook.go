@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	edutrack "lahuerta.tecmm.edu.mx/edutrack"
+)
+
+// WebhookPayload is the JSON body posted to webhook endpoints.
+type WebhookPayload struct {
+	ID        uint                       `json:"id"`
+	Event     edutrack.NotificationEvent `json:"event"`
+	Subject   string                     `json:"subject"`
+	Body      string                     `json:"body"`
+	AccountID uint                       `json:"account_id"`
+	TenantID  string                     `json:"tenant_id"`
+	CreatedAt time.Time                  `json:"created_at"`
+}
+
+// WebhookChannel delivers notifications as JSON to the recipient's endpoint.
+type WebhookChannel struct {
+	// Client used to post the payload. Defaults to a client with a 10 second
+	// timeout that refuses to connect to private addresses.
+	Client *http.Client
+}
+
+// publicClient is the default webhook client. Endpoints are chosen by users,
+// so it checks each address it dials, after resolution and on redirects.
+var publicClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: refusePrivateAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// refusePrivateAddress rejects connections to loopback, private, link-local
+// and other non-public addresses.
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	addr := addrPort.Addr().Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return fmt.Errorf("webhook address %s is not public", addr)
+	}
+	return nil
+}
+
+// Name implements Channel.
+func (c *WebhookChannel) Name() edutrack.NotificationChannel {
+	return edutrack.ChannelWebhook
+}
+
+// Send implements Channel.
+func (c *WebhookChannel) Send(ctx context.Context, n *edutrack.Notification) error {
+	if n.Target == "" {
+		return fmt.Errorf("notification %d has no webhook URL", n.ID)
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		ID:        n.ID,
+		Event:     n.Event,
+		Subject:   n.Subject,
+		Body:      n.Body,
+		AccountID: n.AccountID,
+		TenantID:  n.TenantID,
+		CreatedAt: n.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.Target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.Client
+	if client == nil {
+		client = publicClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
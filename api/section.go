@@ -0,0 +1,563 @@
+package edutrack
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EnrollmentStatus represents whether a student has a place in a section.
+type EnrollmentStatus string
+
+const (
+	// EnrollmentEnrolled indicates the student attends the section.
+	EnrollmentEnrolled EnrollmentStatus = "enrolled"
+
+	// EnrollmentWaitlisted indicates the student waits for a place in a full
+	// section.
+	EnrollmentWaitlisted EnrollmentStatus = "waitlisted"
+)
+
+// Section represents a group of a subject, with its own teacher, capacity,
+// schedule and students. Large subjects run in several sections.
+type Section struct {
+	gorm.Model
+
+	// Name of the section within its subject (e.g., "A", "1TM"), unique
+	// among the sections not deleted.
+	Name string `gorm:"uniqueIndex:idx_section_name_subject_undeleted,where:deleted_at IS NULL"`
+
+	// Maximum number of enrolled students, 0 for no limit. Students enrolled
+	// in a full section wait for a place.
+	Capacity int
+
+	// Schedule of the classes (e.g., "Lun y Mié 8:00-10:00, aula 12").
+	Schedule string
+
+	// Foreign keys.
+
+	// SubjectID links to the subject. The section is deleted along with it.
+	SubjectID uint    `gorm:"uniqueIndex:idx_section_name_subject_undeleted"`
+	Subject   Subject `gorm:"constraint:OnDelete:CASCADE"`
+
+	// Teacher of the section. Deleting the teacher leaves the section
+	// unassigned.
+	TeacherID *uint
+	Teacher   *Teacher `gorm:"constraint:OnDelete:SET NULL"`
+
+	// TenantID links the section to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+
+	// Calculated fields (not stored in the database).
+
+	// Enrolled and Waitlisted count the students of the section by status.
+	Enrolled   int `gorm:"-"`
+	Waitlisted int `gorm:"-"`
+}
+
+// Enrollment links a student to a section, as enrolled or waitlisted. A
+// student is in at most one section of a subject. The enrolled students of
+// the sections of a subject are the students of the subject.
+type Enrollment struct {
+	gorm.Model
+
+	Status EnrollmentStatus `gorm:"index"`
+
+	// Foreign keys.
+
+	// SectionID links to the section.
+	SectionID uint    `gorm:"uniqueIndex:idx_enrollment_section_student"`
+	Section   Section `gorm:"constraint:OnDelete:CASCADE"`
+
+	// StudentID links to the student.
+	StudentID uint    `gorm:"uniqueIndex:idx_enrollment_section_student"`
+	Student   Student `gorm:"constraint:OnDelete:CASCADE"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+
+	// Calculated fields (not stored in the database).
+
+	// Position of a waitlisted student in the waitlist, starting at 1; 0
+	// for enrolled students.
+	Position int `gorm:"-"`
+}
+
+// SectionFilter represents the filters of FindSections. Zero values are
+// ignored.
+type SectionFilter struct {
+	SubjectID uint
+	TeacherID uint
+}
+
+// SectionCreate represents the fields of a new section.
+type SectionCreate struct {
+	Name      string
+	Capacity  int
+	Schedule  string
+	SubjectID uint
+	TeacherID *uint
+}
+
+// SectionUpdate represents the fields to update of a section. Nil fields are
+// kept.
+type SectionUpdate struct {
+	Name     *string
+	Capacity *int
+	Schedule *string
+
+	// A non-nil TeacherID pointing to nil unassigns the teacher.
+	TeacherID **uint
+}
+
+// FindSections returns the sections of the tenant of the account in the
+// context matching the filter, with their subject, teacher and counts.
+func FindSections(ctx context.Context, db *gorm.DB, filter SectionFilter) ([]Section, error) {
+	db = TenantDB(ctx, db)
+
+	query := db
+	if filter.SubjectID != 0 {
+		query = query.Where("subject_id = ?", filter.SubjectID)
+	}
+	if filter.TeacherID != 0 {
+		query = query.Where("teacher_id = ?", filter.TeacherID)
+	}
+
+	var sections []Section
+	if err := query.Preload("Subject").Preload("Teacher.Account").Order("subject_id, name").Find(&sections).Error; err != nil {
+		return nil, err
+	}
+	for i := range sections {
+		if err := countEnrollments(db, &sections[i]); err != nil {
+			return nil, err
+		}
+	}
+	return sections, nil
+}
+
+// FindSectionByID returns a section of the tenant of the account in the
+// context, with its subject, teacher and counts.
+func FindSectionByID(ctx context.Context, db *gorm.DB, id uint) (*Section, error) {
+	db = TenantDB(ctx, db)
+
+	var section Section
+	if err := db.Preload("Subject").Preload("Teacher.Account").First(&section, id).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+	if err := countEnrollments(db, &section); err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// CreateSection creates a section of a subject.
+func CreateSection(ctx context.Context, db *gorm.DB, create SectionCreate) (*Section, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	tx := TenantDB(ctx, db)
+
+	var subject Subject
+	if err := tx.First(&subject, create.SubjectID).Error; err != nil {
+		return nil, InvalidField("subject_id", FieldNotFound, "subject.not_found")
+	}
+	if err := checkSectionTeacher(tx, create.TeacherID); err != nil {
+		return nil, err
+	}
+
+	section := &Section{
+		Name:      create.Name,
+		Capacity:  create.Capacity,
+		Schedule:  create.Schedule,
+		SubjectID: subject.ID,
+		TeacherID: create.TeacherID,
+		TenantID:  account.TenantID,
+	}
+	if err := tx.Create(section).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+	return FindSectionByID(ctx, db, section.ID)
+}
+
+// UpdateSection updates the set fields of a section, matching the If-Match
+// precondition of the context. Raising the capacity enrolls the waitlisted
+// students in order, which are returned; it cannot be lowered below the
+// number of enrolled students.
+func UpdateSection(ctx context.Context, db *gorm.DB, id uint, update SectionUpdate) (*Section, []Enrollment, error) {
+	section, err := FindSectionByID(ctx, db, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := CheckIfMatch(ctx, section.Model); err != nil {
+		return nil, nil, err
+	}
+
+	if update.Name != nil {
+		section.Name = *update.Name
+	}
+	if update.Schedule != nil {
+		section.Schedule = *update.Schedule
+	}
+	if update.TeacherID != nil {
+		if err := checkSectionTeacher(TenantDB(ctx, db), *update.TeacherID); err != nil {
+			return nil, nil, err
+		}
+		section.TeacherID = *update.TeacherID
+	}
+	if update.Capacity != nil {
+		if *update.Capacity != 0 && *update.Capacity < section.Enrolled {
+			return nil, nil, Errorf(ECONFLICT, "section.capacity", section.Enrolled)
+		}
+		section.Capacity = *update.Capacity
+	}
+
+	var promoted []Enrollment
+	err = TenantDB(ctx, db).Transaction(func(tx *gorm.DB) error {
+		// Omit the associations, loaded for the response.
+		if err := tx.Omit(clause.Associations).Save(section).Error; err != nil {
+			return err
+		}
+		promoted, err = promoteWaitlist(tx, section)
+		return err
+	})
+	if err != nil {
+		return nil, nil, TranslateDBError(err)
+	}
+
+	section, err = FindSectionByID(ctx, db, section.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return section, promoted, nil
+}
+
+// DeleteSection deletes a section like DeleteRecord. The enrollments of a
+// section keep its students in the subject, so it fails with ECONFLICT
+// while the section has enrolled or waitlisted students.
+func DeleteSection(ctx context.Context, db *gorm.DB, section *Section) error {
+	return TenantDB(ctx, db).Transaction(func(tx *gorm.DB) error {
+		effect, err := sectionEnrollments(tx, section)
+		if err != nil {
+			return err
+		}
+		if effect != nil {
+			return Errorf(ECONFLICT, "record.referenced", effect.Count, effect.Type)
+		}
+		return TranslateDBError(DeleteRecord(tx, section))
+	})
+}
+
+// PreviewDeleteSection returns what DeleteSection would do to a section,
+// without deleting anything.
+func PreviewDeleteSection(ctx context.Context, db *gorm.DB, section *Section) (*DeletePreview, error) {
+	db = TenantDB(ctx, db)
+	preview, err := PreviewDelete(db, section)
+	if err != nil {
+		return nil, err
+	}
+	effect, err := sectionEnrollments(db, section)
+	if err != nil {
+		return nil, err
+	}
+	if effect != nil {
+		preview.Allowed = false
+		preview.Effects = append(preview.Effects, *effect)
+	}
+	return preview, nil
+}
+
+// sectionEnrollments returns the enrollments restricting the deletion of a
+// section, or nil if it has none.
+func sectionEnrollments(db *gorm.DB, section *Section) (*DeleteEffect, error) {
+	if err := countEnrollments(db, section); err != nil {
+		return nil, err
+	}
+	count := section.Enrolled + section.Waitlisted
+	if count == 0 {
+		return nil, nil
+	}
+	return &DeleteEffect{Type: "enrollments", Field: "section_id", Rule: DeleteRestrict, Count: count}, nil
+}
+
+// FindEnrollments returns the enrollments of a section, the enrolled
+// students first and then the waitlist in order. Only secretaries and the
+// teachers of the section or its subject can see them.
+func FindEnrollments(ctx context.Context, db *gorm.DB, section *Section) ([]Enrollment, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	if !account.IsSecretary() && !isSectionTeacher(db, account, section) {
+		return nil, &Error{Code: EFORBIDDEN}
+	}
+
+	var enrollments []Enrollment
+	err := db.Where("section_id = ?", section.ID).
+		Where("student_id IN (?)", db.Model(&Student{}).Select("id")).
+		Preload("Student.Account").Preload("Student.Career").
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE WHEN status = ? THEN 0 ELSE 1 END, id",
+			Vars: []any{EnrollmentEnrolled},
+		}}).
+		Find(&enrollments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	position := 0
+	for i := range enrollments {
+		if enrollments[i].Status == EnrollmentWaitlisted {
+			position++
+			enrollments[i].Position = position
+		}
+	}
+	return enrollments, nil
+}
+
+// EnrollStudent enrolls a student in a section, or places them at the end
+// of its waitlist if the section is full. It fails with ECONFLICT if the
+// student is already in a section of the subject.
+func EnrollStudent(ctx context.Context, db *gorm.DB, section *Section, studentID uint) (*Enrollment, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db = TenantDB(ctx, db)
+
+	var student Student
+	if err := db.First(&student, studentID).Error; err != nil {
+		return nil, InvalidField("student_id", FieldNotFound, "student.not_found")
+	}
+
+	enrollment := &Enrollment{
+		SectionID: section.ID,
+		StudentID: student.ID,
+		TenantID:  account.TenantID,
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		err := tx.Model(&Enrollment{}).
+			Where("student_id = ? AND section_id IN (?)", student.ID, subjectSections(tx, section.SubjectID)).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return Errorf(ECONFLICT, "section.enrolled")
+		}
+
+		enrolled, err := enrolledCount(tx, section.ID)
+		if err != nil {
+			return err
+		}
+		enrollment.Status = EnrollmentEnrolled
+		if section.Capacity > 0 && enrolled >= int64(section.Capacity) {
+			enrollment.Status = EnrollmentWaitlisted
+		}
+		if err := tx.Create(enrollment).Error; err != nil {
+			return err
+		}
+		if enrollment.Status == EnrollmentEnrolled {
+			return addSubjectStudent(tx, section.SubjectID, student.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	if enrollment.Status == EnrollmentWaitlisted {
+		var ahead int64
+		err := db.Model(&Enrollment{}).
+			Where("section_id = ? AND status = ? AND id <= ?", section.ID, EnrollmentWaitlisted, enrollment.ID).
+			Count(&ahead).Error
+		if err != nil {
+			return nil, err
+		}
+		enrollment.Position = int(ahead)
+	}
+	enrollment.Student = student
+	return enrollment, nil
+}
+
+// UnenrollStudent removes a student from a section or its waitlist. The
+// place left by an enrolled student goes to the first waitlisted student,
+// whose enrollment is returned if any.
+func UnenrollStudent(ctx context.Context, db *gorm.DB, section *Section, studentID uint) ([]Enrollment, error) {
+	db = TenantDB(ctx, db)
+
+	var enrollment Enrollment
+	if err := db.Where("section_id = ? AND student_id = ?", section.ID, studentID).First(&enrollment).Error; err != nil {
+		return nil, Errorf(ENOTFOUND, "section.not_enrolled")
+	}
+
+	var promoted []Enrollment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&enrollment).Error; err != nil {
+			return err
+		}
+		if enrollment.Status != EnrollmentEnrolled {
+			return nil
+		}
+		err := tx.Exec("DELETE FROM student_subjects WHERE subject_id = ? AND student_id = ?",
+			section.SubjectID, enrollment.StudentID).Error
+		if err != nil {
+			return err
+		}
+		promoted, err = promoteWaitlist(tx, section)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// FindStudentSection returns the section of a subject a student is enrolled
+// in, or nil if none.
+func FindStudentSection(db *gorm.DB, studentID, subjectID uint) (*Section, error) {
+	var sections []Section
+	err := db.Where("subject_id = ? AND id IN (?)", subjectID,
+		db.Model(&Enrollment{}).Select("section_id").
+			Where("student_id = ? AND status = ?", studentID, EnrollmentEnrolled)).
+		Limit(1).Find(&sections).Error
+	if err != nil || len(sections) == 0 {
+		return nil, err
+	}
+	return &sections[0], nil
+}
+
+// StudentSectionID returns the ID of the section of a subject a student is
+// enrolled in, or nil if none.
+func StudentSectionID(db *gorm.DB, studentID, subjectID uint) (*uint, error) {
+	section, err := FindStudentSection(db, studentID, subjectID)
+	if err != nil || section == nil {
+		return nil, err
+	}
+	return &section.ID, nil
+}
+
+// promoteWaitlist enrolls the waitlisted students of a section, in order,
+// while it has places, returning their enrollments.
+func promoteWaitlist(tx *gorm.DB, section *Section) ([]Enrollment, error) {
+	query := tx.Where("section_id = ? AND status = ?", section.ID, EnrollmentWaitlisted).
+		Where("student_id IN (?)", tx.Model(&Student{}).Select("id")).
+		Order("id")
+	if section.Capacity > 0 {
+		enrolled, err := enrolledCount(tx, section.ID)
+		if err != nil {
+			return nil, err
+		}
+		free := section.Capacity - int(enrolled)
+		if free <= 0 {
+			return nil, nil
+		}
+		query = query.Limit(free)
+	}
+
+	var promoted []Enrollment
+	if err := query.Find(&promoted).Error; err != nil {
+		return nil, err
+	}
+	for i := range promoted {
+		promoted[i].Status = EnrollmentEnrolled
+		if err := tx.Model(&promoted[i]).Update("status", EnrollmentEnrolled).Error; err != nil {
+			return nil, err
+		}
+		if err := addSubjectStudent(tx, section.SubjectID, promoted[i].StudentID); err != nil {
+			return nil, err
+		}
+	}
+	return promoted, nil
+}
+
+// addSubjectStudent adds a student to the students of a subject.
+func addSubjectStudent(tx *gorm.DB, subjectID, studentID uint) error {
+	return tx.Exec("INSERT INTO student_subjects (subject_id, student_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		subjectID, studentID).Error
+}
+
+// enrolledCount returns the number of students enrolled in a section.
+func enrolledCount(tx *gorm.DB, sectionID uint) (int64, error) {
+	var count int64
+	err := tx.Model(&Enrollment{}).
+		Where("section_id = ? AND status = ?", sectionID, EnrollmentEnrolled).
+		Where("student_id IN (?)", tx.Model(&Student{}).Select("id")).
+		Count(&count).Error
+	return count, err
+}
+
+// countEnrollments sets the counts of students of a section.
+func countEnrollments(db *gorm.DB, section *Section) error {
+	var counts []struct {
+		Status EnrollmentStatus
+		Count  int
+	}
+	err := db.Model(&Enrollment{}).Select("status, COUNT(*) AS count").
+		Where("section_id = ?", section.ID).
+		Where("student_id IN (?)", db.Model(&Student{}).Select("id")).
+		Group("status").Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+	section.Enrolled, section.Waitlisted = 0, 0
+	for _, count := range counts {
+		switch count.Status {
+		case EnrollmentEnrolled:
+			section.Enrolled = count.Count
+		case EnrollmentWaitlisted:
+			section.Waitlisted = count.Count
+		}
+	}
+	return nil
+}
+
+// checkSectionTeacher checks the teacher of a section exists, if any.
+func checkSectionTeacher(db *gorm.DB, teacherID *uint) error {
+	if teacherID == nil {
+		return nil
+	}
+	var teacher Teacher
+	if err := db.First(&teacher, *teacherID).Error; err != nil {
+		return InvalidField("teacher_id", FieldNotFound, "teacher.not_found")
+	}
+	return nil
+}
+
+// subjectSections returns the query of the IDs of the sections of a
+// subject.
+func subjectSections(db *gorm.DB, subjectID uint) *gorm.DB {
+	return db.Model(&Section{}).Select("id").Where("subject_id = ?", subjectID)
+}
+
+// taughtSubjects returns the query of the IDs of the subjects the teacher
+// account teaches, as the teacher of the subject or of one of its sections.
+func taughtSubjects(db *gorm.DB, account *Account) *gorm.DB {
+	teachers := func() *gorm.DB {
+		return db.Model(&Teacher{}).Select("id").Where("account_id = ?", account.ID)
+	}
+	sections := db.Model(&Section{}).Select("subject_id").Where("teacher_id IN (?)", teachers())
+	return db.Model(&Subject{}).Select("id").Where("teacher_id IN (?) OR id IN (?)", teachers(), sections)
+}
+
+// isSectionTeacher reports whether the account is the teacher of the
+// section or of its subject.
+func isSectionTeacher(db *gorm.DB, account *Account, section *Section) bool {
+	if !account.IsTeacher() {
+		return false
+	}
+	var teacher Teacher
+	if err := db.Where("account_id = ?", account.ID).Limit(1).Find(&teacher).Error; err != nil || teacher.ID == 0 {
+		return false
+	}
+	if section.TeacherID != nil && *section.TeacherID == teacher.ID {
+		return true
+	}
+	return section.Subject.TeacherID != nil && *section.Subject.TeacherID == teacher.ID
+}
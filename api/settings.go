@@ -0,0 +1,206 @@
+package edutrack
+
+import (
+	"strconv"
+	"time"
+	// Embeds the timezone database, as the runtime image may not have one.
+	_ "time/tzdata"
+
+	"gorm.io/gorm"
+
+	"lahuerta.tecmm.edu.mx/edutrack/i18n"
+)
+
+// DefaultTimezone is the timezone of the institutions that have not
+// configured one.
+const DefaultTimezone = "America/Mexico_City"
+
+// TenantSettings holds the configuration of an institution. Institutions
+// without settings use DefaultTenantSettings.
+type TenantSettings struct {
+	gorm.Model
+
+	// IANA name of the timezone of the institution, which decides the
+	// current day and when the days of the academic calendar start.
+	Timezone string
+
+	// Locale of the messages for accounts and requests without a language.
+	Locale i18n.Locale
+
+	// Lowest and highest grade values of the grade scale.
+	GradeMin float64
+	GradeMax float64
+
+	// Lowest average that passes a subject.
+	PassingGrade float64
+
+	// Number of late arrivals that count as one absence. Zero never converts
+	// them.
+	LatesPerAbsence int
+
+	// First day of the current academic period, at midnight UTC like the
+	// attendance dates. Reports only include the grades and attendance since
+	// this day; the zero value includes everything.
+	AcademicStart time.Time
+
+	// Days after its publication a student can appeal a grade. Zero
+	// disables appeals.
+	AppealDays int
+
+	// Extraordinary and special exam attempts a student can take to pass a
+	// failed subject. Zero disables the kind of exam.
+	ExtraordinaryAttempts int
+	SpecialAttempts       int
+
+	// Foreign keys.
+
+	// TenantID links the settings to their institution.
+	TenantID string `gorm:"uniqueIndex"`
+	Tenant   Tenant
+}
+
+// DefaultAppealDays is the appeal window of the institutions that have not
+// configured one.
+const DefaultAppealDays = 5
+
+// Exam attempt limits of the institutions that have not configured them.
+const (
+	DefaultExtraordinaryAttempts = 2
+	DefaultSpecialAttempts       = 1
+)
+
+// DefaultTenantSettings returns the settings of an institution that has not
+// configured them.
+func DefaultTenantSettings(tenantID string) TenantSettings {
+	return TenantSettings{
+		Timezone:     DefaultTimezone,
+		Locale:       i18n.Default,
+		GradeMin:     0,
+		GradeMax:     100,
+		PassingGrade: 70,
+		AppealDays:   DefaultAppealDays,
+		TenantID:     tenantID,
+
+		ExtraordinaryAttempts: DefaultExtraordinaryAttempts,
+		SpecialAttempts:       DefaultSpecialAttempts,
+	}
+}
+
+// FindTenantSettings returns the settings of an institution, falling back to
+// the defaults when none were configured.
+func FindTenantSettings(db *gorm.DB, tenantID string) (TenantSettings, error) {
+	var settings TenantSettings
+	if err := db.Where("tenant_id = ?", tenantID).Limit(1).Find(&settings).Error; err != nil {
+		return settings, err
+	}
+	if settings.ID == 0 {
+		return DefaultTenantSettings(tenantID), nil
+	}
+	return settings, nil
+}
+
+// Location returns the timezone of the institution, or UTC if it is not a
+// known one.
+func (s *TenantSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Today returns the current day in the timezone of the institution, at
+// midnight UTC like the attendance dates.
+func (s *TenantSettings) Today() time.Time {
+	year, month, day := time.Now().In(s.Location()).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// AcademicStartTime returns the instant the current academic period started
+// in the timezone of the institution, or the zero time if it is not set.
+func (s *TenantSettings) AcademicStartTime() time.Time {
+	if s.AcademicStart.IsZero() {
+		return time.Time{}
+	}
+	year, month, day := s.AcademicStart.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, s.Location())
+}
+
+// AppealDeadline returns until when a grade published at the given time can
+// be appealed.
+func (s *TenantSettings) AppealDeadline(published time.Time) time.Time {
+	return published.AddDate(0, 0, s.AppealDays)
+}
+
+// Passed reports whether an average passes a subject.
+func (s *TenantSettings) Passed(average float64) bool {
+	return average >= s.PassingGrade
+}
+
+// Absences returns the absences that count for a student with the given
+// absences and late arrivals, converting the late arrivals as configured.
+func (s *TenantSettings) Absences(absent, late int) int {
+	if s.LatesPerAbsence <= 0 {
+		return absent
+	}
+	return absent + late/s.LatesPerAbsence
+}
+
+// Validate checks the settings are consistent, returning an EINVALID error
+// with the invalid fields.
+func (s *TenantSettings) Validate() error {
+	var invalid []FieldError
+	if _, err := time.LoadLocation(s.Timezone); err != nil || s.Timezone == "" {
+		invalid = append(invalid, FieldError{Field: "timezone", Code: FieldInvalidChoice, Message: "settings.timezone.invalid"})
+	}
+	if s.GradeMax <= s.GradeMin {
+		invalid = append(invalid, FieldError{Field: "grade_max", Code: FieldTooSmall, Message: "field.too_small", Args: []any{formatGrade(s.GradeMin)}})
+	} else if field := s.gradeOutOfScale("passing_grade", s.PassingGrade); field != nil {
+		invalid = append(invalid, *field)
+	}
+	if s.LatesPerAbsence < 0 {
+		invalid = append(invalid, FieldError{Field: "lates_per_absence", Code: FieldTooSmall, Message: "field.too_small", Args: []any{"0"}})
+	}
+	for _, count := range []struct {
+		field string
+		value int
+	}{
+		{"appeal_days", s.AppealDays},
+		{"extraordinary_attempts", s.ExtraordinaryAttempts},
+		{"special_attempts", s.SpecialAttempts},
+	} {
+		if count.value < 0 {
+			invalid = append(invalid, FieldError{Field: count.field, Code: FieldTooSmall, Message: "field.too_small", Args: []any{"0"}})
+		}
+	}
+	if invalid != nil {
+		return Invalid(invalid...)
+	}
+	return nil
+}
+
+// ValidateGrade checks a grade value is within the grade scale, returning an
+// EINVALID error for the given field otherwise.
+func (s *TenantSettings) ValidateGrade(field string, value float64) error {
+	if invalid := s.gradeOutOfScale(field, value); invalid != nil {
+		return Invalid(*invalid)
+	}
+	return nil
+}
+
+// gradeOutOfScale returns the field error of a grade value outside the grade
+// scale, or nil if it is within it.
+func (s *TenantSettings) gradeOutOfScale(field string, value float64) *FieldError {
+	if value < s.GradeMin {
+		return &FieldError{Field: field, Code: FieldTooSmall, Message: "field.too_small", Args: []any{formatGrade(s.GradeMin)}}
+	}
+	if value > s.GradeMax {
+		return &FieldError{Field: field, Code: FieldTooLarge, Message: "field.too_large", Args: []any{formatGrade(s.GradeMax)}}
+	}
+	return nil
+}
+
+// formatGrade formats a grade value for a message, without trailing zeros.
+func formatGrade(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,59 @@
+package edutrack
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTenantSettings_Absences(t *testing.T) {
+	tests := []struct {
+		name            string
+		latesPerAbsence int
+		absent, late    int
+		want            int
+	}{
+		{"no conversion", 0, 2, 5, 2},
+		{"three lates", 3, 2, 5, 3},
+		{"every late", 1, 0, 4, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := TenantSettings{LatesPerAbsence: tt.latesPerAbsence}
+			if got := settings.Absences(tt.absent, tt.late); got != tt.want {
+				t.Errorf("Absences(%d, %d) = %d, want %d", tt.absent, tt.late, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantSettings_ValidateGrade(t *testing.T) {
+	settings := DefaultTenantSettings("tenant")
+	settings.GradeMin, settings.GradeMax = 5, 10
+
+	for value, code := range map[float64]string{4.9: FieldTooSmall, 5: "", 10: "", 10.5: FieldTooLarge} {
+		err := settings.ValidateGrade("value", value)
+		if code == "" {
+			if err != nil {
+				t.Errorf("ValidateGrade(%v) error = %v, want nil", value, err)
+			}
+			continue
+		}
+		if fields := ErrorFields(err, ""); len(fields) != 1 || fields[0].Code != code {
+			t.Errorf("ValidateGrade(%v) fields = %+v, want %s", value, fields, code)
+		}
+	}
+}
+
+func TestTenantSettings_AcademicStartTime(t *testing.T) {
+	settings := DefaultTenantSettings("tenant")
+	if got := settings.AcademicStartTime(); !got.IsZero() {
+		t.Errorf("AcademicStartTime() = %v, want zero", got)
+	}
+
+	settings.AcademicStart = time.Date(2026, 8, 24, 0, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 8, 24, 6, 0, 0, 0, time.UTC)
+	if got := settings.AcademicStartTime(); !got.Equal(want) {
+		t.Errorf("AcademicStartTime() = %v, want midnight in Mexico City %v", got, want)
+	}
+}
@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3-compatible backend (AWS S3, MinIO, ...).
+type S3Config struct {
+	// Base URL of the service (e.g., "https://s3.us-east-1.amazonaws.com"
+	// or "http://localhost:9000").
+	Endpoint string
+
+	// Region the requests are signed for (e.g., "us-east-1").
+	Region string
+
+	// Bucket storing the objects.
+	Bucket string
+
+	// Credentials of the access key.
+	AccessKey string
+	SecretKey string
+
+	// Whether the bucket goes in the path (http://host/bucket/key), as
+	// MinIO expects, instead of in the host (http://bucket.host/key).
+	PathStyle bool
+}
+
+// S3 stores objects in a bucket of an S3-compatible service, signing the
+// requests with AWS Signature Version 4. Download URLs are presigned S3
+// URLs, so files are downloaded from the service directly.
+type S3 struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+
+	// now returns the signing time; replaced in tests.
+	now func() time.Time
+}
+
+// NewS3 returns an S3 backend with the given configuration.
+func NewS3(config S3Config) (*S3, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: invalid S3 endpoint %q", config.Endpoint)
+	}
+	if config.Bucket == "" || config.Region == "" {
+		return nil, errors.New("storage: S3 bucket and region are required")
+	}
+	return &S3{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+// objectURL returns the URL of an object, without query.
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if s.config.PathStyle {
+		u.Path += "/" + s.config.Bucket + "/" + key
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path += "/" + key
+	}
+	return &u
+}
+
+// Put implements Storage.
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if !validKey(key) {
+		return fmt.Errorf("storage: invalid key %q", key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), body)
+	if err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get implements Storage.
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !validKey(key) {
+		return nil, fmt.Errorf("storage: invalid key %q", key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("storage: %w", err)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete implements Storage.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return fmt.Errorf("storage: invalid key %q", key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	resp, err := s.do(req)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL implements Storage with a presigned GET URL.
+func (s *S3) SignedURL(ctx context.Context, key string, lifetime time.Duration) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	u := s.objectURL(key)
+	now := s.now().UTC()
+
+	query := url.Values{
+		"X-Amz-Algorithm":     {sigV4Algorithm},
+		"X-Amz-Credential":    {s.config.AccessKey + "/" + s.scope(now)},
+		"X-Amz-Date":          {now.Format(amzDateFormat)},
+		"X-Amz-Expires":       {strconv.Itoa(int(lifetime.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	canonical := canonicalRequest(http.MethodGet, u.EscapedPath(), query, u.Host, nil, []string{"host"}, unsignedPayload)
+	u.RawQuery = canonicalQuery(query) + "&X-Amz-Signature=" + s.signature(now, canonical)
+	return u.String(), nil
+}
+
+// do signs and sends a request, returning ErrNotFound for missing objects
+// and an error with the status for any other failure.
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	s.sign(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, fmt.Errorf("storage: S3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(message)))
+}
+
+// AWS Signature Version 4 constants.
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// sign adds the Authorization header of AWS Signature Version 4 to a
+// request. The payload is not signed, so bodies are streamed.
+func (s *S3) sign(req *http.Request) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	canonical := canonicalRequest(req.Method, req.URL.EscapedPath(), req.URL.Query(), req.URL.Host, req.Header, signed, unsignedPayload)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.config.AccessKey, s.scope(now), strings.Join(signed, ";"), s.signature(now, canonical)))
+}
+
+// scope returns the credential scope of a signature made at the given time.
+func (s *S3) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+// signature returns the signature of a canonical request made at the given
+// time.
+func (s *S3) signature(t time.Time, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := sigV4Algorithm + "\n" + t.Format(amzDateFormat) + "\n" + s.scope(t) + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalRequest builds the canonical request of AWS Signature Version 4.
+// signed lists the lowercase names of the signed headers, sorted; the host
+// header is taken from host.
+func canonicalRequest(method, escapedPath string, query url.Values, host string, header http.Header, signed []string, payloadHash string) string {
+	var headers strings.Builder
+	for _, name := range signed {
+		value := header.Get(name)
+		if name == "host" {
+			value = host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	if escapedPath == "" {
+		escapedPath = "/"
+	}
+	return strings.Join([]string{
+		method,
+		escapedPath,
+		canonicalQuery(query),
+		headers.String(),
+		strings.Join(signed, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// canonicalQuery encodes a query sorted by name, escaping as AWS expects.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; isUnreserved(rune(c)) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with the given key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,212 @@
+// Package storage stores the files uploaded to EduTrack, such as logos and
+// avatars, in a pluggable backend: the local disk or an S3-compatible
+// object store.
+//
+// Objects are addressed by keys scoped to their institution (see NewKey)
+// and downloaded through signed URLs that expire, so they can be linked
+// from the browser without credentials.
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist.
+	ErrNotFound = errors.New("storage: object not found")
+
+	// ErrTooLarge is returned when a file exceeds the size limit.
+	ErrTooLarge = errors.New("storage: file too large")
+
+	// ErrContentType is returned when the type of a file is not allowed.
+	ErrContentType = errors.New("storage: content type not allowed")
+
+	// ErrInvalidSignature is returned when a signed URL was not signed by
+	// the Signer or has expired.
+	ErrInvalidSignature = errors.New("storage: invalid or expired signature")
+)
+
+// Storage is a backend storing files by key.
+type Storage interface {
+	// Put stores an object, replacing any object with the same key.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+
+	// Get returns the content of an object, or ErrNotFound. The caller
+	// must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete deletes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// SignedURL returns a URL to download an object without credentials
+	// until the given lifetime elapses.
+	SignedURL(ctx context.Context, key string, lifetime time.Duration) (string, error)
+}
+
+// Limits restricts the files accepted for a use.
+type Limits struct {
+	// Maximum size in bytes.
+	MaxSize int64
+
+	// Allowed media types, detected from the content of the file.
+	ContentTypes []string
+}
+
+// ImageLimits accepts the images used as logos and avatars.
+var ImageLimits = Limits{
+	MaxSize:      2 << 20,
+	ContentTypes: []string{"image/png", "image/jpeg", "image/webp"},
+}
+
+// DocumentLimits accepts the supporting documents of the absence
+// justifications, scanned or photographed.
+var DocumentLimits = Limits{
+	MaxSize:      5 << 20,
+	ContentTypes: []string{"application/pdf", "image/png", "image/jpeg", "image/webp"},
+}
+
+// extensions maps the accepted media types to the extension of their keys.
+var extensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// Read reads a file checking it against the limits. It returns the content
+// and the media type detected from it, ignoring the type declared by the
+// client, or ErrTooLarge or ErrContentType.
+func (l Limits) Read(r io.Reader) ([]byte, string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, l.MaxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > l.MaxSize {
+		return nil, "", ErrTooLarge
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if !slices.Contains(l.ContentTypes, contentType) {
+		return nil, "", ErrContentType
+	}
+	return data, contentType, nil
+}
+
+// NewKey returns a new random key for an object of an institution, grouped
+// by kind (e.g., "logo" or "avatar") and with the extension of its media
+// type: tenants/<tenant>/<kind>/<random>.<ext>.
+func NewKey(tenantID, kind, contentType string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("storage: failed to generate key: %w", err)
+	}
+	return path.Join("tenants", tenantID, kind, hex.EncodeToString(b)+extensions[contentType]), nil
+}
+
+// TenantOf returns the institution a key belongs to, if it was built by
+// NewKey.
+func TenantOf(key string) (string, bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) < 3 || parts[0] != "tenants" || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// ContentType returns the media type of an object from the extension of
+// its key.
+func ContentType(key string) string {
+	ext := path.Ext(key)
+	for contentType, e := range extensions {
+		if e == ext {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// validKey reports whether a key is safe to use as a path and in URLs:
+// relative, without empty, "." or ".." segments and only with unreserved
+// URI characters.
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, c := range segment {
+			if !isUnreserved(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isUnreserved reports whether a character is unreserved in URIs.
+func isUnreserved(c rune) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '_' || c == '.' || c == '~'
+}
+
+// Signer signs the download URLs of objects served by the API itself, as
+// the local backend does, with an HMAC of the key and expiry.
+type Signer struct {
+	secret  []byte
+	baseURL string
+}
+
+// NewSigner returns a Signer with the given secret, whose URLs point to
+// baseURL followed by the key (e.g., "/files" gives "/files/<key>?...").
+func NewSigner(secret []byte, baseURL string) *Signer {
+	// Derive a key of its own, so signatures are not valid elsewhere.
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("edutrack storage"))
+	return &Signer{secret: mac.Sum(nil), baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// URL returns the URL to download the object with the given key until
+// expires.
+func (s *Signer) URL(key string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{"expires": {exp}, "signature": {s.signature(key, exp)}}
+	return s.baseURL + "/" + key + "?" + query.Encode()
+}
+
+// Verify checks the expires and signature query parameters of a download
+// URL of the object with the given key, returning ErrInvalidSignature if it
+// was not signed by s or has expired.
+func (s *Signer) Verify(key string, query url.Values, now time.Time) error {
+	exp := query.Get("expires")
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() > expires {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(s.signature(key, exp))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// signature returns the HMAC of a key and its expiry.
+func (s *Signer) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// png is the signature of a PNG image, enough to detect its type.
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestLimits_Read(t *testing.T) {
+	limits := Limits{MaxSize: 32, ContentTypes: []string{"image/png"}}
+
+	data, contentType, err := limits.Read(bytes.NewReader(png))
+	if err != nil || contentType != "image/png" || !bytes.Equal(data, png) {
+		t.Errorf("Read(png) = %q, %q, %v", data, contentType, err)
+	}
+
+	if _, _, err := limits.Read(strings.NewReader("<html><body>")); err != ErrContentType {
+		t.Errorf("Read(html) error = %v, want ErrContentType", err)
+	}
+	if _, _, err := limits.Read(bytes.NewReader(append(png, make([]byte, 32)...))); err != ErrTooLarge {
+		t.Errorf("Read(large) error = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	key, err := NewKey("a1b2c3d4", "logo", "image/png")
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+	if !strings.HasPrefix(key, "tenants/a1b2c3d4/logo/") || !strings.HasSuffix(key, ".png") || !validKey(key) {
+		t.Errorf("NewKey() = %q", key)
+	}
+	if other, _ := NewKey("a1b2c3d4", "logo", "image/png"); other == key {
+		t.Errorf("NewKey() returned %q twice", key)
+	}
+
+	if tenant, ok := TenantOf(key); !ok || tenant != "a1b2c3d4" {
+		t.Errorf("TenantOf(%q) = %q, %v", key, tenant, ok)
+	}
+	if _, ok := TenantOf("logo.png"); ok {
+		t.Error("TenantOf(logo.png) ok = true, want false")
+	}
+	if got := ContentType(key); got != "image/png" {
+		t.Errorf("ContentType(%q) = %q", key, got)
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"tenants/a/logo/x.png": true,
+		"":                     false,
+		"/etc/passwd":          false,
+		"tenants/../secret":    false,
+		"tenants//x":           false,
+		"tenants/a b":          false,
+		"tenants/a?b":          false,
+	} {
+		if got := validKey(key); got != want {
+			t.Errorf("validKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestSigner(t *testing.T) {
+	signer := NewSigner([]byte("secret"), "/files/")
+	now := time.Unix(1700000000, 0)
+	key := "tenants/a/logo/x.png"
+
+	raw := signer.URL(key, now.Add(time.Minute))
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("URL() = %q: %v", raw, err)
+	}
+	if u.Path != "/files/"+key {
+		t.Errorf("Path = %q, want /files/%s", u.Path, key)
+	}
+	if err := signer.Verify(key, u.Query(), now); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		signer *Signer
+		key    string
+		now    time.Time
+	}{
+		{"expired", signer, key, now.Add(2 * time.Minute)},
+		{"other key", signer, "tenants/b/logo/x.png", now},
+		{"other secret", NewSigner([]byte("other"), "/files"), key, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.signer.Verify(tt.key, u.Query(), tt.now); err != ErrInvalidSignature {
+				t.Errorf("Verify() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestLocal(t *testing.T) {
+	local := NewLocal(t.TempDir(), NewSigner([]byte("secret"), "/files"))
+	ctx := context.Background()
+	key := "tenants/a/logo/x.png"
+
+	if err := local.Put(ctx, key, bytes.NewReader(png), int64(len(png)), "image/png"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	body, err := local.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if !bytes.Equal(data, png) {
+		t.Errorf("Get() = %q, want %q", data, png)
+	}
+
+	url, err := local.SignedURL(ctx, key, time.Minute)
+	if err != nil || !strings.HasPrefix(url, "/files/"+key+"?") {
+		t.Errorf("SignedURL() = %q, %v", url, err)
+	}
+
+	if err := local.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := local.Get(ctx, key); err != ErrNotFound {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+	if err := local.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing object error = %v", err)
+	}
+	if err := local.Put(ctx, "../escape.png", bytes.NewReader(png), 0, "image/png"); err == nil {
+		t.Error("Put() with an invalid key error = nil")
+	}
+}
@@ -1,13 +1,18 @@
 package edutrack
 
-import "gorm.io/gorm"
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
 
 // Student represents a student enrolled in the institution.
 type Student struct {
 	gorm.Model
 
-	// Unique student ID or registration number within the institution.
-	StudentID string `gorm:"uniqueIndex:idx_student_tenant"`
+	// Unique student ID or registration number within the institution. Deleted
+	// students do not keep it.
+	StudentID string `gorm:"uniqueIndex:idx_student_tenant_undeleted,where:deleted_at IS NULL"`
 
 	// The current semester of the student.
 	Semester int `gorm:"not null;default:1"`
@@ -15,19 +20,20 @@ type Student struct {
 	// Foreign keys.
 
 	// TenantID links the student to an institution.
-	TenantID string `gorm:"uniqueIndex:idx_student_tenant"`
+	TenantID string `gorm:"uniqueIndex:idx_student_tenant_undeleted"`
 	Tenant   Tenant
 
-	// AccountID links the student to their login account.
+	// AccountID links the student to their login account. The student is
+	// deleted along with it.
 	AccountID uint
-	Account   Account
+	Account   Account `gorm:"constraint:OnDelete:CASCADE"`
 
 	// CareerID links the student to the career they are enrolled in.
 	CareerID uint
 	Career   Career
 
 	// Subjects this student is attending.
-	Subjects []Subject `gorm:"many2many:student_subjects;"`
+	Subjects []Subject `gorm:"many2many:student_subjects;constraint:OnDelete:CASCADE"`
 
 	// Calculated fields (not stored in the database).
 
@@ -36,6 +42,9 @@ type Student struct {
 
 	// SubjectAverages holds the average grade for each subject.
 	SubjectAverages []SubjectAverage `gorm:"-"`
+
+	// Attendance summarizes the attendance records of the student.
+	Attendance AttendanceSummary `gorm:"-"`
 }
 
 // SubjectAverage is a helper struct to hold the average grade for a subject.
@@ -43,25 +52,63 @@ type SubjectAverage struct {
 	SubjectID   uint    `json:"subjectId"`
 	SubjectName string  `json:"subjectName"`
 	Average     float64 `json:"average"`
+
+	// Final grade of the subject, from the last graded exam attempt or the
+	// average, and the kind of attempt it comes from.
+	Final   float64  `json:"final"`
+	Attempt ExamKind `json:"attempt"`
+
+	// Whether the final grade reaches the passing grade of the institution.
+	Passed bool `json:"passed"`
 }
 
-// CalculateAverages computes the overall average and per-subject averages for the student.
-func (s *Student) CalculateAverages(db *gorm.DB) {
+// CalculateReport computes the overall average, per-subject averages and
+// final grades, and attendance summary of the student, following the
+// settings of their institution: only the grades, exam attempts and
+// attendance of the current academic period count, and late arrivals are
+// converted to absences.
+func (s *Student) CalculateReport(db *gorm.DB) error {
+	settings, err := FindTenantSettings(db, s.TenantID)
+	if err != nil {
+		return err
+	}
+
 	var grades []Grade
-	// Fetch all grades for the student, preloading the topic and its subject.
-	db.Preload("Topic.Subject").Where("student_id = ?", s.ID).Find(&grades)
+	// Fetch the grades of the period, preloading the topic and its subject.
+	query := db.Preload("Topic.Subject").Where("student_id = ? AND status <> ?", s.ID, GradeDraft)
+	if !settings.AcademicStart.IsZero() {
+		query = query.Where("created_at >= ?", settings.AcademicStartTime())
+	}
+	if err := query.Find(&grades).Error; err != nil {
+		return err
+	}
 
-	if len(grades) == 0 {
+	// Fetch the graded exam attempts of the period, in the order taken.
+	var attempts []ExamAttempt
+	query = db.Preload("Subject").Where("student_id = ? AND value IS NOT NULL", s.ID)
+	if !settings.AcademicStart.IsZero() {
+		query = query.Where("date >= ?", settings.AcademicStart)
+	}
+	if err := query.Order("id").Find(&attempts).Error; err != nil {
+		return err
+	}
+
+	if err := s.calculateAttendance(db, &settings); err != nil {
+		return err
+	}
+
+	if len(grades) == 0 && len(attempts) == 0 {
 		s.OverallAverage = 0
 		s.SubjectAverages = []SubjectAverage{}
-		return
+		return nil
 	}
 
 	var totalSum float64
 	subjectGrades := make(map[uint]struct {
-		sum   float64
-		count int
-		name  string
+		sum      float64
+		count    int
+		name     string
+		attempts []ExamAttempt
 	})
 
 	for _, grade := range grades {
@@ -76,19 +123,366 @@ func (s *Student) CalculateAverages(db *gorm.DB) {
 			subjectGrades[grade.Topic.SubjectID] = subjectData
 		}
 	}
+	for _, attempt := range attempts {
+		subjectData := subjectGrades[attempt.SubjectID]
+		subjectData.name = attempt.Subject.Name
+		subjectData.attempts = append(subjectData.attempts, attempt)
+		subjectGrades[attempt.SubjectID] = subjectData
+	}
 
 	// Calculate overall average
-	s.OverallAverage = totalSum / float64(len(grades))
+	if len(grades) > 0 {
+		s.OverallAverage = totalSum / float64(len(grades))
+	}
 
-	// Calculate per-subject averages
+	// Calculate per-subject averages and final grades
 	s.SubjectAverages = make([]SubjectAverage, 0, len(subjectGrades))
 	for subjectID, data := range subjectGrades {
+		var average float64
 		if data.count > 0 {
-			s.SubjectAverages = append(s.SubjectAverages, SubjectAverage{
-				SubjectID:   subjectID,
-				SubjectName: data.name,
-				Average:     data.sum / float64(data.count),
-			})
+			average = data.sum / float64(data.count)
 		}
+		result := settings.FinalResult(average, data.attempts)
+		s.SubjectAverages = append(s.SubjectAverages, SubjectAverage{
+			SubjectID:   subjectID,
+			SubjectName: data.name,
+			Average:     average,
+			Final:       result.Value,
+			Attempt:     result.Kind,
+			Passed:      result.Passed,
+		})
+	}
+	return nil
+}
+
+// calculateAttendance counts the attendance records of the student in the
+// current academic period.
+func (s *Student) calculateAttendance(db *gorm.DB, settings *TenantSettings) error {
+	var counts []struct {
+		Status AttendanceStatus
+		Count  int
+	}
+	query := db.Model(&Attendance{}).Select("status, COUNT(*) AS count").Where("student_id = ?", s.ID)
+	if !settings.AcademicStart.IsZero() {
+		query = query.Where("date >= ?", settings.AcademicStart)
+	}
+	if err := query.Group("status").Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	s.Attendance = AttendanceSummary{}
+	for _, count := range counts {
+		switch count.Status {
+		case AttendancePresent:
+			s.Attendance.Present = count.Count
+		case AttendanceAbsent:
+			s.Attendance.Absent = count.Count
+		case AttendanceLate:
+			s.Attendance.Late = count.Count
+		case AttendanceExcused:
+			s.Attendance.Excused = count.Count
+		}
+	}
+	s.Attendance.Absences = settings.Absences(s.Attendance.Absent, s.Attendance.Late)
+	return nil
+}
+
+// StudentService manages the students of the tenant of the account in the
+// context.
+type StudentService interface {
+	// FindStudents returns the students visible to the account: their own
+	// record for students, the linked ones for guardians and the ones
+	// matching the filter for everyone else.
+	FindStudents(ctx context.Context, filter StudentFilter) ([]Student, error)
+
+	// FindStudentByID returns a student with their averages and attendance.
+	FindStudentByID(ctx context.Context, id uint) (*Student, error)
+
+	// CreateStudent creates a student along with their login account.
+	CreateStudent(ctx context.Context, create StudentCreate) (*Student, error)
+
+	// UpdateStudent updates the set fields of a student. It fails with
+	// EPRECONDITION if the student does not match the If-Match precondition
+	// of the context or is modified concurrently.
+	UpdateStudent(ctx context.Context, id uint, update StudentUpdate) (*Student, error)
+
+	// DeleteStudent deletes a student along with their grades and
+	// attendance, with the same precondition as UpdateStudent.
+	DeleteStudent(ctx context.Context, id uint) error
+
+	// PreviewDeleteStudent returns what DeleteStudent would do, without
+	// deleting anything.
+	PreviewDeleteStudent(ctx context.Context, id uint) (*DeletePreview, error)
+}
+
+// StudentFilter represents the filters of FindStudents. Zero values are
+// ignored.
+type StudentFilter struct {
+	CareerID  uint
+	Semester  int
+	StudentID string // Partial match.
+	Name      string // Partial match.
+}
+
+// StudentCreate represents the fields of a new student.
+type StudentCreate struct {
+	StudentID string
+	Name      string
+	Email     string
+	Password  string
+	CareerID  uint
+	Semester  int
+}
+
+// StudentUpdate represents the fields to update of a student. Nil fields
+// are kept.
+type StudentUpdate struct {
+	StudentID *string
+	CareerID  *uint
+	Semester  *int
+}
+
+// NewStudentService returns a StudentService backed by the database.
+func NewStudentService(db *gorm.DB) StudentService {
+	return &studentService{db: db}
+}
+
+type studentService struct {
+	db *gorm.DB
+}
+
+func (s *studentService) FindStudents(ctx context.Context, filter StudentFilter) ([]Student, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db := TenantDB(ctx, contextDB(ctx, s.db))
+
+	var students []Student
+	switch {
+	case account.IsStudent():
+		// Students can only see their own information.
+		own, err := findOwnStudent(db, account)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.Preload("Account").Preload("Career").First(&students, own.ID).Error; err != nil {
+			return nil, TranslateDBError(err)
+		}
+	case account.IsGuardian():
+		// Guardians can only see their linked students.
+		ids, err := GuardianStudentIDs(db, account.ID, account.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.Preload("Account").Preload("Career").Where("id IN ?", ids).Find(&students).Error; err != nil {
+			return nil, err
+		}
+	default:
+		// Teachers and secretaries can see all students with filters.
+		query := db
+		if filter.CareerID != 0 {
+			query = query.Where("career_id = ?", filter.CareerID)
+		}
+		if filter.Semester != 0 {
+			query = query.Where("semester = ?", filter.Semester)
+		}
+		if filter.StudentID != "" {
+			query = query.Where("student_id LIKE ?", "%"+filter.StudentID+"%")
+		}
+		if filter.Name != "" {
+			query = query.Joins("Account").Where("Account.name LIKE ?", "%"+filter.Name+"%")
+		}
+		if err := query.Preload("Account").Preload("Career").Find(&students).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range students {
+		if err := students[i].CalculateReport(db); err != nil {
+			return nil, err
+		}
+	}
+	return students, nil
+}
+
+func (s *studentService) FindStudentByID(ctx context.Context, id uint) (*Student, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+	db := contextDB(ctx, s.db).WithContext(ctx)
+
+	if account.IsStudent() {
+		// Students can only access their own student record.
+		own, err := findOwnStudent(db, account)
+		if err != nil {
+			return nil, err
+		}
+		if own.ID != id {
+			return nil, &Error{Code: EFORBIDDEN}
+		}
+	}
+
+	if account.IsGuardian() {
+		// Guardians can only access their linked students.
+		linked, err := IsGuardianOf(ctx, db, account.ID, id)
+		if err != nil {
+			return nil, err
+		}
+		if !linked {
+			return nil, &Error{Code: EFORBIDDEN}
+		}
+	}
+
+	var student Student
+	if err := TenantDB(ctx, db).Preload("Account").Preload("Career").First(&student, id).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	if err := student.CalculateReport(db); err != nil {
+		return nil, err
+	}
+	return &student, nil
+}
+
+func (s *studentService) CreateStudent(ctx context.Context, create StudentCreate) (*Student, error) {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return nil, &Error{Code: EUNAUTHORIZED}
+	}
+
+	var invalid []FieldError
+	for _, required := range []struct{ field, value, message string }{
+		{"student_id", create.StudentID, "student.student_id.missing"},
+		{"name", create.Name, "student.name.missing"},
+		{"email", create.Email, "student.email.missing"},
+		{"password", create.Password, "student.password.missing"},
+	} {
+		if required.value == "" {
+			invalid = append(invalid, FieldError{Field: required.field, Code: FieldRequired, Message: required.message})
+		}
+	}
+	if create.Semester <= 0 {
+		invalid = append(invalid, semesterTooSmall)
+	}
+	if invalid != nil {
+		return nil, Invalid(invalid...)
+	}
+
+	hashedPassword, err := HashPassword(create.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	student := &Student{
+		StudentID: create.StudentID,
+		CareerID:  create.CareerID,
+		Semester:  create.Semester,
+		TenantID:  account.TenantID,
+	}
+
+	// Create the account and the student linked to it atomically.
+	err = TenantDB(ctx, contextDB(ctx, s.db)).Transaction(func(tx *gorm.DB) error {
+		newAccount := &Account{
+			Name:     create.Name,
+			Email:    create.Email,
+			Password: hashedPassword,
+			Role:     RoleStudent,
+			Active:   true,
+			TenantID: account.TenantID,
+		}
+		if err := tx.Create(newAccount).Error; err != nil {
+			return err
+		}
+
+		student.AccountID = newAccount.ID
+		return tx.Create(student).Error
+	})
+	if err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	// Reload with the account and career.
+	return findTenantStudent(ctx, contextDB(ctx, s.db).WithContext(ctx), student.ID)
+}
+
+func (s *studentService) UpdateStudent(ctx context.Context, id uint, update StudentUpdate) (*Student, error) {
+	db := contextDB(ctx, s.db).WithContext(ctx)
+
+	student, err := findTenantStudent(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckIfMatch(ctx, student.Model); err != nil {
+		return nil, err
+	}
+
+	if update.StudentID != nil {
+		student.StudentID = *update.StudentID
+	}
+	if update.CareerID != nil {
+		student.CareerID = *update.CareerID
+	}
+	if update.Semester != nil {
+		if *update.Semester <= 0 {
+			return nil, Invalid(semesterTooSmall)
+		}
+		student.Semester = *update.Semester
+	}
+
+	if err := TenantDB(ctx, contextDB(ctx, s.db)).Save(student).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+
+	// Reload, as the career may have changed.
+	return findTenantStudent(ctx, db, student.ID)
+}
+
+func (s *studentService) DeleteStudent(ctx context.Context, id uint) error {
+	db := contextDB(ctx, s.db).WithContext(ctx)
+
+	student, err := findTenantStudent(ctx, db, id)
+	if err != nil {
+		return err
+	}
+	if err := CheckIfMatch(ctx, student.Model); err != nil {
+		return err
+	}
+	return TranslateDBError(DeleteRecord(TenantDB(ctx, contextDB(ctx, s.db)), student))
+}
+
+func (s *studentService) PreviewDeleteStudent(ctx context.Context, id uint) (*DeletePreview, error) {
+	db := contextDB(ctx, s.db).WithContext(ctx)
+
+	student, err := findTenantStudent(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckIfMatch(ctx, student.Model); err != nil {
+		return nil, err
+	}
+	return PreviewDelete(TenantDB(ctx, contextDB(ctx, s.db)), student)
+}
+
+// semesterTooSmall rejects a semester that is not positive.
+var semesterTooSmall = FieldError{Field: "semester", Code: FieldTooSmall, Message: "student.semester.too_small"}
+
+// findTenantStudent returns a student of the tenant of the account in the
+// context.
+func findTenantStudent(ctx context.Context, db *gorm.DB, id uint) (*Student, error) {
+	var student Student
+	if err := TenantDB(ctx, db).First(&student, id).Error; err != nil {
+		return nil, TranslateDBError(err)
+	}
+	return &student, nil
+}
+
+// findOwnStudent returns the student record of a student account.
+func findOwnStudent(db *gorm.DB, account *Account) (*Student, error) {
+	var student Student
+	if err := db.Where("account_id = ? AND tenant_id = ?", account.ID, account.TenantID).First(&student).Error; err != nil {
+		return nil, TranslateDBError(err)
 	}
+	return &student, nil
 }
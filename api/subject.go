@@ -10,8 +10,9 @@ type Subject struct {
 	// Name of the subject (e.g., "Matemáticas I", "Programación").
 	Name string
 
-	// Unique code for the subject within a career and tenant.
-	Code string `gorm:"uniqueIndex:idx_subject_code_career_tenant"`
+	// Unique code for the subject within a career and tenant, among the
+	// subjects not deleted.
+	Code string `gorm:"uniqueIndex:idx_subject_code_career_tenant_undeleted,where:deleted_at IS NULL"`
 
 	// Description of the subject content.
 	Description string
@@ -25,20 +26,21 @@ type Subject struct {
 	// Foreign keys.
 
 	// TenantID links the subject to an institution.
-	TenantID string `gorm:"uniqueIndex:idx_subject_code_career_tenant"`
+	TenantID string `gorm:"uniqueIndex:idx_subject_code_career_tenant_undeleted"`
 	Tenant   Tenant
 
 	// Career this subject belongs to.
-	CareerID uint `gorm:"uniqueIndex:idx_subject_code_career_tenant"`
+	CareerID uint `gorm:"uniqueIndex:idx_subject_code_career_tenant_undeleted"`
 	Career   Career
 
-	// Teacher assigned to this subject.
+	// Teacher assigned to this subject. Deleting the teacher leaves the
+	// subject unassigned.
 	TeacherID *uint
-	Teacher   *Teacher
+	Teacher   *Teacher `gorm:"constraint:OnDelete:SET NULL"`
 
 	// Students attending this subject.
-	Students []Student `gorm:"many2many:student_subjects;"`
+	Students []Student `gorm:"many2many:student_subjects;constraint:OnDelete:CASCADE"`
 
-	// Topics that belong to this subject.
-	Topics []Topic
+	// Topics that belong to this subject, deleted along with it.
+	Topics []Topic `gorm:"constraint:OnDelete:CASCADE"`
 }
@@ -11,10 +11,11 @@ type Teacher struct {
 	TenantID string
 	Tenant   Tenant
 
-	// The account used for authentication and personal info.
+	// The account used for authentication and personal info. The teacher is
+	// deleted along with it.
 	AccountID uint
-	Account   Account
+	Account   Account `gorm:"constraint:OnDelete:CASCADE"`
 
 	// Subjects this teacher can teach.
-	Subjects []Subject `gorm:"many2many:teacher_subjects;"`
+	Subjects []Subject `gorm:"many2many:teacher_subjects;constraint:OnDelete:CASCADE"`
 }
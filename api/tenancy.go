@@ -0,0 +1,279 @@
+package edutrack
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// tenantReferences maps the foreign key columns of tenant-scoped models to
+// the tables they reference. Writes through a tenant session check that the
+// referenced rows belong to the same tenant.
+var tenantReferences = map[string]string{
+	"account_id":       "accounts",
+	"appeal_id":        "grade_appeals",
+	"career_id":        "careers",
+	"grade_id":         "grades",
+	"justification_id": "justifications",
+	"section_id":       "sections",
+	"student_id":       "students",
+	"subject_id":       "subjects",
+	"teacher_id":       "teachers",
+	"topic_id":         "topics",
+	"webhook_id":       "webhooks",
+}
+
+// tenantCallback is the name prefix of the tenant scope callbacks.
+const tenantCallback = "edutrack:tenant_scope"
+
+// unmodifiedKey is the statement setting marking writes conditioned on the
+// loaded version of a record.
+const unmodifiedKey = tenantCallback + ":unmodified"
+
+// WithTenantScope returns a context whose database operations are scoped to
+// the tenant when used with a database where RegisterTenantScope was called.
+func WithTenantScope(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantScopeContextKey, tenantID)
+}
+
+// TenantScopeFromContext returns the tenant the context is scoped to.
+func TenantScopeFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantScopeContextKey).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// TenantDB returns a session scoped to the tenant of the account in the
+// context. Queries, updates and deletes of tenant-scoped models only see
+// the rows of the tenant; creates are assigned to it; and foreign keys of
+// writes must reference rows of the same tenant. Updates and deletes of a
+// loaded record fail with EPRECONDITION if the record was modified since.
+func TenantDB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		db = db.WithContext(ctx)
+		_ = db.AddError(&Error{Code: EUNAUTHORIZED})
+		return db
+	}
+	return db.WithContext(WithTenantScope(ctx, account.TenantID))
+}
+
+// RegisterTenantScope registers the callbacks enforcing the tenant scope of
+// TenantDB sessions. It is safe to call it more than once.
+func RegisterTenantScope(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if callbacks.Query().Get(tenantCallback+":query") != nil {
+		return nil
+	}
+
+	if err := callbacks.Query().Before("gorm:query").Register(tenantCallback+":query", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register(tenantCallback+":row", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register(tenantCallback+":delete", func(db *gorm.DB) {
+		scopeToTenant(db)
+		requireUnmodified(db)
+	}); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register(unmodifiedKey+":delete", checkUnmodified); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register(tenantCallback+":update", func(db *gorm.DB) {
+		scopeToTenant(db)
+		requireUnmodified(db)
+		checkTenantReferences(db)
+	}); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register(unmodifiedKey+":update", checkUnmodified); err != nil {
+		return err
+	}
+	return callbacks.Create().Before("gorm:create").Register(tenantCallback+":create", assignTenant)
+}
+
+// statementTenant returns the tenant the statement is scoped to, if any, and
+// whether its model is tenant-scoped.
+func statementTenant(db *gorm.DB) (string, bool) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Context == nil {
+		return "", false
+	}
+	tenantID, ok := TenantScopeFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema.LookUpField("tenant_id") == nil {
+		return "", false
+	}
+	return tenantID, true
+}
+
+// scopeToTenant restricts a statement to the rows of its tenant.
+func scopeToTenant(db *gorm.DB) {
+	tenantID, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: db.Statement.Table, Name: "tenant_id"}, Value: tenantID},
+	}})
+}
+
+// requireUnmodified restricts the update or delete of a loaded record to
+// the version that was loaded, so concurrent modifications are not
+// silently overwritten.
+func requireUnmodified(db *gorm.DB) {
+	if _, ok := statementTenant(db); !ok || db.Statement.ReflectValue.Kind() != reflect.Struct {
+		return
+	}
+	field := db.Statement.Schema.LookUpField("updated_at")
+	if field == nil {
+		return
+	}
+	updatedAt, zero := field.ValueOf(db.Statement.Context, db.Statement.ReflectValue)
+	if zero {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: db.Statement.Table, Name: field.DBName}, Value: updatedAt},
+	}})
+	db.InstanceSet(unmodifiedKey, true)
+}
+
+// checkUnmodified fails a write restricted by requireUnmodified that did not
+// find the loaded version of the record.
+func checkUnmodified(db *gorm.DB) {
+	if _, ok := db.InstanceGet(unmodifiedKey); ok && db.Error == nil && db.Statement.RowsAffected == 0 {
+		_ = db.AddError(&Error{Code: EPRECONDITION, Message: modifiedMessage})
+	}
+}
+
+// assignTenant assigns new rows to the tenant of the statement, rejecting
+// rows of other tenants and foreign keys referencing them.
+func assignTenant(db *gorm.DB) {
+	tenantID, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField("tenant_id")
+	eachRow(db, func(row reflect.Value) {
+		value, zero := field.ValueOf(db.Statement.Context, row)
+		if zero {
+			_ = field.Set(db.Statement.Context, row, tenantID)
+		} else if value != tenantID {
+			_ = db.AddError(&Error{Code: EFORBIDDEN})
+			return
+		}
+
+		// Rows created with an explicit primary key (e.g., by Save) must
+		// not collide with a row of another tenant.
+		if pk := db.Statement.Schema.PrioritizedPrimaryField; pk != nil {
+			if id, zero := pk.ValueOf(db.Statement.Context, row); !zero {
+				var count int64
+				rawSession(db).Table(db.Statement.Table).
+					Where(pk.DBName+" = ? AND tenant_id <> ?", id, tenantID).Count(&count)
+				if count > 0 {
+					_ = db.AddError(&Error{Code: EFORBIDDEN})
+				}
+			}
+		}
+	})
+
+	checkTenantReferences(db)
+}
+
+// checkTenantReferences checks the foreign keys written by a statement
+// reference rows of its tenant.
+func checkTenantReferences(db *gorm.DB) {
+	tenantID, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+
+	check := func(column string, id any) {
+		table, ok := tenantReferences[column]
+		if !ok || table == db.Statement.Table || isZeroID(id) {
+			return
+		}
+
+		var count int64
+		rawSession(db).Table(table).
+			Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", id, tenantID).Count(&count)
+		if count == 0 {
+			_ = db.AddError(InvalidField(column, FieldNotFound, "field.not_found", column))
+		}
+	}
+
+	// Updates with a map only write the given columns.
+	if values, ok := db.Statement.Dest.(map[string]any); ok {
+		for column, value := range values {
+			if field := db.Statement.Schema.LookUpField(column); field != nil {
+				check(field.DBName, value)
+			}
+		}
+		return
+	}
+
+	eachRow(db, func(row reflect.Value) {
+		for _, field := range db.Statement.Schema.Fields {
+			if _, ok := tenantReferences[field.DBName]; !ok || !isIntegerField(field) {
+				continue
+			}
+			if value, zero := field.ValueOf(db.Statement.Context, row); !zero {
+				check(field.DBName, value)
+			}
+		}
+	})
+}
+
+// eachRow calls fn with every struct written by a statement.
+func eachRow(db *gorm.DB, fn func(row reflect.Value)) {
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range value.Len() {
+			row := reflect.Indirect(value.Index(i))
+			if row.Kind() == reflect.Struct {
+				fn(row)
+			}
+		}
+	case reflect.Struct:
+		fn(value)
+	}
+}
+
+// rawSession returns a new unscoped session sharing the connection (and
+// transaction) of a statement, for the queries of the callbacks.
+func rawSession(db *gorm.DB) *gorm.DB {
+	ctx := WithTenantScope(db.Statement.Context, "")
+	return db.Session(&gorm.Session{NewDB: true, Context: ctx})
+}
+
+// isIntegerField checks if a field holds an integer, as foreign keys do.
+func isIntegerField(field *schema.Field) bool {
+	switch field.IndirectFieldType.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return !field.PrimaryKey
+	}
+	return false
+}
+
+// isZeroID checks if a foreign key value is unset.
+func isZeroID(id any) bool {
+	if id == nil {
+		return true
+	}
+	value := reflect.ValueOf(id)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return true
+		}
+		value = value.Elem()
+	}
+	return value.IsZero()
+}
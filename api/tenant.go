@@ -20,10 +20,17 @@ type Tenant struct {
 	// The institute's logo URL.
 	LogoURL string
 
+	// Storage key of the uploaded logo, empty if none.
+	LogoKey string
+
 	// License linked to the institute.
 	License   License
 	LicenseID uint
 
+	// Settings of the institute, nil if it uses the defaults. Only loaded
+	// when preloaded.
+	Settings *TenantSettings
+
 	// Built-ins, extracted from gorm.Model.
 	CreatedAt time.Time
 	UpdatedAt time.Time
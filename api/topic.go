@@ -1,6 +1,10 @@
 package edutrack
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Topic represents a specific topic within a subject, created by a teacher.
 // Grades are given based on these topics.
@@ -13,6 +17,9 @@ type Topic struct {
 	// Description of the topic.
 	Description string
 
+	// Deadline after which the grades of the topic are locked, if any.
+	GradesLockAt *time.Time
+
 	// Foreign keys.
 
 	// SubjectID links the topic to a subject.
@@ -23,6 +30,12 @@ type Topic struct {
 	TenantID string `gorm:"index"`
 	Tenant   Tenant
 
-	// Grades associated with this topic.
-	Grades []Grade
+	// Grades associated with this topic, deleted along with it.
+	Grades []Grade `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// GradesLocked reports whether the deadline of the grades of the topic
+// passed.
+func (t *Topic) GradesLocked(now time.Time) bool {
+	return t.GradesLockAt != nil && !now.Before(*t.GradesLockAt)
 }
@@ -0,0 +1,445 @@
+package edutrack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// TrashModels returns the models whose deleted records can be restored from
+// the trash, in dependency order: records only reference records of the
+// previous models.
+func TrashModels() []any {
+	return []any{
+		&Account{},
+		&Career{},
+		&Teacher{},
+		&Subject{},
+		&Section{},
+		&Topic{},
+		&Student{},
+		&Justification{},
+		&Attendance{},
+		&Grade{},
+		&GradeAppeal{},
+		&ExamAttempt{},
+		&Webhook{},
+	}
+}
+
+// TrashItem represents a deleted record in the trash.
+type TrashItem struct {
+	// Type of the record, e.g., "students".
+	Type string `json:"type"`
+
+	ID        uint      `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+
+	// The deleted record.
+	Record any `json:"record"`
+}
+
+// TrashService manages the deleted records of the tenant of the account in
+// the context. Only secretaries have access to the trash.
+type TrashService interface {
+	// FindTrash returns the deleted records of a type, or of every type if
+	// empty, most recently deleted first.
+	FindTrash(ctx context.Context, typ string) ([]TrashItem, error)
+
+	// RestoreTrash restores a deleted record along with its associations:
+	// the deleted records it references and the records deleted with it
+	// that reference it. It returns the restored records, and fails with
+	// ECONFLICT if a unique value of the record was reused since or a
+	// referenced record was purged.
+	RestoreTrash(ctx context.Context, typ string, id uint) ([]TrashItem, error)
+
+	// PurgeTrash permanently deletes a deleted record, along with the
+	// deleted records referencing it and its links (e.g., enrollments). It
+	// fails with ECONFLICT if records not deleted still reference it.
+	PurgeTrash(ctx context.Context, typ string, id uint) error
+}
+
+// NewTrashService returns a TrashService backed by the database.
+func NewTrashService(db *gorm.DB) TrashService {
+	return &trashService{db: db}
+}
+
+type trashService struct {
+	db *gorm.DB
+}
+
+func (s *trashService) FindTrash(ctx context.Context, typ string) ([]TrashItem, error) {
+	if err := requireSecretary(ctx); err != nil {
+		return nil, err
+	}
+	kinds, err := trashKinds(s.db)
+	if err != nil {
+		return nil, err
+	}
+	if typ != "" {
+		kind, err := findTrashKind(kinds, typ)
+		if err != nil {
+			return nil, err
+		}
+		kinds = []*schema.Schema{kind}
+	}
+
+	db := TenantDB(ctx, s.db)
+	items := []TrashItem{}
+	for _, kind := range kinds {
+		records := reflect.New(reflect.SliceOf(kind.ModelType))
+		if err := db.Unscoped().Where("deleted_at IS NOT NULL").Find(records.Interface()).Error; err != nil {
+			return nil, err
+		}
+		for i := range records.Elem().Len() {
+			items = append(items, newTrashItem(kind, records.Elem().Index(i).Addr().Interface()))
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+func (s *trashService) RestoreTrash(ctx context.Context, typ string, id uint) ([]TrashItem, error) {
+	if err := requireSecretary(ctx); err != nil {
+		return nil, err
+	}
+	kinds, err := trashKinds(s.db)
+	if err != nil {
+		return nil, err
+	}
+	kind, err := findTrashKind(kinds, typ)
+	if err != nil {
+		return nil, err
+	}
+
+	restorer := &trashRestorer{kinds: kinds, seen: map[string]bool{}}
+	err = TenantDB(ctx, s.db).Transaction(func(tx *gorm.DB) error {
+		record, err := findTrashed(tx, kind, id)
+		if err != nil {
+			return err
+		}
+		restorer.tx = tx
+		return restorer.restore(kind, record)
+	})
+	if err != nil {
+		return nil, TranslateDBError(err)
+	}
+	return restorer.restored, nil
+}
+
+func (s *trashService) PurgeTrash(ctx context.Context, typ string, id uint) error {
+	if err := requireSecretary(ctx); err != nil {
+		return err
+	}
+	kinds, err := trashKinds(s.db)
+	if err != nil {
+		return err
+	}
+	kind, err := findTrashKind(kinds, typ)
+	if err != nil {
+		return err
+	}
+
+	err = TenantDB(ctx, s.db).Transaction(func(tx *gorm.DB) error {
+		record, err := findTrashed(tx, kind, id)
+		if err != nil {
+			return err
+		}
+		return purgeRecord(tx, kinds, kind, record, true)
+	})
+	return TranslateDBError(err)
+}
+
+// PurgeExpiredTrash permanently deletes the records of every tenant deleted
+// before the given time. Records still referenced by other records are kept
+// until those are purged as well. It returns the number of records purged.
+func PurgeExpiredTrash(ctx context.Context, db *gorm.DB, before time.Time) (int, error) {
+	db = db.WithContext(ctx)
+	kinds, err := trashKinds(db)
+	if err != nil {
+		return 0, err
+	}
+
+	// Referencing records are purged first, so the records they reference
+	// can be purged in the same run.
+	purged := 0
+	for i := len(kinds) - 1; i >= 0; i-- {
+		kind := kinds[i]
+		records := reflect.New(reflect.SliceOf(kind.ModelType))
+		err := db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Find(records.Interface()).Error
+		if err != nil {
+			return purged, err
+		}
+
+		for j := range records.Elem().Len() {
+			record := records.Elem().Index(j).Addr().Interface()
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return purgeRecord(tx, kinds, kind, record, false)
+			})
+			if ErrorCode(err) == ECONFLICT {
+				continue
+			} else if err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// trashRestorer restores records from the trash within a transaction.
+type trashRestorer struct {
+	tx       *gorm.DB
+	kinds    []*schema.Schema
+	seen     map[string]bool
+	restored []TrashItem
+}
+
+// restore restores a deleted record and its associations.
+func (r *trashRestorer) restore(kind *schema.Schema, record any) error {
+	item := newTrashItem(kind, record)
+	key := fmt.Sprintf("%s:%d", item.Type, item.ID)
+	if r.seen[key] {
+		return nil
+	}
+	r.seen[key] = true
+
+	// Restore the deleted records it references first, so the record is
+	// valid once restored.
+	value := reflect.ValueOf(record).Elem()
+	for _, field := range kind.Fields {
+		parentKind := referencedKind(r.kinds, kind, field)
+		if parentKind == nil {
+			continue
+		}
+		id, zero := field.ValueOf(r.tx.Statement.Context, value)
+		if zero {
+			continue
+		}
+		id = reflect.Indirect(reflect.ValueOf(id)).Interface()
+
+		parent := reflect.New(parentKind.ModelType).Interface()
+		if err := r.tx.Unscoped().First(parent, id).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			conflict := Errorf(ECONFLICT, "trash.purged_reference", field.DBName)
+			conflict.Fields = []FieldError{{Field: field.DBName, Code: FieldNotFound, Message: conflict.Message, Args: conflict.Args}}
+			return conflict
+		} else if err != nil {
+			return err
+		}
+		if trashModel(parent).DeletedAt.Valid {
+			if err := r.restore(parentKind, parent); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := r.tx.Unscoped().Model(record).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	r.restored = append(r.restored, item)
+
+	// Restore the records deleted along with it that reference it.
+	for _, childKind := range r.kinds {
+		for _, field := range childKind.Fields {
+			if referencedKind(r.kinds, childKind, field) != kind {
+				continue
+			}
+
+			children := reflect.New(reflect.SliceOf(childKind.ModelType))
+			err := r.tx.Unscoped().Where(clause.Eq{Column: field.DBName, Value: item.ID}).
+				Where("deleted_at = ?", item.DeletedAt).Find(children.Interface()).Error
+			if err != nil {
+				return err
+			}
+			for i := range children.Elem().Len() {
+				if err := r.restore(childKind, children.Elem().Index(i).Addr().Interface()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// purgeRecord permanently deletes a deleted record. Links and other records
+// that only exist along with it (e.g., enrollments, guardian links or
+// notifications) are deleted too, and nullified references to it cleared.
+// Deleted records referencing it are purged as well if cascade is set;
+// otherwise, as records not deleted referencing it, they make it fail with
+// ECONFLICT.
+func purgeRecord(tx *gorm.DB, kinds []*schema.Schema, kind *schema.Schema, record any, cascade bool) error {
+	id := trashModel(record).ID
+	keys, err := foreignKeys(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, model := range models() {
+		sch, err := parseModel(tx, model)
+		if err != nil {
+			return err
+		}
+
+		// Rows of join tables linking the record.
+		for _, rel := range sch.Relationships.Many2Many {
+			for _, ref := range rel.References {
+				if ref.PrimaryKey == nil || ref.PrimaryKey.Schema.Table != kind.Table {
+					continue
+				}
+				err := tx.Exec("DELETE FROM ? WHERE ? = ?",
+					clause.Table{Name: rel.JoinTable.Table}, clause.Column{Name: ref.ForeignKey.DBName}, id).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if sch.Table == kind.Table {
+			continue
+		}
+		for _, field := range sch.Fields {
+			if tenantReferences[field.DBName] != kind.Table || !isIntegerField(field) {
+				continue
+			}
+			where := clause.Eq{Column: field.DBName, Value: id}
+
+			if key := findForeignKey(keys, sch.Table, field.DBName); key != nil && key.Rule == DeleteNullify {
+				if err := tx.Unscoped().Model(model).Where(where).Update(field.DBName, nil).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			childKind := findKind(kinds, sch.Table)
+			if childKind == nil {
+				if err := tx.Unscoped().Where(where).Delete(reflect.New(sch.ModelType).Interface()).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			var live int64
+			if err := tx.Model(model).Where(where).Count(&live).Error; err != nil {
+				return err
+			}
+			if live > 0 {
+				return Errorf(ECONFLICT, "trash.referenced", sch.Table)
+			}
+
+			children := reflect.New(reflect.SliceOf(childKind.ModelType))
+			if err := tx.Unscoped().Where(where).Find(children.Interface()).Error; err != nil {
+				return err
+			}
+			if children.Elem().Len() > 0 && !cascade {
+				return Errorf(ECONFLICT, "trash.referenced_deleted", sch.Table)
+			}
+			for i := range children.Elem().Len() {
+				if err := purgeRecord(tx, kinds, childKind, children.Elem().Index(i).Addr().Interface(), cascade); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return tx.Unscoped().Delete(record).Error
+}
+
+// findTrashed returns a deleted record of the tenant of the session.
+func findTrashed(tx *gorm.DB, kind *schema.Schema, id uint) (any, error) {
+	record := reflect.New(kind.ModelType).Interface()
+	if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(record, id).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// trashKinds returns the schemas of the trash models.
+func trashKinds(db *gorm.DB) ([]*schema.Schema, error) {
+	var kinds []*schema.Schema
+	for _, model := range TrashModels() {
+		sch, err := parseModel(db, model)
+		if err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, sch)
+	}
+	return kinds, nil
+}
+
+// findTrashKind returns the schema of a type of the trash, failing with
+// EINVALID for unknown types.
+func findTrashKind(kinds []*schema.Schema, typ string) (*schema.Schema, error) {
+	if kind := findKind(kinds, typ); kind != nil {
+		return kind, nil
+	}
+	return nil, InvalidField("type", FieldInvalidChoice, "field.trash.type.invalid", typ)
+}
+
+// findKind returns the schema of the given table, or nil.
+func findKind(kinds []*schema.Schema, table string) *schema.Schema {
+	for _, kind := range kinds {
+		if kind.Table == table {
+			return kind
+		}
+	}
+	return nil
+}
+
+// referencedKind returns the schema of the trash model referenced by a
+// foreign key field of another one, or nil.
+func referencedKind(kinds []*schema.Schema, kind *schema.Schema, field *schema.Field) *schema.Schema {
+	table, ok := tenantReferences[field.DBName]
+	if !ok || table == kind.Table || !isIntegerField(field) {
+		return nil
+	}
+	return findKind(kinds, table)
+}
+
+// newTrashItem returns the trash item of a record.
+func newTrashItem(kind *schema.Schema, record any) TrashItem {
+	model := trashModel(record)
+	return TrashItem{
+		Type:      kind.Table,
+		ID:        model.ID,
+		DeletedAt: model.DeletedAt.Time,
+		Record:    record,
+	}
+}
+
+// trashModel returns the gorm.Model embedded in a record of the trash.
+func trashModel(record any) gorm.Model {
+	return reflect.ValueOf(record).Elem().FieldByName("Model").Interface().(gorm.Model)
+}
+
+// parseModel returns the schema of a model.
+func parseModel(db *gorm.DB, model any) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// requireSecretary checks the account in the context is a secretary.
+func requireSecretary(ctx context.Context) error {
+	account := AccountFromContext(ctx)
+	if account == nil {
+		return &Error{Code: EUNAUTHORIZED}
+	}
+	if !account.IsSecretary() {
+		return &Error{Code: EFORBIDDEN}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package edutrack
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvent represents an event tenants can subscribe to with a webhook.
+type WebhookEvent string
+
+const (
+	// WebhookStudentCreated is emitted when a student is registered.
+	WebhookStudentCreated WebhookEvent = "student.created"
+
+	// WebhookGradeCreated is emitted when a grade is posted, either created
+	// published or published from a draft. Drafts are never emitted.
+	WebhookGradeCreated WebhookEvent = "grade.created"
+
+	// WebhookGradeUpdated is emitted when a posted grade is modified.
+	WebhookGradeUpdated WebhookEvent = "grade.updated"
+
+	// WebhookAttendanceRecorded is emitted when an attendance record is created.
+	WebhookAttendanceRecorded WebhookEvent = "attendance.recorded"
+
+	// WebhookEnrollmentChanged is emitted when a student is enrolled in,
+	// waitlisted for or removed from a section of a subject.
+	WebhookEnrollmentChanged WebhookEvent = "enrollment.changed"
+)
+
+// WebhookEvents returns all the events a webhook can subscribe to.
+func WebhookEvents() []WebhookEvent {
+	return []WebhookEvent{
+		WebhookStudentCreated,
+		WebhookGradeCreated,
+		WebhookGradeUpdated,
+		WebhookAttendanceRecorded,
+		WebhookEnrollmentChanged,
+	}
+}
+
+// IsValidWebhookEvent returns true if the given event can be subscribed to.
+func IsValidWebhookEvent(event WebhookEvent) bool {
+	for _, e := range WebhookEvents() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is an HTTP endpoint registered by a tenant to receive events.
+type Webhook struct {
+	gorm.Model
+
+	// URL the events are posted to.
+	URL string
+
+	// Shared secret used to sign the payloads (HMAC-SHA256).
+	// Only returned to the client when the webhook is created.
+	Secret string `json:"-"`
+
+	// Comma-separated list of subscribed events.
+	Events string
+
+	// Whether the webhook receives events.
+	Active bool `gorm:"default:true"`
+
+	// Optional description of the integration.
+	Description string
+
+	// Foreign keys.
+
+	// TenantID links the webhook to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// EventList returns the subscribed events as a slice.
+func (w *Webhook) EventList() []WebhookEvent {
+	var events []WebhookEvent
+	for _, e := range strings.Split(w.Events, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			events = append(events, WebhookEvent(e))
+		}
+	}
+	return events
+}
+
+// SetEvents stores the given events as the webhook subscription.
+func (w *Webhook) SetEvents(events []WebhookEvent) {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = string(e)
+	}
+	w.Events = strings.Join(names, ",")
+}
+
+// Subscribes returns true if the webhook is subscribed to the event.
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	for _, e := range w.EventList() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateWebhookSecret generates a new random signing secret.
+// Format: "whsec_" followed by 32 hex characters.
+func GenerateWebhookSecret() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 signature of the
+// payload for the given timestamp. The signed message is "<timestamp>.<payload>".
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDeliveryStatus represents the state of a webhook delivery.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending is waiting to be delivered (or retried).
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+
+	// WebhookDeliverySending was claimed by a deliverer that is posting it.
+	WebhookDeliverySending WebhookDeliveryStatus = "sending"
+
+	// WebhookDeliverySucceeded was acknowledged with a 2xx response.
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+
+	// WebhookDeliveryFailed exhausted all its delivery attempts.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery records a single event sent (or to be sent) to a webhook.
+// Deliveries form the log shown to secretaries and can be replayed.
+type WebhookDelivery struct {
+	gorm.Model
+
+	// The event being delivered.
+	Event WebhookEvent `gorm:"index"`
+
+	// JSON payload posted to the webhook.
+	Payload string
+
+	// Delivery state.
+	Status WebhookDeliveryStatus `gorm:"index;default:'pending'"`
+
+	// Number of delivery attempts made so far.
+	Attempts int
+
+	// When the next delivery attempt is due.
+	NextAttemptAt time.Time `gorm:"index"`
+
+	// HTTP status code of the last response (0 if no response).
+	ResponseStatus int
+
+	// Error returned by the last failed attempt.
+	LastError string
+
+	// When the delivery succeeded.
+	DeliveredAt *time.Time
+
+	// Foreign keys.
+
+	// WebhookID links the delivery to its webhook.
+	WebhookID uint    `gorm:"index"`
+	Webhook   Webhook `gorm:"constraint:OnDelete:CASCADE"`
+
+	// TenantID links the record to an institution.
+	TenantID string `gorm:"index"`
+	Tenant   Tenant
+}
+
+// WebhookEnvelope is the JSON body posted to webhook endpoints.
+type WebhookEnvelope struct {
+	Event     WebhookEvent `json:"event"`
+	TenantID  string       `json:"tenant_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	Data      any          `json:"data"`
+}
+
+// EnrollmentChange is the data of the enrollment.changed webhook event.
+type EnrollmentChange struct {
+	// Action is "enrolled", "waitlisted" or "removed". Waitlisted students
+	// given a place are "enrolled".
+	Action    string `json:"action"`
+	SubjectID uint   `json:"subject_id"`
+	SectionID uint   `json:"section_id"`
+	StudentID uint   `json:"student_id"`
+}
+
+// EmitWebhookEvent creates a pending delivery of the event for every active
+// webhook of the tenant subscribed to it. db should be the transaction of
+// the change the event reports.
+func EmitWebhookEvent(db *gorm.DB, tenantID string, event WebhookEvent, data any) error {
+	var webhooks []Webhook
+	if err := db.Where("tenant_id = ? AND active = ?", tenantID, true).Find(&webhooks).Error; err != nil {
+		return fmt.Errorf("failed to load webhooks: %w", err)
+	}
+
+	var payload []byte
+	now := time.Now()
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event) {
+			continue
+		}
+
+		if payload == nil {
+			var err error
+			payload, err = json.Marshal(WebhookEnvelope{
+				Event:     event,
+				TenantID:  tenantID,
+				CreatedAt: now,
+				Data:      data,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+		}
+
+		delivery := &WebhookDelivery{
+			Event:         event,
+			Payload:       string(payload),
+			Status:        WebhookDeliveryPending,
+			NextAttemptAt: now,
+			WebhookID:     webhook.ID,
+			TenantID:      tenantID,
+		}
+		if err := db.Create(delivery).Error; err != nil {
+			return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+		}
+	}
+
+	return nil
+}
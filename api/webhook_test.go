@@ -0,0 +1,98 @@
+package edutrack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestWebhook_SetEvents(t *testing.T) {
+	w := &Webhook{}
+	w.SetEvents([]WebhookEvent{WebhookGradeCreated, WebhookStudentCreated})
+
+	if w.Events != "grade.created,student.created" {
+		t.Errorf("Webhook.Events = %q, want %q", w.Events, "grade.created,student.created")
+	}
+
+	events := w.EventList()
+	if len(events) != 2 || events[0] != WebhookGradeCreated || events[1] != WebhookStudentCreated {
+		t.Errorf("Webhook.EventList() = %v", events)
+	}
+}
+
+func TestWebhook_Subscribes(t *testing.T) {
+	w := &Webhook{Events: "grade.created, attendance.recorded"}
+
+	tests := []struct {
+		event WebhookEvent
+		want  bool
+	}{
+		{WebhookGradeCreated, true},
+		{WebhookAttendanceRecorded, true},
+		{WebhookGradeUpdated, false},
+		{WebhookStudentCreated, false},
+	}
+
+	for _, tt := range tests {
+		if got := w.Subscribes(tt.event); got != tt.want {
+			t.Errorf("Webhook.Subscribes(%q) = %v, want %v", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestWebhook_EmptyEvents(t *testing.T) {
+	w := &Webhook{}
+	if events := w.EventList(); len(events) != 0 {
+		t.Errorf("Webhook.EventList() = %v, want empty", events)
+	}
+}
+
+func TestIsValidWebhookEvent(t *testing.T) {
+	for _, e := range WebhookEvents() {
+		if !IsValidWebhookEvent(e) {
+			t.Errorf("IsValidWebhookEvent(%q) = false, want true", e)
+		}
+	}
+
+	if IsValidWebhookEvent("grade.deleted") {
+		t.Error("IsValidWebhookEvent(\"grade.deleted\") = true, want false")
+	}
+}
+
+func TestGenerateWebhookSecret(t *testing.T) {
+	s1, err := GenerateWebhookSecret()
+	if err != nil {
+		t.Fatalf("GenerateWebhookSecret() error = %v", err)
+	}
+
+	if !strings.HasPrefix(s1, "whsec_") || len(s1) != len("whsec_")+32 {
+		t.Errorf("GenerateWebhookSecret() = %q, want whsec_ followed by 32 hex characters", s1)
+	}
+
+	s2, _ := GenerateWebhookSecret()
+	if s1 == s2 {
+		t.Errorf("GenerateWebhookSecret() generated duplicate secrets: %s", s1)
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"event":"grade.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(payload)))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got := SignWebhookPayload("secret", 1700000000, payload); got != want {
+		t.Errorf("SignWebhookPayload() = %q, want %q", got, want)
+	}
+
+	if SignWebhookPayload("other", 1700000000, payload) == want {
+		t.Error("SignWebhookPayload() produced the same signature with a different secret")
+	}
+
+	if SignWebhookPayload("secret", 1700000001, payload) == want {
+		t.Error("SignWebhookPayload() produced the same signature with a different timestamp")
+	}
+}